@@ -0,0 +1,86 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/bootstrap"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"math"
+	"os"
+)
+
+// runSubcommand runs the subcommand named by the first argument of the node binary, if any, and exits
+func runSubcommand(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	switch args[0] {
+	case "export-blocks":
+		exportBlocks(args[1:])
+	case "import-blocks":
+		importBlocks(args[1:])
+	default:
+		return
+	}
+	os.Exit(0)
+}
+
+// exportBlocks writes a range of blocks of a node that is not running to a block archive
+func exportBlocks(args []string) {
+	flags := flag.NewFlagSet("export-blocks", flag.ExitOnError)
+	archivePath := flags.String("archive", "", "path/to/blocks.archive to write")
+	from := flags.Uint64("from", 1, "first block height to export")
+	to := flags.Uint64("to", math.MaxUint64, "last block height to export, defaults to the last block")
+	var filePaths config.FilesPaths
+	flags.Var(&filePaths, "config", "path/to/config.json")
+	_ = flags.Parse(args)
+
+	cfg, logger := subcommandConfig(flags, filePaths, *archivePath)
+	manifest, err := bootstrap.ExportBlocks(cfg, logger, *archivePath, primitives.BlockHeight(*from), primitives.BlockHeight(*to))
+	if err != nil {
+		logger.Error("error exporting blocks", log.Error(err))
+		os.Exit(1)
+	}
+	fmt.Printf("exported blocks %d-%d to %s\n", manifest.FirstHeight(), manifest.LastHeight(), *archivePath)
+}
+
+// importBlocks appends the blocks of a block archive to the blocks files of a node that is not running
+func importBlocks(args []string) {
+	flags := flag.NewFlagSet("import-blocks", flag.ExitOnError)
+	archivePath := flags.String("archive", "", "path/to/blocks.archive to read")
+	var filePaths config.FilesPaths
+	flags.Var(&filePaths, "config", "path/to/config.json")
+	_ = flags.Parse(args)
+
+	cfg, logger := subcommandConfig(flags, filePaths, *archivePath)
+	numImported, err := bootstrap.ImportBlocks(cfg, logger, *archivePath)
+	if err != nil {
+		logger.Error("error importing blocks", log.Error(err))
+		os.Exit(1)
+	}
+	fmt.Printf("imported %d blocks from %s\n", numImported, *archivePath)
+}
+
+func subcommandConfig(flags *flag.FlagSet, filePaths config.FilesPaths, archivePath string) (config.NodeConfig, log.Logger) {
+	logger := log.GetLogger().WithOutput(log.NewFormattingOutput(os.Stderr, log.NewHumanReadableFormatter()))
+	if archivePath == "" {
+		fmt.Fprintln(os.Stderr, "missing -archive")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := config.GetNodeConfigFromFiles(filePaths, "")
+	if err != nil {
+		logger.Error("error reading configuration", log.Error(err))
+		os.Exit(1)
+	}
+	return cfg, logger
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bootstrap
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/archive"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"os"
+)
+
+// ExportBlocks writes blocks from..to of a node that is not running to a block archive at archivePath
+func ExportBlocks(nodeConfig config.NodeConfig, logger log.Logger, archivePath string, from primitives.BlockHeight, to primitives.BlockHeight) (*archive.Manifest, error) {
+	blockPersistence, err := filesystem.NewBlockPersistence(nodeConfig, logger, metric.NewRegistry())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed initializing blocks database")
+	}
+	defer blockPersistence.GracefulShutdown(context.Background())
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create block archive %s", archivePath)
+	}
+	defer f.Close()
+
+	manifest, err := archive.Export(f, blockPersistence, nodeConfig.NetworkType(), nodeConfig.VirtualChainId(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, errors.Wrapf(err, "failed to sync block archive %s", archivePath)
+	}
+	logger.Info("exported blocks archive", log.String("path", archivePath), log.Int("num-blocks", len(manifest.Entries)), logfields.BlockHeight(manifest.LastHeight()))
+	return manifest, nil
+}
+
+// ImportBlocks appends the blocks of the block archive at archivePath to the blocks files of a node that is not
+// running. blocks are validated as by VerifyBlockStorage, including their proofs
+func ImportBlocks(nodeConfig config.NodeConfig, logger log.Logger, archivePath string) (int, error) {
+	verifyProof, err := blockProofVerifier(nodeConfig, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open block archive %s", archivePath)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to open block archive %s", archivePath)
+	}
+
+	blockPersistence, err := filesystem.NewBlockPersistence(nodeConfig, logger, metric.NewRegistry())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed initializing blocks database")
+	}
+	defer blockPersistence.GracefulShutdown(context.Background())
+
+	return archive.Import(f, info.Size(), blockPersistence, nodeConfig.NetworkType(), nodeConfig.VirtualChainId(), verifyProof, logger)
+}
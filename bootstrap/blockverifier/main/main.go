@@ -0,0 +1,64 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+// blockverifier checks the blocks files of a node that is not running, and optionally truncates them after the last
+// valid block. exits with 2 if an invalid block was found and the files were not truncated
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/bootstrap"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/scribe/log"
+	"os"
+)
+
+func main() {
+	truncate := flag.Bool("truncate", false, "truncate the blocks files after the last valid block")
+	version := flag.Bool("version", false, "returns information about version")
+
+	var filePaths config.FilesPaths
+	flag.Var(&filePaths, "config", "path/to/config.json")
+
+	flag.Parse()
+
+	if *version {
+		fmt.Println(config.GetVersion())
+		os.Exit(0)
+	}
+
+	logger := log.GetLogger().WithOutput(log.NewFormattingOutput(os.Stderr, log.NewHumanReadableFormatter()))
+
+	cfg, err := config.GetNodeConfigFromFiles(filePaths, "")
+	if err != nil {
+		logger.Error("error reading configuration", log.Error(err))
+		os.Exit(1)
+	}
+
+	report, err := bootstrap.VerifyBlockStorage(cfg, logger, *truncate)
+	if err != nil {
+		logger.Error("error verifying blocks files", log.Error(err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("data dir:          %s\n", cfg.BlockStorageFileSystemDataDir())
+	fmt.Printf("segment files:     %d\n", report.NumSegments)
+	fmt.Printf("valid blocks:      %d\n", report.NumBlocks)
+	fmt.Printf("last valid height: %d\n", report.LastValidHeight)
+	if report.Problem == nil {
+		fmt.Println("result:            OK")
+		return
+	}
+	fmt.Printf("invalid block:     segment %d offset %d\n", report.Segment, report.Offset)
+	fmt.Printf("problem:           %s\n", report.Problem)
+	if report.Truncated {
+		fmt.Printf("result:            TRUNCATED to block %d\n", report.LastValidHeight)
+		return
+	}
+	fmt.Println("result:            INVALID, run with -truncate to cut the blocks files after the last valid block")
+	os.Exit(2)
+}
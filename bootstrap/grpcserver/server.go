@@ -0,0 +1,126 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	membuffers "github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"net"
+)
+
+var LogTag = log.String("adapter", "grpc-server")
+
+// the public api is served over grpc with the membuffers of the client protocol as messages instead of protobuf, so
+// grpc clients exchange the very same requests and responses http clients do. go clients importing this package may
+// select the codec with grpc.CallContentSubtype(CodecName)
+const CodecName = "membuffers"
+
+const ServiceName = "orbs.PublicApi"
+
+// RawMessage is the raw membuffer of a client protocol message
+type RawMessage []byte
+
+type membuffersCodec struct{}
+
+func (membuffersCodec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case membuffers.Message:
+		return m.Raw(), nil
+	case *RawMessage:
+		return *m, nil
+	}
+	return nil, errors.Errorf("%T is not a membuffer", v)
+}
+
+func (membuffersCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*RawMessage)
+	if !ok {
+		return errors.Errorf("%T is not a raw membuffer", v)
+	}
+	*m = append(RawMessage(nil), data...)
+	return nil
+}
+
+func (membuffersCodec) Name() string {
+	return CodecName
+}
+
+func (membuffersCodec) String() string {
+	return CodecName
+}
+
+func init() {
+	encoding.RegisterCodec(membuffersCodec{})
+}
+
+type GrpcServer struct {
+	supervised.ChanShutdownWaiter
+	server *grpc.Server
+
+	logger    log.Logger
+	publicApi publicapi.PublicApi
+
+	port int
+}
+
+// NewGrpcServer serves the same public api instance the http server does, so both transports share its metrics and
+// its send transaction timeout
+func NewGrpcServer(cfg config.GrpcServerConfig, logger log.Logger, publicApi publicapi.PublicApi) *GrpcServer {
+	server := &GrpcServer{
+		// every request is read with the membuffers codec, whatever content subtype the client asked for
+		server:             grpc.NewServer(grpc.CustomCodec(membuffersCodec{})),
+		logger:             logger.WithTags(LogTag),
+		publicApi:          publicApi,
+		ChanShutdownWaiter: supervised.NewChanWaiter("NodeGrpcServer"),
+	}
+	server.server.RegisterService(server.serviceDesc(), server)
+
+	listener, err := net.Listen("tcp", cfg.GrpcAddress())
+	if err != nil {
+		panic(fmt.Sprintf("failed to start grpc server: %s", err.Error()))
+	}
+	server.port = listener.Addr().(*net.TCPAddr).Port
+
+	go func() {
+		if err := server.server.Serve(listener); err != nil {
+			server.logger.Error("failed serving grpc requests", log.Error(err))
+		}
+	}()
+
+	server.logger.Info("started grpc server", log.String("address", cfg.GrpcAddress()))
+
+	return server
+}
+
+func (s *GrpcServer) Port() int {
+	return s.port
+}
+
+// subscriptions stream until their client leaves, so they are cut once the shutdown context is done
+func (s *GrpcServer) GracefulShutdown(shutdownContext context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-shutdownContext.Done():
+		s.logger.Error("failed to stop grpc server gracefully", log.Error(shutdownContext.Err()))
+		s.server.Stop()
+	}
+	s.Shutdown()
+}
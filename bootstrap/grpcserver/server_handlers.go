@@ -0,0 +1,233 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	membuffers "github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var errInvalidRequest = status.Error(codes.InvalidArgument, "grpc request is not a valid membuffer")
+
+type clientResponse interface {
+	membuffers.Message
+	RequestResult() *client.RequestResult
+}
+
+type clientCall func(ctx context.Context, request RawMessage) (clientResponse, error)
+
+func (s *GrpcServer) serviceDesc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			s.unaryMethod("SendTransaction", s.sendTransaction),
+			s.unaryMethod("SendTransactionAsync", s.sendTransactionAsync),
+			s.unaryMethod("RunQuery", s.runQuery),
+			s.unaryMethod("GetTransactionStatus", s.getTransactionStatus),
+			s.unaryMethod("GetTransactionReceiptProof", s.getTransactionReceiptProof),
+			s.unaryMethod("GetBlock", s.getBlock),
+		},
+		Streams: []grpc.StreamDesc{
+			{StreamName: "SubscribeBlockHeaders", Handler: s.subscribeBlockHeaders, ServerStreams: true},
+		},
+	}
+}
+
+// a call answered by the public api returns its response whatever the request status, which is also sent as header
+// metadata the way the http server sends it as http headers
+func (s *GrpcServer) unaryMethod(name string, call clientCall) grpc.MethodDesc {
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		response, err := call(ctx, *request.(*RawMessage))
+		if response == nil {
+			if err == nil {
+				err = errors.New("public api returned no response")
+			}
+			if status.Code(err) == codes.Unknown {
+				err = status.Error(codes.Internal, err.Error())
+			}
+			s.logger.Info("grpc request failed", log.String("method", name), log.Error(err))
+			return nil, err
+		}
+
+		requestResult := response.RequestResult()
+		md := metadata.Pairs(
+			"x-orbs-request-result", requestResult.RequestStatus().String(),
+			"x-orbs-block-height", fmt.Sprintf("%d", requestResult.BlockHeight()),
+			"x-orbs-block-timestamp", fmt.Sprintf("%d", requestResult.BlockTimestamp()),
+		)
+		if err != nil {
+			md.Append("x-orbs-error-details", err.Error())
+		}
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			s.logger.Info("error setting response header", log.Error(err))
+		}
+		return response, nil
+	}
+
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			request := &RawMessage{}
+			if err := dec(request); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return handler(ctx, request)
+			}
+			return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: s, FullMethod: "/" + ServiceName + "/" + name}, handler)
+		},
+	}
+}
+
+func (s *GrpcServer) sendTransaction(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.SendTransactionRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received send-transaction", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.SendTransaction(ctx, &services.SendTransactionInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+func (s *GrpcServer) sendTransactionAsync(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.SendTransactionRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received send-transaction-async", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.SendTransactionAsync(ctx, &services.SendTransactionInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+func (s *GrpcServer) runQuery(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.RunQueryRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received run-query", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.RunQuery(ctx, &services.RunQueryInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+func (s *GrpcServer) getTransactionStatus(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.GetTransactionStatusRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received get-transaction-status", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.GetTransactionStatus(ctx, &services.GetTransactionStatusInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+func (s *GrpcServer) getTransactionReceiptProof(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.GetTransactionReceiptProofRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received get-transaction-receipt-proof", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.GetTransactionReceiptProof(ctx, &services.GetTransactionReceiptProofInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+func (s *GrpcServer) getBlock(ctx context.Context, request RawMessage) (clientResponse, error) {
+	clientRequest := client.GetBlockRequestReader(request)
+	if !clientRequest.IsValid() {
+		return nil, errInvalidRequest
+	}
+
+	s.logger.Info("grpc server received get-block", log.Stringable("request", clientRequest))
+	result, err := s.publicApi.GetBlock(ctx, &services.GetBlockInput{ClientRequest: clientRequest})
+	if result == nil || result.ClientResponse == nil {
+		return nil, err
+	}
+	return result.ClientResponse, err
+}
+
+// subscribeBlockHeaders takes a get-block request naming the first block to stream, zero for the blocks committed from
+// now on, and streams a get-block response holding only the headers of each block as it commits
+func (s *GrpcServer) subscribeBlockHeaders(_ interface{}, stream grpc.ServerStream) error {
+	request := &RawMessage{}
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	clientRequest := client.GetBlockRequestReader(*request)
+	if !clientRequest.IsValid() {
+		return errInvalidRequest
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	s.logger.Info("grpc server received subscribe-block-headers", log.Stringable("request", clientRequest))
+	headers, err := s.publicApi.SubscribeBlockHeaders(ctx, &publicapi.SubscribeBlockHeadersInput{
+		ProtocolVersion: clientRequest.ProtocolVersion(),
+		VirtualChainId:  clientRequest.VirtualChainId(),
+		FromBlockHeight: clientRequest.BlockHeight(),
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	for {
+		select {
+		case h, open := <-headers:
+			if !open {
+				return status.Error(codes.Unavailable, "block headers are no longer streamed")
+			}
+			if err := stream.SendMsg(blockHeadersResponse(h)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+func blockHeadersResponse(h *blockstorage.BlockHeaders) *client.GetBlockResponse {
+	return (&client.GetBlockResponseBuilder{
+		RequestResult: &client.RequestResultBuilder{
+			RequestStatus:  protocol.REQUEST_STATUS_COMPLETED,
+			BlockHeight:    h.TransactionsBlockHeader.BlockHeight(),
+			BlockTimestamp: h.TransactionsBlockHeader.Timestamp(),
+		},
+		TransactionsBlockHeader: protocol.TransactionsBlockHeaderBuilderFromRaw(h.TransactionsBlockHeader.Raw()),
+		ResultsBlockHeader:      protocol.ResultsBlockHeaderBuilderFromRaw(h.ResultsBlockHeader.Raw()),
+	}).Build()
+}
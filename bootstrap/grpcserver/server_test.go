@@ -0,0 +1,123 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/publicapi/testkit"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestGrpcServer_RunQuery_ReturnsClientResponse(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			withGrpcHarness(ctx, t, parent, func(h *harness) {
+				response := &client.RunQueryResponseBuilder{
+					RequestResult: &client.RequestResultBuilder{RequestStatus: protocol.REQUEST_STATUS_COMPLETED, BlockHeight: 3},
+					QueryResult:   &protocol.QueryResultBuilder{ExecutionResult: protocol.EXECUTION_RESULT_SUCCESS},
+				}
+				h.publicApi.When("RunQuery", mock.Any, mock.Any).Return(&services.RunQueryOutput{ClientResponse: response.Build()}, nil).Times(1)
+
+				var header metadata.MD
+				raw := RawMessage{}
+				err := h.conn.Invoke(ctx, "/"+ServiceName+"/RunQuery", (&client.RunQueryRequestBuilder{SignedQuery: &protocol.SignedQueryBuilder{}}).Build(), &raw,
+					grpc.CallContentSubtype(CodecName), grpc.Header(&header))
+
+				require.NoError(t, err, "should succeed")
+				result := client.RunQueryResponseReader(raw)
+				require.Equal(t, protocol.EXECUTION_RESULT_SUCCESS, result.QueryResult().ExecutionResult(), "should return the response of the public api")
+				require.Equal(t, []string{"REQUEST_STATUS_COMPLETED"}, header.Get("x-orbs-request-result"))
+				require.Equal(t, []string{"3"}, header.Get("x-orbs-block-height"))
+			})
+		})
+	})
+}
+
+func TestGrpcServer_SendTransaction_Error(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			withGrpcHarness(ctx, t, parent, func(h *harness) {
+				h.publicApi.When("SendTransaction", mock.Any, mock.Any).Return(nil, errors.Errorf("kaboom")).Times(1)
+
+				raw := RawMessage{}
+				err := h.conn.Invoke(ctx, "/"+ServiceName+"/SendTransaction", (&client.SendTransactionRequestBuilder{
+					SignedTransaction: builders.TransferTransaction().Builder(),
+				}).Build(), &raw, grpc.CallContentSubtype(CodecName))
+
+				require.Equal(t, codes.Internal, status.Code(err), "should fail with internal error")
+			})
+		})
+	})
+}
+
+func TestGrpcServer_SubscribeBlockHeaders_StreamsHeadersOfCommittedBlocks(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			withGrpcHarness(ctx, t, parent, func(h *harness) {
+				block := builders.BlockPair().WithHeight(4).Build()
+				headers := make(chan *blockstorage.BlockHeaders, 1)
+				headers <- &blockstorage.BlockHeaders{TransactionsBlockHeader: block.TransactionsBlock.Header, ResultsBlockHeader: block.ResultsBlock.Header}
+				h.publicApi.When("SubscribeBlockHeaders", mock.Any, mock.AnyIf("subscription from requested height", func(i interface{}) bool {
+					input, ok := i.(*publicapi.SubscribeBlockHeadersInput)
+					return ok && input.FromBlockHeight == 4
+				})).Return((<-chan *blockstorage.BlockHeaders)(headers), nil).Times(1)
+
+				stream, err := h.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/"+ServiceName+"/SubscribeBlockHeaders", grpc.CallContentSubtype(CodecName))
+				require.NoError(t, err)
+				require.NoError(t, stream.SendMsg((&client.GetBlockRequestBuilder{BlockHeight: 4}).Build()))
+				require.NoError(t, stream.CloseSend())
+
+				raw := RawMessage{}
+				require.NoError(t, stream.RecvMsg(&raw), "should stream the header of the committed block")
+				response := client.GetBlockResponseReader(raw)
+				require.EqualValues(t, 4, response.RequestResult().BlockHeight())
+				require.EqualValues(t, 4, response.TransactionsBlockHeader().BlockHeight())
+				require.EqualValues(t, 4, response.ResultsBlockHeader().BlockHeight())
+			})
+		})
+	})
+}
+
+type harness struct {
+	publicApi *testkit.MockPublicApi
+	server    *GrpcServer
+	conn      *grpc.ClientConn
+}
+
+func withGrpcHarness(ctx context.Context, t *testing.T, parent *with.LoggingHarness, f func(h *harness)) {
+	cfg := config.ForGamma(nil, nil, nil, ":0", false, "").SetString(config.GRPC_ADDRESS, "127.0.0.1:0")
+	papiMock := &testkit.MockPublicApi{}
+	server := NewGrpcServer(cfg, parent.Logger, papiMock)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		server.GracefulShutdown(shutdownCtx)
+	}()
+
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("127.0.0.1:%d", server.Port()), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	f(&harness{publicApi: papiMock, server: server, conn: conn})
+}
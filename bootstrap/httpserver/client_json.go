@@ -0,0 +1,614 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package httpserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	membuffers "github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"math/big"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the membuffers client protocol endpoints also speak JSON when the request is sent with this Content-Type, so callers
+// do not need an Orbs SDK. a JSON request is translated to the membuffer request it stands for and the response is
+// translated back from the membuffer response, so both representations make exactly the same public api calls
+const jsonContentType = "application/json"
+
+// arguments are typed by the kind of protocol.Argument they stand for: uint32, uint64, string, bytes, bool, uint256,
+// bytes20 or bytes32, or any of those suffixed with Array. a value is a string, numbers in decimal and bytes in hex,
+// and the value of an array is a JSON array of those
+type JsonArgument struct {
+	Type  string
+	Value interface{}
+}
+
+type JsonSigner struct {
+	NetworkType string // NETWORK_TYPE_MAIN_NET or NETWORK_TYPE_TEST_NET
+	PublicKey   primitives.Ed25519PublicKey
+}
+
+// the request of send-transaction and send-transaction-async, of run-query as well, and the shape of the transactions
+// of a get-block response. Signature is the ed25519 signature of the transaction hash, which is calculated over the
+// membuffer the transaction is translated to. as the JSON has no canonical form to hash, calc-transaction-hash returns
+// the hash to sign for the transaction sent to it without a signature
+type JsonTransaction struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	Timestamp       primitives.TimestampNano
+	Signer          *JsonSigner
+	ContractName    primitives.ContractName
+	MethodName      primitives.MethodName
+	InputArguments  []*JsonArgument
+	Signature       []byte `json:",omitempty"`
+}
+
+// the request of get-transaction-status and get-transaction-receipt-proof
+type JsonTransactionRef struct {
+	ProtocolVersion      primitives.ProtocolVersion
+	VirtualChainId       primitives.VirtualChainId
+	TransactionTimestamp primitives.TimestampNano
+	Txhash               primitives.Sha256
+}
+
+type JsonGetBlockRequest struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	BlockHeight     primitives.BlockHeight
+}
+
+type JsonRequestResult struct {
+	RequestStatus  string
+	BlockHeight    primitives.BlockHeight
+	BlockTimestamp primitives.TimestampNano
+}
+
+type JsonEvent struct {
+	ContractName    primitives.ContractName
+	EventName       primitives.EventName
+	OutputArguments []*JsonArgument
+}
+
+type JsonTransactionReceipt struct {
+	Txhash          primitives.Sha256
+	ExecutionResult string
+	OutputArguments []*JsonArgument
+	OutputEvents    []*JsonEvent
+}
+
+type JsonQueryResult struct {
+	ExecutionResult string
+	OutputArguments []*JsonArgument
+	OutputEvents    []*JsonEvent
+}
+
+// the response of send-transaction, send-transaction-async and get-transaction-status
+type JsonTransactionStatusResponse struct {
+	RequestResult      *JsonRequestResult
+	TransactionStatus  string
+	TransactionReceipt *JsonTransactionReceipt `json:",omitempty"` // omitted until the transaction has a receipt
+}
+
+type JsonTransactionReceiptProofResponse struct {
+	RequestResult      *JsonRequestResult
+	TransactionStatus  string
+	TransactionReceipt *JsonTransactionReceipt `json:",omitempty"` // omitted until the transaction has a receipt
+	PackedProof        []byte                  `json:",omitempty"`
+}
+
+// the response of calc-transaction-hash. Transaction lets the signer check the hash before signing it
+type JsonTransactionHashResponse struct {
+	Txhash      primitives.Sha256
+	Transaction []byte // raw membuffer of protocol.Transaction
+}
+
+type JsonRunQueryResponse struct {
+	RequestResult *JsonRequestResult
+	QueryResult   *JsonQueryResult
+}
+
+// headers, metadata, proofs and state diffs are only of use to whoever verifies the block, so they are left as membuffers
+type JsonGetBlockResponse struct {
+	RequestResult             *JsonRequestResult
+	TransactionsBlockHeader   []byte // raw membuffer of protocol.TransactionsBlockHeader
+	TransactionsBlockMetadata []byte // raw membuffer of protocol.TransactionsBlockMetadata
+	SignedTransactions        []*JsonTransaction
+	TransactionsBlockProof    []byte // raw membuffer of protocol.TransactionsBlockProof
+	ResultsBlockHeader        []byte // raw membuffer of protocol.ResultsBlockHeader
+	TransactionReceipts       []*JsonTransactionReceipt
+	ContractStateDiffs        [][]byte // raw membuffers of protocol.ContractStateDiff
+	ResultsBlockProof         []byte   // raw membuffer of protocol.ResultsBlockProof
+}
+
+// the go type protocol.ArgumentBuilderFromNative takes for each kind of argument, arrays are slices of these
+var argumentElementTypes = map[string]reflect.Type{
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"string":  reflect.TypeOf(""),
+	"bytes":   reflect.TypeOf([]byte(nil)),
+	"bool":    reflect.TypeOf(false),
+	"uint256": reflect.TypeOf((*big.Int)(nil)),
+	"bytes20": reflect.TypeOf([20]byte{}),
+	"bytes32": reflect.TypeOf([32]byte{}),
+}
+
+type clientRequestBuilder interface {
+	Write(buf []byte) error
+	CalcRequiredSize() membuffers.Offset
+}
+
+func isJsonRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == jsonContentType
+}
+
+// readClientRequest returns the raw membuffer of a client request, translated by fromJson if the request was sent as JSON
+func readClientRequest(r *http.Request, fromJson func(bytes []byte) (clientRequestBuilder, error)) ([]byte, *httpErr) {
+	bytes, e := readInput(r)
+	if e != nil || !isJsonRequest(r) {
+		return bytes, e
+	}
+
+	builder, err := fromJson(bytes)
+	if err != nil {
+		return nil, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid json client request: " + err.Error()}
+	}
+	buf := make([]byte, builder.CalcRequiredSize())
+	if err := builder.Write(buf); err != nil {
+		return nil, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid json client request: " + err.Error()}
+	}
+	return buf, nil
+}
+
+// writeClientResponse answers in the representation the request was sent in
+func (s *HttpServer) writeClientResponse(w http.ResponseWriter, r *http.Request, message membuffers.Message, requestResult *client.RequestResult, errorForVerbosity error) {
+	if !isJsonRequest(r) {
+		s.writeMembuffResponse(w, message, requestResult, errorForVerbosity)
+		return
+	}
+
+	response, err := clientResponseToJson(message)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), "failed to encode response"})
+		return
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), "failed to encode response"})
+		return
+	}
+	s.writeResponse(w, jsonContentType, data, requestResult, errorForVerbosity)
+}
+
+func sendTransactionRequestFromJson(bytes []byte) (clientRequestBuilder, error) {
+	request := &JsonTransaction{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		return nil, err
+	}
+	transaction, err := transactionFromJson(request)
+	if err != nil {
+		return nil, err
+	}
+	return &client.SendTransactionRequestBuilder{
+		SignedTransaction: &protocol.SignedTransactionBuilder{
+			Transaction: transaction,
+			Signature:   request.Signature,
+		},
+	}, nil
+}
+
+func transactionFromJson(request *JsonTransaction) (*protocol.TransactionBuilder, error) {
+	signer, inputArguments, err := callFromJson(request)
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.TransactionBuilder{
+		ProtocolVersion:    request.ProtocolVersion,
+		VirtualChainId:     request.VirtualChainId,
+		Timestamp:          request.Timestamp,
+		Signer:             signer,
+		ContractName:       request.ContractName,
+		MethodName:         request.MethodName,
+		InputArgumentArray: inputArguments,
+	}, nil
+}
+
+func runQueryRequestFromJson(bytes []byte) (clientRequestBuilder, error) {
+	request := &JsonTransaction{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		return nil, err
+	}
+	signer, inputArguments, err := callFromJson(request)
+	if err != nil {
+		return nil, err
+	}
+	return &client.RunQueryRequestBuilder{
+		SignedQuery: &protocol.SignedQueryBuilder{
+			Query: &protocol.QueryBuilder{
+				ProtocolVersion:    request.ProtocolVersion,
+				VirtualChainId:     request.VirtualChainId,
+				Timestamp:          request.Timestamp,
+				Signer:             signer,
+				ContractName:       request.ContractName,
+				MethodName:         request.MethodName,
+				InputArgumentArray: inputArguments,
+			},
+			Signature: request.Signature,
+		},
+	}, nil
+}
+
+func getTransactionStatusRequestFromJson(bytes []byte) (clientRequestBuilder, error) {
+	ref, err := transactionRefFromJson(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &client.GetTransactionStatusRequestBuilder{TransactionRef: ref}, nil
+}
+
+func getTransactionReceiptProofRequestFromJson(bytes []byte) (clientRequestBuilder, error) {
+	ref, err := transactionRefFromJson(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &client.GetTransactionReceiptProofRequestBuilder{TransactionRef: ref}, nil
+}
+
+func getBlockRequestFromJson(bytes []byte) (clientRequestBuilder, error) {
+	request := &JsonGetBlockRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		return nil, err
+	}
+	return &client.GetBlockRequestBuilder{
+		ProtocolVersion: request.ProtocolVersion,
+		VirtualChainId:  request.VirtualChainId,
+		BlockHeight:     request.BlockHeight,
+	}, nil
+}
+
+func transactionRefFromJson(bytes []byte) (*client.TransactionRefBuilder, error) {
+	request := &JsonTransactionRef{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		return nil, err
+	}
+	return &client.TransactionRefBuilder{
+		ProtocolVersion:      request.ProtocolVersion,
+		VirtualChainId:       request.VirtualChainId,
+		TransactionTimestamp: request.TransactionTimestamp,
+		Txhash:               request.Txhash,
+	}, nil
+}
+
+func callFromJson(request *JsonTransaction) (*protocol.SignerBuilder, primitives.PackedArgumentArray, error) {
+	if request.Signer == nil {
+		return nil, nil, errors.New("missing signer")
+	}
+	var networkType protocol.SignerNetworkType
+	switch request.Signer.NetworkType {
+	case protocol.NETWORK_TYPE_MAIN_NET.String():
+		networkType = protocol.NETWORK_TYPE_MAIN_NET
+	case protocol.NETWORK_TYPE_TEST_NET.String():
+		networkType = protocol.NETWORK_TYPE_TEST_NET
+	default:
+		return nil, nil, errors.Errorf("unknown signer network type %s", request.Signer.NetworkType)
+	}
+	signer := &protocol.SignerBuilder{
+		Scheme: protocol.SIGNER_SCHEME_EDDSA,
+		Eddsa:  &protocol.EdDSA01SignerBuilder{NetworkType: networkType, SignerPublicKey: request.Signer.PublicKey},
+	}
+
+	natives := make([]interface{}, 0, len(request.InputArguments))
+	for i, argument := range request.InputArguments {
+		native, err := argumentFromJson(argument)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "input argument %d", i)
+		}
+		natives = append(natives, native)
+	}
+	inputArguments, err := protocol.PackedInputArgumentsFromNatives(natives)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signer, inputArguments, nil
+}
+
+func argumentFromJson(argument *JsonArgument) (interface{}, error) {
+	if argument == nil {
+		return nil, errors.New("missing argument")
+	}
+	elementName := strings.TrimSuffix(argument.Type, "Array")
+	elementType, known := argumentElementTypes[elementName]
+	if !known {
+		return nil, errors.Errorf("unknown argument type %s", argument.Type)
+	}
+
+	if elementName == argument.Type {
+		value, ok := argument.Value.(string)
+		if !ok {
+			return nil, errors.Errorf("value of %s argument is not a string", argument.Type)
+		}
+		return argumentElementFromJson(elementName, value)
+	}
+
+	values, ok := argument.Value.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("value of %s argument is not an array", argument.Type)
+	}
+	array := reflect.MakeSlice(reflect.SliceOf(elementType), len(values), len(values))
+	for i, v := range values {
+		value, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("element %d of %s argument is not a string", i, argument.Type)
+		}
+		element, err := argumentElementFromJson(elementName, value)
+		if err != nil {
+			return nil, err
+		}
+		array.Index(i).Set(reflect.ValueOf(element))
+	}
+	return array.Interface(), nil
+}
+
+func argumentElementFromJson(elementName string, value string) (interface{}, error) {
+	switch elementName {
+	case "uint32":
+		n, err := strconv.ParseUint(value, 10, 32)
+		return uint32(n), err
+	case "uint64":
+		return strconv.ParseUint(value, 10, 64)
+	case "string":
+		return value, nil
+	case "bytes":
+		return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	case "bool":
+		return strconv.ParseBool(value)
+	case "uint256":
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+			return nil, errors.Errorf("%s is not a valid uint256", value)
+		}
+		return n, nil
+	case "bytes20":
+		var fixed [20]byte
+		return fixed, decodeFixedHex(value, fixed[:])
+	case "bytes32":
+		var fixed [32]byte
+		return fixed, decodeFixedHex(value, fixed[:])
+	}
+	return nil, errors.Errorf("unknown argument type %s", elementName)
+}
+
+func decodeFixedHex(value string, fixed []byte) error {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(fixed) {
+		return errors.Errorf("%s is not %d bytes long", value, len(fixed))
+	}
+	copy(fixed, decoded)
+	return nil
+}
+
+func clientResponseToJson(message membuffers.Message) (interface{}, error) {
+	switch response := message.(type) {
+	case *client.SendTransactionResponse:
+		receipt, err := transactionReceiptToJson(response.TransactionReceipt())
+		return &JsonTransactionStatusResponse{
+			RequestResult:      requestResultToJson(response.RequestResult()),
+			TransactionStatus:  response.TransactionStatus().String(),
+			TransactionReceipt: receipt,
+		}, err
+	case *client.GetTransactionStatusResponse:
+		receipt, err := transactionReceiptToJson(response.TransactionReceipt())
+		return &JsonTransactionStatusResponse{
+			RequestResult:      requestResultToJson(response.RequestResult()),
+			TransactionStatus:  response.TransactionStatus().String(),
+			TransactionReceipt: receipt,
+		}, err
+	case *client.GetTransactionReceiptProofResponse:
+		receipt, err := transactionReceiptToJson(response.TransactionReceipt())
+		return &JsonTransactionReceiptProofResponse{
+			RequestResult:      requestResultToJson(response.RequestResult()),
+			TransactionStatus:  response.TransactionStatus().String(),
+			TransactionReceipt: receipt,
+			PackedProof:        response.PackedProof(),
+		}, err
+	case *client.RunQueryResponse:
+		return runQueryResponseToJson(response)
+	case *client.GetBlockResponse:
+		return getBlockResponseToJson(response)
+	}
+	return nil, errors.Errorf("no json representation of %T", message)
+}
+
+func requestResultToJson(result *client.RequestResult) *JsonRequestResult {
+	return &JsonRequestResult{
+		RequestStatus:  result.RequestStatus().String(),
+		BlockHeight:    result.BlockHeight(),
+		BlockTimestamp: result.BlockTimestamp(),
+	}
+}
+
+func runQueryResponseToJson(response *client.RunQueryResponse) (*JsonRunQueryResponse, error) {
+	result := response.QueryResult()
+	arguments, err := argumentsToJson(result.RawOutputArgumentArrayWithHeader())
+	if err != nil {
+		return nil, err
+	}
+	events, err := eventsToJson(result.RawOutputEventsArrayWithHeader())
+	if err != nil {
+		return nil, err
+	}
+	return &JsonRunQueryResponse{
+		RequestResult: requestResultToJson(response.RequestResult()),
+		QueryResult: &JsonQueryResult{
+			ExecutionResult: result.ExecutionResult().String(),
+			OutputArguments: arguments,
+			OutputEvents:    events,
+		},
+	}, nil
+}
+
+func getBlockResponseToJson(response *client.GetBlockResponse) (*JsonGetBlockResponse, error) {
+	block := &JsonGetBlockResponse{
+		RequestResult:             requestResultToJson(response.RequestResult()),
+		TransactionsBlockHeader:   response.TransactionsBlockHeader().Raw(),
+		TransactionsBlockMetadata: response.TransactionsBlockMetadata().Raw(),
+		SignedTransactions:        []*JsonTransaction{},
+		TransactionsBlockProof:    response.TransactionsBlockProof().Raw(),
+		ResultsBlockHeader:        response.ResultsBlockHeader().Raw(),
+		TransactionReceipts:       []*JsonTransactionReceipt{},
+		ContractStateDiffs:        [][]byte{},
+		ResultsBlockProof:         response.ResultsBlockProof().Raw(),
+	}
+	for i := response.SignedTransactionsIterator(); i.HasNext(); {
+		transaction, err := transactionToJson(i.NextSignedTransactions())
+		if err != nil {
+			return nil, err
+		}
+		block.SignedTransactions = append(block.SignedTransactions, transaction)
+	}
+	for i := response.TransactionReceiptsIterator(); i.HasNext(); {
+		receipt, err := transactionReceiptToJson(i.NextTransactionReceipts())
+		if err != nil {
+			return nil, err
+		}
+		block.TransactionReceipts = append(block.TransactionReceipts, receipt)
+	}
+	for i := response.ContractStateDiffsIterator(); i.HasNext(); {
+		block.ContractStateDiffs = append(block.ContractStateDiffs, i.NextContractStateDiffs().Raw())
+	}
+	return block, nil
+}
+
+func transactionToJson(signedTransaction *protocol.SignedTransaction) (*JsonTransaction, error) {
+	transaction := signedTransaction.Transaction()
+	arguments, err := argumentsToJson(transaction.RawInputArgumentArrayWithHeader())
+	if err != nil {
+		return nil, err
+	}
+	var signer *JsonSigner
+	if transaction.Signer().IsSchemeEddsa() {
+		eddsa := transaction.Signer().Eddsa()
+		signer = &JsonSigner{NetworkType: eddsa.NetworkType().String(), PublicKey: eddsa.SignerPublicKey()}
+	}
+	return &JsonTransaction{
+		ProtocolVersion: transaction.ProtocolVersion(),
+		VirtualChainId:  transaction.VirtualChainId(),
+		Timestamp:       transaction.Timestamp(),
+		Signer:          signer,
+		ContractName:    transaction.ContractName(),
+		MethodName:      transaction.MethodName(),
+		InputArguments:  arguments,
+		Signature:       signedTransaction.Signature(),
+	}, nil
+}
+
+// the membuffer response of a transaction without a receipt yet carries an empty one
+func transactionReceiptToJson(receipt *protocol.TransactionReceipt) (*JsonTransactionReceipt, error) {
+	if len(receipt.Raw()) == 0 {
+		return nil, nil
+	}
+	arguments, err := argumentsToJson(receipt.RawOutputArgumentArrayWithHeader())
+	if err != nil {
+		return nil, err
+	}
+	events, err := eventsToJson(receipt.RawOutputEventsArrayWithHeader())
+	if err != nil {
+		return nil, err
+	}
+	return &JsonTransactionReceipt{
+		Txhash:          receipt.Txhash(),
+		ExecutionResult: receipt.ExecutionResult().String(),
+		OutputArguments: arguments,
+		OutputEvents:    events,
+	}, nil
+}
+
+func eventsToJson(packedEventsWithHeader []byte) ([]*JsonEvent, error) {
+	events := []*JsonEvent{}
+	for i := protocol.EventsArrayReader(packedEventsWithHeader).EventsIterator(); i.HasNext(); {
+		event := i.NextEvents()
+		arguments, err := argumentsToJson(event.RawOutputArgumentArrayWithHeader())
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &JsonEvent{ContractName: event.ContractName(), EventName: event.EventName(), OutputArguments: arguments})
+	}
+	return events, nil
+}
+
+func argumentsToJson(packedArgumentsWithHeader []byte) ([]*JsonArgument, error) {
+	natives, err := protocol.PackedOutputArgumentsToNatives(packedArgumentsWithHeader)
+	if err != nil {
+		return nil, err
+	}
+	arguments := make([]*JsonArgument, 0, len(natives))
+	for i, native := range natives {
+		argument, err := argumentToJson(native)
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %d", i)
+		}
+		arguments = append(arguments, argument)
+	}
+	return arguments, nil
+}
+
+// natives are expected of the types in argumentElementTypes or slices of those, a kind of argument added to the
+// protocol after them has no JSON representation
+func argumentToJson(native interface{}) (*JsonArgument, error) {
+	t := reflect.TypeOf(native)
+	if elementName, ok := argumentElementName(t); ok {
+		return &JsonArgument{Type: elementName, Value: argumentElementToJson(native)}, nil
+	}
+
+	if t == nil || t.Kind() != reflect.Slice {
+		return nil, errors.Errorf("no json representation of argument of type %v", t)
+	}
+	elementName, ok := argumentElementName(t.Elem())
+	if !ok {
+		return nil, errors.Errorf("no json representation of argument of type %v", t)
+	}
+	array := reflect.ValueOf(native)
+	values := make([]string, array.Len())
+	for i := range values {
+		values[i] = argumentElementToJson(array.Index(i).Interface())
+	}
+	return &JsonArgument{Type: elementName + "Array", Value: values}, nil
+}
+
+func argumentElementName(t reflect.Type) (string, bool) {
+	for name, elementType := range argumentElementTypes {
+		if elementType == t {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func argumentElementToJson(native interface{}) string {
+	switch v := native.(type) {
+	case []byte:
+		return hex.EncodeToString(v)
+	case [20]byte:
+		return hex.EncodeToString(v[:])
+	case [32]byte:
+		return hex.EncodeToString(v[:])
+	}
+	return fmt.Sprint(native) // numbers in decimal, bools and strings as they are
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package httpserver
+
+import (
+	"encoding/json"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func TestClientJson_ArgumentsOfEveryKindSurviveTheRoundTrip(t *testing.T) {
+	natives := []interface{}{
+		uint32(7), uint64(1) << 60, "hello", []byte{0x01, 0x02}, true, big.NewInt(256), [20]byte{0x03}, [32]byte{0x04},
+		[]uint32{1, 2}, []uint64{}, []string{"a", "b"}, [][]byte{{0x05}}, []bool{false}, []*big.Int{big.NewInt(9)},
+		[][20]byte{{0x06}}, [][32]byte{{0x07}},
+	}
+	argumentArray, err := protocol.ArgumentArrayFromNatives(natives)
+	require.NoError(t, err)
+
+	arguments, err := argumentsToJson(argumentArray.Raw())
+	require.NoError(t, err)
+	require.Equal(t, "uint64", arguments[1].Type)
+	require.Equal(t, "1152921504606846976", arguments[1].Value, "uint64 should be a decimal string")
+	require.Equal(t, "0102", arguments[3].Value, "bytes should be hex")
+	require.Equal(t, "bytes32Array", arguments[15].Type)
+
+	// arguments are sent back as the client would send them, through JSON
+	data, err := json.Marshal(arguments)
+	require.NoError(t, err)
+	var received []*JsonArgument
+	require.NoError(t, json.Unmarshal(data, &received))
+
+	for i, argument := range received {
+		native, err := argumentFromJson(argument)
+		require.NoError(t, err, "argument %d of type %s", i, argument.Type)
+		require.Equal(t, natives[i], native, "argument %d of type %s", i, argument.Type)
+	}
+}
+
+func TestClientJson_RejectsInvalidArguments(t *testing.T) {
+	for _, argument := range []*JsonArgument{
+		{Type: "uint32", Value: "4294967296"},
+		{Type: "uint64", Value: 10},
+		{Type: "uint256", Value: "-1"},
+		{Type: "bytes20", Value: "0x0102"},
+		{Type: "boolArray", Value: "true"},
+		{Type: "float", Value: "1.5"},
+	} {
+		_, err := argumentFromJson(argument)
+		require.Error(t, err, "argument %s of %v was accepted", argument.Type, argument.Value)
+	}
+}
+
+func TestClientJson_FailsOnArgumentsWithoutJsonRepresentation(t *testing.T) {
+	for _, native := range []interface{}{float64(1.5), []float64{1.5}, nil} {
+		_, err := argumentToJson(native)
+		require.Error(t, err, "argument %v of type %T was represented", native, native)
+	}
+}
@@ -12,6 +12,9 @@ import (
 	membuffers "github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/snapshot"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
 	"io/ioutil"
 	"net"
@@ -23,7 +26,6 @@ import (
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
-	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
 )
 
@@ -40,10 +42,12 @@ type HttpServer struct {
 	httpServer *http.Server
 	router     *http.ServeMux
 
-	logger         log.Logger
-	publicApi      services.PublicApi
-	metricRegistry metric.Registry
-	config         config.HttpServerConfig
+	logger           log.Logger
+	publicApi        publicapi.PublicApi
+	snapshotExporter *snapshot.Exporter
+	poolInspector    transactionpool.PoolInspector
+	metricRegistry   metric.Registry
+	config           config.HttpServerConfig
 
 	port int
 }
@@ -120,10 +124,18 @@ func (s *HttpServer) GracefulShutdown(shutdownContext context.Context) {
 
 }
 
-func (s *HttpServer) RegisterPublicApi(publicApi services.PublicApi) {
+func (s *HttpServer) RegisterPublicApi(publicApi publicapi.PublicApi) {
 	s.publicApi = publicApi
 }
 
+func (s *HttpServer) RegisterStateSnapshotExporter(exporter *snapshot.Exporter) {
+	s.snapshotExporter = exporter
+}
+
+func (s *HttpServer) RegisterTransactionPoolInspector(inspector transactionpool.PoolInspector) {
+	s.poolInspector = inspector
+}
+
 // Allows handler to be called via XHR requests from any host
 func wrapHandlerWithCORS(f func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -166,10 +178,17 @@ func (s *HttpServer) createRouter() *http.ServeMux {
 
 	s.registerHttpHandler(router, "/api/v1/send-transaction", true, s.sendTransactionHandler)
 	s.registerHttpHandler(router, "/api/v1/send-transaction-async", true, s.sendTransactionAsyncHandler)
+	s.registerHttpHandler(router, "/api/v1/calc-transaction-hash", true, s.calcTransactionHashHandler)
 	s.registerHttpHandler(router, "/api/v1/run-query", true, s.runQueryHandler)
 	s.registerHttpHandler(router, "/api/v1/get-transaction-status", true, s.getTransactionStatusHandler)
 	s.registerHttpHandler(router, "/api/v1/get-transaction-receipt-proof", true, s.getTransactionReceiptProofHandler)
 	s.registerHttpHandler(router, "/api/v1/get-block", true, s.getBlockHandler)
+	s.registerHttpHandler(router, "/api/v1/get-state-proof", true, s.getStateProofHandler)
+	s.registerHttpHandler(router, "/api/v1/update-pending-transaction", true, s.updatePendingTransactionHandler)
+	s.registerHttpHandler(router, "/api/v1/subscribe-transaction-status", true, s.subscribeTransactionStatusHandler)
+	s.registerHttpHandler(router, "/api/v1/get-contract-events", true, s.getContractEventsHandler)
+	s.registerHttpHandler(router, "/api/v1/subscribe-contract-events", true, s.subscribeContractEventsHandler)
+	s.registerHttpHandler(router, "/api/v1/batch", true, s.batchHandler)
 	s.registerHttpHandler(router, "/status", true, s.getStatus)
 	s.registerHttpHandler(router, "/metrics", true, s.dumpMetricsAsJSON)
 	s.registerHttpHandler(router, "/metrics.json", true, s.dumpMetricsAsJSON)
@@ -184,6 +203,15 @@ func (s *HttpServer) createRouter() *http.ServeMux {
 		registerPprof(router)
 	}
 
+	if s.config.StateSnapshotExport() {
+		s.registerHttpHandler(router, "/debug/state-snapshot", false, s.exportStateSnapshotHandler)
+	}
+
+	if s.config.TransactionPoolInspection() {
+		s.registerHttpHandler(router, "/debug/transaction-pool/pending", false, s.inspectPendingPoolHandler)
+		s.registerHttpHandler(router, "/debug/transaction-pool/committed", false, s.inspectCommittedPoolHandler)
+	}
+
 	return router
 }
 
@@ -229,8 +257,12 @@ func translateRequestStatusToHttpCode(responseCode protocol.RequestStatus) int {
 }
 
 func (s *HttpServer) writeMembuffResponse(w http.ResponseWriter, message membuffers.Message, requestResult *client.RequestResult, errorForVerbosity error) {
+	s.writeResponse(w, "application/membuffers", message.Raw(), requestResult, errorForVerbosity)
+}
+
+func (s *HttpServer) writeResponse(w http.ResponseWriter, contentType string, data []byte, requestResult *client.RequestResult, errorForVerbosity error) {
 	httpCode := translateRequestStatusToHttpCode(requestResult.RequestStatus())
-	w.Header().Set("Content-Type", "application/membuffers")
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("X-ORBS-REQUEST-RESULT", requestResult.RequestStatus().String())
 	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", requestResult.BlockHeight()))
 	w.Header().Set("X-ORBS-BLOCK-TIMESTAMP", sprintfTimestamp(requestResult.BlockTimestamp()))
@@ -239,7 +271,7 @@ func (s *HttpServer) writeMembuffResponse(w http.ResponseWriter, message membuff
 		w.Header().Set("X-ORBS-ERROR-DETAILS", errorForVerbosity.Error())
 	}
 	w.WriteHeader(httpCode)
-	_, err := w.Write(message.Raw())
+	_, err := w.Write(data)
 	if err != nil {
 		s.logger.Info("error writing response", log.Error(err))
 	}
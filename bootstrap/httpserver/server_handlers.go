@@ -7,12 +7,25 @@
 package httpserver
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	membuffers "github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type IndexResponse struct {
@@ -21,6 +34,132 @@ type IndexResponse struct {
 	Version     config.Version
 }
 
+// state proofs are not part of the membuffers client protocol so they are served as JSON
+type GetStateProofRequest struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	BlockHeight     primitives.BlockHeight
+	ContractName    primitives.ContractName
+	Key             []byte
+}
+
+type GetStateProofResponse struct {
+	RequestStatus          string
+	BlockHeight            primitives.BlockHeight
+	StateMerkleRootHash    primitives.Sha256
+	Value                  []byte
+	Proof                  *statestorage.StateProof
+	NextResultsBlockHeader []byte `json:",omitempty"` // raw membuffer of protocol.ResultsBlockHeader
+	NextResultsBlockProof  []byte `json:",omitempty"` // raw membuffer of protocol.ResultsBlockProof
+}
+
+// cancelling or replacing a pending transaction is not part of the membuffers client protocol so it is served as JSON.
+// Signature is the ed25519 signature of transactionpool.PendingTransactionUpdateDigest by the signer of the transaction
+type UpdatePendingTransactionRequest struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	TxHash          primitives.Sha256
+	SignerPublicKey primitives.Ed25519PublicKey
+	Signature       primitives.Ed25519Sig
+	Replacement     []byte `json:",omitempty"` // raw membuffer of protocol.SignedTransaction, omitted to cancel
+}
+
+type UpdatePendingTransactionResponse struct {
+	RequestStatus     string
+	TransactionStatus string // of the replacement, TRANSACTION_STATUS_RESERVED for a cancellation
+	Withdrawn         bool   // the transaction was withdrawn from the pool of the node, which broadcast the withdrawal
+	BlockHeight       primitives.BlockHeight
+	BlockTimestamp    primitives.TimestampNano
+}
+
+// transaction status subscriptions are streamed as server-sent events, each carrying one of these as JSON
+type TransactionStatusEventResponse struct {
+	Txhash             primitives.Sha256
+	RequestStatus      string
+	TransactionStatus  string
+	BlockHeight        primitives.BlockHeight
+	BlockTimestamp     primitives.TimestampNano
+	TransactionReceipt []byte `json:",omitempty"` // raw membuffer of protocol.TransactionReceipt
+}
+
+// contract events are not part of the membuffers client protocol so they are served as JSON
+type GetContractEventsRequest struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	ContractName    primitives.ContractName
+	EventName       primitives.EventName
+	FromBlockHeight primitives.BlockHeight
+	ToBlockHeight   primitives.BlockHeight
+}
+
+type GetContractEventsResponse struct {
+	RequestStatus   string
+	Events          []*ContractEventResponse
+	LastBlockHeight primitives.BlockHeight
+}
+
+// also streamed as the data of each server-sent event of a contract event subscription
+type ContractEventResponse struct {
+	BlockHeight         primitives.BlockHeight
+	BlockTimestamp      primitives.TimestampNano
+	Txhash              primitives.Sha256
+	ContractName        primitives.ContractName
+	EventName           primitives.EventName
+	OutputArgumentArray []byte // raw membuffer of protocol.ArgumentArray
+}
+
+// a batch carries raw membuffer requests of the read-only endpoints which can be served as of a block height, named by
+// Type after their url path (run-query, get-transaction-status or get-block). responses are returned in order
+type BatchRequest struct {
+	Requests []*BatchItemRequest
+}
+
+type BatchItemRequest struct {
+	Type    string
+	Request []byte // raw membuffer of the client request
+}
+
+type BatchResponse struct {
+	BlockHeight primitives.BlockHeight // every query of the batch ran against the state of this block
+	Responses   []*BatchItemResponse
+}
+
+type BatchItemResponse struct {
+	Type          string
+	HttpStatus    int
+	RequestStatus string `json:",omitempty"`
+	Response      []byte `json:",omitempty"` // raw membuffer of the client response
+	Error         string `json:",omitempty"`
+}
+
+// the transaction pool inspection is a debugging aid, so hashes, keys and durations are shown as text
+type PendingPoolResponse struct {
+	Transactions []*PendingTransactionResponse
+}
+
+type PendingTransactionResponse struct {
+	TxHash             string
+	SignerPublicKey    string
+	ContractName       primitives.ContractName
+	MethodName         primitives.MethodName
+	Timestamp          primitives.TimestampNano
+	GatewayNodeAddress string
+	SizeInBytes        uint32
+	TimeInPool         string
+}
+
+type CommittedPoolResponse struct {
+	Transactions []*CommittedTransactionResponse
+}
+
+type CommittedTransactionResponse struct {
+	TxHash          string
+	ExecutionResult string
+	BlockHeight     primitives.BlockHeight
+	BlockTimestamp  primitives.TimestampNano
+	SizeInBytes     uint32
+}
+
 // Serves both index and 404 because router is built that way
 func (s *HttpServer) Index(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -102,7 +241,7 @@ func (s *HttpServer) dumpMetricsAsPrometheus(w http.ResponseWriter, r *http.Requ
 }
 
 func (s *HttpServer) sendTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, e := readInput(r)
+	bytes, e := readClientRequest(r, sendTransactionRequestFromJson)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
@@ -117,14 +256,14 @@ func (s *HttpServer) sendTransactionHandler(w http.ResponseWriter, r *http.Reque
 	s.logger.Info("http HttpServer received send-transaction", log.Stringable("request", clientRequest))
 	result, err := s.publicApi.SendTransaction(r.Context(), &services.SendTransactionInput{ClientRequest: clientRequest})
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
 
 func (s *HttpServer) sendTransactionAsyncHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, e := readInput(r)
+	bytes, e := readClientRequest(r, sendTransactionRequestFromJson)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
@@ -139,36 +278,89 @@ func (s *HttpServer) sendTransactionAsyncHandler(w http.ResponseWriter, r *http.
 	s.logger.Info("http HttpServer received send-transaction-async", log.Stringable("request", clientRequest))
 	result, err := s.publicApi.SendTransactionAsync(r.Context(), &services.SendTransactionInput{ClientRequest: clientRequest})
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
 
-func (s *HttpServer) runQueryHandler(w http.ResponseWriter, r *http.Request) {
+// calcTransactionHashHandler returns the hash a JSON transaction is signed by, which is calculated over the membuffer
+// send-transaction translates the same JSON to
+func (s *HttpServer) calcTransactionHashHandler(w http.ResponseWriter, r *http.Request) {
+	if !isJsonRequest(r) {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusUnsupportedMediaType, nil, "calc-transaction-hash accepts only " + jsonContentType + " requests"})
+		return
+	}
 	bytes, e := readInput(r)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
 	}
 
+	request := &JsonTransaction{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid json client request: " + err.Error()})
+		return
+	}
+	builder, err := transactionFromJson(request)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid json client request: " + err.Error()})
+		return
+	}
+	transaction := builder.Build()
+
+	s.writeJsonResponse(w, &JsonTransactionHashResponse{Txhash: digest.CalcTxHash(transaction), Transaction: transaction.Raw()})
+}
+
+func (s *HttpServer) runQueryHandler(w http.ResponseWriter, r *http.Request) {
+	bytes, e := readClientRequest(r, runQueryRequestFromJson)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
 	clientRequest := client.RunQueryRequestReader(bytes)
 	if e := validate(clientRequest); e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
 	}
 
-	s.logger.Info("http HttpServer received run-query", log.Stringable("request", clientRequest))
-	result, err := s.publicApi.RunQuery(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest})
+	blockHeight, e := readBlockHeightParam(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	s.logger.Info("http HttpServer received run-query", log.Stringable("request", clientRequest), log.Stringable("block-height", blockHeight))
+	var result *services.RunQueryOutput
+	var err error
+	if blockHeight == 0 {
+		result, err = s.publicApi.RunQuery(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest})
+	} else {
+		result, err = s.publicApi.RunQueryAtBlockHeight(r.Context(), &publicapi.RunQueryAtBlockHeightInput{ClientRequest: clientRequest, BlockHeight: blockHeight})
+	}
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
 
+// queries run against the last committed block unless the url carries an explicit ?block-height=N
+func readBlockHeightParam(r *http.Request) (primitives.BlockHeight, *httpErr) {
+	raw := r.URL.Query().Get("block-height")
+	if raw == "" {
+		return 0, nil
+	}
+	height, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, &httpErr{http.StatusBadRequest, log.Error(err), "block-height url parameter is not a valid block height"}
+	}
+	return primitives.BlockHeight(height), nil
+}
+
 func (s *HttpServer) getTransactionStatusHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, e := readInput(r)
+	bytes, e := readClientRequest(r, getTransactionStatusRequestFromJson)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
@@ -183,14 +375,14 @@ func (s *HttpServer) getTransactionStatusHandler(w http.ResponseWriter, r *http.
 	s.logger.Info("http HttpServer received get-transaction-status", log.Stringable("request", clientRequest))
 	result, err := s.publicApi.GetTransactionStatus(r.Context(), &services.GetTransactionStatusInput{ClientRequest: clientRequest})
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
 
 func (s *HttpServer) getTransactionReceiptProofHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, e := readInput(r)
+	bytes, e := readClientRequest(r, getTransactionReceiptProofRequestFromJson)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
@@ -205,14 +397,14 @@ func (s *HttpServer) getTransactionReceiptProofHandler(w http.ResponseWriter, r
 	s.logger.Info("http HttpServer received get-transaction-receipt-proof", log.Stringable("request", clientRequest))
 	result, err := s.publicApi.GetTransactionReceiptProof(r.Context(), &services.GetTransactionReceiptProofInput{ClientRequest: clientRequest})
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
 
 func (s *HttpServer) getBlockHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, e := readInput(r)
+	bytes, e := readClientRequest(r, getBlockRequestFromJson)
 	if e != nil {
 		s.writeErrorResponseAndLog(w, e)
 		return
@@ -227,8 +419,570 @@ func (s *HttpServer) getBlockHandler(w http.ResponseWriter, r *http.Request) {
 	s.logger.Info("http HttpServer received get-block", log.Stringable("request", clientRequest))
 	result, err := s.publicApi.GetBlock(r.Context(), &services.GetBlockInput{ClientRequest: clientRequest})
 	if result != nil && result.ClientResponse != nil {
-		s.writeMembuffResponse(w, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+		s.writeClientResponse(w, r, result.ClientResponse, result.ClientResponse.RequestResult(), err)
+	} else {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
+	}
+}
+
+func (s *HttpServer) getStateProofHandler(w http.ResponseWriter, r *http.Request) {
+	bytes, e := readInput(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	request := &GetStateProofRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid get-state-proof request"})
+		return
+	}
+
+	s.logger.Info("http HttpServer received get-state-proof", log.Stringable("contract", request.ContractName), log.Stringable("block-height", request.BlockHeight))
+	result, err := s.publicApi.GetStateProof(r.Context(), &publicapi.GetStateProofInput{
+		ProtocolVersion: request.ProtocolVersion,
+		VirtualChainId:  request.VirtualChainId,
+		BlockHeight:     request.BlockHeight,
+		ContractName:    request.ContractName,
+		Key:             request.Key,
+	})
+	if result != nil {
+		s.writeStateProofResponse(w, result, err)
 	} else {
 		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
 	}
 }
+
+func (s *HttpServer) writeStateProofResponse(w http.ResponseWriter, result *publicapi.GetStateProofOutput, errorForVerbosity error) {
+	response := &GetStateProofResponse{
+		RequestStatus:       result.RequestStatus.String(),
+		BlockHeight:         result.BlockHeight,
+		StateMerkleRootHash: result.StateMerkleRootHash,
+		Value:               result.Value,
+		Proof:               result.Proof,
+	}
+	if result.NextResultsBlockHeader != nil && result.NextResultsBlockProof != nil {
+		response.NextResultsBlockHeader = result.NextResultsBlockHeader.Raw()
+		response.NextResultsBlockProof = result.NextResultsBlockProof.Raw()
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), "failed to encode get-state-proof response"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", result.BlockHeight))
+	if errorForVerbosity != nil {
+		w.Header().Set("X-ORBS-ERROR-DETAILS", errorForVerbosity.Error())
+	}
+	w.WriteHeader(translateRequestStatusToHttpCode(result.RequestStatus))
+	_, err = w.Write(data)
+	if err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+func (s *HttpServer) updatePendingTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	bytes, e := readInput(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	request := &UpdatePendingTransactionRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid update-pending-transaction request"})
+		return
+	}
+
+	input := &publicapi.UpdatePendingTransactionInput{
+		ProtocolVersion: request.ProtocolVersion,
+		VirtualChainId:  request.VirtualChainId,
+		TxHash:          request.TxHash,
+		SignerPublicKey: request.SignerPublicKey,
+		Signature:       request.Signature,
+	}
+	if len(request.Replacement) > 0 {
+		input.Replacement = protocol.SignedTransactionReader(request.Replacement)
+		if !input.Replacement.IsValid() {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, nil, "http request replacement is not a valid SignedTransaction"})
+			return
+		}
+	}
+
+	s.logger.Info("http HttpServer received update-pending-transaction", log.Stringable("txHash", request.TxHash))
+	result, err := s.publicApi.UpdatePendingTransaction(r.Context(), input)
+	if result == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
+		return
+	}
+
+	data, e2 := json.Marshal(&UpdatePendingTransactionResponse{
+		RequestStatus:     result.RequestStatus.String(),
+		TransactionStatus: result.TransactionStatus.String(),
+		Withdrawn:         result.Withdrawn,
+		BlockHeight:       result.BlockHeight,
+		BlockTimestamp:    result.BlockTimestamp,
+	})
+	if e2 != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(e2), "failed to encode update-pending-transaction response"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", result.BlockHeight))
+	if err != nil {
+		w.Header().Set("X-ORBS-ERROR-DETAILS", err.Error())
+	}
+	w.WriteHeader(translateRequestStatusToHttpCode(result.RequestStatus))
+	if _, err := w.Write(data); err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+// subscribeTransactionStatusHandler streams the status of the transactions given as txhash url parameters, one
+// "status" event per transition, and ends the response once all of them reached a final status
+func (s *HttpServer) subscribeTransactionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, nil, "streaming is not supported"})
+		return
+	}
+
+	input := &publicapi.SubscribeTransactionStatusInput{}
+	for _, raw := range r.URL.Query()["txhash"] {
+		txHash, err := hex.DecodeString(strings.TrimPrefix(raw, "0x"))
+		if err != nil || len(txHash) != 32 {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, nil, "txhash url parameter is not a hex transaction hash"})
+			return
+		}
+		input.Txhashes = append(input.Txhashes, txHash)
+	}
+
+	events, err := s.publicApi.SubscribeTransactionStatus(r.Context(), input)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), err.Error()})
+		return
+	}
+
+	s.logger.Info("http HttpServer received subscribe-transaction-status", log.Int("transactions", len(input.Txhashes)))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for event := range events {
+		response := &TransactionStatusEventResponse{
+			Txhash:            event.Txhash,
+			RequestStatus:     event.RequestStatus.String(),
+			TransactionStatus: event.TransactionStatus.String(),
+			BlockHeight:       event.BlockHeight,
+			BlockTimestamp:    event.BlockTimestamp,
+		}
+		if event.TransactionReceipt != nil {
+			response.TransactionReceipt = event.TransactionReceipt.Raw()
+		}
+		if err := s.writeServerSentEvent(w, flusher, "status", response); err != nil {
+			return // the request context is cancelled, which ends the subscription
+		}
+	}
+}
+
+func (s *HttpServer) getContractEventsHandler(w http.ResponseWriter, r *http.Request) {
+	bytes, e := readInput(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	request := &GetContractEventsRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid get-contract-events request"})
+		return
+	}
+
+	s.logger.Info("http HttpServer received get-contract-events", log.Stringable("contract", request.ContractName), log.Stringable("from-block-height", request.FromBlockHeight))
+	result, err := s.publicApi.GetContractEvents(r.Context(), &publicapi.GetContractEventsInput{
+		ProtocolVersion: request.ProtocolVersion,
+		VirtualChainId:  request.VirtualChainId,
+		ContractName:    request.ContractName,
+		EventName:       request.EventName,
+		FromBlockHeight: request.FromBlockHeight,
+		ToBlockHeight:   request.ToBlockHeight,
+	})
+	if result == nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
+		return
+	}
+
+	response := &GetContractEventsResponse{
+		RequestStatus:   result.RequestStatus.String(),
+		Events:          []*ContractEventResponse{},
+		LastBlockHeight: result.LastBlockHeight,
+	}
+	for _, event := range result.Events {
+		response.Events = append(response.Events, toContractEventResponse(event))
+	}
+
+	data, e2 := json.Marshal(response)
+	if e2 != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(e2), "failed to encode get-contract-events response"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-ORBS-REQUEST-RESULT", result.RequestStatus.String())
+	w.Header().Set("X-ORBS-BLOCK-HEIGHT", fmt.Sprintf("%d", result.LastBlockHeight))
+	if err != nil {
+		w.Header().Set("X-ORBS-ERROR-DETAILS", err.Error())
+	}
+	w.WriteHeader(translateRequestStatusToHttpCode(result.RequestStatus))
+	if _, err := w.Write(data); err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+// subscribeContractEventsHandler streams the events of the contract url parameter as "contract-event" events. the
+// virtual-chain-id, event and from (block height) url parameters are passed along as well
+func (s *HttpServer) subscribeContractEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, nil, "streaming is not supported"})
+		return
+	}
+
+	query := r.URL.Query()
+	input := &publicapi.SubscribeContractEventsInput{
+		ContractName: primitives.ContractName(query.Get("contract")),
+		EventName:    primitives.EventName(query.Get("event")),
+	}
+	if raw := query.Get("virtual-chain-id"); raw != "" {
+		vcid, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "virtual-chain-id url parameter is not a valid number"})
+			return
+		}
+		input.VirtualChainId = primitives.VirtualChainId(vcid)
+	}
+	if raw := query.Get("from"); raw != "" {
+		from, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "from url parameter is not a valid block height"})
+			return
+		}
+		input.FromBlockHeight = primitives.BlockHeight(from)
+	}
+
+	events, err := s.publicApi.SubscribeContractEvents(r.Context(), input)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), err.Error()})
+		return
+	}
+
+	s.logger.Info("http HttpServer received subscribe-contract-events", log.Stringable("contract", input.ContractName), log.Stringable("from-block-height", input.FromBlockHeight))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for event := range events {
+		if err := s.writeServerSentEvent(w, flusher, "contract-event", toContractEventResponse(event)); err != nil {
+			return // the request context is cancelled, which ends the subscription
+		}
+	}
+}
+
+// batchHandler serves a batch of requests in order. queries run against the block height given as url parameter, or
+// else against the block the first query of the batch ran against, so their results are consistent with each other
+func (s *HttpServer) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(s.config.HttpBatchMaxSizeBytes()))
+	}
+	bytes, e := readInput(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	request := &BatchRequest{}
+	if err := json.Unmarshal(bytes, request); err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Error(err), "http request is not a valid batch request"})
+		return
+	}
+	if len(request.Requests) == 0 {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, nil, "batch request is empty"})
+		return
+	}
+	if maxRequests := s.config.HttpBatchMaxRequests(); uint32(len(request.Requests)) > maxRequests {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusBadRequest, log.Int("requests", len(request.Requests)), fmt.Sprintf("batch request exceeds the limit of %d requests", maxRequests)})
+		return
+	}
+
+	blockHeight, e := readBlockHeightParam(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	s.logger.Info("http HttpServer received batch", log.Int("requests", len(request.Requests)), log.Stringable("block-height", blockHeight))
+	response := &BatchResponse{Responses: make([]*BatchItemResponse, 0, len(request.Requests))}
+	for _, item := range request.Requests {
+		response.Responses = append(response.Responses, s.serveBatchItem(r, item, &blockHeight))
+	}
+	response.BlockHeight = blockHeight
+
+	s.writeJsonResponse(w, response)
+}
+
+// serveBatchItem serves a single request of a batch. blockHeight pins the state queries run against once a query ran,
+// transactions committed in later blocks are reported pending and blocks following it are not served
+func (s *HttpServer) serveBatchItem(r *http.Request, item *BatchItemRequest, blockHeight *primitives.BlockHeight) *BatchItemResponse {
+	var message membuffers.Message
+	var requestResult *client.RequestResult
+	var err error
+
+	switch item.Type {
+	case "run-query":
+		clientRequest := client.RunQueryRequestReader(item.Request)
+		if e := validate(clientRequest); e != nil {
+			return batchItemError(item, e)
+		}
+		var result *services.RunQueryOutput
+		if *blockHeight == 0 {
+			result, err = s.publicApi.RunQuery(r.Context(), &services.RunQueryInput{ClientRequest: clientRequest})
+		} else {
+			result, err = s.publicApi.RunQueryAtBlockHeight(r.Context(), &publicapi.RunQueryAtBlockHeightInput{ClientRequest: clientRequest, BlockHeight: *blockHeight})
+		}
+		if result != nil && result.ClientResponse != nil {
+			message, requestResult = result.ClientResponse, result.ClientResponse.RequestResult()
+			if *blockHeight == 0 {
+				*blockHeight = requestResult.BlockHeight()
+			}
+		}
+
+	case "get-transaction-status":
+		clientRequest := client.GetTransactionStatusRequestReader(item.Request)
+		if e := validate(clientRequest); e != nil {
+			return batchItemError(item, e)
+		}
+		var result *services.GetTransactionStatusOutput
+		result, err = s.publicApi.GetTransactionStatusAtBlockHeight(r.Context(), &publicapi.GetTransactionStatusAtBlockHeightInput{ClientRequest: clientRequest, BlockHeight: *blockHeight})
+		if result != nil && result.ClientResponse != nil {
+			message, requestResult = result.ClientResponse, result.ClientResponse.RequestResult()
+		}
+
+	case "get-transaction-receipt-proof":
+		// the proof is of the latest committed state of a transaction, which cannot be read as of the batch block
+		return batchItemError(item, &httpErr{http.StatusBadRequest, nil, fmt.Sprintf("batch request type %q cannot be pinned to the batch block height", item.Type)})
+
+	case "get-block":
+		clientRequest := client.GetBlockRequestReader(item.Request)
+		if e := validate(clientRequest); e != nil {
+			return batchItemError(item, e)
+		}
+		if *blockHeight != 0 && clientRequest.BlockHeight() > *blockHeight {
+			return batchItemError(item, &httpErr{http.StatusBadRequest, nil, fmt.Sprintf("block %d follows the batch block height %d", clientRequest.BlockHeight(), *blockHeight)})
+		}
+		var result *services.GetBlockOutput
+		result, err = s.publicApi.GetBlock(r.Context(), &services.GetBlockInput{ClientRequest: clientRequest})
+		if result != nil && result.ClientResponse != nil {
+			message, requestResult = result.ClientResponse, result.ClientResponse.RequestResult()
+		}
+
+	default:
+		return batchItemError(item, &httpErr{http.StatusBadRequest, nil, fmt.Sprintf("batch request type %q is not supported", item.Type)})
+	}
+
+	if message == nil {
+		e := &httpErr{http.StatusInternalServerError, nil, "no response"}
+		if err != nil {
+			e.message = err.Error()
+		}
+		return batchItemError(item, e)
+	}
+
+	response := &BatchItemResponse{
+		Type:          item.Type,
+		HttpStatus:    translateRequestStatusToHttpCode(requestResult.RequestStatus()),
+		RequestStatus: requestResult.RequestStatus().String(),
+		Response:      message.Raw(),
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	return response
+}
+
+func batchItemError(item *BatchItemRequest, e *httpErr) *BatchItemResponse {
+	return &BatchItemResponse{Type: item.Type, HttpStatus: e.code, Error: e.message}
+}
+
+func toContractEventResponse(event *blockstorage.ContractEvent) *ContractEventResponse {
+	return &ContractEventResponse{
+		BlockHeight:         event.BlockHeight,
+		BlockTimestamp:      event.BlockTimestamp,
+		Txhash:              event.Txhash,
+		ContractName:        event.ContractName,
+		EventName:           event.EventName,
+		OutputArgumentArray: event.OutputArgumentArray,
+	}
+}
+
+func (s *HttpServer) writeServerSentEvent(w http.ResponseWriter, flusher http.Flusher, name string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("failed to encode server-sent event", log.Error(err), log.String("event", name))
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		s.logger.Info("error writing server-sent event", log.Error(err), log.String("event", name))
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+func (s *HttpServer) inspectPendingPoolHandler(w http.ResponseWriter, r *http.Request) {
+	if s.poolInspector == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, e := readPendingPoolFilter(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	response := &PendingPoolResponse{Transactions: []*PendingTransactionResponse{}}
+	for _, tx := range s.poolInspector.InspectPendingPool(filter) {
+		response.Transactions = append(response.Transactions, &PendingTransactionResponse{
+			TxHash:             tx.TxHash.String(),
+			SignerPublicKey:    tx.SignerPublicKey.String(),
+			ContractName:       tx.ContractName,
+			MethodName:         tx.MethodName,
+			Timestamp:          tx.Timestamp,
+			GatewayNodeAddress: tx.GatewayNodeAddress.String(),
+			SizeInBytes:        tx.SizeInBytes,
+			TimeInPool:         tx.TimeInPool.String(),
+		})
+	}
+	s.writeJsonResponse(w, response)
+}
+
+func (s *HttpServer) inspectCommittedPoolHandler(w http.ResponseWriter, r *http.Request) {
+	if s.poolInspector == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, e := readLimitParam(r)
+	if e != nil {
+		s.writeErrorResponseAndLog(w, e)
+		return
+	}
+
+	response := &CommittedPoolResponse{Transactions: []*CommittedTransactionResponse{}}
+	for _, tx := range s.poolInspector.InspectCommittedPool(limit) {
+		response.Transactions = append(response.Transactions, &CommittedTransactionResponse{
+			TxHash:          tx.TxHash.String(),
+			ExecutionResult: tx.ExecutionResult.String(),
+			BlockHeight:     tx.BlockHeight,
+			BlockTimestamp:  tx.BlockTimestamp,
+			SizeInBytes:     tx.SizeInBytes,
+		})
+	}
+	s.writeJsonResponse(w, response)
+}
+
+func readPendingPoolFilter(r *http.Request) (*transactionpool.PendingPoolFilter, *httpErr) {
+	query := r.URL.Query()
+	filter := &transactionpool.PendingPoolFilter{ContractName: primitives.ContractName(query.Get("contract"))}
+
+	if raw := query.Get("signer"); raw != "" {
+		signer, err := hex.DecodeString(strings.TrimPrefix(raw, "0x"))
+		if err != nil {
+			return nil, &httpErr{http.StatusBadRequest, log.Error(err), "signer url parameter is not a hex public key"}
+		}
+		filter.SignerPublicKey = signer
+	}
+
+	if raw := query.Get("min-age"); raw != "" {
+		minAge, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, &httpErr{http.StatusBadRequest, log.Error(err), "min-age url parameter is not a valid duration"}
+		}
+		filter.MinTimeInPool = minAge
+	}
+
+	limit, e := readLimitParam(r)
+	if e != nil {
+		return nil, e
+	}
+	filter.Limit = limit
+
+	return filter, nil
+}
+
+func readLimitParam(r *http.Request) (int, *httpErr) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseUint(raw, 10, 31)
+	if err != nil {
+		return 0, &httpErr{http.StatusBadRequest, log.Error(err), "limit url parameter is not a valid number"}
+	}
+	return int(limit), nil
+}
+
+func (s *HttpServer) writeJsonResponse(w http.ResponseWriter, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), "failed to encode response"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		s.logger.Info("error writing response", log.Error(err))
+	}
+}
+
+func (s *HttpServer) exportStateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if s.snapshotExporter == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	out := &trackingWriter{w: w}
+	header, err := s.snapshotExporter.Export(r.Context(), out)
+	if err != nil {
+		if !out.written {
+			s.writeErrorResponseAndLog(w, &httpErr{http.StatusInternalServerError, log.Error(err), err.Error()})
+		} else { // the snapshot trailer is never written so the client can tell the snapshot is incomplete
+			s.logger.Error("failed exporting state snapshot", log.Error(err))
+		}
+		return
+	}
+	s.logger.Info("http HttpServer exported state snapshot", log.Stringable("block-height", header.BlockHeight))
+}
+
+// once a response body was written the status code can no longer be changed
+type trackingWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
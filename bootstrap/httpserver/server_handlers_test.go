@@ -10,9 +10,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/publicapi/testkit"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/snapshot"
+	stateStorageTestkit "github.com/orbs-network/orbs-network-go/services/statestorage/testkit"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -23,6 +31,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -108,6 +117,225 @@ func TestHttpServer_RunQuery_Error(t *testing.T) {
 	})
 }
 
+func TestHttpServer_RunQuery_AtBlockHeight(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			response := &client.RunQueryResponseBuilder{
+				RequestResult: aCompletedResult(),
+				QueryResult: &protocol.QueryResultBuilder{
+					ExecutionResult: protocol.EXECUTION_RESULT_SUCCESS,
+				},
+			}
+
+			h.onRunQueryAtBlockHeight(7).Return(&services.RunQueryOutput{ClientResponse: response.Build()})
+
+			rec := h.runQueryWithUrl("/api/v1/run-query?block-height=7")
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+		})
+	})
+}
+
+func TestHttpServer_RunQuery_InvalidBlockHeight(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			rec := h.runQueryWithUrl("/api/v1/run-query?block-height=latest")
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+		})
+	})
+}
+
+func TestHttpServer_CalcTransactionHash_ReturnsTheHashSendTransactionSigns(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			transaction := `{"ProtocolVersion":1,"VirtualChainId":42,"Timestamp":1000,"Signer":{"NetworkType":"NETWORK_TYPE_TEST_NET","PublicKey":"AQI="},
+				"ContractName":"Token","MethodName":"transfer","InputArguments":[{"Type":"uint64","Value":"17"}]}`
+
+			rec := h.postJson(h.server.calcTransactionHashHandler, transaction)
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			result := &JsonTransactionHashResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+			require.EqualValues(t, "transfer", protocol.TransactionReader(result.Transaction).MethodName(), "should return the transaction it hashed")
+
+			h.publicApi.When("SendTransaction", mock.Any, mock.AnyIf("transaction hashed by calc-transaction-hash", func(i interface{}) bool {
+				sent := i.(*services.SendTransactionInput).ClientRequest.SignedTransaction().Transaction()
+				return digest.CalcTxHash(sent).Equal(result.Txhash)
+			})).Return(&services.SendTransactionOutput{ClientResponse: (&client.SendTransactionResponseBuilder{RequestResult: aCompletedResult()}).Build()}).Times(1)
+
+			h.postJson(h.server.sendTransactionHandler, transaction)
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "send-transaction should sign the hash calc-transaction-hash returned, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_CalcTransactionHash_AcceptsOnlyJson(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			req, _ := http.NewRequest("POST", "", bytes.NewReader((&protocol.TransactionBuilder{}).Build().Raw()))
+			rec := httptest.NewRecorder()
+			h.server.calcTransactionHashHandler(rec, req)
+
+			require.Equal(t, http.StatusUnsupportedMediaType, rec.Code, "should fail with 415")
+		})
+	})
+}
+
+func TestHttpServer_RunQuery_Json(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			outputArguments, err := protocol.ArgumentArrayFromNatives([]interface{}{uint64(17), "balance"})
+			require.NoError(t, err)
+			response := &client.RunQueryResponseBuilder{
+				RequestResult: aCompletedResult(),
+				QueryResult: &protocol.QueryResultBuilder{
+					ExecutionResult:     protocol.EXECUTION_RESULT_SUCCESS,
+					OutputArgumentArray: outputArguments.RawArgumentsArray(),
+				},
+			}
+
+			h.publicApi.When("RunQuery", mock.Any, mock.AnyIf("query translated from json", func(i interface{}) bool {
+				query := i.(*services.RunQueryInput).ClientRequest.SignedQuery().Query()
+				natives, err := protocol.PackedOutputArgumentsToNatives(query.RawInputArgumentArrayWithHeader())
+				return err == nil && query.MethodName() == "getBalance" && query.Signer().Eddsa().NetworkType() == protocol.NETWORK_TYPE_TEST_NET &&
+					len(natives) == 1 && natives[0] == [20]byte{0x0a}
+			})).Return(&services.RunQueryOutput{ClientResponse: response.Build()}).Times(1)
+
+			rec := h.postJson(h.server.runQueryHandler, `{"ProtocolVersion":1,"VirtualChainId":42,"Signer":{"NetworkType":"NETWORK_TYPE_TEST_NET"},
+				"ContractName":"Token","MethodName":"getBalance","InputArguments":[{"Type":"bytes20","Value":"0x0a00000000000000000000000000000000000000"}]}`)
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "should answer in json")
+			require.Equal(t, "REQUEST_STATUS_COMPLETED", rec.Header().Get("X-ORBS-REQUEST-RESULT"))
+
+			result := &JsonRunQueryResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+			require.Equal(t, "EXECUTION_RESULT_SUCCESS", result.QueryResult.ExecutionResult)
+			require.Len(t, result.QueryResult.OutputArguments, 2)
+			require.Equal(t, "uint64", result.QueryResult.OutputArguments[0].Type)
+			require.Equal(t, "17", result.QueryResult.OutputArguments[0].Value)
+			require.Equal(t, "balance", result.QueryResult.OutputArguments[1].Value)
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "query was not translated from json, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_SendTransaction_InvalidJson(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("SendTransaction", mock.Any, mock.Any)
+
+			rec := h.postJson(h.server.sendTransactionHandler, `{"Signer":{"NetworkType":"NETWORK_TYPE_TEST_NET"},"InputArguments":[{"Type":"uint64","Value":10}]}`)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			require.Contains(t, rec.Body.String(), "input argument 0", "should tell which argument is invalid")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_GetTransactionStatus_Json(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			response := &client.GetTransactionStatusResponseBuilder{
+				RequestResult: &client.RequestResultBuilder{
+					RequestStatus: protocol.REQUEST_STATUS_IN_PROCESS,
+					BlockHeight:   5,
+				},
+				TransactionStatus: protocol.TRANSACTION_STATUS_PENDING,
+			}
+
+			h.onGetTransactionStatus().Return(&services.GetTransactionStatusOutput{ClientResponse: response.Build()})
+
+			rec := h.postJson(h.server.getTransactionStatusHandler, `{"ProtocolVersion":1,"VirtualChainId":42,"Txhash":"AQI="}`)
+
+			require.Equal(t, http.StatusAccepted, rec.Code, "should be accepted while pending")
+			result := &JsonTransactionStatusResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+			require.Equal(t, "TRANSACTION_STATUS_PENDING", result.TransactionStatus)
+			require.EqualValues(t, 5, result.RequestResult.BlockHeight)
+			require.Nil(t, result.TransactionReceipt, "pending transaction has no receipt")
+		})
+	})
+}
+
+func TestHttpServer_ExportStateSnapshot_NotRegistered(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			rec := h.exportStateSnapshot()
+
+			require.Equal(t, http.StatusServiceUnavailable, rec.Code, "should fail with 503 when no exporter is registered")
+		})
+	})
+}
+
+func TestHttpServer_ExportStateSnapshot_Error(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			stateStorage := &stateStorageTestkit.MockStateStorage{}
+			stateStorage.When("ExportStateSnapshot", mock.Any, mock.Any).Return(errors.Errorf("kaboom")).Times(1)
+			h.server.RegisterStateSnapshotExporter(snapshot.NewExporter(stateStorage, &services.MockBlockStorage{}, parent.Logger))
+
+			rec := h.exportStateSnapshot()
+
+			require.Equal(t, http.StatusInternalServerError, rec.Code, "should fail with 500")
+		})
+	})
+}
+
+func TestHttpServer_InspectPendingPool_PassesFilterToInspector(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			inspector := &fakePoolInspector{pending: []*transactionpool.PendingTransactionInfo{{
+				TxHash:      []byte{0x01, 0x02},
+				SizeInBytes: 100,
+				TimeInPool:  2 * time.Minute,
+			}}}
+			h.server.RegisterTransactionPoolInspector(inspector)
+
+			rec := h.inspectPendingPool("?signer=0a0b&contract=foo&min-age=1m&limit=10")
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			require.EqualValues(t, []byte{0x0a, 0x0b}, inspector.filter.SignerPublicKey)
+			require.EqualValues(t, "foo", inspector.filter.ContractName)
+			require.Equal(t, time.Minute, inspector.filter.MinTimeInPool)
+			require.Equal(t, 10, inspector.filter.Limit)
+
+			response := &PendingPoolResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+			require.Len(t, response.Transactions, 1)
+			require.Equal(t, "0102", response.Transactions[0].TxHash)
+			require.Equal(t, "2m0s", response.Transactions[0].TimeInPool)
+		})
+	})
+}
+
+func TestHttpServer_InspectPendingPool_InvalidFilter(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.server.RegisterTransactionPoolInspector(&fakePoolInspector{})
+
+			require.Equal(t, http.StatusBadRequest, h.inspectPendingPool("?signer=xyz").Code, "should fail with 400 on a signer which is not hex")
+			require.Equal(t, http.StatusBadRequest, h.inspectPendingPool("?min-age=soon").Code, "should fail with 400 on an invalid age")
+		})
+	})
+}
+
+func TestHttpServer_InspectCommittedPool_NotRegistered(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			req, _ := http.NewRequest("GET", "", nil)
+			rec := httptest.NewRecorder()
+			h.server.inspectCommittedPoolHandler(rec, req)
+
+			require.Equal(t, http.StatusServiceUnavailable, rec.Code, "should fail with 503 when no inspector is registered")
+		})
+	})
+}
+
 func TestHttpServer_GetTransactionStatus_Basic(t *testing.T) {
 	with.Logging(t, func(parent *with.LoggingHarness) {
 		withServerHarness(parent, func(h *harness) {
@@ -169,6 +397,268 @@ func TestHttpServer_GetTransactionReceiptProof_Error(t *testing.T) {
 	})
 }
 
+func TestHttpServer_GetStateProof_Basic(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.onGetStateProof().Return(&publicapi.GetStateProofOutput{
+				RequestStatus:       protocol.REQUEST_STATUS_COMPLETED,
+				BlockHeight:         7,
+				StateMerkleRootHash: []byte{0x01, 0x02},
+				Value:               []byte("bar"),
+				Proof:               &statestorage.StateProof{Nodes: []*statestorage.StateProofNode{{SiblingHash: []byte{0x03}, PrefixSize: 3}}},
+			}, nil)
+
+			rec := h.getStateProof(`{"VirtualChainId":42,"ContractName":"foo","Key":"YmFy"}`)
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"), "should have our content type")
+			require.Equal(t, "REQUEST_STATUS_COMPLETED", rec.Header().Get("X-ORBS-REQUEST-RESULT"), "should have request result header")
+			require.Equal(t, "7", rec.Header().Get("X-ORBS-BLOCK-HEIGHT"), "should have block height header")
+
+			response := &GetStateProofResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+			require.EqualValues(t, []byte("bar"), response.Value)
+			require.EqualValues(t, []byte{0x01, 0x02}, response.StateMerkleRootHash)
+			require.EqualValues(t, 3, response.Proof.Nodes[0].PrefixSize)
+		})
+	})
+}
+
+func TestHttpServer_GetStateProof_PassesRequestToPublicApi(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.onGetStateProof().Call(func(ctx interface{}, input *publicapi.GetStateProofInput) (*publicapi.GetStateProofOutput, error) {
+				require.EqualValues(t, 42, input.VirtualChainId)
+				require.EqualValues(t, 5, input.BlockHeight)
+				require.EqualValues(t, "foo", input.ContractName)
+				require.EqualValues(t, []byte("bar"), input.Key)
+				return &publicapi.GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND}, errors.Errorf("too old")
+			})
+
+			rec := h.getStateProof(`{"VirtualChainId":42,"BlockHeight":5,"ContractName":"foo","Key":"YmFy"}`)
+
+			require.Equal(t, http.StatusNotFound, rec.Code, "should fail with 404")
+			require.Equal(t, "too old", rec.Header().Get("X-ORBS-ERROR-DETAILS"), "should have error details")
+		})
+	})
+}
+
+func TestHttpServer_GetStateProof_InvalidRequest(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("GetStateProof", mock.Any, mock.Any)
+
+			rec := h.getStateProof("not json")
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_UpdatePendingTransaction_PassesRequestToPublicApi(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			replacement := builders.TransferTransaction().Build()
+			h.onUpdatePendingTransaction().Call(func(ctx interface{}, input *publicapi.UpdatePendingTransactionInput) (*publicapi.UpdatePendingTransactionOutput, error) {
+				require.EqualValues(t, 42, input.VirtualChainId)
+				require.EqualValues(t, []byte{0x01, 0x02}, input.TxHash)
+				require.EqualValues(t, []byte{0x03}, input.SignerPublicKey)
+				require.EqualValues(t, []byte{0x04}, input.Signature)
+				require.EqualValues(t, replacement.Raw(), input.Replacement.Raw())
+				return &publicapi.UpdatePendingTransactionOutput{
+					RequestStatus:     protocol.REQUEST_STATUS_IN_PROCESS,
+					TransactionStatus: protocol.TRANSACTION_STATUS_PENDING,
+					BlockHeight:       7,
+				}, nil
+			})
+
+			request, _ := json.Marshal(&UpdatePendingTransactionRequest{
+				VirtualChainId:  42,
+				TxHash:          []byte{0x01, 0x02},
+				SignerPublicKey: []byte{0x03},
+				Signature:       []byte{0x04},
+				Replacement:     replacement.Raw(),
+			})
+			rec := h.updatePendingTransaction(string(request))
+
+			require.Equal(t, http.StatusAccepted, rec.Code, "should be accepted")
+			require.Equal(t, "REQUEST_STATUS_IN_PROCESS", rec.Header().Get("X-ORBS-REQUEST-RESULT"), "should have request result header")
+
+			response := &UpdatePendingTransactionResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+			require.Equal(t, "TRANSACTION_STATUS_PENDING", response.TransactionStatus)
+			require.EqualValues(t, 7, response.BlockHeight)
+		})
+	})
+}
+
+func TestHttpServer_UpdatePendingTransaction_InvalidReplacement(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("UpdatePendingTransaction", mock.Any, mock.Any)
+
+			rec := h.updatePendingTransaction(`{"VirtualChainId":42,"TxHash":"AQI=","Replacement":"AQI="}`)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_SubscribeTransactionStatus_StreamsEvents(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			events := make(chan *publicapi.TransactionStatusEvent, 1)
+			events <- &publicapi.TransactionStatusEvent{
+				Txhash:            []byte{0x01, 0x02},
+				RequestStatus:     protocol.REQUEST_STATUS_COMPLETED,
+				TransactionStatus: protocol.TRANSACTION_STATUS_COMMITTED,
+				BlockHeight:       7,
+			}
+			close(events)
+			h.onSubscribeTransactionStatus().Call(func(ctx interface{}, input *publicapi.SubscribeTransactionStatusInput) (<-chan *publicapi.TransactionStatusEvent, error) {
+				require.Len(t, input.Txhashes, 2)
+				require.EqualValues(t, 0x0a, input.Txhashes[0][0])
+				require.EqualValues(t, 0x0b, input.Txhashes[1][0])
+				return events, nil
+			})
+
+			rec := h.subscribeTransactionStatus("?txhash=0x" + strings.Repeat("0a", 32) + "&txhash=" + strings.Repeat("0b", 32))
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+			require.True(t, strings.HasPrefix(rec.Body.String(), "event: status\ndata: "), "should stream a status event")
+
+			response := &TransactionStatusEventResponse{}
+			data := strings.TrimSuffix(strings.TrimPrefix(rec.Body.String(), "event: status\ndata: "), "\n\n")
+			require.NoError(t, json.Unmarshal([]byte(data), response))
+			require.Equal(t, "TRANSACTION_STATUS_COMMITTED", response.TransactionStatus)
+			require.Equal(t, "REQUEST_STATUS_COMPLETED", response.RequestStatus)
+			require.EqualValues(t, 7, response.BlockHeight)
+		})
+	})
+}
+
+func TestHttpServer_SubscribeTransactionStatus_InvalidTxhash(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("SubscribeTransactionStatus", mock.Any, mock.Any)
+
+			rec := h.subscribeTransactionStatus("?txhash=0102")
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_GetContractEvents_PassesRequestToPublicApi(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.onGetContractEvents().Call(func(ctx interface{}, input *publicapi.GetContractEventsInput) (*publicapi.GetContractEventsOutput, error) {
+				require.EqualValues(t, 42, input.VirtualChainId)
+				require.EqualValues(t, "Token", input.ContractName)
+				require.EqualValues(t, "Transfer", input.EventName)
+				require.EqualValues(t, 5, input.FromBlockHeight)
+				return &publicapi.GetContractEventsOutput{
+					RequestStatus:   protocol.REQUEST_STATUS_COMPLETED,
+					Events:          []*blockstorage.ContractEvent{{BlockHeight: 6, ContractName: "Token", EventName: "Transfer"}},
+					LastBlockHeight: 9,
+				}, nil
+			})
+
+			rec := h.getContractEvents(`{"VirtualChainId":42,"ContractName":"Token","EventName":"Transfer","FromBlockHeight":5}`)
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			response := &GetContractEventsResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+			require.Len(t, response.Events, 1)
+			require.EqualValues(t, 6, response.Events[0].BlockHeight)
+			require.EqualValues(t, 9, response.LastBlockHeight)
+		})
+	})
+}
+
+func TestHttpServer_SubscribeContractEvents_InvalidFromBlockHeight(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("SubscribeContractEvents", mock.Any, mock.Any)
+
+			req, _ := http.NewRequest("GET", "/api/v1/subscribe-contract-events?contract=Token&from=latest", nil)
+			rec := httptest.NewRecorder()
+			h.server.subscribeContractEventsHandler(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_Batch_RunsQueriesAgainstTheSameBlock(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			queryResult := &protocol.QueryResultBuilder{ExecutionResult: protocol.EXECUTION_RESULT_SUCCESS}
+			h.onRunQuery().Return(&services.RunQueryOutput{ClientResponse: (&client.RunQueryResponseBuilder{RequestResult: aCompletedResult(), QueryResult: queryResult}).Build()})
+			h.onRunQueryAtBlockHeight(1).Return(&services.RunQueryOutput{ClientResponse: (&client.RunQueryResponseBuilder{RequestResult: aCompletedResult(), QueryResult: queryResult}).Build()})
+			h.onGetBlock().Return(&services.GetBlockOutput{ClientResponse: (&client.GetBlockResponseBuilder{RequestResult: aCompletedResult()}).Build()}).Times(1)
+			h.onGetTransactionStatusAtBlockHeight(1).Return(&services.GetTransactionStatusOutput{ClientResponse: (&client.GetTransactionStatusResponseBuilder{RequestResult: aCompletedResult(), TransactionStatus: protocol.TRANSACTION_STATUS_PENDING}).Build()})
+			h.publicApi.Never("GetTransactionStatus", mock.Any, mock.Any)
+
+			query := (&client.RunQueryRequestBuilder{SignedQuery: &protocol.SignedQueryBuilder{}}).Build().Raw()
+			request, _ := json.Marshal(&BatchRequest{Requests: []*BatchItemRequest{
+				{Type: "run-query", Request: query},
+				{Type: "get-transaction-status", Request: (&client.GetTransactionStatusRequestBuilder{}).Build().Raw()},
+				{Type: "run-query", Request: query},
+				{Type: "send-transaction", Request: query},
+				{Type: "get-block", Request: (&client.GetBlockRequestBuilder{BlockHeight: 1}).Build().Raw()},
+				{Type: "get-block", Request: (&client.GetBlockRequestBuilder{BlockHeight: 2}).Build().Raw()},
+			}})
+			rec := h.batch(string(request))
+
+			require.Equal(t, http.StatusOK, rec.Code, "should succeed")
+			response := &BatchResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), response))
+			require.EqualValues(t, 1, response.BlockHeight, "batch should report the block its queries ran against")
+			require.Len(t, response.Responses, 6)
+			require.Equal(t, "run-query", response.Responses[0].Type)
+			require.Equal(t, "REQUEST_STATUS_COMPLETED", response.Responses[0].RequestStatus)
+			require.Equal(t, "get-transaction-status", response.Responses[1].Type)
+			require.Equal(t, http.StatusOK, response.Responses[1].HttpStatus, "transaction status should be read as of the batch block")
+			require.Equal(t, http.StatusOK, response.Responses[2].HttpStatus)
+			require.Equal(t, http.StatusBadRequest, response.Responses[3].HttpStatus, "unsupported request type should fail on its own")
+			require.Equal(t, http.StatusOK, response.Responses[4].HttpStatus, "the batch block should be served")
+			require.Equal(t, http.StatusBadRequest, response.Responses[5].HttpStatus, "a block following the batch block should not be served")
+
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "the second query should run at the block of the first, %v", err)
+		})
+	})
+}
+
+func TestHttpServer_Batch_EnforcesRequestLimit(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		withServerHarness(parent, func(h *harness) {
+			h.publicApi.Never("RunQuery", mock.Any, mock.Any)
+
+			items := make([]*BatchItemRequest, h.server.config.HttpBatchMaxRequests()+1)
+			for i := range items {
+				items[i] = &BatchItemRequest{Type: "run-query"}
+			}
+			request, _ := json.Marshal(&BatchRequest{Requests: items})
+			rec := h.batch(string(request))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, "should fail with 400")
+			ok, err := h.publicApi.Verify()
+			require.True(t, ok, "public api should not be called, %v", err)
+		})
+	})
+}
+
 func TestHttpServer_GetBlock_Basic(t *testing.T) {
 	with.Logging(t, func(parent *with.LoggingHarness) {
 		withServerHarness(parent, func(h *harness) {
@@ -361,7 +851,7 @@ func aCompletedResult() *client.RequestResultBuilder {
 
 type harness struct {
 	*with.LoggingHarness
-	publicApi *services.MockPublicApi
+	publicApi *testkit.MockPublicApi
 	server    *HttpServer
 }
 
@@ -393,10 +883,40 @@ func (h *harness) onGetTransactionReceiptProof() *mock.MockFunction {
 	return h.publicApi.When("GetTransactionReceiptProof", mock.Any, mock.Any).Times(1)
 }
 
+func (h *harness) onGetStateProof() *mock.MockFunction {
+	return h.publicApi.When("GetStateProof", mock.Any, mock.Any).Times(1)
+}
+
+func (h *harness) onUpdatePendingTransaction() *mock.MockFunction {
+	return h.publicApi.When("UpdatePendingTransaction", mock.Any, mock.Any).Times(1)
+}
+
+func (h *harness) onSubscribeTransactionStatus() *mock.MockFunction {
+	return h.publicApi.When("SubscribeTransactionStatus", mock.Any, mock.Any).Times(1)
+}
+
+func (h *harness) onGetContractEvents() *mock.MockFunction {
+	return h.publicApi.When("GetContractEvents", mock.Any, mock.Any).Times(1)
+}
+
 func (h *harness) onRunQuery() *mock.MockFunction {
 	return h.publicApi.When("RunQuery", mock.Any, mock.Any).Times(1)
 }
 
+func (h *harness) onRunQueryAtBlockHeight(height primitives.BlockHeight) *mock.MockFunction {
+	return h.publicApi.When("RunQueryAtBlockHeight", mock.Any, mock.AnyIf("query at requested height", func(i interface{}) bool {
+		input, ok := i.(*publicapi.RunQueryAtBlockHeightInput)
+		return ok && input.BlockHeight == height
+	})).Times(1)
+}
+
+func (h *harness) onGetTransactionStatusAtBlockHeight(height primitives.BlockHeight) *mock.MockFunction {
+	return h.publicApi.When("GetTransactionStatusAtBlockHeight", mock.Any, mock.AnyIf("status at requested height", func(i interface{}) bool {
+		input, ok := i.(*publicapi.GetTransactionStatusAtBlockHeightInput)
+		return ok && input.BlockHeight == height
+	})).Times(1)
+}
+
 func (h *harness) sendTransaction(builder *protocol.SignedTransactionBuilder) *httptest.ResponseRecorder {
 	request := (&client.SendTransactionRequestBuilder{
 		SignedTransaction: builders.TransferTransaction().Builder(),
@@ -420,11 +940,15 @@ func (h *harness) sendTransactionAsync(builder *protocol.SignedTransactionBuilde
 }
 
 func (h *harness) runQuery() *httptest.ResponseRecorder {
+	return h.runQueryWithUrl("")
+}
+
+func (h *harness) runQueryWithUrl(url string) *httptest.ResponseRecorder {
 	request := (&client.RunQueryRequestBuilder{
 		SignedQuery: &protocol.SignedQueryBuilder{},
 	}).Build()
 
-	req, _ := http.NewRequest("POST", "", bytes.NewReader(request.Raw()))
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(request.Raw()))
 	rec := httptest.NewRecorder()
 	h.server.runQueryHandler(rec, req)
 	return rec
@@ -449,6 +973,70 @@ func (h *harness) getTransactionReceiptProof() *httptest.ResponseRecorder {
 	return rec
 }
 
+func (h *harness) postJson(handler http.HandlerFunc, request string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", bytes.NewReader([]byte(request)))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func (h *harness) getStateProof(request string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", bytes.NewReader([]byte(request)))
+	rec := httptest.NewRecorder()
+	h.server.getStateProofHandler(rec, req)
+	return rec
+}
+
+func (h *harness) updatePendingTransaction(request string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", bytes.NewReader([]byte(request)))
+	rec := httptest.NewRecorder()
+	h.server.updatePendingTransactionHandler(rec, req)
+	return rec
+}
+
+func (h *harness) getContractEvents(request string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "", bytes.NewReader([]byte(request)))
+	rec := httptest.NewRecorder()
+	h.server.getContractEventsHandler(rec, req)
+	return rec
+}
+
+func (h *harness) batch(request string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/api/v1/batch", bytes.NewReader([]byte(request)))
+	rec := httptest.NewRecorder()
+	h.server.batchHandler(rec, req)
+	return rec
+}
+
+func (h *harness) subscribeTransactionStatus(query string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/subscribe-transaction-status"+query, nil)
+	rec := httptest.NewRecorder()
+	h.server.subscribeTransactionStatusHandler(rec, req)
+	return rec
+}
+
+func (h *harness) inspectPendingPool(query string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/debug/transaction-pool/pending"+query, nil)
+	rec := httptest.NewRecorder()
+	h.server.inspectPendingPoolHandler(rec, req)
+	return rec
+}
+
+type fakePoolInspector struct {
+	filter  *transactionpool.PendingPoolFilter
+	pending []*transactionpool.PendingTransactionInfo
+}
+
+func (f *fakePoolInspector) InspectPendingPool(filter *transactionpool.PendingPoolFilter) []*transactionpool.PendingTransactionInfo {
+	f.filter = filter
+	return f.pending
+}
+
+func (f *fakePoolInspector) InspectCommittedPool(limit int) []*transactionpool.CommittedTransactionInfo {
+	return nil
+}
+
 func (h *harness) getBlock() *httptest.ResponseRecorder {
 	request := (&client.GetBlockRequestBuilder{BlockHeight: 1}).Build()
 	req, _ := http.NewRequest("POST", "", bytes.NewReader(request.Raw()))
@@ -467,8 +1055,15 @@ func (h *harness) GetRobotsTxtThroughHTTP() (*http.Response, error) {
 	return http.Get(h.buildUrl("/robots.txt"))
 }
 
+func (h *harness) exportStateSnapshot() *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "", nil)
+	rec := httptest.NewRecorder()
+	h.server.exportStateSnapshotHandler(rec, req)
+	return rec
+}
+
 func withUnregisteredPublicApiServerHarness(parent *with.LoggingHarness, f func(h *harness)) {
-	papiMock := &services.MockPublicApi{}
+	papiMock := &testkit.MockPublicApi{}
 	h := &harness{
 		LoggingHarness: parent,
 		publicApi:      papiMock,
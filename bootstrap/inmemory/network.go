@@ -20,6 +20,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/services/management"
 	nativeProcessorAdapter "github.com/orbs-network/orbs-network-go/services/processor/native/adapter"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
 	stateStorageMemoryAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
 	txPoolAdapter "github.com/orbs-network/orbs-network-go/services/transactionpool/adapter"
@@ -173,7 +174,7 @@ func reverse(nodes []*Node) (reversed []*Node) {
 	return
 }
 
-func (n *Network) PublicApi(nodeIndex int) services.PublicApi {
+func (n *Network) PublicApi(nodeIndex int) publicapi.PublicApi {
 	return n.Nodes[nodeIndex].nodeLogic.PublicApi()
 }
 
@@ -10,10 +10,10 @@ import (
 	"github.com/orbs-network/orbs-network-go/bootstrap"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	"github.com/orbs-network/orbs-network-go/synchronization"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
-	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/pkg/errors"
 
 	blockStorageAdapter "github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
@@ -38,7 +38,7 @@ type Node struct {
 	metricRegistry              metric.Registry
 }
 
-func (n *Node) GetPublicApi() services.PublicApi {
+func (n *Node) GetPublicApi() publicapi.PublicApi {
 	return n.nodeLogic.PublicApi()
 }
 
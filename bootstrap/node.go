@@ -9,22 +9,30 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/bootstrap/grpcserver"
 	"github.com/orbs-network/orbs-network-go/bootstrap/httpserver"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
 	ethereumAdapter "github.com/orbs-network/orbs-network-go/services/crosschainconnector/ethereum/adapter"
+	gossipAdapter "github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/quic"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
 	"github.com/orbs-network/orbs-network-go/services/management"
 	managementAdapter "github.com/orbs-network/orbs-network-go/services/management/adapter"
 	nativeProcessorAdapter "github.com/orbs-network/orbs-network-go/services/processor/native/adapter"
-	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	stateStorageFilesystemAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/filesystem"
+	stateStorageMemoryAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/snapshot"
 	txPoolAdapter "github.com/orbs-network/orbs-network-go/services/transactionpool/adapter"
 	"github.com/orbs-network/orbs-network-go/synchronization/supervised"
 	"github.com/orbs-network/scribe/log"
 	"github.com/pkg/errors"
+	"os"
 )
 
 type Node struct {
@@ -32,9 +40,11 @@ type Node struct {
 	logic            NodeLogic
 	cancelFunc       context.CancelFunc
 	httpServer       *httpserver.HttpServer
-	transport        *tcp.DirectTransport
+	grpcServer       *grpcserver.GrpcServer
+	transport        gossipAdapter.Transport
 	logger           log.Logger
 	blockPersistence *filesystem.BlockPersistence
+	statePersistence stateStorageAdapter.StatePersistence
 }
 
 func GetMetricRegistry(nodeConfig config.NodeConfig) metric.Registry {
@@ -60,7 +70,7 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) *Node {
 
 	httpServer := httpserver.NewHttpServer(nodeConfig, nodeLogger, metricRegistry)
 
-	transport := tcp.NewDirectTransport(ctx, nodeConfig, nodeLogger, metricRegistry)
+	transport := newGossipTransport(ctx, nodeConfig, nodeLogger, metricRegistry)
 
 	var managementProvider management.Provider
 	if nodeConfig.ManagementFilePath() == "" {
@@ -76,7 +86,7 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) *Node {
 		panic(fmt.Sprintf("failed initializing blocks database, err=%s", err.Error()))
 	}
 
-	statePersistence := stateStorageAdapter.NewStatePersistence(metricRegistry)
+	statePersistence := newStatePersistence(nodeConfig, nodeLogger, metricRegistry)
 	ethereumConnection := ethereumAdapter.NewEthereumRpcConnection(nodeConfig, logger, metricRegistry)
 	nativeCompiler := nativeProcessorAdapter.NewNativeCompiler(nodeConfig, nodeLogger, metricRegistry)
 	nodeLogic := NewNodeLogic(ctx,
@@ -84,6 +94,13 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) *Node {
 		nodeLogger, metricRegistry, nodeConfig, ethereumConnection)
 
 	httpServer.RegisterPublicApi(nodeLogic.PublicApi())
+	httpServer.RegisterStateSnapshotExporter(nodeLogic.StateSnapshotExporter())
+	httpServer.RegisterTransactionPoolInspector(nodeLogic.TransactionPoolInspector())
+
+	var grpcServer *grpcserver.GrpcServer
+	if nodeConfig.GrpcAddress() != "" {
+		grpcServer = grpcserver.NewGrpcServer(nodeConfig, nodeLogger, nodeLogic.PublicApi())
+	}
 
 	n := &Node{
 		logger:           nodeLogger,
@@ -91,7 +108,9 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) *Node {
 		logic:            nodeLogic,
 		transport:        transport,
 		httpServer:       httpServer,
+		grpcServer:       grpcServer,
 		blockPersistence: blockPersistence,
+		statePersistence: statePersistence,
 	}
 
 	// TODO re-enable Ethereum access (with refTime based finality)
@@ -101,11 +120,90 @@ func NewNode(nodeConfig config.NodeConfig, logger log.Logger) *Node {
 	n.Supervise(nodeLogic)
 	n.Supervise(transport)
 	n.Supervise(httpServer)
+	if grpcServer != nil {
+		n.Supervise(grpcServer)
+	}
 	return n
 }
 
+func newGossipTransport(ctx context.Context, nodeConfig config.NodeConfig, logger log.Logger, metricRegistry metric.Registry) gossipAdapter.Transport {
+	transportSigner, err := signer.New(nodeConfig)
+	if err != nil {
+		logger.Error("Gossip transport signer error cannot start", log.Error(err))
+		panic(fmt.Sprintf("Gossip transport signer error cannot start: %s", err))
+	}
+
+	switch nodeConfig.GossipTransport() {
+	case "tcp":
+		return tcp.NewDirectTransport(ctx, nodeConfig, transportSigner, logger, metricRegistry)
+	case "quic":
+		transport, err := quic.NewQuicTransport(ctx, nodeConfig, transportSigner, logger, metricRegistry)
+		if err != nil {
+			panic(fmt.Sprintf("failed initializing quic gossip transport, err=%s", err.Error()))
+		}
+		return transport
+	default:
+		panic(fmt.Sprintf("unknown gossip transport %s", nodeConfig.GossipTransport()))
+	}
+}
+
+func newStatePersistence(nodeConfig config.NodeConfig, logger log.Logger, metricRegistry metric.Registry) stateStorageAdapter.StatePersistence {
+	if nodeConfig.StateStorageFileSystemDataDir() == "" {
+		if nodeConfig.StateStorageArchiveMode() {
+			return stateStorageMemoryAdapter.NewArchiveStatePersistence(metricRegistry)
+		}
+		return stateStorageMemoryAdapter.NewStatePersistence(metricRegistry)
+	}
+
+	statePersistence, err := stateStorageFilesystemAdapter.NewStatePersistence(nodeConfig, logger, metricRegistry)
+	if err != nil {
+		panic(fmt.Sprintf("failed initializing state database, err=%s", err.Error()))
+	}
+	return statePersistence
+}
+
+// ImportStateSnapshot loads a state snapshot into the state database of a node that was not started yet, the node then
+// executes blocks from the block following the snapshot. the proof of that block is verified with the consensus algo
+// of the node, see blockProofVerifier
+func ImportStateSnapshot(nodeConfig config.NodeConfig, logger log.Logger, snapshotPath string) error {
+	if nodeConfig.StateStorageFileSystemDataDir() == "" {
+		return errors.New("state snapshot can only be imported into a node that keeps its state on disk")
+	}
+
+	verifyProof, err := blockProofVerifier(nodeConfig, logger)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open state snapshot %s", snapshotPath)
+	}
+	defer f.Close()
+
+	statePersistence, err := stateStorageFilesystemAdapter.NewStatePersistence(nodeConfig, logger, metric.NewRegistry())
+	if err != nil {
+		return errors.Wrap(err, "failed initializing state database")
+	}
+	defer statePersistence.GracefulShutdown(context.Background())
+
+	header, err := snapshot.Import(nodeConfig, f, statePersistence, verifyProof, logger)
+	if err != nil {
+		return err
+	}
+	logger.Info("state snapshot imported, block sync continues from the next block", logfields.BlockHeight(header.BlockHeight+1))
+	return nil
+}
+
 func (n *Node) GracefulShutdown(shutdownContext context.Context) {
 	n.logger.Info("Shutting down")
 	n.cancelFunc()
-	supervised.ShutdownAllGracefully(shutdownContext, n.httpServer, n.transport, n.blockPersistence)
+	shutdowners := []supervised.GracefulShutdowner{n.httpServer, n.transport, n.blockPersistence}
+	if n.grpcServer != nil {
+		shutdowners = append(shutdowners, n.grpcServer)
+	}
+	if statePersistence, ok := n.statePersistence.(supervised.GracefulShutdowner); ok {
+		shutdowners = append(shutdowners, statePersistence)
+	}
+	supervised.ShutdownAllGracefully(shutdownContext, shutdowners...)
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	stateStorageAdapter "github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/snapshot"
 	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	txPoolAdapter "github.com/orbs-network/orbs-network-go/services/transactionpool/adapter"
 	"github.com/orbs-network/orbs-network-go/services/virtualmachine"
@@ -43,13 +44,17 @@ import (
 
 type NodeLogic interface {
 	govnr.ShutdownWaiter
-	PublicApi() services.PublicApi
+	PublicApi() publicapi.PublicApi
+	StateSnapshotExporter() *snapshot.Exporter
+	TransactionPoolInspector() transactionpool.PoolInspector
 }
 
 type nodeLogic struct {
 	govnr.TreeSupervisor
-	publicApi      services.PublicApi
-	consensusAlgos []services.ConsensusAlgo
+	publicApi                publicapi.PublicApi
+	snapshotExporter         *snapshot.Exporter
+	transactionPoolInspector transactionpool.PoolInspector
+	consensusAlgos           []services.ConsensusAlgo
 }
 
 func NewNodeLogic(parentCtx context.Context,
@@ -84,14 +89,17 @@ func NewNodeLogic(parentCtx context.Context,
 		panic(fmt.Sprintf("Node logic signer error cannot start: %s", err))
 	}
 
-	gossipService := gossip.NewGossip(ctx, gossipTransport, nodeConfig, logger, metricRegistry)
+	gossipService := gossip.NewGossip(ctx, gossipTransport, nodeConfig, signer, logger, metricRegistry)
 	management := management.NewManagement(ctx, nodeConfig, managementProvider, gossipService, logger, metricRegistry)
 	stateStorageService := statestorage.NewStateStorage(nodeConfig, statePersistence, stateBlockHeightReporter, logger, metricRegistry)
 	virtualMachineService := virtualmachine.NewVirtualMachine(stateStorageService, processors, crosschainConnectors, management, nodeConfig, logger)
 	transactionPoolService := transactionpool.NewTransactionPool(ctx, maybeClock, gossipService, virtualMachineService, signer, transactionPoolBlockHeightReporter, nodeConfig, logger, metricRegistry)
+	if err := transactionPoolService.RestorePendingPool(ctx, blockPersistence); err != nil {
+		logger.Error("failed to restore the pending pool journal, pending transactions are kept in memory only", log.Error(err))
+	}
 	serviceSyncCommitters := []servicesync.BlockPairCommitter{servicesync.NewStateStorageCommitter(stateStorageService), servicesync.NewTxPoolCommitter(transactionPoolService)}
 	blockStorageService := blockstorage.NewBlockStorage(ctx, nodeConfig, blockPersistence, gossipService, logger, metricRegistry, serviceSyncCommitters)
-	publicApiService := publicapi.NewPublicApi(nodeConfig, transactionPoolService, virtualMachineService, blockStorageService, logger, metricRegistry)
+	publicApiService := publicapi.NewPublicApi(nodeConfig, transactionPoolService, virtualMachineService, blockStorageService, stateStorageService, logger, metricRegistry)
 	consensusContextService := consensuscontext.NewConsensusContext(transactionPoolService, virtualMachineService, stateStorageService, management, nodeConfig, logger, metricRegistry)
 
 	consensusAlgo := createConsensusAlgo(nodeConfig)(ctx, gossipService, blockStorageService, consensusContextService, management, signer, logger, metricRegistry)
@@ -99,8 +107,10 @@ func NewNodeLogic(parentCtx context.Context,
 	logger.Info("Node started")
 
 	node := &nodeLogic{
-		publicApi:      publicApiService,
-		consensusAlgos: []services.ConsensusAlgo{consensusAlgo},
+		publicApi:                publicApiService,
+		snapshotExporter:         snapshot.NewExporter(stateStorageService, blockStorageService, logger),
+		transactionPoolInspector: transactionPoolService,
+		consensusAlgos:           []services.ConsensusAlgo{consensusAlgo},
 	}
 
 	node.Supervise(management)
@@ -152,6 +162,14 @@ func createConsensusAlgo(nodeConfig config.NodeConfig) func(ctx context.Context,
 	}
 }
 
-func (n *nodeLogic) PublicApi() services.PublicApi {
+func (n *nodeLogic) PublicApi() publicapi.PublicApi {
 	return n.publicApi
 }
+
+func (n *nodeLogic) StateSnapshotExporter() *snapshot.Exporter {
+	return n.snapshotExporter
+}
+
+func (n *nodeLogic) TransactionPoolInspector() transactionpool.PoolInspector {
+	return n.transactionPoolInspector
+}
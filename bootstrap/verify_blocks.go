@@ -0,0 +1,69 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package bootstrap
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/services/consensusalgo/benchmarkconsensus"
+	"github.com/orbs-network/orbs-network-go/services/consensusalgo/leanhelixconsensus"
+	managementAdapter "github.com/orbs-network/orbs-network-go/services/management/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// VerifyBlockStorage checks the blocks files of a node that is not running, typically a backup about to be promoted to
+// a live node. block proofs are verified with the consensus algo of the node, lean helix proofs against the committees
+// listed in its management file. if truncate is set, the blocks files are cut after the last valid block
+func VerifyBlockStorage(nodeConfig config.NodeConfig, logger log.Logger, truncate bool) (*filesystem.VerificationReport, error) {
+	verifyProof, err := blockProofVerifier(nodeConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	return filesystem.VerifyBlocks(nodeConfig, logger, verifyProof, truncate)
+}
+
+func blockProofVerifier(nodeConfig config.NodeConfig, logger log.Logger) (adapter.BlockProofVerifier, error) {
+	switch nodeConfig.ActiveConsensusAlgo() {
+	case consensus.CONSENSUS_ALGO_TYPE_BENCHMARK_CONSENSUS:
+		return func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+			return benchmarkconsensus.VerifyBlockProof(block, nodeConfig.BenchmarkConsensusConstantLeader())
+		}, nil
+
+	case consensus.CONSENSUS_ALGO_TYPE_LEAN_HELIX:
+		if nodeConfig.ManagementFilePath() == "" {
+			return nil, errors.New("lean helix block proofs can only be verified against committees of a management file")
+		}
+		data, err := managementAdapter.NewFileProvider(nodeConfig).Get(context.Background(), 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading management file %s", nodeConfig.ManagementFilePath())
+		}
+		instanceId := leanhelixconsensus.CalcInstanceId(nodeConfig.NetworkType(), nodeConfig.VirtualChainId())
+		return func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+			// the committee of a block is the one of the reference time of the block before it, and of genesis for the first block
+			refTime := data.GenesisReference
+			if prevBlock != nil {
+				refTime = prevBlock.TransactionsBlock.Header.ReferenceTime()
+			}
+			if refTime == 0 {
+				refTime = data.CurrentReference
+			}
+			committee, err := data.CommitteeAt(refTime)
+			if err != nil {
+				return err
+			}
+			return leanhelixconsensus.VerifyBlockProof(logger, instanceId, block, prevBlock, committee.Members, committee.Weights)
+		}, nil
+
+	default:
+		return nil, errors.Errorf("block proofs of consensus algo %s cannot be verified", nodeConfig.ActiveConsensusAlgo())
+	}
+}
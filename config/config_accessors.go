@@ -69,6 +69,7 @@ const (
 	BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT   = "BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT"
 	BLOCK_SYNC_DESCENDING_ENABLED       = "BLOCK_SYNC_DESCENDING_ENABLED"
 
+	// Deprecated: no longer read, committed transactions are found by hash alone
 	BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE = "BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE"
 
 	CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK   = "CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK"
@@ -76,6 +77,9 @@ const (
 	CONSENSUS_CONTEXT_TRIGGERS_ENABLED                = "CONSENSUS_CONTEXT_TRIGGERS_ENABLED"
 
 	STATE_STORAGE_HISTORY_SNAPSHOT_NUM = "STATE_STORAGE_HISTORY_SNAPSHOT_NUM"
+	STATE_STORAGE_FILE_SYSTEM_DATA_DIR = "STATE_STORAGE_FILE_SYSTEM_DATA_DIR"
+	STATE_STORAGE_DIVERGENCE_POLICY    = "STATE_STORAGE_DIVERGENCE_POLICY"
+	STATE_STORAGE_ARCHIVE_MODE         = "STATE_STORAGE_ARCHIVE_MODE"
 
 	BLOCK_TRACKER_GRACE_DISTANCE = "BLOCK_TRACKER_GRACE_DISTANCE"
 	BLOCK_TRACKER_GRACE_TIMEOUT  = "BLOCK_TRACKER_GRACE_TIMEOUT"
@@ -89,15 +93,29 @@ const (
 	TRANSACTION_POOL_PROPAGATION_BATCHING_TIMEOUT          = "TRANSACTION_POOL_PROPAGATION_BATCHING_TIMEOUT"
 	TRANSACTION_POOL_TIME_BETWEEN_EMPTY_BLOCKS             = "TRANSACTION_POOL_TIME_BETWEEN_EMPTY_BLOCKS"
 	TRANSACTION_POOL_NODE_SYNC_REJECT_TIME                 = "TRANSACTION_POOL_NODE_SYNC_REJECT_TIME"
+	TRANSACTION_POOL_ORDERING_POLICY                       = "TRANSACTION_POOL_ORDERING_POLICY"
+	TRANSACTION_POOL_ORDERING_CONTRACT_QUOTA_PERCENT       = "TRANSACTION_POOL_ORDERING_CONTRACT_QUOTA_PERCENT"
+	TRANSACTION_POOL_SIGNER_RATE_LIMIT_PER_SECOND          = "TRANSACTION_POOL_SIGNER_RATE_LIMIT_PER_SECOND"
+	TRANSACTION_POOL_SIGNER_RATE_LIMIT_BURST               = "TRANSACTION_POOL_SIGNER_RATE_LIMIT_BURST"
+	TRANSACTION_POOL_GATEWAY_RATE_LIMIT_PER_SECOND         = "TRANSACTION_POOL_GATEWAY_RATE_LIMIT_PER_SECOND"
+	TRANSACTION_POOL_GATEWAY_RATE_LIMIT_BURST              = "TRANSACTION_POOL_GATEWAY_RATE_LIMIT_BURST"
+	TRANSACTION_POOL_PENDING_POOL_JOURNAL_PATH             = "TRANSACTION_POOL_PENDING_POOL_JOURNAL_PATH"
 
 	GOSSIP_LISTEN_PORT                    = "GOSSIP_LISTEN_PORT"
 	GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL = "GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL"
 	GOSSIP_NETWORK_TIMEOUT                = "GOSSIP_NETWORK_TIMEOUT"
 	GOSSIP_RECONNECT_INTERVAL             = "GOSSIP_RECONNECT_INTERVAL"
+	GOSSIP_TRANSPORT                      = "GOSSIP_TRANSPORT"
+	GOSSIP_TRANSPORT_SECURITY             = "GOSSIP_TRANSPORT_SECURITY"
+	GOSSIP_RELAY_TTL                      = "GOSSIP_RELAY_TTL"
+	GOSSIP_RELAY_FANOUT_TRANSACTION_RELAY = "GOSSIP_RELAY_FANOUT_TRANSACTION_RELAY"
+	GOSSIP_RELAY_FANOUT_BLOCK_SYNC        = "GOSSIP_RELAY_FANOUT_BLOCK_SYNC"
 
 	PUBLIC_API_SEND_TRANSACTION_TIMEOUT = "PUBLIC_API_SEND_TRANSACTION_TIMEOUT"
 	PUBLIC_API_NODE_SYNC_WARNING_TIME   = "PUBLIC_API_NODE_SYNC_WARNING_TIME"
 
+	PUBLIC_API_TRANSACTION_STATUS_SUBSCRIPTION_TIMEOUT = "PUBLIC_API_TRANSACTION_STATUS_SUBSCRIPTION_TIMEOUT"
+
 	PROCESSOR_ARTIFACT_PATH               = "PROCESSOR_ARTIFACT_PATH"
 	PROCESSOR_SANITIZE_DEPLOYED_CONTRACTS = "PROCESSOR_SANITIZE_DEPLOYED_CONTRACTS"
 	PROCESSOR_PERFORM_WARM_UP_COMPILATION = "PROCESSOR_PERFORM_WARM_UP_COMPILATION"
@@ -113,11 +131,23 @@ const (
 
 	BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR                = "BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR"
 	BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES = "BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES"
+	BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCKS_PER_SEGMENT  = "BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCKS_PER_SEGMENT"
+	BLOCK_STORAGE_FILE_SYSTEM_PRUNING_DEPTH           = "BLOCK_STORAGE_FILE_SYSTEM_PRUNING_DEPTH"
+	BLOCK_STORAGE_FILE_SYSTEM_TRANSACTION_INDEX_DEPTH = "BLOCK_STORAGE_FILE_SYSTEM_TRANSACTION_INDEX_DEPTH"
 
 	PROFILING = "PROFILING"
 
+	STATE_SNAPSHOT_EXPORT = "STATE_SNAPSHOT_EXPORT"
+
+	TRANSACTION_POOL_INSPECTION = "TRANSACTION_POOL_INSPECTION"
+
 	HTTP_ADDRESS = "HTTP_ADDRESS"
 
+	HTTP_BATCH_MAX_REQUESTS   = "HTTP_BATCH_MAX_REQUESTS"
+	HTTP_BATCH_MAX_SIZE_BYTES = "HTTP_BATCH_MAX_SIZE_BYTES"
+
+	GRPC_ADDRESS = "GRPC_ADDRESS"
+
 	NTP_ENDPOINT = "NTP_ENDPOINT"
 
 	SIGNER_ENDPOINT = "SIGNER_ENDPOINT"
@@ -238,10 +268,6 @@ func (c *config) BlockSyncCollectResponseTimeout() time.Duration {
 	return c.kv[BLOCK_SYNC_COLLECT_RESPONSE_TIMEOUT].DurationValue
 }
 
-func (c *config) BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration {
-	return c.kv[BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE].DurationValue
-}
-
 func (c *config) ConsensusContextMaximumTransactionsInBlock() uint32 {
 	return c.kv[CONSENSUS_CONTEXT_MAXIMUM_TRANSACTIONS_IN_BLOCK].Uint32Value
 }
@@ -258,6 +284,18 @@ func (c *config) StateStorageHistorySnapshotNum() uint32 {
 	return c.kv[STATE_STORAGE_HISTORY_SNAPSHOT_NUM].Uint32Value
 }
 
+func (c *config) StateStorageFileSystemDataDir() string {
+	return c.kv[STATE_STORAGE_FILE_SYSTEM_DATA_DIR].StringValue
+}
+
+func (c *config) StateStorageDivergencePolicy() string {
+	return c.kv[STATE_STORAGE_DIVERGENCE_POLICY].StringValue
+}
+
+func (c *config) StateStorageArchiveMode() bool {
+	return c.kv[STATE_STORAGE_ARCHIVE_MODE].BoolValue
+}
+
 func (c *config) BlockTrackerGraceDistance() uint32 {
 	return c.kv[BLOCK_TRACKER_GRACE_DISTANCE].Uint32Value
 }
@@ -302,6 +340,34 @@ func (c *config) TransactionPoolNodeSyncRejectTime() time.Duration {
 	return c.kv[TRANSACTION_POOL_NODE_SYNC_REJECT_TIME].DurationValue
 }
 
+func (c *config) TransactionPoolOrderingPolicy() string {
+	return c.kv[TRANSACTION_POOL_ORDERING_POLICY].StringValue
+}
+
+func (c *config) TransactionPoolOrderingContractQuotaPercent() uint32 {
+	return c.kv[TRANSACTION_POOL_ORDERING_CONTRACT_QUOTA_PERCENT].Uint32Value
+}
+
+func (c *config) TransactionPoolSignerRateLimitPerSecond() uint32 {
+	return c.kv[TRANSACTION_POOL_SIGNER_RATE_LIMIT_PER_SECOND].Uint32Value
+}
+
+func (c *config) TransactionPoolSignerRateLimitBurst() uint32 {
+	return c.kv[TRANSACTION_POOL_SIGNER_RATE_LIMIT_BURST].Uint32Value
+}
+
+func (c *config) TransactionPoolGatewayRateLimitPerSecond() uint32 {
+	return c.kv[TRANSACTION_POOL_GATEWAY_RATE_LIMIT_PER_SECOND].Uint32Value
+}
+
+func (c *config) TransactionPoolGatewayRateLimitBurst() uint32 {
+	return c.kv[TRANSACTION_POOL_GATEWAY_RATE_LIMIT_BURST].Uint32Value
+}
+
+func (c *config) TransactionPoolPendingPoolJournalPath() string {
+	return c.kv[TRANSACTION_POOL_PENDING_POOL_JOURNAL_PATH].StringValue
+}
+
 func (c *config) PublicApiSendTransactionTimeout() time.Duration {
 	return c.kv[PUBLIC_API_SEND_TRANSACTION_TIMEOUT].DurationValue
 }
@@ -310,6 +376,10 @@ func (c *config) PublicApiNodeSyncWarningTime() time.Duration {
 	return c.kv[PUBLIC_API_NODE_SYNC_WARNING_TIME].DurationValue
 }
 
+func (c *config) PublicApiTransactionStatusSubscriptionTimeout() time.Duration {
+	return c.kv[PUBLIC_API_TRANSACTION_STATUS_SUBSCRIPTION_TIMEOUT].DurationValue
+}
+
 func (c *config) BlockSyncCollectChunksTimeout() time.Duration {
 	return c.kv[BLOCK_SYNC_COLLECT_CHUNKS_TIMEOUT].DurationValue
 }
@@ -346,6 +416,26 @@ func (c *config) GossipReconnectInterval() time.Duration {
 	return c.kv[GOSSIP_RECONNECT_INTERVAL].DurationValue
 }
 
+func (c *config) GossipTransport() string {
+	return c.kv[GOSSIP_TRANSPORT].StringValue
+}
+
+func (c *config) GossipTransportSecurity() string {
+	return c.kv[GOSSIP_TRANSPORT_SECURITY].StringValue
+}
+
+func (c *config) GossipRelayTtl() uint32 {
+	return c.kv[GOSSIP_RELAY_TTL].Uint32Value
+}
+
+func (c *config) GossipRelayFanoutTransactionRelay() uint32 {
+	return c.kv[GOSSIP_RELAY_FANOUT_TRANSACTION_RELAY].Uint32Value
+}
+
+func (c *config) GossipRelayFanoutBlockSync() uint32 {
+	return c.kv[GOSSIP_RELAY_FANOUT_BLOCK_SYNC].Uint32Value
+}
+
 func (c *config) BenchmarkConsensusRequiredQuorumPercentage() uint32 {
 	return c.kv[BENCHMARK_CONSENSUS_REQUIRED_QUORUM_PERCENTAGE].Uint32Value
 }
@@ -398,14 +488,46 @@ func (c *config) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES].Uint32Value
 }
 
+func (c *config) BlockStorageFileSystemMaxBlocksPerSegment() uint32 {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCKS_PER_SEGMENT].Uint32Value
+}
+
+func (c *config) BlockStorageFileSystemPruningDepth() uint32 {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_PRUNING_DEPTH].Uint32Value
+}
+
+func (c *config) BlockStorageFileSystemTransactionIndexDepth() uint32 {
+	return c.kv[BLOCK_STORAGE_FILE_SYSTEM_TRANSACTION_INDEX_DEPTH].Uint32Value
+}
+
 func (c *config) Profiling() bool {
 	return c.kv[PROFILING].BoolValue
 }
 
+func (c *config) StateSnapshotExport() bool {
+	return c.kv[STATE_SNAPSHOT_EXPORT].BoolValue
+}
+
+func (c *config) TransactionPoolInspection() bool {
+	return c.kv[TRANSACTION_POOL_INSPECTION].BoolValue
+}
+
 func (c *config) HttpAddress() string {
 	return c.kv[HTTP_ADDRESS].StringValue
 }
 
+func (c *config) HttpBatchMaxRequests() uint32 {
+	return c.kv[HTTP_BATCH_MAX_REQUESTS].Uint32Value
+}
+
+func (c *config) HttpBatchMaxSizeBytes() uint32 {
+	return c.kv[HTTP_BATCH_MAX_SIZE_BYTES].Uint32Value
+}
+
+func (c *config) GrpcAddress() string {
+	return c.kv[GRPC_ADDRESS].StringValue
+}
+
 func (c *config) NTPEndpoint() string {
 	return c.kv[NTP_ENDPOINT].StringValue
 }
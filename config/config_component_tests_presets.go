@@ -21,6 +21,7 @@ func ForDirectTransportTests(nodeAddress primitives.NodeAddress, keepAliveInterv
 	cfg.SetDuration(GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL, keepAliveInterval)
 	cfg.SetDuration(GOSSIP_NETWORK_TIMEOUT, networkTimeout)
 	cfg.SetDuration(GOSSIP_RECONNECT_INTERVAL, 20*time.Millisecond)
+	cfg.SetString(GOSSIP_TRANSPORT_SECURITY, "tls")
 	cfg.SetDuration(MANAGEMENT_POLLING_INTERVAL, 100*time.Millisecond)
 
 	return cfg
@@ -34,6 +35,7 @@ func ForGossipAdapterTests(nodeAddress primitives.NodeAddress) GossipTransportCo
 	cfg.SetDuration(GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL, 20*time.Millisecond)
 	cfg.SetDuration(GOSSIP_NETWORK_TIMEOUT, 1*time.Second)
 	cfg.SetDuration(GOSSIP_RECONNECT_INTERVAL, 20*time.Millisecond)
+	cfg.SetString(GOSSIP_TRANSPORT_SECURITY, "tls")
 	cfg.SetDuration(MANAGEMENT_POLLING_INTERVAL, 1*time.Second)
 
 	return cfg
@@ -63,10 +65,12 @@ func ForPublicApiTests(virtualChain uint32, txTimeout time.Duration, outOfSyncWa
 	return cfg
 }
 
-func ForStateStorageTest(numOfStateRevisionsToRetain uint32, graceBlockDiff uint32, graceTimeoutMillis uint64) StateStorageConfig {
+func ForStateStorageTest(numOfStateRevisionsToRetain uint32, graceBlockDiff uint32, graceTimeoutMillis uint64, divergencePolicy string, archiveMode bool) StateStorageConfig {
 	cfg := emptyConfig()
 
 	cfg.SetUint32(STATE_STORAGE_HISTORY_SNAPSHOT_NUM, numOfStateRevisionsToRetain)
+	cfg.SetString(STATE_STORAGE_DIVERGENCE_POLICY, divergencePolicy)
+	cfg.SetBool(STATE_STORAGE_ARCHIVE_MODE, archiveMode)
 	cfg.SetDuration(BLOCK_TRACKER_GRACE_TIMEOUT, time.Duration(graceTimeoutMillis)*time.Millisecond)
 	cfg.SetUint32(BLOCK_TRACKER_GRACE_DISTANCE, graceBlockDiff)
 	return cfg
@@ -49,12 +49,17 @@ type NodeConfig interface {
 	BlockSyncCollectResponseTimeout() time.Duration
 	BlockSyncCollectChunksTimeout() time.Duration
 	BlockSyncDescendingEnabled() bool
-	BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration
 	BlockStorageFileSystemDataDir() string
 	BlockStorageFileSystemMaxBlockSizeInBytes() uint32
+	BlockStorageFileSystemMaxBlocksPerSegment() uint32
+	BlockStorageFileSystemPruningDepth() uint32
+	BlockStorageFileSystemTransactionIndexDepth() uint32
 
 	// state storage
 	StateStorageHistorySnapshotNum() uint32
+	StateStorageFileSystemDataDir() string
+	StateStorageDivergencePolicy() string
+	StateStorageArchiveMode() bool
 
 	// block tracker
 	BlockTrackerGraceDistance() uint32
@@ -74,16 +79,29 @@ type NodeConfig interface {
 	TransactionPoolPropagationBatchingTimeout() time.Duration
 	TransactionPoolTimeBetweenEmptyBlocks() time.Duration
 	TransactionPoolNodeSyncRejectTime() time.Duration
+	TransactionPoolOrderingPolicy() string
+	TransactionPoolOrderingContractQuotaPercent() uint32
+	TransactionPoolSignerRateLimitPerSecond() uint32
+	TransactionPoolSignerRateLimitBurst() uint32
+	TransactionPoolGatewayRateLimitPerSecond() uint32
+	TransactionPoolGatewayRateLimitBurst() uint32
+	TransactionPoolPendingPoolJournalPath() string
 
 	// gossip
 	GossipListenPort() uint16
 	GossipConnectionKeepAliveInterval() time.Duration
 	GossipNetworkTimeout() time.Duration
 	GossipReconnectInterval() time.Duration
+	GossipTransport() string
+	GossipTransportSecurity() string
+	GossipRelayTtl() uint32
+	GossipRelayFanoutTransactionRelay() uint32
+	GossipRelayFanoutBlockSync() uint32
 
 	// public api
 	PublicApiSendTransactionTimeout() time.Duration
 	PublicApiNodeSyncWarningTime() time.Duration
+	PublicApiTransactionStatusSubscriptionTimeout() time.Duration
 
 	// processor
 	ProcessorArtifactPath() string
@@ -103,10 +121,19 @@ type NodeConfig interface {
 
 	// http server
 	HttpAddress() string
+	HttpBatchMaxRequests() uint32
+	HttpBatchMaxSizeBytes() uint32
+	GrpcAddress() string
 
 	// profiling
 	Profiling() bool
 
+	// serving state snapshots for bootstrapping new nodes
+	StateSnapshotExport() bool
+
+	// serving the contents of the transaction pool for debugging
+	TransactionPoolInspection() bool
+
 	// NTP Network Time Protocol
 	NTPEndpoint() string
 
@@ -142,7 +169,6 @@ type BlockStorageConfig interface {
 	BlockSyncCollectResponseTimeout() time.Duration
 	BlockSyncCollectChunksTimeout() time.Duration
 	BlockSyncDescendingEnabled() bool
-	BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration
 	TransactionExpirationWindow() time.Duration
 	BlockTrackerGraceTimeout() time.Duration
 }
@@ -150,6 +176,16 @@ type BlockStorageConfig interface {
 type FilesystemBlockPersistenceConfig interface {
 	BlockStorageFileSystemDataDir() string
 	BlockStorageFileSystemMaxBlockSizeInBytes() uint32
+	BlockStorageFileSystemMaxBlocksPerSegment() uint32
+	BlockStorageFileSystemPruningDepth() uint32
+	BlockStorageFileSystemTransactionIndexDepth() uint32
+	VirtualChainId() primitives.VirtualChainId
+	NetworkType() protocol.SignerNetworkType
+}
+
+type FilesystemStatePersistenceConfig interface {
+	StateStorageFileSystemDataDir() string
+	StateStorageArchiveMode() bool
 	VirtualChainId() primitives.VirtualChainId
 	NetworkType() protocol.SignerNetworkType
 }
@@ -160,6 +196,7 @@ type GossipTransportConfig interface {
 	GossipConnectionKeepAliveInterval() time.Duration
 	GossipNetworkTimeout() time.Duration
 	GossipReconnectInterval() time.Duration
+	GossipTransportSecurity() string
 }
 
 type ConsensusContextConfig interface {
@@ -175,11 +212,14 @@ type ConsensusContextConfig interface {
 type PublicApiConfig interface {
 	PublicApiSendTransactionTimeout() time.Duration
 	PublicApiNodeSyncWarningTime() time.Duration
+	PublicApiTransactionStatusSubscriptionTimeout() time.Duration
 	VirtualChainId() primitives.VirtualChainId
 }
 
 type StateStorageConfig interface {
 	StateStorageHistorySnapshotNum() uint32
+	StateStorageDivergencePolicy() string
+	StateStorageArchiveMode() bool
 	BlockTrackerGraceDistance() uint32
 	BlockTrackerGraceTimeout() time.Duration
 }
@@ -198,6 +238,13 @@ type TransactionPoolConfig interface {
 	TransactionPoolPropagationBatchingTimeout() time.Duration
 	TransactionPoolTimeBetweenEmptyBlocks() time.Duration
 	TransactionPoolNodeSyncRejectTime() time.Duration
+	TransactionPoolOrderingPolicy() string
+	TransactionPoolOrderingContractQuotaPercent() uint32
+	TransactionPoolSignerRateLimitPerSecond() uint32
+	TransactionPoolSignerRateLimitBurst() uint32
+	TransactionPoolGatewayRateLimitPerSecond() uint32
+	TransactionPoolGatewayRateLimitBurst() uint32
+	TransactionPoolPendingPoolJournalPath() string
 }
 
 type TransactionPoolConfigForTests interface {
@@ -238,12 +285,20 @@ type ValidatorNode interface {
 
 type HttpServerConfig interface {
 	HttpAddress() string
+	HttpBatchMaxRequests() uint32
+	HttpBatchMaxSizeBytes() uint32
 	Profiling() bool
+	StateSnapshotExport() bool
+	TransactionPoolInspection() bool
 	ManagementFilePath() string
 	ManagementPollingInterval() time.Duration
 	TransactionPoolTimeBetweenEmptyBlocks() time.Duration
 }
 
+type GrpcServerConfig interface {
+	GrpcAddress() string
+}
+
 type SignerConfig interface {
 	NodePrivateKey() primitives.EcdsaSecp256K1PrivateKey
 	SignerEndpoint() string
@@ -54,8 +54,15 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetDuration(PUBLIC_API_SEND_TRANSACTION_TIMEOUT, 20*time.Second)
 	// 5 empty blocks
 	cfg.SetDuration(PUBLIC_API_NODE_SYNC_WARNING_TIME, 50*time.Second)
+	// a client streaming transaction statuses subscribes again once it ends, zero keeps the stream open until the client leaves
+	cfg.SetDuration(PUBLIC_API_TRANSACTION_STATUS_SUBSCRIPTION_TIMEOUT, 5*time.Minute)
+	// deprecated and no longer read, kept so that config files which set it keep loading
 	cfg.SetDuration(BLOCK_STORAGE_TRANSACTION_RECEIPT_QUERY_TIMESTAMP_GRACE, 5*time.Second)
 	cfg.SetUint32(STATE_STORAGE_HISTORY_SNAPSHOT_NUM, 5)
+	// one of halt, replay or log - see statestorage.DivergencePolicyHalt
+	cfg.SetString(STATE_STORAGE_DIVERGENCE_POLICY, "halt")
+	// archive mode keeps the state of every block height so queries can run against old blocks, at the cost of disk space
+	cfg.SetBool(STATE_STORAGE_ARCHIVE_MODE, false)
 
 	cfg.SetUint32(TRANSACTION_POOL_PENDING_POOL_SIZE_IN_BYTES, 20*1024*1024)
 	// roughly 6 leader changes in leanHelix
@@ -67,10 +74,31 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetDuration(TRANSACTION_POOL_COMMITTED_POOL_CLEAR_EXPIRED_INTERVAL, 30*time.Second)
 	cfg.SetUint32(TRANSACTION_POOL_PROPAGATION_BATCH_SIZE, 100)
 	cfg.SetDuration(TRANSACTION_POOL_PROPAGATION_BATCHING_TIMEOUT, 100*time.Millisecond)
+	// one of fifo, signer-round-robin or contract-quota - see transactionpool.TransactionOrderingPolicy
+	cfg.SetString(TRANSACTION_POOL_ORDERING_POLICY, "fifo")
+	cfg.SetUint32(TRANSACTION_POOL_ORDERING_CONTRACT_QUOTA_PERCENT, 25)
+	// token bucket limits of transactions per second, per signer public key and per gateway node forwarding them - 0 is no limit
+	cfg.SetUint32(TRANSACTION_POOL_SIGNER_RATE_LIMIT_PER_SECOND, 0)
+	cfg.SetUint32(TRANSACTION_POOL_SIGNER_RATE_LIMIT_BURST, 100)
+	cfg.SetUint32(TRANSACTION_POOL_GATEWAY_RATE_LIMIT_PER_SECOND, 0)
+	cfg.SetUint32(TRANSACTION_POOL_GATEWAY_RATE_LIMIT_BURST, 1000)
+	// path of a journal which keeps pending transactions across restarts - empty keeps them in memory only
+	cfg.SetString(TRANSACTION_POOL_PENDING_POOL_JOURNAL_PATH, "")
 
 	cfg.SetDuration(GOSSIP_CONNECTION_KEEP_ALIVE_INTERVAL, 1*time.Second)
 	cfg.SetDuration(GOSSIP_RECONNECT_INTERVAL, 1*time.Minute)
 	cfg.SetDuration(GOSSIP_NETWORK_TIMEOUT, 30*time.Second)
+	// one of tcp or quic - every node in the network must use the same one
+	cfg.SetString(GOSSIP_TRANSPORT, "tcp")
+	// one of tls, tls-accept-plaintext or plaintext-accept-tls, see tcp.TransportSecurityTls. a network of nodes that
+	// predate tls upgrades by rolling plaintext-accept-tls to every node, then tls-accept-plaintext, then tls
+	cfg.SetString(GOSSIP_TRANSPORT_SECURITY, "tls")
+	// broadcasts of a topic with a fanout are relayed: sent to that many random peers, each passing them on to as many
+	// of its own until they travelled TTL hops. a fanout of 0 sends broadcasts straight to every peer. consensus
+	// messages are always sent straight to every peer, as relaying gives no guarantee that every node receives them
+	cfg.SetUint32(GOSSIP_RELAY_TTL, 4)
+	cfg.SetUint32(GOSSIP_RELAY_FANOUT_TRANSACTION_RELAY, 0)
+	cfg.SetUint32(GOSSIP_RELAY_FANOUT_BLOCK_SYNC, 0)
 
 	// TODO: remove with Ethereum connector
 	cfg.SetDuration(ETHEREUM_FINALITY_TIME_COMPONENT, 10*time.Minute)
@@ -84,13 +112,28 @@ func defaultProductionConfig() mutableNodeConfig {
 	cfg.SetString(PROCESSOR_ARTIFACT_PATH, filepath.Join(GetProjectSourceTmpPath(), "processor-artifacts"))
 	cfg.SetString(BLOCK_STORAGE_FILE_SYSTEM_DATA_DIR, "/usr/local/var/orbs") // TODO V1 use build tags to replace with /var/lib/orbs for linux
 	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCK_SIZE_IN_BYTES, 64*1024*1024)
+	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_MAX_BLOCKS_PER_SEGMENT, 100000)
+	// pruning drops the transactions and receipts of old blocks, which then can no longer be served to block sync
+	// peers or to receipt queries, so all blocks are kept whole unless configured otherwise
+	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_PRUNING_DEPTH, 0)
+	// the transactions of older blocks are looked up in the transaction index on disk, zero keeps all of them in memory
+	cfg.SetUint32(BLOCK_STORAGE_FILE_SYSTEM_TRANSACTION_INDEX_DEPTH, 100000)
 
 	// TODO: remove with new logger
 	cfg.SetDuration(LOGGER_FILE_TRUNCATION_INTERVAL, 15*time.Minute)
 	cfg.SetBool(LOGGER_FULL_LOG, false)
 
 	cfg.SetBool(PROFILING, true)
+	// a snapshot export holds off state commits while the full state is streamed, so it is only served when asked for
+	cfg.SetBool(STATE_SNAPSHOT_EXPORT, false)
+	// the pool inspection lists who sends which transactions through this node, so it is only served when asked for
+	cfg.SetBool(TRANSACTION_POOL_INSPECTION, false)
 	cfg.SetString(HTTP_ADDRESS, ":8080")
+	// every request of a batch is served by the same http call, so a batch is held to what a single call may cost
+	cfg.SetUint32(HTTP_BATCH_MAX_REQUESTS, 100)
+	cfg.SetUint32(HTTP_BATCH_MAX_SIZE_BYTES, 4*1024*1024)
+	// the grpc transport of the public api is served in addition to http only when an address is configured
+	cfg.SetString(GRPC_ADDRESS, "")
 
 	return cfg
 }
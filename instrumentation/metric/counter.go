@@ -0,0 +1,46 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package metric
+
+import (
+	"sync/atomic"
+)
+
+// Counter only goes up, so monitoring can tell a restart of the node from events that stopped happening
+type Counter struct {
+	name  string
+	pName string
+	value int64
+}
+
+func newCounter(name string, pName string) *Counter {
+	return &Counter{name: name, pName: prometheusName(pName)}
+}
+
+func (c *Counter) Name() string {
+	return c.name
+}
+
+func (c *Counter) Value() interface{} {
+	return c.IntValue()
+}
+
+func (c *Counter) Export() interface{} {
+	return c.Value()
+}
+
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.value, 1)
+}
+
+func (c *Counter) Add(i uint64) {
+	atomic.AddInt64(&c.value, int64(i))
+}
+
+func (c *Counter) IntValue() int64 {
+	return atomic.LoadInt64(&c.value)
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package metric
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCounter_Inc(t *testing.T) {
+	c := Counter{}
+	c.Inc()
+
+	require.EqualValues(t, 1, c.IntValue(), "counter value differed from expected")
+}
+
+func TestCounter_Add(t *testing.T) {
+	c := Counter{}
+	c.Inc()
+	c.Add(10)
+
+	require.EqualValues(t, 11, c.IntValue(), "counter value differed from expected")
+}
+
+func TestCounter_ExportPrometheus(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("StateStorage.StateRootDivergence.Count")
+	counter.Inc()
+
+	result := r.ExportPrometheus()
+
+	require.Regexp(t, "# TYPE StateStorage_StateRootDivergence_Count counter", result)
+	require.Regexp(t, "StateStorage_StateRootDivergence_Count 1", result)
+}
@@ -50,6 +50,14 @@ func (g *Gauge) exportPrometheus(labelString string) string {
 	return typeRow + fmt.Sprintf("%s %s\n", g.pName, strconv.FormatInt(g.IntValue(), 10))
 }
 
+func (c *Counter) exportPrometheus(labelString string) string {
+	typeRow := prometheusType(c.pName, "counter")
+	if len(labelString) > 0 {
+		return typeRow + fmt.Sprintf("%s{%s} %s\n", c.pName, labelString, strconv.FormatInt(c.IntValue(), 10))
+	}
+	return typeRow + fmt.Sprintf("%s %s\n", c.pName, strconv.FormatInt(c.IntValue(), 10))
+}
+
 // Note: rate is not exported
 func (r *Rate) exportPrometheus(labelString string) string {
 	return ""
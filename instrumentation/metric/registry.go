@@ -28,6 +28,7 @@ type Factory interface {
 	NewGauge(name string) *Gauge
 	NewGaugeWithValue(name string, value int64) *Gauge
 	NewGaugeWithPrometheusName(name string, pName string) *Gauge
+	NewCounter(name string) *Counter
 	NewRate(name string) *Rate
 	NewText(name string, defaultValue ...string) *Text
 }
@@ -132,6 +133,12 @@ func (r *inMemoryRegistry) NewGaugeWithValue(name string, value int64) *Gauge {
 	return g
 }
 
+func (r *inMemoryRegistry) NewCounter(name string) *Counter {
+	c := newCounter(name, name)
+	r.register(c)
+	return c
+}
+
 func (r *inMemoryRegistry) NewLatency(name string, maxDuration time.Duration) *Histogram {
 	return r.NewLatencyWithPrometheusName(name, name, maxDuration)
 }
@@ -20,6 +20,8 @@ import (
 )
 
 func main() {
+	runSubcommand(os.Args[1:])
+
 	logger := instrumentation.GetBootstrapCrashLogger()
 	var node *bootstrap.Node
 	func() { // context of bootstrap crash logging
@@ -33,6 +35,7 @@ func main() {
 		silentLog := flag.Bool("silent", false, "disable output to stdout")
 		pathToLog := flag.String("log", "", "path/to/node.log")
 		version := flag.Bool("version", false, "returns information about version")
+		importStateSnapshot := flag.String("import-state-snapshot", "", "path/to/state.snapshot to load into an empty state database before starting")
 
 		var filePaths config.FilesPaths
 		flag.Var(&filePaths, "config", "path/to/config.json")
@@ -52,6 +55,13 @@ func main() {
 
 		logger = instrumentation.GetLogger(*pathToLog, *silentLog, cfg)
 
+		if *importStateSnapshot != "" {
+			if err := bootstrap.ImportStateSnapshot(cfg, logger, *importStateSnapshot); err != nil {
+				logger.Error("error importing state snapshot", log.Error(err))
+				os.Exit(1)
+			}
+		}
+
 		node = bootstrap.NewNode(
 			cfg,
 			logger,
@@ -29,6 +29,13 @@ func (bw *blockWriter) Close() error {
 	return bw.ws.Close()
 }
 
+// replaceFile closes the file being written and continues writing to ws, the caller is expected to hold the lock
+func (bw *blockWriter) replaceFile(ws writerSyncer) error {
+	err := bw.ws.Close()
+	bw.ws = ws
+	return err
+}
+
 func newBlockWriter(ws writerSyncer, codec blockCodec) *blockWriter {
 	return &blockWriter{
 		ws:    ws,
@@ -23,9 +23,15 @@ const chunkLengthSize = int(unsafe.Sizeof(uint32(0)))
 
 const orbsFormatMagic = uint32(0x5342524f) // "ORBS"
 const orbsFormatVersion = 0
+
+// set on the file version of segment files rewritten by pruning
+const orbsFormatVersionPrunedFlag = uint32(0x80000000)
 const blockMagic = uint32(0x6b4f4c42) // "BLOk"
 const blockVersion = 0
 
+// set on the block version of blocks whose transactions and receipts were pruned
+const blockVersionPrunedFlag = uint32(0x80000000)
+
 type codec struct {
 	maxBlockSize int
 }
@@ -101,13 +107,17 @@ func (bh *blockHeader) read(r io.Reader) error {
 		return fmt.Errorf("invalid block magic number %v", bh.Magic)
 	}
 
-	if bh.Version != blockVersion {
+	if bh.Version&^blockVersionPrunedFlag != blockVersion {
 		return fmt.Errorf("invalid block version %d", bh.Version)
 	}
 
 	return nil
 }
 
+func (bh *blockHeader) isPruned() bool {
+	return bh.Version&blockVersionPrunedFlag != 0
+}
+
 func newBlocksFileHeader(networkType, vchainId uint32) *blocksFileHeader {
 	return &blocksFileHeader{
 		Magic:       orbsFormatMagic,
@@ -139,12 +149,16 @@ func (bfh *blocksFileHeader) read(r io.Reader) error {
 	if bfh.Magic != orbsFormatMagic {
 		return fmt.Errorf("invalid magic number %v", bfh.Magic)
 	}
-	if bfh.FileVersion != orbsFormatVersion {
+	if bfh.FileVersion&^orbsFormatVersionPrunedFlag != orbsFormatVersion {
 		return fmt.Errorf("invalid version %d", bfh.FileVersion)
 	}
 	return nil
 }
 
+func (bfh *blocksFileHeader) isPruned() bool {
+	return bfh.FileVersion&orbsFormatVersionPrunedFlag != 0
+}
+
 func (bfh *blocksFileHeader) write(w io.Writer) error {
 	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
 	sw := newChecksumWriter(w, checkSum)
@@ -181,6 +195,9 @@ func (c *codec) encode(block *protocol.BlockPairContainer, w io.Writer) (int, er
 
 	// calc header
 	blockHeader := newBlockHeader()
+	if isPrunedBlock(block) {
+		blockHeader.Version |= blockVersionPrunedFlag
+	}
 	blockHeader.addFixed(tb.Header)
 	blockHeader.addFixed(tb.Metadata)
 	blockHeader.addFixed(tb.BlockProof)
@@ -316,7 +333,12 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		return nil, budget.bytesRead, err
 	}
 
-	receipts, _, err := c.readReceiptsSection(tr, budget, fixed.resultsBlockHeader.NumTransactionReceipts())
+	numReceipts, numTxs := fixed.resultsBlockHeader.NumTransactionReceipts(), fixed.transactionsBlockHeader.NumSignedTransactions()
+	if serializationHeader.isPruned() {
+		numReceipts, numTxs = 0, 0
+	}
+
+	receipts, _, err := c.readReceiptsSection(tr, budget, numReceipts)
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
@@ -326,7 +348,7 @@ func (c *codec) decode(r io.Reader) (*protocol.BlockPairContainer, int, error) {
 		return nil, budget.bytesRead, err
 	}
 
-	txs, _, err := c.readTransactionsSection(tr, budget, fixed.transactionsBlockHeader.NumSignedTransactions())
+	txs, _, err := c.readTransactionsSection(tr, budget, numTxs)
 	if err != nil {
 		return nil, budget.bytesRead, err
 	}
@@ -529,6 +551,29 @@ func readChunk(reader io.Reader, budget *readingBudget) ([]byte, error) {
 	return chunk, nil
 }
 
+// pruneBlock returns a copy of the block without its transactions and receipts, keeping the headers, proofs and state diffs
+func pruneBlock(block *protocol.BlockPairContainer) *protocol.BlockPairContainer {
+	return &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{
+			Header:             block.TransactionsBlock.Header,
+			Metadata:           block.TransactionsBlock.Metadata,
+			SignedTransactions: []*protocol.SignedTransaction{},
+			BlockProof:         block.TransactionsBlock.BlockProof,
+		},
+		ResultsBlock: &protocol.ResultsBlockContainer{
+			Header:              block.ResultsBlock.Header,
+			TransactionReceipts: []*protocol.TransactionReceipt{},
+			ContractStateDiffs:  block.ResultsBlock.ContractStateDiffs,
+			BlockProof:          block.ResultsBlock.BlockProof,
+		},
+	}
+}
+
+func isPrunedBlock(block *protocol.BlockPairContainer) bool {
+	return (len(block.TransactionsBlock.SignedTransactions) == 0 && block.TransactionsBlock.Header.NumSignedTransactions() > 0) ||
+		(len(block.ResultsBlock.TransactionReceipts) == 0 && block.ResultsBlock.Header.NumTransactionReceipts() > 0)
+}
+
 func transactionReceiptsToMessages(receipts []*protocol.TransactionReceipt) (messages []membuffers.Message) {
 	messages = make([]membuffers.Message, 0, len(receipts))
 	for _, receipt := range receipts {
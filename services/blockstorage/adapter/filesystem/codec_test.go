@@ -43,6 +43,21 @@ func TestCodec_EncodesAndDecodes(t *testing.T) {
 	test.RequireCmpEqual(t, block, decodedBlock, "expected to decode an identical block as encoded")
 }
 
+func TestCodec_EncodesAndDecodesPrunedBlock(t *testing.T) {
+	block := builders.BlockPair().WithHeight(1).WithTransactions(5).WithReceiptsForTransactions().Build()
+	pruned := pruneBlock(block)
+	rw := new(bytes.Buffer)
+	c := newCodec(1024 * 1024)
+
+	_, err := c.encode(pruned, rw)
+	require.NoError(t, err)
+
+	decodedBlock, _, err := c.decode(rw)
+	require.NoError(t, err, "expected to decode pruned block record successfully")
+	test.RequireCmpEqual(t, pruned, decodedBlock, "expected to decode an identical pruned block as encoded")
+	require.EqualValues(t, 5, decodedBlock.TransactionsBlock.Header.NumSignedTransactions(), "expected pruned block to keep its header")
+}
+
 func TestCodec_DetectsDataCorruption(t *testing.T) {
 	ctrlRand := rand.NewControlledRand(t)
 
@@ -16,8 +16,11 @@ import (
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
 	"io"
+	"io/ioutil"
+	"os"
 	"testing"
 )
 
@@ -44,7 +47,7 @@ func TestConstructIndexFromReader(t *testing.T) {
 		})
 
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		blockHeightIndex, err := buildIndex(rw, 0, harness.Logger, codec, metrics)
+		blockHeightIndex, err := indexNewSegment(t, rw, harness.Logger, codec, metrics)
 
 		require.NoError(t, err, "expected index to construct with no error")
 		require.EqualValues(t, numBlocks, blockHeightIndex.getLastBlockHeight(), "expected index to reach topHeight block height")
@@ -91,7 +94,7 @@ func TestConstructIndexFromReaderInterleavedOrder(t *testing.T) {
 		})
 
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		blockHeightIndex, err := buildIndex(rw, 0, harness.Logger, codec, metrics)
+		blockHeightIndex, err := indexNewSegment(t, rw, harness.Logger, codec, metrics)
 
 		require.NoError(t, err, "expected index to construct with no error")
 		require.EqualValues(t, numBlocks, blockHeightIndex.getLastBlockHeight(), "expected index to reach topHeight block height")
@@ -139,7 +142,7 @@ func TestConstructIndexFromReaderInterleavedOrderWithGap(t *testing.T) {
 		})
 
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		blockHeightIndex, err := buildIndex(rw, 0, harness.Logger, codec, metrics)
+		blockHeightIndex, err := indexNewSegment(t, rw, harness.Logger, codec, metrics)
 
 		require.NoError(t, err, "expected index to construct with no error")
 		require.EqualValues(t, getBlockHeight(sequentialTopBlock), blockHeightIndex.getLastBlockHeight(), "expected index to reach sequential top height")
@@ -211,16 +214,16 @@ func TestBuildIndexSucceedsIndexingFromReader(t *testing.T) {
 		r, done := newBlockFileReadStream(t, ctrlRand, numBlocks, maxTransactions, maxStateDiffs, codec)
 
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		bhIndex, err := buildIndex(r, 0, harness.Logger, codec, metrics)
+		bhIndex, err := indexNewSegment(t, r, harness.Logger, codec, metrics)
 
-		require.NoError(t, err, "expected buildIndex to succeed")
+		require.NoError(t, err, "expected indexSegment to succeed")
 		require.Equal(t, bhIndex.getLastBlockHeight(), primitives.BlockHeight(numBlocks), "expected block height to match the encoded block count")
 
 		<-done
 	})
 }
 
-// The purpose of this test is to assure that buildIndex handles the case where a reader returns less bytes than
+// The purpose of this test is to assure that indexSegment handles the case where a reader returns less bytes than
 // requested, even when more will be available in a subsequent read.
 // (this is the behaviour of the buffered reader we use for reading the block file)
 // To test this, we wrap the file reader with a reader that only returns one byte at a time.
@@ -237,9 +240,9 @@ func TestBuildIndexHandlesPartialReads(t *testing.T) {
 
 		rBuffered, done2 := OneByteAtATimeReader(t, r)
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		bhIndex, err := buildIndex(rBuffered, 0, harness.Logger, codec, metrics)
+		bhIndex, err := indexNewSegment(t, rBuffered, harness.Logger, codec, metrics)
 
-		require.NoError(t, err, "expected buildIndex to succeed with a buffered reader")
+		require.NoError(t, err, "expected indexSegment to succeed with a buffered reader")
 		require.Equal(t, bhIndex.getLastBlockHeight(), primitives.BlockHeight(numBlocks), "expected block height to match the encoded block count")
 
 		<-done
@@ -253,13 +256,30 @@ func TestBuildIndexHandlesEmptyFile(t *testing.T) {
 
 		r := bytes.NewReader(make([]byte, 0, 0))
 		metrics := generateBlockStorageMetrics(metric.NewRegistry())
-		bhIndex, err := buildIndex(r, 0, harness.Logger, codec, metrics)
+		bhIndex, err := indexNewSegment(t, r, harness.Logger, codec, metrics)
 
-		require.NoError(t, err, "expected buildIndex to succeed")
+		require.NoError(t, err, "expected indexSegment to succeed")
 		require.Equal(t, bhIndex.getLastBlockHeight(), primitives.BlockHeight(0), "expected block height to be zero")
 	})
 }
 
+// indexNewSegment indexes the blocks read from r as the first segment, with its index file in a temporary directory
+func indexNewSegment(t *testing.T, r io.Reader, logger log.Logger, c blockCodec, metrics *metrics) (*blockHeightIndex, error) {
+	dir, err := ioutil.TempDir("", "construct_index_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	indexFile, err := createSegmentIndexFile(segmentIndexFileName(dir, 0), 0, false)
+	require.NoError(t, err)
+	defer indexFile.close()
+
+	bhIndex := newBlockHeightIndex(logger, 0, 0)
+	if err := indexSegment(r, bhIndex, indexFile, logger, c, metrics); err != nil {
+		return nil, err
+	}
+	return bhIndex, nil
+}
+
 type mockCodec struct {
 	mock.Mock
 }
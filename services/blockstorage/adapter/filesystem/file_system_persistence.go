@@ -24,6 +24,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -34,6 +36,7 @@ type metrics struct {
 }
 
 const blocksFilename = "blocks"
+const lockFilename = "blocks.lock"
 
 func generateBlockStorageMetrics(m metric.Factory) *metrics {
 	return &metrics{
@@ -55,6 +58,11 @@ type BlockPersistence struct {
 	logger       log.Logger
 	blockWriter  *blockWriter
 	codec        blockCodec
+	indexFile    *segmentIndexFile // guarded by the blockWriter lock
+	txIndex      *txIndex          // written under the blockWriter lock
+	lockFile     *os.File
+	segmentFiles sync.RWMutex // taken for writing while a pruned segment file replaces the original
+	pruner       *pruner      // nil when pruning is not configured
 }
 
 func (f *BlockPersistence) GetSyncState() internodesync.SyncState {
@@ -69,11 +77,19 @@ func getBlockHeight(block *protocol.BlockPairContainer) primitives.BlockHeight {
 }
 
 func (f *BlockPersistence) GracefulShutdown(shutdownContext context.Context) {
-	logger := f.logger.WithTags(log.String("filename", blocksFileName(f.config)))
+	logger := f.logger.WithTags(log.String("dir", f.config.BlockStorageFileSystemDataDir()))
+	f.stopPruning(shutdownContext)
 	if err := f.blockWriter.Close(); err != nil {
 		logger.Error("failed to close blocks file")
 		return
 	}
+	if err := f.indexFile.close(); err != nil {
+		logger.Error("failed to close segment index file", log.Error(err))
+	}
+	if err := f.txIndex.close(); err != nil {
+		logger.Error("failed to close transaction index", log.Error(err))
+	}
+	closeSilently(f.lockFile, logger)
 	logger.Info("closed blocks file")
 }
 
@@ -82,42 +98,178 @@ func NewBlockPersistence(conf config.FilesystemBlockPersistenceConfig, parent lo
 	metrics := generateBlockStorageMetrics(metricFactory)
 	codec := newCodec(conf.BlockStorageFileSystemMaxBlockSizeInBytes())
 
-	file, blocksOffset, err := openBlocksFile(conf, logger)
+	lockFile, err := lockDataDir(conf, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	bhIndex, err := buildIndex(bufio.NewReaderSize(file, 1024*1024), blocksOffset, logger, codec, metrics)
+	bhIndex, file, indexFile, err := loadSegments(conf, logger, codec, metrics)
 	if err != nil {
-		closeSilently(file, logger)
+		closeSilently(lockFile, logger)
 		return nil, err
 	}
 
 	newTip, err := newFileBlockWriter(file, codec, bhIndex.fetchNextOffset())
 	if err != nil {
 		closeSilently(file, logger)
+		_ = indexFile.close()
+		closeSilently(lockFile, logger)
 		return nil, err
 	}
 
 	adapter := &BlockPersistence{
-		bhIndex:      bhIndex,
-		config:       conf,
-		blockTracker: synchronization.NewBlockTracker(logger, uint64(bhIndex.getLastBlockHeight()), 5),
-		metrics:      metrics,
-		logger:       logger,
-		blockWriter:  newTip,
-		codec:        codec,
+		bhIndex:     bhIndex,
+		config:      conf,
+		metrics:     metrics,
+		logger:      logger,
+		blockWriter: newTip,
+		codec:       codec,
+		indexFile:   indexFile,
+		lockFile:    lockFile,
+	}
+
+	// blocks loaded from segment index files leave the cached top blocks to be read from disk
+	if err := bhIndex.resolveBlocks(adapter.fetchBlock); err != nil {
+		closeSilently(file, logger)
+		_ = indexFile.close()
+		closeSilently(lockFile, logger)
+		return nil, errors.Wrap(err, "failed reading top blocks")
 	}
 
-	if size, err := getBlockFileSize(file); err != nil {
+	if adapter.txIndex, err = loadTxIndex(conf.BlockStorageFileSystemDataDir(), bhIndex, logger); err != nil {
+		closeSilently(file, logger)
+		_ = indexFile.close()
+		closeSilently(lockFile, logger)
+		return nil, err
+	}
+	adapter.blockTracker = synchronization.NewBlockTracker(logger, uint64(bhIndex.getLastBlockHeight()), 5)
+
+	if size, err := getSegmentFilesSize(conf); err != nil {
 		return adapter, err
 	} else {
 		adapter.metrics.sizeOnDisk.Add(size)
 	}
 
+	adapter.startPruning()
+
 	return adapter, nil
 }
 
+// loadSegments builds the index from the segment index files, scanning only the blocks written after the last valid
+// record of each. returns the open file and index file of the segment being written
+func loadSegments(conf config.FilesystemBlockPersistenceConfig, logger log.Logger, codec blockCodec, metrics *metrics) (*blockHeightIndex, *os.File, *segmentIndexFile, error) {
+	dir := conf.BlockStorageFileSystemDataDir()
+	numSegments, err := countSegmentFiles(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if numSegments == 0 {
+		numSegments = 1 // the first segment is created below
+	}
+
+	var bhIndex *blockHeightIndex
+	activeId := numSegments - 1
+	for id := uint32(0); ; id++ {
+		writable := id == activeId
+		file, header, firstBlockOffset, err := openSegmentFile(conf, id, writable, logger)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if bhIndex == nil {
+			bhIndex = newBlockHeightIndex(logger, firstBlockOffset, conf.BlockStorageFileSystemTransactionIndexDepth())
+		} else {
+			bhIndex.startSegment(id, firstBlockOffset)
+		}
+
+		indexFile, err := loadSegment(conf, id, file, header.isPruned(), bhIndex, logger, codec, metrics)
+		if err != nil {
+			closeSilently(file, logger)
+			return nil, nil, nil, err
+		}
+		if writable {
+			return bhIndex, file, indexFile, nil
+		}
+
+		closeSilently(file, logger)
+		if err := indexFile.close(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+// loadSegment appends the blocks of a segment to the index. blocks listed in the segment index file are not read,
+// except for the last one which is decoded to verify it was fully written. the blocks following it are scanned and
+// appended to the index file, which is rebuilt from scratch if missing or corrupt
+func loadSegment(conf config.FilesystemBlockPersistenceConfig, id uint32, file *os.File, pruned bool, bhIndex *blockHeightIndex, logger log.Logger, codec blockCodec, metrics *metrics) (*segmentIndexFile, error) {
+	filename := segmentIndexFileName(conf.BlockStorageFileSystemDataDir(), id)
+	size, err := getBlockFileSize(file)
+	if err != nil {
+		return nil, err
+	}
+
+	indexFile, records, err := openSegmentIndexFile(filename, id, pruned)
+	if err != nil {
+		logger.Info("rebuilding segment index", log.Uint32("segment", id), log.Error(err))
+		if indexFile, err = createSegmentIndexFile(filename, id, pruned); err != nil {
+			return nil, err
+		}
+	}
+
+	records = validIndexRecords(records, bhIndex.fetchNextOffset(), size, file, codec)
+	end := segmentIndexHeaderSize()
+	if len(records) > 0 {
+		end = records[len(records)-1].end
+	}
+	if err := indexFile.truncate(end); err != nil {
+		_ = indexFile.close()
+		return nil, err
+	}
+	for _, record := range records {
+		if err := bhIndex.appendBlock(record.entry, record.txHashes, record.eventContracts, nil, nil); err != nil {
+			_ = indexFile.close()
+			return nil, errors.Wrapf(err, "failed loading index of segment %d", id)
+		}
+	}
+	if len(records) > 0 {
+		metrics.indexLastUpdateTime.Update(time.Now().Unix())
+	}
+	if pruned {
+		bhIndex.markActiveSegmentPruned()
+	}
+
+	offset := bhIndex.fetchNextOffset()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = indexFile.close()
+		return nil, errors.Wrapf(err, "failed to seek to block offset %d", offset)
+	}
+	if err := indexSegment(bufio.NewReaderSize(file, 1024*1024), bhIndex, indexFile, logger, codec, metrics); err != nil {
+		_ = indexFile.close()
+		return nil, err
+	}
+	return indexFile, nil
+}
+
+// validIndexRecords returns the leading records which list consecutive blocks of the segment file. the last block
+// listed is decoded, as its record may have been appended before the block was flushed to disk
+func validIndexRecords(records []*indexRecord, firstBlockOffset int64, segmentFileSize int64, file *os.File, codec blockCodec) []*indexRecord {
+	offset := firstBlockOffset
+	for n, record := range records {
+		if record.entry.Offset != offset || offset+int64(record.entry.Size) > segmentFileSize {
+			records = records[:n]
+			break
+		}
+		offset += int64(record.entry.Size)
+	}
+
+	if len(records) > 0 {
+		last := records[len(records)-1].entry
+		if _, _, err := codec.decode(io.NewSectionReader(file, last.Offset, int64(last.Size))); err != nil {
+			records = records[:len(records)-1]
+		}
+	}
+	return records
+}
+
 func getBlockFileSize(file *os.File) (int64, error) {
 	if fi, err := file.Stat(); err != nil {
 		return 0, errors.Wrap(err, "unable to read file size for metrics")
@@ -126,74 +278,109 @@ func getBlockFileSize(file *os.File) (int64, error) {
 	}
 }
 
-func openBlocksFile(conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (*os.File, int64, error) {
+func getSegmentFilesSize(conf config.FilesystemBlockPersistenceConfig) (int64, error) {
+	dir := conf.BlockStorageFileSystemDataDir()
+	numSegments, err := countSegmentFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for id := uint32(0); id < numSegments; id++ {
+		fi, err := os.Stat(segmentFileName(dir, id))
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to read file size for metrics")
+		}
+		size += fi.Size()
+	}
+	return size, nil
+}
+
+func lockDataDir(conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (*os.File, error) {
 	dir := conf.BlockStorageFileSystemDataDir()
-	filename := blocksFileName(conf)
 	err := os.MkdirAll(dir, os.ModePerm)
 	if err != nil {
-		return nil, 0, errors.Wrapf(err, "failed to verify data directory exists %s", dir)
+		return nil, errors.Wrapf(err, "failed to verify data directory exists %s", dir)
 	}
 
+	filename := filepath.Join(dir, lockFilename)
 	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
-		return nil, 0, errors.Wrapf(err, "failed to open blocks file for writing %s", filename)
+		return nil, errors.Wrapf(err, "failed to open lock file %s", filename)
 	}
 
 	err = advisoryLockExclusive(file)
 	if err != nil {
 		closeSilently(file, logger)
-		return nil, 0, errors.Wrapf(err, "failed to obtain exclusive lock for writing %s", filename)
+		return nil, errors.Wrapf(err, "failed to obtain exclusive lock for writing %s", filename)
 	}
 
-	firstBlockOffset, err := validateFileHeader(file, conf, logger)
+	return file, nil
+}
+
+func openSegmentFile(conf config.FilesystemBlockPersistenceConfig, id uint32, writable bool, logger log.Logger) (*os.File, *blocksFileHeader, int64, error) {
+	filename := segmentFileName(conf.BlockStorageFileSystemDataDir(), id)
+
+	var file *os.File
+	var err error
+	if writable {
+		file, err = os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	} else {
+		file, err = os.Open(filename)
+	}
+	if err != nil {
+		return nil, nil, 0, errors.Wrapf(err, "failed to open blocks file %s", filename)
+	}
+
+	header, firstBlockOffset, err := validateFileHeader(file, conf, logger)
 	if err != nil {
 		closeSilently(file, logger)
-		return nil, 0, errors.Wrapf(err, "failed to obtain exclusive lock for writing %s", filename)
+		return nil, nil, 0, errors.Wrapf(err, "failed to validate blocks file header %s", filename)
 	}
 
-	return file, firstBlockOffset, nil
+	return file, header, firstBlockOffset, nil
 }
 
-func validateFileHeader(file *os.File, conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (int64, error) {
+func validateFileHeader(file *os.File, conf config.FilesystemBlockPersistenceConfig, logger log.Logger) (*blocksFileHeader, int64, error) {
 
 	info, err := file.Stat()
 	if err != nil {
-		return 0, err
+		return nil, 0, err
 	}
 	if info.Size() == 0 { // empty file
 		if err := writeNewFileHeader(file, conf, logger); err != nil {
-			return 0, err
+			return nil, 0, err
 		}
 	}
 
 	offset, err := file.Seek(0, io.SeekStart)
 	if err != nil {
-		return 0, errors.Wrapf(err, "error reading blocks file header")
+		return nil, 0, errors.Wrapf(err, "error reading blocks file header")
 	}
 	if offset != 0 {
-		return 0, fmt.Errorf("error reading blocks file header")
+		return nil, 0, fmt.Errorf("error reading blocks file header")
 	}
 
 	header := newBlocksFileHeader(0, 0)
 	err = header.read(file)
 	if err != nil {
-		return 0, errors.Wrapf(err, "error reading blocks file header")
+		return nil, 0, errors.Wrapf(err, "error reading blocks file header")
 	}
 
 	if header.NetworkType != uint32(conf.NetworkType()) {
-		return 0, fmt.Errorf("blocks file network type mismatch. found netowrk type %d expected %d", header.NetworkType, conf.NetworkType())
+		return nil, 0, fmt.Errorf("blocks file network type mismatch. found netowrk type %d expected %d", header.NetworkType, conf.NetworkType())
 	}
 
 	if header.ChainId != uint32(conf.VirtualChainId()) {
-		return 0, fmt.Errorf("blocks file virtual chain id mismatch. found vchain id %d expected %d", header.ChainId, conf.VirtualChainId())
+		return nil, 0, fmt.Errorf("blocks file virtual chain id mismatch. found vchain id %d expected %d", header.ChainId, conf.VirtualChainId())
 	}
 
 	offset, err = file.Seek(0, io.SeekCurrent) // read current offset
 	if err != nil {
-		return 0, errors.Wrapf(err, "error reading blocks file header")
+		return nil, 0, errors.Wrapf(err, "error reading blocks file header")
 	}
 
-	return offset, nil
+	return header, offset, nil
 }
 
 func writeNewFileHeader(file *os.File, conf config.FilesystemBlockPersistenceConfig, logger log.Logger) error {
@@ -228,9 +415,10 @@ func newFileBlockWriter(file *os.File, codec blockCodec, nextBlockOffset int64)
 	return result, nil
 }
 
-func buildIndex(r io.Reader, firstBlockOffset int64, logger log.Logger, c blockCodec, metrics *metrics) (*blockHeightIndex, error) {
-	bhIndex := newBlockHeightIndex(logger, firstBlockOffset)
-	offset := int64(firstBlockOffset)
+// indexSegment appends the blocks read from r to the segment being written in the index, and to its index file
+func indexSegment(r io.Reader, bhIndex *blockHeightIndex, indexFile *segmentIndexFile, logger log.Logger, c blockCodec, metrics *metrics) error {
+	offset := bhIndex.fetchNextOffset()
+	pruned := false
 	for {
 		aBlock, blockSize, err := c.decode(r)
 		if err != nil {
@@ -241,14 +429,22 @@ func buildIndex(r io.Reader, firstBlockOffset int64, logger log.Logger, c blockC
 			}
 			break // index up to EOF or first invalid record.
 		}
-		err = bhIndex.appendBlock(offset+int64(blockSize), aBlock, nil)
+		entry, txHashes, eventContracts := newIndexEntry(aBlock, offset, blockSize), txHashesOf(aBlock), adapter.ContractsEmittingEvents(aBlock)
+		err = bhIndex.appendBlock(entry, txHashes, eventContracts, aBlock, nil)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed building block height index")
+			return errors.Wrap(err, "failed building block height index")
+		}
+		if err := indexFile.appendRecord(entry, txHashes, eventContracts); err != nil {
+			return err
 		}
 		metrics.indexLastUpdateTime.Update(time.Now().Unix())
 		offset = offset + int64(blockSize)
+		pruned = pruned || isPrunedBlock(aBlock)
 	}
-	return bhIndex, nil
+	if pruned {
+		bhIndex.markActiveSegmentPruned()
+	}
+	return nil
 }
 
 func (f *BlockPersistence) WriteNextBlock(blockPair *protocol.BlockPairContainer) (bool, primitives.BlockHeight, error) {
@@ -261,22 +457,76 @@ func (f *BlockPersistence) WriteNextBlock(blockPair *protocol.BlockPairContainer
 		return false, f.bhIndex.getLastBlockHeight(), nil
 	}
 
+	if err := f.rollSegmentIfFull(); err != nil {
+		return false, f.bhIndex.getLastBlockHeight(), err
+	}
+
 	n, err := f.blockWriter.writeBlock(blockPair)
 	if err != nil {
 		return false, f.bhIndex.getLastBlockHeight(), err
 	}
 
-	startPos := f.bhIndex.fetchNextOffset()
-	err = f.bhIndex.appendBlock(startPos+int64(n), blockPair, f.blockTracker)
+	entry, txHashes, eventContracts := newIndexEntry(blockPair, f.bhIndex.fetchNextOffset(), n), txHashesOf(blockPair), adapter.ContractsEmittingEvents(blockPair)
+	err = f.bhIndex.appendBlock(entry, txHashes, eventContracts, blockPair, f.blockTracker)
 	if err != nil {
 		return false, f.bhIndex.getLastBlockHeight(), errors.Wrap(err, "failed to update index after writing block")
 	}
+	if err := f.indexFile.appendRecord(entry, txHashes, eventContracts); err != nil {
+		f.logger.Error("failed to append to segment index file, blocks following its last record are scanned on startup", log.Error(err), logfields.BlockHeight(bh))
+	}
+	if err := f.txIndex.add(bh, txHashes, f.bhIndex.getLastBlockHeight()); err != nil {
+		f.logger.Error("failed to index transactions, they are indexed again on startup", log.Error(err), logfields.BlockHeight(bh))
+	}
 
 	f.metrics.indexLastUpdateTime.Update(time.Now().Unix())
 	f.metrics.sizeOnDisk.Add(int64(n))
+
+	f.notifyPruner()
+
 	return true, f.bhIndex.getLastBlockHeight(), nil
 }
 
+// rollSegmentIfFull seals the segment being written once it holds the configured number of blocks. the index file of
+// the sealed segment is synced before the next segment file is created, so every sealed segment found on startup
+// normally has a complete one
+func (f *BlockPersistence) rollSegmentIfFull() error {
+	maxBlocks := f.config.BlockStorageFileSystemMaxBlocksPerSegment()
+	id, numBlocks := f.bhIndex.fetchActiveSegment()
+	if maxBlocks == 0 || numBlocks < int(maxBlocks) {
+		return nil
+	}
+
+	if err := f.indexFile.file.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to sync segment index of segment %d", id)
+	}
+
+	file, _, firstBlockOffset, err := openSegmentFile(f.config, id+1, true, f.logger)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(firstBlockOffset, io.SeekStart); err != nil {
+		closeSilently(file, f.logger)
+		return errors.Wrapf(err, "failed to seek to first block offset %d", firstBlockOffset)
+	}
+	indexFile, err := createSegmentIndexFile(segmentIndexFileName(f.config.BlockStorageFileSystemDataDir(), id+1), id+1, false)
+	if err != nil {
+		closeSilently(file, f.logger)
+		return err
+	}
+	if err := f.blockWriter.replaceFile(file); err != nil {
+		f.logger.Error("failed to close sealed blocks file", log.Error(err), log.Uint32("segment", id))
+	}
+	if err := f.indexFile.close(); err != nil {
+		f.logger.Error("failed to close sealed segment index file", log.Error(err), log.Uint32("segment", id))
+	}
+	f.indexFile = indexFile
+
+	f.bhIndex.startSegment(id+1, firstBlockOffset)
+	f.metrics.sizeOnDisk.Add(firstBlockOffset)
+	f.logger.Info("sealed blocks file segment", log.Uint32("segment", id), log.Int("number-of-blocks", numBlocks))
+	return nil
+}
+
 func (f *BlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint8, cursor adapter.CursorFunc) error {
 
 	sequentialHeight := f.bhIndex.getLastBlockHeight()
@@ -284,13 +534,6 @@ func (f *BlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint
 		return fmt.Errorf("requested unsupported block height %d. Supported range for scan is determined by sequence top height (%d)", from, sequentialHeight)
 	}
 
-	file, err := os.Open(f.blockFileName())
-	if err != nil {
-		return errors.Wrap(err, "failed to open blocks file for reading")
-	}
-	defer closeSilently(file, f.logger)
-
-	var offset int64
 	fromHeight := from
 	wantsMore := true
 	eof := false
@@ -299,18 +542,12 @@ func (f *BlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint
 		if toHeight > sequentialHeight {
 			toHeight = sequentialHeight
 		}
-		page := make([]*protocol.BlockPairContainer, 0, pageSize)
-		for height := fromHeight; height <= toHeight; height++ {
-			aBlock, nextOffset, err := f.fetchBlockFromFile(height, offset, file)
-			if err != nil {
-				if err == io.EOF || err == io.ErrUnexpectedEOF {
-					eof = true
-					break
-				}
+		page, err := f.fetchBlocks(fromHeight, toHeight)
+		if err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
 				return errors.Wrapf(err, "failed to decode block")
 			}
-			offset = nextOffset
-			page = append(page, aBlock)
+			eof = true
 		}
 		if len(page) > 0 {
 			wantsMore = cursor(page[0].ResultsBlock.Header.BlockHeight(), page)
@@ -322,29 +559,36 @@ func (f *BlockPersistence) ScanBlocks(from primitives.BlockHeight, pageSize uint
 	return nil
 }
 
-func (f *BlockPersistence) seekBlockOffset(height primitives.BlockHeight, currentOffset int64, file *os.File) (error, int64) {
-	if expectedOffset, ok := f.bhIndex.fetchBlockOffset(height); ok {
-		if currentOffset != expectedOffset {
-			newOffset, err := file.Seek(expectedOffset, io.SeekStart)
-			if newOffset != expectedOffset || err != nil {
-				return errors.Wrapf(err, "failed to seek in blocks file to position %v", expectedOffset), 0
-			}
+// fetchBlocks reads a range of blocks, returning the blocks read before an error. segment files are not replaced by
+// pruning while blocks are read, but may be replaced between calls
+func (f *BlockPersistence) fetchBlocks(from primitives.BlockHeight, to primitives.BlockHeight) ([]*protocol.BlockPairContainer, error) {
+	f.segmentFiles.RLock()
+	defer f.segmentFiles.RUnlock()
+
+	r := newSegmentReader(f.config.BlockStorageFileSystemDataDir(), f.codec, f.logger)
+	defer r.close()
+
+	page := make([]*protocol.BlockPairContainer, 0, to-from+1)
+	for height := from; height <= to; height++ {
+		location, ok := f.bhIndex.fetchBlockLocation(height)
+		if !ok {
+			return page, fmt.Errorf("failed to find requested block %d", uint64(height))
 		}
-		return nil, expectedOffset
+		aBlock, err := r.read(location)
+		if err != nil {
+			return page, err
+		}
+		page = append(page, aBlock)
 	}
-	return fmt.Errorf("failed to find requested block %d", uint64(height)), 0
+	return page, nil
 }
 
-func (f *BlockPersistence) fetchBlockFromFile(height primitives.BlockHeight, currentOffset int64, file *os.File) (*protocol.BlockPairContainer, int64, error) {
-	err, newOffset := f.seekBlockOffset(height, currentOffset, file)
+func (f *BlockPersistence) fetchBlock(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
+	blocks, err := f.fetchBlocks(height, height)
 	if err != nil {
-		return nil, 0, err
-	}
-	aBlock, blockSize, err := f.codec.decode(file)
-	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	return aBlock, newOffset + int64(blockSize), nil
+	return blocks[0], nil
 }
 
 func (f *BlockPersistence) GetLastBlockHeight() (primitives.BlockHeight, error) {
@@ -372,62 +616,71 @@ func (f *BlockPersistence) GetResultsBlock(height primitives.BlockHeight) (*prot
 }
 
 func (f *BlockPersistence) GetBlock(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
-	file, err := os.Open(f.blockFileName())
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to open blocks file for reading")
-	}
-	defer closeSilently(file, f.logger)
-
-	if aBlock, _, err := f.fetchBlockFromFile(height, 0, file); err != nil {
+	if aBlock, err := f.fetchBlock(height); err != nil {
 		return nil, errors.Wrapf(err, "failed to decode block")
 	} else {
 		return aBlock, nil
 	}
 }
 
-func (f *BlockPersistence) GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (block *protocol.BlockPairContainer, txIndexInBlock int, err error) {
-	scanFrom, ok := f.bhIndex.getEarliestTxBlockInBucketForTsRange(minBlockTs, maxBlockTs)
+// GetBlockByTx finds recent transactions in memory and older ones in the transaction index on disk
+func (f *BlockPersistence) GetBlockByTx(txHash primitives.Sha256) (block *protocol.BlockPairContainer, txIndexInBlock int, err error) {
+	location, ok := f.bhIndex.getTxLocation(txHash)
 	if !ok {
+		// ignores blocks which are not fully synced, like the in-memory lookup
+		if location, ok, err = f.txIndex.get(txHash); err != nil || !ok || location.height > f.bhIndex.getLastBlockHeight() {
+			return nil, 0, err
+		}
+	}
+
+	b, err := f.fetchBlock(location.height)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to fetch block by txHash")
+	}
+
+	receipts := b.ResultsBlock.TransactionReceipts
+	if location.index >= len(receipts) { // the receipts of a pruned block were dropped
 		return nil, 0, nil
 	}
+	if !bytes.Equal(receipts[location.index].Txhash(), txHash) {
+		return nil, 0, fmt.Errorf("index points at a different transaction in block %d", location.height)
+	}
+	return b, location.index, nil
+}
 
-	err = f.ScanBlocks(scanFrom, 1, func(h primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
-		b := page[0]
-		if b.ResultsBlock.Header.Timestamp() > maxBlockTs {
-			return false
-		}
-		if b.ResultsBlock.Header.Timestamp() < minBlockTs {
-			return true
-		}
+// GetContractEventBlockHeights finds the events of recent blocks in memory. older ones are looked up in the segment
+// index files of the segments which hold blocks emitting events of the contract
+func (f *BlockPersistence) GetContractEventBlockHeights(contract primitives.ContractName, from primitives.BlockHeight, to primitives.BlockHeight) ([]primitives.BlockHeight, error) {
+	recent, ids, belowHeight := f.bhIndex.contractEventHeights(contract, from, to)
 
-		for i, receipt := range b.ResultsBlock.TransactionReceipts {
-			if bytes.Equal(receipt.Txhash(), txHash) { // found requested transaction
-				block = b
-				txIndexInBlock = i
-				return false
+	var heights []primitives.BlockHeight
+	for _, id := range ids {
+		records, err := readSegmentIndexFile(segmentIndexFileName(f.config.BlockStorageFileSystemDataDir(), id), id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan segment %d for events of contract %s", id, contract)
+		}
+		for _, record := range records {
+			height := primitives.BlockHeight(record.entry.Height)
+			if height < from || height > to || height >= belowHeight {
+				continue
+			}
+			for _, emitting := range record.eventContracts {
+				if emitting == contract {
+					heights = append(heights, height)
+					break
+				}
 			}
 		}
-		return true
-	})
-
-	if err != nil {
-		return nil, 0, errors.Wrap(err, "failed to fetch block by txHash")
 	}
-	return block, txIndexInBlock, nil
+	// blocks written by a sync session are appended to segments in descending order
+	sort.Slice(heights, func(a, b int) bool { return heights[a] < heights[b] })
+	return append(heights, recent...), nil
 }
 
 func (f *BlockPersistence) GetBlockTracker() *synchronization.BlockTracker {
 	return f.blockTracker
 }
 
-func (f *BlockPersistence) blockFileName() string {
-	return blocksFileName(f.config)
-}
-
-func blocksFileName(config config.FilesystemBlockPersistenceConfig) string {
-	return filepath.Join(config.BlockStorageFileSystemDataDir(), blocksFilename)
-}
-
 func closeSilently(file *os.File, logger log.Logger) {
 	err := file.Close()
 	if err != nil {
@@ -16,26 +16,48 @@ import (
 	"sync"
 )
 
+type blockLocation struct {
+	segment uint32
+	offset  int64
+}
+
+type txLocation struct {
+	height primitives.BlockHeight
+	index  int
+}
+
 type blockHeightIndex struct {
 	sync.RWMutex
-	heightOffset         map[primitives.BlockHeight]int64
-	firstBlockInTsBucket map[uint32]primitives.BlockHeight
-	nextOffset           int64
-	sequentialTopBlock   *protocol.BlockPairContainer
-	topBlock             *protocol.BlockPairContainer
-	lastWrittenBlock     *protocol.BlockPairContainer
-	logger               log.Logger
+	heightLocation     map[primitives.BlockHeight]blockLocation
+	txLocation         map[string]txLocation // transactions of the blocks from txIndexLowHeight, older ones are looked up in the txIndex
+	txHashesAtHeight   map[primitives.BlockHeight][]string
+	eventContracts     map[primitives.BlockHeight][]primitives.ContractName // of the blocks from txIndexLowHeight emitting events, older ones are scanned for
+	txIndexDepth       primitives.BlockHeight                               // zero keeps every transaction in txLocation
+	txIndexLowHeight   primitives.BlockHeight
+	segments           []*segmentIndex // the last segment is the one being written
+	topHeight          primitives.BlockHeight
+	sequentialHeight   primitives.BlockHeight
+	lastWrittenHeight  primitives.BlockHeight
+	lowestServedHeight primitives.BlockHeight
+	sequentialTopBlock *protocol.BlockPairContainer
+	topBlock           *protocol.BlockPairContainer
+	lastWrittenBlock   *protocol.BlockPairContainer
+	logger             log.Logger
 }
 
-func newBlockHeightIndex(logger log.Logger, firstBlockOffset int64) *blockHeightIndex {
+func newBlockHeightIndex(logger log.Logger, firstBlockOffset int64, txIndexDepth uint32) *blockHeightIndex {
 	return &blockHeightIndex{
-		logger:               logger,
-		heightOffset:         map[primitives.BlockHeight]int64{},
-		firstBlockInTsBucket: map[uint32]primitives.BlockHeight{},
-		nextOffset:           firstBlockOffset,
-		sequentialTopBlock:   nil,
-		topBlock:             nil,
-		lastWrittenBlock:     nil,
+		logger:             logger,
+		heightLocation:     map[primitives.BlockHeight]blockLocation{},
+		txLocation:         map[string]txLocation{},
+		txHashesAtHeight:   map[primitives.BlockHeight][]string{},
+		eventContracts:     map[primitives.BlockHeight][]primitives.ContractName{},
+		txIndexDepth:       primitives.BlockHeight(txIndexDepth),
+		txIndexLowHeight:   1,
+		segments:           []*segmentIndex{newSegmentIndex(0, firstBlockOffset)},
+		sequentialTopBlock: nil,
+		topBlock:           nil,
+		lastWrittenBlock:   nil,
 	}
 }
 
@@ -43,60 +65,131 @@ func (i *blockHeightIndex) getSyncState() internodesync.SyncState {
 	i.RLock()
 	defer i.RUnlock()
 	return internodesync.SyncState{
-		TopBlock:        i.topBlock,
-		InOrderBlock:    i.sequentialTopBlock,
-		LastSyncedBlock: i.lastWrittenBlock,
+		TopBlock:                i.topBlock,
+		InOrderBlock:            i.sequentialTopBlock,
+		LastSyncedBlock:         i.lastWrittenBlock,
+		LowestServedBlockHeight: i.lowestServedHeight,
 	}
 }
 
+func (i *blockHeightIndex) activeSegment() *segmentIndex {
+	return i.segments[len(i.segments)-1]
+}
+
 func (i *blockHeightIndex) fetchNextOffset() int64 {
 	i.RLock()
 	defer i.RUnlock()
 
-	return i.nextOffset
+	return i.activeSegment().endOffset
+}
+
+func (i *blockHeightIndex) fetchActiveSegment() (id uint32, numBlocks int) {
+	i.RLock()
+	defer i.RUnlock()
+
+	active := i.activeSegment()
+	return active.id, len(active.entries)
 }
 
-func (i *blockHeightIndex) fetchBlockOffset(height primitives.BlockHeight) (offset int64, ok bool) {
+func (i *blockHeightIndex) fetchBlockLocation(height primitives.BlockHeight) (location blockLocation, ok bool) {
 	i.RLock()
 	defer i.RUnlock()
 
-	offset, ok = i.heightOffset[height]
+	location, ok = i.heightLocation[height]
 	return
 }
 
+// seals the segment being written, all following blocks are appended to a new segment
+func (i *blockHeightIndex) startSegment(id uint32, firstBlockOffset int64) *segmentIndex {
+	i.Lock()
+	defer i.Unlock()
+
+	sealed := i.activeSegment()
+	i.segments = append(i.segments, newSegmentIndex(id, firstBlockOffset))
+	return sealed
+}
+
 // ignores blocks which are not fully synced (storage is missing blocks with lower height)
-func (i *blockHeightIndex) getEarliestTxBlockInBucketForTsRange(rangeStart primitives.TimestampNano, rangeEnd primitives.TimestampNano) (primitives.BlockHeight, bool) {
+func (i *blockHeightIndex) getTxLocation(txHash primitives.Sha256) (txLocation, bool) {
+	i.RLock()
+	defer i.RUnlock()
+
+	location, exists := i.txLocation[string(txHash)]
+	if !exists || location.height > i.sequentialHeight {
+		return txLocation{}, false
+	}
+	return location, true
+}
+
+// segmentsHoldingBlocksAbove returns the ids of the segments holding any block above height
+func (i *blockHeightIndex) segmentsHoldingBlocksAbove(height primitives.BlockHeight) []uint32 {
 	i.RLock()
 	defer i.RUnlock()
 
-	fromBucket := blockTsBucketKey(rangeStart)
-	toBucket := blockTsBucketKey(rangeEnd)
-	sequentialHeight := getBlockHeight(i.sequentialTopBlock)
-	for b := fromBucket; b <= toBucket; b++ {
-		blockHeight, exists := i.firstBlockInTsBucket[b]
-		if blockHeight > sequentialHeight {
-			return 0, false
-		} else if exists {
-			return blockHeight, true
+	var ids []uint32
+	for _, segment := range i.segments {
+		for _, entry := range segment.entries {
+			if primitives.BlockHeight(entry.Height) > height {
+				ids = append(ids, segment.id)
+				break
+			}
 		}
 	}
-	return 0, false
+	return ids
+}
+
+// contractEventHeights returns the heights of the synced blocks in from..to which are in memory and emitted events of
+// the contract, and the ids of the segments holding older blocks of the range which emitted events of the contract,
+// oldest first, together with the height below which blocks are not in memory
+func (i *blockHeightIndex) contractEventHeights(contract primitives.ContractName, from primitives.BlockHeight, to primitives.BlockHeight) ([]primitives.BlockHeight, []uint32, primitives.BlockHeight) {
+	i.RLock()
+	defer i.RUnlock()
 
+	if to > i.sequentialHeight {
+		to = i.sequentialHeight
+	}
+	belowHeight := i.txIndexLowHeight
+	if belowHeight > i.sequentialHeight+1 {
+		belowHeight = i.sequentialHeight + 1
+	}
+
+	var ids []uint32
+	for _, segment := range i.segments {
+		if !segment.eventContracts[contract] {
+			continue
+		}
+		for _, entry := range segment.entries {
+			if height := primitives.BlockHeight(entry.Height); height >= from && height <= to && height < belowHeight {
+				ids = append(ids, segment.id)
+				break
+			}
+		}
+	}
+
+	var heights []primitives.BlockHeight
+	if from < belowHeight {
+		from = belowHeight
+	}
+	for height := from; height <= to; height++ {
+		for _, emitting := range i.eventContracts[height] {
+			if emitting == contract {
+				heights = append(heights, height)
+				break
+			}
+		}
+	}
+	return heights, ids, belowHeight
 }
 
 func (i *blockHeightIndex) validateCandidateBlockHeight(candidateBlockHeight primitives.BlockHeight) (err error) {
 	i.RLock()
 	defer i.RUnlock()
 
-	topHeight := getBlockHeight(i.topBlock)
-	sequentialHeight := getBlockHeight(i.sequentialTopBlock)
-	lastWrittenHeight := getBlockHeight(i.lastWrittenBlock)
-
-	if lastWrittenHeight > sequentialHeight && candidateBlockHeight != lastWrittenHeight-1 {
-		err = fmt.Errorf("sync session in progress, expected block height %d", lastWrittenHeight-1)
+	if i.lastWrittenHeight > i.sequentialHeight && candidateBlockHeight != i.lastWrittenHeight-1 {
+		err = fmt.Errorf("sync session in progress, expected block height %d", i.lastWrittenHeight-1)
 
-	} else if sequentialHeight == topHeight && candidateBlockHeight <= sequentialHeight {
-		err = fmt.Errorf("expected block height higher than current top %d", sequentialHeight)
+	} else if i.sequentialHeight == i.topHeight && candidateBlockHeight <= i.sequentialHeight {
+		err = fmt.Errorf("expected block height higher than current top %d", i.sequentialHeight)
 	}
 
 	if err != nil {
@@ -105,54 +198,146 @@ func (i *blockHeightIndex) validateCandidateBlockHeight(candidateBlockHeight pri
 	return
 }
 
-func (i *blockHeightIndex) appendBlock(newOffset int64, newBlock *protocol.BlockPairContainer, blockTracker *synchronization.BlockTracker) error {
-	newBlockHeight := getBlockHeight(newBlock)
+// appends a block at the end of the segment being written. newBlock is nil for blocks loaded from a segment index
+// file, leaving the cached top blocks to be resolved once all segments were loaded
+func (i *blockHeightIndex) appendBlock(entry indexEntry, txHashes []primitives.Sha256, eventContracts []primitives.ContractName, newBlock *protocol.BlockPairContainer, blockTracker *synchronization.BlockTracker) error {
+	newBlockHeight := primitives.BlockHeight(entry.Height)
 	if err := i.validateCandidateBlockHeight(newBlockHeight); err != nil {
 		return err
 	}
 
 	i.Lock()
 	defer i.Unlock()
-	topHeight := getBlockHeight(i.topBlock)
-	sequentialHeight := getBlockHeight(i.sequentialTopBlock)
-	numTxReceipts := newBlock.ResultsBlock.Header.NumTransactionReceipts()
-	blockTs := newBlock.ResultsBlock.Header.Timestamp()
 
-	i.heightOffset[newBlockHeight] = i.nextOffset
-	i.nextOffset = newOffset
+	active := i.activeSegment()
+	i.heightLocation[newBlockHeight] = blockLocation{segment: active.id, offset: entry.Offset}
+	active.entries = append(active.entries, entry)
+	active.endOffset = entry.Offset + int64(entry.Size)
+	for _, contract := range eventContracts {
+		active.eventContracts[contract] = true
+	}
+	if newBlockHeight >= i.txIndexLowHeight {
+		hashes := make([]string, 0, len(txHashes))
+		for index, txHash := range txHashes {
+			hashes = append(hashes, string(txHash))
+			i.txLocation[string(txHash)] = txLocation{height: newBlockHeight, index: index}
+		}
+		i.txHashesAtHeight[newBlockHeight] = hashes
+		if len(eventContracts) > 0 {
+			i.eventContracts[newBlockHeight] = eventContracts
+		}
+	}
 	// update indices
-	i.lastWrittenBlock = newBlock
-	lastWrittenHeight := newBlockHeight
-	if newBlockHeight > topHeight {
-		i.topBlock = newBlock
-		topHeight = newBlockHeight
-	}
-
-	if lastWrittenHeight == sequentialHeight+1 {
-		for height := sequentialHeight + 1; height <= topHeight; height++ {
-			if _, ok := i.heightOffset[height]; !ok { // block does not exists
-				i.lastWrittenBlock = i.topBlock
-				return fmt.Errorf("offset missing for blockHeight (%d), in range (%d - %d) assumed to exist in file storage", uint64(height), uint64(sequentialHeight+1), uint64(topHeight))
+	i.lastWrittenHeight, i.lastWrittenBlock = newBlockHeight, newBlock
+	if newBlockHeight > i.topHeight {
+		i.topHeight, i.topBlock = newBlockHeight, newBlock
+	}
+
+	if i.lastWrittenHeight == i.sequentialHeight+1 {
+		for height := i.sequentialHeight + 1; height <= i.topHeight; height++ {
+			if _, ok := i.heightLocation[height]; !ok { // block does not exists
+				i.lastWrittenHeight, i.lastWrittenBlock = i.topHeight, i.topBlock
+				return fmt.Errorf("offset missing for blockHeight (%d), in range (%d - %d) assumed to exist in file storage", uint64(height), uint64(i.sequentialHeight+1), uint64(i.topHeight))
 			}
 			if blockTracker != nil {
 				blockTracker.IncrementTo(height)
 			}
 		}
-		i.lastWrittenBlock = i.topBlock
-		i.sequentialTopBlock = i.topBlock
+		i.lastWrittenHeight, i.lastWrittenBlock = i.topHeight, i.topBlock
+		i.sequentialHeight, i.sequentialTopBlock = i.topHeight, i.topBlock
+		i.evictTxLocations()
+	}
+
+	return nil
+}
+
+// keeps the transactions and contracts emitting events of the txIndexDepth blocks up to the sequential height in memory
+func (i *blockHeightIndex) evictTxLocations() {
+	if i.txIndexDepth == 0 {
+		return
+	}
+	for ; i.txIndexLowHeight+i.txIndexDepth <= i.sequentialHeight; i.txIndexLowHeight++ {
+		for _, txHash := range i.txHashesAtHeight[i.txIndexLowHeight] {
+			if i.txLocation[txHash].height == i.txIndexLowHeight {
+				delete(i.txLocation, txHash)
+			}
+		}
+		delete(i.txHashesAtHeight, i.txIndexLowHeight)
+		delete(i.eventContracts, i.txIndexLowHeight)
 	}
+}
+
+// fills in the cached top blocks left out while appending blocks loaded from segment index files
+func (i *blockHeightIndex) resolveBlocks(fetch func(height primitives.BlockHeight) (*protocol.BlockPairContainer, error)) error {
+	i.RLock()
+	heights := []primitives.BlockHeight{i.topHeight, i.sequentialHeight, i.lastWrittenHeight}
+	i.RUnlock()
 
-	if numTxReceipts > 0 {
-		bucketKey := blockTsBucketKey(blockTs)
-		firstBlockHeightInBucket, exists := i.firstBlockInTsBucket[bucketKey]
-		if !exists || newBlockHeight < firstBlockHeightInBucket {
-			i.firstBlockInTsBucket[bucketKey] = newBlockHeight
+	blocks := make(map[primitives.BlockHeight]*protocol.BlockPairContainer, len(heights))
+	for _, height := range heights {
+		if _, ok := blocks[height]; ok || height == 0 {
+			continue
 		}
+		block, err := fetch(height)
+		if err != nil {
+			return err
+		}
+		blocks[height] = block
 	}
 
+	i.Lock()
+	defer i.Unlock()
+	i.topBlock, i.sequentialTopBlock, i.lastWrittenBlock = blocks[i.topHeight], blocks[i.sequentialHeight], blocks[i.lastWrittenHeight]
 	return nil
 }
 
+func (i *blockHeightIndex) markActiveSegmentPruned() {
+	i.Lock()
+	defer i.Unlock()
+
+	i.markPruned(i.activeSegment())
+}
+
+func (i *blockHeightIndex) markPruned(segment *segmentIndex) {
+	segment.pruned = true
+	if lowest := segment.maxHeight() + 1; lowest > i.lowestServedHeight {
+		i.lowestServedHeight = lowest
+	}
+}
+
+// returns the sealed segments which hold only blocks at least pruningDepth blocks below the sequential top
+func (i *blockHeightIndex) getSegmentsToPrune(pruningDepth primitives.BlockHeight) []*segmentIndex {
+	i.RLock()
+	defer i.RUnlock()
+
+	var result []*segmentIndex
+	for _, segment := range i.segments[:len(i.segments)-1] {
+		if !segment.pruned && len(segment.entries) > 0 && segment.maxHeight()+pruningDepth <= i.sequentialHeight {
+			result = append(result, segment.clone())
+		}
+	}
+	return result
+}
+
+// replaces the entries of a segment after its file was rewritten by pruning
+func (i *blockHeightIndex) replacePrunedSegment(pruned *segmentIndex) {
+	i.Lock()
+	defer i.Unlock()
+
+	for _, segment := range i.segments {
+		if segment.id != pruned.id {
+			continue
+		}
+		segment.entries = pruned.entries
+		segment.endOffset = pruned.endOffset
+		segment.eventContracts = pruned.eventContracts
+		for _, entry := range pruned.entries {
+			i.heightLocation[primitives.BlockHeight(entry.Height)] = blockLocation{segment: pruned.id, offset: entry.Offset}
+		}
+		i.markPruned(segment)
+	}
+}
+
 func (i *blockHeightIndex) getLastBlock() *protocol.BlockPairContainer {
 	i.RLock()
 	defer i.RUnlock()
@@ -162,11 +347,5 @@ func (i *blockHeightIndex) getLastBlock() *protocol.BlockPairContainer {
 func (i *blockHeightIndex) getLastBlockHeight() primitives.BlockHeight {
 	i.RLock()
 	defer i.RUnlock()
-	return getBlockHeight(i.sequentialTopBlock)
-}
-
-const minuteToNanoRatio = 60 * 1000 * 1000 * 1000
-
-func blockTsBucketKey(nano primitives.TimestampNano) uint32 {
-	return uint32(nano / minuteToNanoRatio)
+	return i.sequentialHeight
 }
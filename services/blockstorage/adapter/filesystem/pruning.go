@@ -0,0 +1,166 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"context"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// pruner rewrites segments in the background, so writing a block never waits for a whole segment to be rewritten
+type pruner struct {
+	cancel       context.CancelFunc
+	handle       *govnr.ForeverHandle
+	blockWritten chan struct{}
+}
+
+// startPruning runs pruneSegments once on startup and then after blocks are written, blocks written while a run is in
+// progress are folded into a single following run. nothing runs when pruning is not configured
+func (f *BlockPersistence) startPruning() {
+	if f.config.BlockStorageFileSystemPruningDepth() == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.pruner = &pruner{cancel: cancel, blockWritten: make(chan struct{}, 1)}
+	f.pruner.blockWritten <- struct{}{}
+	f.pruner.handle = govnr.Forever(ctx, "blocks file pruner", logfields.GovnrErrorer(f.logger), func() {
+		select {
+		case <-ctx.Done():
+		case <-f.pruner.blockWritten:
+			f.pruneSegments()
+		}
+	})
+	f.pruner.handle.MarkSupervised() // GracefulShutdown waits for it
+}
+
+func (f *BlockPersistence) notifyPruner() {
+	if f.pruner == nil {
+		return
+	}
+	select {
+	case f.pruner.blockWritten <- struct{}{}:
+	default: // a run is already pending
+	}
+}
+
+// stopPruning waits for a run in progress to end, so the segment files are not closed under it
+func (f *BlockPersistence) stopPruning(shutdownContext context.Context) {
+	if f.pruner == nil {
+		return
+	}
+	f.pruner.cancel()
+	f.pruner.handle.WaitUntilShutdown(shutdownContext)
+}
+
+// pruneSegments rewrites every sealed segment whose blocks are all older than the pruning depth without their
+// transactions and receipts. a failure is logged and retried after the next block is written
+func (f *BlockPersistence) pruneSegments() {
+	pruningDepth := f.config.BlockStorageFileSystemPruningDepth()
+	for _, segment := range f.bhIndex.getSegmentsToPrune(primitives.BlockHeight(pruningDepth)) {
+		if err := f.pruneSegment(segment); err != nil {
+			f.logger.Error("failed to prune blocks file segment", log.Error(err), log.Uint32("segment", segment.id))
+			return
+		}
+	}
+}
+
+// pruneSegment writes the pruned segment and its index file next to the originals and renames them over the
+// originals, so a crash leaves either of them in place. the index file is replaced last, a crash before that is
+// detected on startup by the index not being flagged pruned like the segment file, and the index is rebuilt
+func (f *BlockPersistence) pruneSegment(segment *segmentIndex) error {
+	dir := f.config.BlockStorageFileSystemDataDir()
+	filename := segmentFileName(dir, segment.id)
+	tmpFilename := filename + ".tmp"
+	indexFilename := segmentIndexFileName(dir, segment.id)
+	tmpIndexFilename := indexFilename + ".tmp"
+
+	src, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrap(err, "failed to open blocks file for reading")
+	}
+	defer closeSilently(src, f.logger)
+
+	indexFile, err := createSegmentIndexFile(tmpIndexFilename, segment.id, true)
+	if err != nil {
+		return err
+	}
+	removeTmpFiles := func() {
+		_ = os.Remove(tmpFilename)
+		_ = os.Remove(tmpIndexFilename)
+	}
+
+	pruned := &segmentIndex{id: segment.id, pruned: true, eventContracts: map[primitives.ContractName]bool{}}
+	err = writeSyncedFile(tmpFilename, func(w io.Writer) error {
+		cw := newChecksumWriter(w, ioutil.Discard) // only counts the bytes written
+
+		header := newBlocksFileHeader(uint32(f.config.NetworkType()), uint32(f.config.VirtualChainId()))
+		header.FileVersion |= orbsFormatVersionPrunedFlag
+		if err := header.write(cw); err != nil {
+			return errors.Wrap(err, "error writing blocks file header")
+		}
+
+		for _, entry := range segment.entries {
+			if _, err := src.Seek(entry.Offset, io.SeekStart); err != nil {
+				return errors.Wrapf(err, "failed to seek in blocks file to position %v", entry.Offset)
+			}
+			aBlock, _, err := f.codec.decode(src)
+			if err != nil {
+				return errors.Wrapf(err, "failed to decode block %d", entry.Height)
+			}
+
+			entry.Offset = int64(cw.bytesWritten)
+			n, err := f.codec.encode(pruneBlock(aBlock), cw)
+			if err != nil {
+				return errors.Wrapf(err, "failed to encode pruned block %d", entry.Height)
+			}
+			entry.Size = uint32(n)
+			// the transaction hashes of the original block keep the transactions of pruned blocks known, their events
+			// were dropped with the receipts
+			if err := indexFile.appendRecord(entry, txHashesOf(aBlock), nil); err != nil {
+				return err
+			}
+			pruned.entries = append(pruned.entries, entry)
+		}
+		pruned.endOffset = int64(cw.bytesWritten)
+		return nil
+	})
+	if closeErr := indexFile.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		removeTmpFiles()
+		return err
+	}
+
+	f.segmentFiles.Lock()
+	err = os.Rename(tmpFilename, filename)
+	if err == nil {
+		f.bhIndex.replacePrunedSegment(pruned)
+	}
+	f.segmentFiles.Unlock()
+	if err != nil {
+		removeTmpFiles()
+		return errors.Wrapf(err, "failed to replace blocks file %s", filename)
+	}
+
+	f.metrics.sizeOnDisk.Add(pruned.endOffset - segment.endOffset)
+	f.logger.Info("pruned blocks file segment", log.Uint32("segment", segment.id), log.Int64("bytes-freed", segment.endOffset-pruned.endOffset))
+
+	if err := os.Rename(tmpIndexFilename, indexFilename); err != nil {
+		_ = os.Remove(tmpIndexFilename)
+		return errors.Wrapf(err, "failed to replace segment index %s", indexFilename)
+	}
+	return nil
+}
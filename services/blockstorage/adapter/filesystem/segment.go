@@ -0,0 +1,415 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const segmentIndexMagic = uint32(0x58444e49) // "INDX"
+
+// version 0 held all the entries of a segment in a single record and version 1 records did not list the contracts
+// emitting events, index files of older versions are rebuilt on startup
+const segmentIndexVersion = 2
+
+const segmentIndexFlagPruned = uint32(1)
+
+const maxTxHashesPerIndexRecord = 1024 * 1024
+const maxEventContractsPerIndexRecord = maxTxHashesPerIndexRecord
+
+// segmentIndex lists the blocks of one segment file in the order they were written
+type segmentIndex struct {
+	id             uint32
+	entries        []indexEntry
+	endOffset      int64
+	pruned         bool
+	eventContracts map[primitives.ContractName]bool // contracts which emitted events in any block of the segment
+}
+
+type indexEntry struct {
+	Height        uint64
+	Offset        int64
+	Size          uint32
+	Timestamp     uint64
+	NumTxReceipts uint32
+}
+
+type segmentIndexHeader struct {
+	Magic   uint32
+	Version uint32
+	Segment uint32
+	Flags   uint32
+}
+
+type indexRecordHeader struct {
+	Entry             indexEntry
+	NumTxHashes       uint32
+	NumEventContracts uint32
+}
+
+// indexRecord is the sidecar index record of one block, followed on disk by a checksum
+type indexRecord struct {
+	entry          indexEntry
+	txHashes       []primitives.Sha256
+	eventContracts []primitives.ContractName
+	end            int64 // position in the index file following the record
+}
+
+func newSegmentIndex(id uint32, firstBlockOffset int64) *segmentIndex {
+	return &segmentIndex{
+		id:             id,
+		endOffset:      firstBlockOffset,
+		eventContracts: map[primitives.ContractName]bool{},
+	}
+}
+
+func newIndexEntry(block *protocol.BlockPairContainer, offset int64, size int) indexEntry {
+	return indexEntry{
+		Height:        uint64(getBlockHeight(block)),
+		Offset:        offset,
+		Size:          uint32(size),
+		Timestamp:     uint64(block.ResultsBlock.Header.Timestamp()),
+		NumTxReceipts: block.ResultsBlock.Header.NumTransactionReceipts(),
+	}
+}
+
+func txHashesOf(block *protocol.BlockPairContainer) []primitives.Sha256 {
+	hashes := make([]primitives.Sha256, 0, len(block.ResultsBlock.TransactionReceipts))
+	for _, receipt := range block.ResultsBlock.TransactionReceipts {
+		hashes = append(hashes, receipt.Txhash())
+	}
+	return hashes
+}
+
+func (s *segmentIndex) maxHeight() primitives.BlockHeight {
+	var max uint64
+	for _, entry := range s.entries {
+		if entry.Height > max {
+			max = entry.Height
+		}
+	}
+	return primitives.BlockHeight(max)
+}
+
+func (s *segmentIndex) clone() *segmentIndex {
+	eventContracts := make(map[primitives.ContractName]bool, len(s.eventContracts))
+	for contract := range s.eventContracts {
+		eventContracts[contract] = true
+	}
+	return &segmentIndex{
+		id:             s.id,
+		entries:        append([]indexEntry{}, s.entries...),
+		endOffset:      s.endOffset,
+		pruned:         s.pruned,
+		eventContracts: eventContracts,
+	}
+}
+
+// the first segment keeps the name of the single blocks file used before segments were introduced
+func segmentFileName(dir string, id uint32) string {
+	if id == 0 {
+		return filepath.Join(dir, blocksFilename)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%d", blocksFilename, id))
+}
+
+func segmentIndexFileName(dir string, id uint32) string {
+	return segmentFileName(dir, id) + ".idx"
+}
+
+// returns the number of segment files in dir, which are numbered consecutively from zero
+func countSegmentFiles(dir string) (uint32, error) {
+	var count uint32
+	for {
+		_, err := os.Stat(segmentFileName(dir, count))
+		if os.IsNotExist(err) {
+			return count, nil
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to stat segment file %s", segmentFileName(dir, count))
+		}
+		count++
+	}
+}
+
+// segmentIndexFile is the sidecar index of a segment file. a record is appended after every block written to the
+// segment, without syncing, as records lost or torn in a crash are detected by their checksum and rebuilt from the
+// blocks following the last valid record
+type segmentIndexFile struct {
+	file *os.File
+	size int64
+}
+
+func createSegmentIndexFile(filename string, id uint32, pruned bool) (*segmentIndexFile, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create segment index %s", filename)
+	}
+
+	header := &segmentIndexHeader{
+		Magic:   segmentIndexMagic,
+		Version: segmentIndexVersion,
+		Segment: id,
+	}
+	if pruned {
+		header.Flags |= segmentIndexFlagPruned
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeWithChecksum(buf, header); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to write segment index header %s", filename)
+	}
+
+	return &segmentIndexFile{file: file, size: int64(buf.Len())}, nil
+}
+
+// openSegmentIndexFile returns the records of an existing index file up to the first torn or corrupt record. an
+// error is returned if the file is missing or its header does not match the segment
+func openSegmentIndexFile(filename string, id uint32, pruned bool) (*segmentIndexFile, []*indexRecord, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := bufio.NewReader(file)
+	header, err := readSegmentIndexHeader(r, filename, id)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	if (header.Flags&segmentIndexFlagPruned != 0) != pruned {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("segment index %s does not describe segment %d as it is on disk", filename, id)
+	}
+
+	records := readIndexRecords(r)
+	position := segmentIndexHeaderSize()
+	if len(records) > 0 {
+		position = records[len(records)-1].end
+	}
+	return &segmentIndexFile{file: file, size: position}, records, nil
+}
+
+// readSegmentIndexFile returns the records of an index file up to the first torn or corrupt record without opening it
+// for writing, the index file of the segment being written is read while blocks are appended to it
+func readSegmentIndexFile(filename string, id uint32) ([]*indexRecord, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	if _, err := readSegmentIndexHeader(r, filename, id); err != nil {
+		return nil, err
+	}
+	return readIndexRecords(r), nil
+}
+
+func readSegmentIndexHeader(r io.Reader, filename string, id uint32) (*segmentIndexHeader, error) {
+	header := &segmentIndexHeader{}
+	if err := readWithChecksum(r, header); err != nil {
+		return nil, errors.Wrapf(err, "failed reading segment index header %s", filename)
+	}
+	if header.Magic != segmentIndexMagic || header.Version != segmentIndexVersion {
+		return nil, fmt.Errorf("invalid segment index %s magic number %v version %d", filename, header.Magic, header.Version)
+	}
+	if header.Segment != id {
+		return nil, fmt.Errorf("segment index %s does not describe segment %d as it is on disk", filename, id)
+	}
+	return header, nil
+}
+
+func readIndexRecords(r io.Reader) []*indexRecord {
+	position := segmentIndexHeaderSize()
+	var records []*indexRecord
+	for {
+		record, size, err := readIndexRecord(r)
+		if err != nil {
+			return records // records up to EOF or the first torn record
+		}
+		position += size
+		record.end = position
+		records = append(records, record)
+	}
+}
+
+func segmentIndexHeaderSize() int64 {
+	return int64(binary.Size(segmentIndexHeader{}) + checksumSize)
+}
+
+func (f *segmentIndexFile) appendRecord(entry indexEntry, txHashes []primitives.Sha256, eventContracts []primitives.ContractName) error {
+	buf := new(bytes.Buffer)
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	cw := newChecksumWriter(buf, checkSum)
+
+	if err := binary.Write(cw, binary.LittleEndian, &indexRecordHeader{Entry: entry, NumTxHashes: uint32(len(txHashes)), NumEventContracts: uint32(len(eventContracts))}); err != nil {
+		return err
+	}
+	for _, txHash := range txHashes {
+		if err := writeMessageBytes(cw, txHash); err != nil {
+			return err
+		}
+	}
+	for _, contract := range eventContracts {
+		if err := writeMessageBytes(cw, []byte(contract)); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(buf, binary.LittleEndian, checkSum.Sum32()); err != nil {
+		return err
+	}
+
+	// a partially written record is overwritten by the next one
+	if _, err := f.file.WriteAt(buf.Bytes(), f.size); err != nil {
+		return errors.Wrapf(err, "failed to append to segment index %s", f.file.Name())
+	}
+	f.size += int64(buf.Len())
+	return nil
+}
+
+// truncate drops every record following position, which is expected to be the end of the header or of a record
+func (f *segmentIndexFile) truncate(position int64) error {
+	if err := f.file.Truncate(position); err != nil {
+		return errors.Wrapf(err, "failed to truncate segment index %s", f.file.Name())
+	}
+	f.size = position
+	return nil
+}
+
+func (f *segmentIndexFile) close() error {
+	if err := f.file.Sync(); err != nil {
+		_ = f.file.Close()
+		return errors.Wrapf(err, "failed to sync segment index %s", f.file.Name())
+	}
+	return f.file.Close()
+}
+
+func readIndexRecord(r io.Reader) (*indexRecord, int64, error) {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	tr := io.TeeReader(r, checkSum)
+
+	header := &indexRecordHeader{}
+	if err := binary.Read(tr, binary.LittleEndian, header); err != nil {
+		return nil, 0, err
+	}
+	if header.NumTxHashes > maxTxHashesPerIndexRecord {
+		return nil, 0, fmt.Errorf("invalid segment index record with %d transaction hashes", header.NumTxHashes)
+	}
+	if header.NumEventContracts > maxEventContractsPerIndexRecord {
+		return nil, 0, fmt.Errorf("invalid segment index record with %d contracts emitting events", header.NumEventContracts)
+	}
+	size := int64(binary.Size(header))
+
+	txHashes := make([]primitives.Sha256, 0, header.NumTxHashes)
+	for i := uint32(0); i < header.NumTxHashes; i++ {
+		txHash, err := readMessageBytes(tr)
+		if err != nil {
+			return nil, 0, err
+		}
+		size += int64(chunkLengthSize + len(txHash))
+		txHashes = append(txHashes, txHash)
+	}
+
+	eventContracts := make([]primitives.ContractName, 0, header.NumEventContracts)
+	for i := uint32(0); i < header.NumEventContracts; i++ {
+		contract, err := readMessageBytes(tr)
+		if err != nil {
+			return nil, 0, err
+		}
+		size += int64(chunkLengthSize + len(contract))
+		eventContracts = append(eventContracts, primitives.ContractName(contract))
+	}
+
+	var sum32 uint32
+	if err := binary.Read(r, binary.LittleEndian, &sum32); err != nil {
+		return nil, 0, err
+	}
+	if sum32 != checkSum.Sum32() {
+		return nil, 0, fmt.Errorf("invalid segment index record, bad checksum")
+	}
+
+	return &indexRecord{entry: header.Entry, txHashes: txHashes, eventContracts: eventContracts}, size + int64(checksumSize), nil
+}
+
+func writeWithChecksum(w io.Writer, data interface{}) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if err := binary.Write(newChecksumWriter(w, checkSum), binary.LittleEndian, data); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, checkSum.Sum32())
+}
+
+func readWithChecksum(r io.Reader, data interface{}) error {
+	checkSum := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if err := binary.Read(io.TeeReader(r, checkSum), binary.LittleEndian, data); err != nil {
+		return err
+	}
+	var sum32 uint32
+	if err := binary.Read(r, binary.LittleEndian, &sum32); err != nil {
+		return err
+	}
+	if sum32 != checkSum.Sum32() {
+		return fmt.Errorf("bad checksum")
+	}
+	return nil
+}
+
+func writeMessageBytes(w io.Writer, b []byte) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readMessageBytes(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > 1024 {
+		return nil, fmt.Errorf("invalid segment index record, field of %d bytes", size)
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func writeSyncedFile(filename string, write func(w io.Writer) error) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
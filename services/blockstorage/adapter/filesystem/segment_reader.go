@@ -0,0 +1,71 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+)
+
+// segmentReader reads blocks by location, keeping the last segment file open so consecutive blocks of the same
+// segment are read without reopening or seeking
+type segmentReader struct {
+	dir     string
+	codec   blockCodec
+	logger  log.Logger
+	file    *os.File
+	segment uint32
+	offset  int64
+}
+
+func newSegmentReader(dir string, codec blockCodec, logger log.Logger) *segmentReader {
+	return &segmentReader{
+		dir:    dir,
+		codec:  codec,
+		logger: logger,
+	}
+}
+
+func (r *segmentReader) read(location blockLocation) (*protocol.BlockPairContainer, error) {
+	if r.file == nil || r.segment != location.segment {
+		r.close()
+		file, err := os.Open(segmentFileName(r.dir, location.segment))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open blocks file for reading")
+		}
+		r.file, r.segment, r.offset = file, location.segment, 0
+	}
+
+	if r.offset != location.offset {
+		newOffset, err := r.file.Seek(location.offset, io.SeekStart)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to seek in blocks file to position %v", location.offset)
+		}
+		if newOffset != location.offset {
+			return nil, fmt.Errorf("failed to seek in blocks file to position %v, reached %v", location.offset, newOffset)
+		}
+	}
+
+	aBlock, blockSize, err := r.codec.decode(r.file)
+	if err != nil {
+		r.offset = -1 // unknown
+		return nil, err
+	}
+	r.offset = location.offset + int64(blockSize)
+	return aBlock, nil
+}
+
+func (r *segmentReader) close() {
+	if r.file != nil {
+		closeSilently(r.file, r.logger)
+		r.file = nil
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"os"
+	"path/filepath"
+)
+
+const txIndexDirname = "blocks.txidx"
+
+var txIndexIndexedHeightKey = []byte("indexed-height")
+var txIndexTxKeyPrefix = []byte("tx/")
+
+// txIndex maps the hash of every committed transaction to the height of its block and its index in the block. it is
+// kept in a LevelDB key/value store next to the segment files, so a transaction of any age is found by its hash alone.
+// writes are not synced: every batch also records the height up to which all blocks are indexed, and on startup the
+// transactions of the blocks above it are indexed again from the segment index files
+type txIndex struct {
+	db     *leveldb.DB
+	failed bool // once a write failed the indexed height is no longer advanced, so the block is indexed on startup
+}
+
+func openTxIndex(dataDir string) (*txIndex, error) {
+	dir := filepath.Join(dataDir, txIndexDirname)
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open transaction index %s", dir)
+	}
+	return &txIndex{db: db}, nil
+}
+
+// loadTxIndex opens the transaction index and indexes the transactions of the blocks it is missing. an index ahead of
+// the blocks, whose files were truncated while the node was down, is rebuilt from scratch
+func loadTxIndex(dataDir string, bhIndex *blockHeightIndex, logger log.Logger) (*txIndex, error) {
+	t, err := openTxIndex(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	indexedHeight, err := t.indexedHeight()
+	if err != nil {
+		_ = t.close()
+		return nil, err
+	}
+	lastHeight := bhIndex.getLastBlockHeight()
+	if indexedHeight > lastHeight {
+		logger.Info("rebuilding transaction index of truncated blocks", log.Uint64("indexed-height", uint64(indexedHeight)), log.Uint64("last-block-height", uint64(lastHeight)))
+		if err := t.close(); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(filepath.Join(dataDir, txIndexDirname)); err != nil {
+			return nil, errors.Wrap(err, "failed to remove transaction index")
+		}
+		if t, err = openTxIndex(dataDir); err != nil {
+			return nil, err
+		}
+		indexedHeight = 0
+	}
+
+	for _, id := range bhIndex.segmentsHoldingBlocksAbove(indexedHeight) {
+		records, err := readSegmentIndexFile(segmentIndexFileName(dataDir, id), id)
+		if err != nil {
+			_ = t.close()
+			return nil, errors.Wrapf(err, "failed to index the transactions of segment %d", id)
+		}
+		for _, record := range records {
+			if height := primitives.BlockHeight(record.entry.Height); height > indexedHeight {
+				if err := t.add(height, record.txHashes, indexedHeight); err != nil {
+					_ = t.close()
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := t.add(0, nil, lastHeight); err != nil {
+		_ = t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *txIndex) indexedHeight() (primitives.BlockHeight, error) {
+	raw, err := t.db.Get(txIndexIndexedHeightKey, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read transaction index height")
+	}
+	if len(raw) != 8 {
+		return 0, fmt.Errorf("transaction index height is corrupt")
+	}
+	return primitives.BlockHeight(binary.LittleEndian.Uint64(raw)), nil
+}
+
+// add indexes the transactions of the block at height, recording that every block up to indexedHeight is indexed
+func (t *txIndex) add(height primitives.BlockHeight, txHashes []primitives.Sha256, indexedHeight primitives.BlockHeight) error {
+	batch := new(leveldb.Batch)
+	for index, txHash := range txHashes {
+		location := make([]byte, 12)
+		binary.LittleEndian.PutUint64(location, uint64(height))
+		binary.LittleEndian.PutUint32(location[8:], uint32(index))
+		batch.Put(txIndexKey(txHash), location)
+	}
+	if !t.failed {
+		raw := make([]byte, 8)
+		binary.LittleEndian.PutUint64(raw, uint64(indexedHeight))
+		batch.Put(txIndexIndexedHeightKey, raw)
+	}
+
+	if err := t.db.Write(batch, nil); err != nil {
+		t.failed = true
+		return errors.Wrapf(err, "failed to index the transactions of block %d", height)
+	}
+	return nil
+}
+
+func (t *txIndex) get(txHash primitives.Sha256) (txLocation, bool, error) {
+	raw, err := t.db.Get(txIndexKey(txHash), nil)
+	if err == leveldb.ErrNotFound {
+		return txLocation{}, false, nil
+	}
+	if err != nil {
+		return txLocation{}, false, errors.Wrapf(err, "failed to look up transaction %s", txHash)
+	}
+	if len(raw) != 12 {
+		return txLocation{}, false, fmt.Errorf("transaction index entry of %s is corrupt", txHash)
+	}
+	return txLocation{height: primitives.BlockHeight(binary.LittleEndian.Uint64(raw)), index: int(binary.LittleEndian.Uint32(raw[8:]))}, true, nil
+}
+
+func (t *txIndex) close() error {
+	return t.db.Close()
+}
+
+func txIndexKey(txHash primitives.Sha256) []byte {
+	return append(append([]byte{}, txIndexTxKeyPrefix...), txHash...)
+}
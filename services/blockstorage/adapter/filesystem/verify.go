@@ -0,0 +1,134 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bufio"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+)
+
+// VerificationReport describes the blocks files walked by VerifyBlocks. Problem is nil if every block is valid,
+// otherwise it describes the first invalid block record, found at Offset of segment file Segment
+type VerificationReport struct {
+	NumSegments     uint32
+	NumBlocks       uint64
+	LastValidHeight primitives.BlockHeight
+	Problem         error
+	Segment         uint32
+	Offset          int64
+	Truncated       bool
+}
+
+// VerifyBlocks walks the blocks files of a node which is not running and checks every block record: its checksums,
+// the chain of prev block hashes, the pointer of the results block to the transactions block, the merkle roots of
+// blocks not pruned and the block proof. the walk stops at the first invalid record, and if truncate is set the blocks
+// files are cut right before it so the node starts from the last valid block
+func VerifyBlocks(conf config.FilesystemBlockPersistenceConfig, parent log.Logger, verifyProof adapter.BlockProofVerifier, truncate bool) (*VerificationReport, error) {
+	logger := parent.WithTags(log.String("adapter", "block-storage"))
+	codec := newCodec(conf.BlockStorageFileSystemMaxBlockSizeInBytes())
+
+	lockFile, err := lockDataDir(conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSilently(lockFile, logger)
+
+	numSegments, err := countSegmentFiles(conf.BlockStorageFileSystemDataDir())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerificationReport{NumSegments: numSegments}
+	var prevBlock *protocol.BlockPairContainer
+	for id := uint32(0); id < numSegments && report.Problem == nil; id++ {
+		if prevBlock, err = verifySegment(conf, id, prevBlock, report, logger, codec, verifyProof); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.Problem == nil {
+		logger.Info("verified blocks files", logfields.BlockHeight(report.LastValidHeight))
+		return report, nil
+	}
+	logger.Error("found invalid block record", log.Uint32("segment", report.Segment), log.Int64("offset", report.Offset), log.Error(report.Problem), logfields.BlockHeight(report.LastValidHeight))
+
+	if truncate {
+		if err := truncateSegments(conf, report.Segment, report.Offset, numSegments); err != nil {
+			return report, err
+		}
+		report.Truncated = true
+		logger.Info("truncated blocks files to the last valid block", logfields.BlockHeight(report.LastValidHeight))
+	}
+	return report, nil
+}
+
+// verifySegment adds the valid blocks of a segment file to the report, or records the first invalid one as its problem.
+// returns the last valid block
+func verifySegment(conf config.FilesystemBlockPersistenceConfig, id uint32, prevBlock *protocol.BlockPairContainer, report *VerificationReport, logger log.Logger, c blockCodec, verifyProof adapter.BlockProofVerifier) (*protocol.BlockPairContainer, error) {
+	file, _, offset, err := openSegmentFile(conf, id, false, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSilently(file, logger)
+
+	r := bufio.NewReaderSize(file, 1024*1024)
+	for {
+		block, blockSize, err := c.decode(r)
+		if err == io.EOF {
+			return prevBlock, nil
+		}
+		if err == nil {
+			err = adapter.ValidateBlock(block, prevBlock, verifyProof)
+		}
+		if err != nil {
+			report.Problem = err
+			report.Segment = id
+			report.Offset = offset
+			return prevBlock, nil
+		}
+
+		report.NumBlocks++
+		report.LastValidHeight = getBlockHeight(block)
+		prevBlock = block
+		offset += int64(blockSize)
+	}
+}
+
+// truncateSegments cuts segment file id at offset and removes the segment files following it. the files following it
+// are removed first, so an interrupted truncation leaves a chain which is found invalid at the same record. index
+// records of the truncated blocks are dropped when the node starts
+func truncateSegments(conf config.FilesystemBlockPersistenceConfig, id uint32, offset int64, numSegments uint32) error {
+	dir := conf.BlockStorageFileSystemDataDir()
+	for last := numSegments - 1; last > id; last-- {
+		if err := os.Remove(segmentIndexFileName(dir, last)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to remove segment index %s", segmentIndexFileName(dir, last))
+		}
+		if err := os.Remove(segmentFileName(dir, last)); err != nil {
+			return errors.Wrapf(err, "failed to remove segment file %s", segmentFileName(dir, last))
+		}
+	}
+
+	filename := segmentFileName(dir, id)
+	file, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open blocks file %s", filename)
+	}
+	defer file.Close()
+
+	if err := file.Truncate(offset); err != nil {
+		return errors.Wrapf(err, "failed to truncate blocks file %s", filename)
+	}
+	return file.Sync()
+}
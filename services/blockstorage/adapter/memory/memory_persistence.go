@@ -24,9 +24,15 @@ type memMetrics struct {
 	size *metric.Gauge
 }
 
+type txLocation struct {
+	height primitives.BlockHeight
+	index  int
+}
+
 type aChainOfBlocks struct {
 	sync.RWMutex
 	blocks             map[primitives.BlockHeight]*protocol.BlockPairContainer
+	txLocations        map[string]txLocation
 	sequentialTopBlock *protocol.BlockPairContainer
 	topBlock           *protocol.BlockPairContainer
 	lastWrittenBlock   *protocol.BlockPairContainer
@@ -66,8 +72,9 @@ func (bp *InMemoryBlockPersistence) GetSyncState() internodesync.SyncState {
 
 func (bp *InMemoryBlockPersistence) createChainOfBlocks(blocks []*protocol.BlockPairContainer) {
 	bp.blockChain = aChainOfBlocks{
-		RWMutex: sync.RWMutex{},
-		blocks:  make(map[primitives.BlockHeight]*protocol.BlockPairContainer),
+		RWMutex:     sync.RWMutex{},
+		blocks:      make(map[primitives.BlockHeight]*protocol.BlockPairContainer),
+		txLocations: make(map[string]txLocation),
 	}
 	count := len(blocks)
 	if count > 0 {
@@ -135,6 +142,9 @@ func (bp *InMemoryBlockPersistence) validateAndAddNextBlock(blockPair *protocol.
 	}
 
 	bp.blockChain.blocks[newBlockHeight] = blockPair
+	for index, receipt := range blockPair.ResultsBlock.TransactionReceipts {
+		bp.blockChain.txLocations[string(receipt.Txhash())] = txLocation{height: newBlockHeight, index: index}
+	}
 	bp.blockChain.lastWrittenBlock = blockPair
 	lastWrittenHeight = newBlockHeight
 	if newBlockHeight > topHeight {
@@ -160,36 +170,40 @@ func (bp *InMemoryBlockPersistence) validateAndAddNextBlock(blockPair *protocol.
 	return true, getBlockHeight(bp.blockChain.sequentialTopBlock)
 }
 
-func (bp *InMemoryBlockPersistence) GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (*protocol.BlockPairContainer, int, error) {
-
+// ignores blocks which are not fully synced (storage is missing blocks with lower height)
+func (bp *InMemoryBlockPersistence) GetBlockByTx(txHash primitives.Sha256) (*protocol.BlockPairContainer, int, error) {
 	bp.blockChain.RLock()
 	defer bp.blockChain.RUnlock()
 
-	var candidateBlocks []*protocol.BlockPairContainer
-	sequentialHeight := getBlockHeight(bp.blockChain.sequentialTopBlock)
-	for height := primitives.BlockHeight(1); height <= sequentialHeight; height++ {
-		if blockPair, _ := bp.blockChain.blocks[height]; blockPair != nil {
-			bts := blockPair.TransactionsBlock.Header.Timestamp()
-			if maxBlockTs < bts {
-				break
-			} else if minBlockTs <= bts {
-				candidateBlocks = append(candidateBlocks, blockPair)
-			}
-		}
-	}
-
-	if len(candidateBlocks) == 0 {
+	location, ok := bp.blockChain.txLocations[string(txHash)]
+	if !ok || location.height > getBlockHeight(bp.blockChain.sequentialTopBlock) {
 		return nil, 0, nil
 	}
+	return bp.blockChain.blocks[location.height], location.index, nil
+}
+
+// ignores blocks which are not fully synced. the blocks are all in memory so they are read instead of indexed
+func (bp *InMemoryBlockPersistence) GetContractEventBlockHeights(contract primitives.ContractName, from primitives.BlockHeight, to primitives.BlockHeight) ([]primitives.BlockHeight, error) {
+	bp.blockChain.RLock()
+	defer bp.blockChain.RUnlock()
 
-	for _, b := range candidateBlocks {
-		for txi, txr := range b.ResultsBlock.TransactionReceipts {
-			if txr.Txhash().Equal(txHash) {
-				return b, txi, nil
+	if sequentialHeight := getBlockHeight(bp.blockChain.sequentialTopBlock); to > sequentialHeight {
+		to = sequentialHeight
+	}
+	var heights []primitives.BlockHeight
+	for height := from; height <= to; height++ {
+		block, ok := bp.blockChain.blocks[height]
+		if !ok {
+			continue
+		}
+		for _, emitting := range adapter.ContractsEmittingEvents(block) {
+			if emitting == contract {
+				heights = append(heights, height)
+				break
 			}
 		}
 	}
-	return nil, 0, nil
+	return heights, nil
 }
 
 func (bp *InMemoryBlockPersistence) getBlockPairAtHeight(height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
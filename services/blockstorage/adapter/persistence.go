@@ -27,6 +27,22 @@ type BlockPersistence interface {
 	GetSyncState() internodesync.SyncState
 	GetTransactionsBlock(height primitives.BlockHeight) (*protocol.TransactionsBlockContainer, error)
 	GetResultsBlock(height primitives.BlockHeight) (*protocol.ResultsBlockContainer, error)
-	GetBlockByTx(txHash primitives.Sha256, minBlockTs primitives.TimestampNano, maxBlockTs primitives.TimestampNano) (block *protocol.BlockPairContainer, txIndexInBlock int, err error)
+	GetBlockByTx(txHash primitives.Sha256) (block *protocol.BlockPairContainer, txIndexInBlock int, err error)
+	GetContractEventBlockHeights(contract primitives.ContractName, from primitives.BlockHeight, to primitives.BlockHeight) ([]primitives.BlockHeight, error)
 	GetBlockTracker() *synchronization.BlockTracker
 }
+
+// ContractsEmittingEvents returns the contracts which emitted events in a block, each once
+func ContractsEmittingEvents(block *protocol.BlockPairContainer) []primitives.ContractName {
+	var contracts []primitives.ContractName
+	seen := map[primitives.ContractName]bool{}
+	for _, receipt := range block.ResultsBlock.TransactionReceipts {
+		for events := protocol.EventsArrayReader(receipt.RawOutputEventsArrayWithHeader()).EventsIterator(); events.HasNext(); {
+			if contract := events.NextEvents().ContractName(); !seen[contract] {
+				seen[contract] = true
+				contracts = append(contracts, contract)
+			}
+		}
+	}
+	return contracts
+}
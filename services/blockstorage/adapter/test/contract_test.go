@@ -270,7 +270,7 @@ func TestBlockPersistenceContract_ReturnsBlockByTx(t *testing.T) {
 
 		tx := blocks[1].TransactionsBlock.SignedTransactions[6].Transaction()
 
-		readBlock, txIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx), blocks[0].ResultsBlock.Header.Timestamp(), blocks[2].ResultsBlock.Header.Timestamp())
+		readBlock, txIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx))
 		require.NoError(t, err)
 		require.EqualValues(t, 6, txIndex)
 		test.RequireCmpEqual(t, readBlock, blocks[1])
@@ -292,19 +292,28 @@ func TestReturnTransactionReceipt(t *testing.T) {
 			require.True(t, added, "block should actually be added (it's not duplicate)")
 		}
 
-		second := primitives.TimestampNano(1 * time.Second)
 		block := blocks[1]
-		blockTimestamp := block.ResultsBlock.Header.Timestamp()
 		txIndex := 6
 		tx := block.TransactionsBlock.SignedTransactions[txIndex].Transaction()
 
-		retrievedBlock, retrievedTxIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx), blockTimestamp-second, blockTimestamp+second)
+		retrievedBlock, retrievedTxIndex, err := adapter.GetBlockByTx(digest.CalcTxHash(tx))
 		require.NoError(t, err)
 		test.RequireCmpEqual(t, block, retrievedBlock, "expected correct block to be retrieved")
 		require.EqualValues(t, txIndex, retrievedTxIndex, "expected correct tx index to be retrieved")
 	})
 }
 
+func TestBlockPersistenceContract_ReturnsNoBlockForUnknownTx(t *testing.T) {
+	withEachAdapter(t, func(t *testing.T, adapter adapter.BlockPersistence) {
+		_, _, err := adapter.WriteNextBlock(builders.BlockPair().WithHeight(1).WithTransactions(3).WithReceiptsForTransactions().Build())
+		require.NoError(t, err, "write should succeed")
+
+		block, _, err := adapter.GetBlockByTx([]byte("will-not-be-found"))
+		require.NoError(t, err)
+		require.Nil(t, block, "expected no block for a transaction which was not committed")
+	})
+}
+
 func newInMemoryAdapter(logger log.Logger) adapter.BlockPersistence {
 	return memory.NewBlockPersistence(logger, metric.NewRegistry())
 }
@@ -45,9 +45,12 @@ func NewFilesystemAdapterDriver(logger log.Logger, conf config.FilesystemBlockPe
 }
 
 type localConfig struct {
-	dir         string
-	chainId     primitives.VirtualChainId
-	networkType protocol.SignerNetworkType
+	dir                 string
+	chainId             primitives.VirtualChainId
+	networkType         protocol.SignerNetworkType
+	maxBlocksPerSegment uint32
+	pruningDepth        uint32
+	txIndexDepth        uint32
 }
 
 func newTempFileConfig() *localConfig {
@@ -69,6 +72,18 @@ func (l *localConfig) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return 64 * 1024 * 1024
 }
 
+func (l *localConfig) BlockStorageFileSystemMaxBlocksPerSegment() uint32 {
+	return l.maxBlocksPerSegment
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningDepth() uint32 {
+	return l.pruningDepth
+}
+
+func (l *localConfig) BlockStorageFileSystemTransactionIndexDepth() uint32 {
+	return l.txIndexDepth
+}
+
 func (l *localConfig) VirtualChainId() primitives.VirtualChainId {
 	return l.chainId
 }
@@ -89,6 +104,18 @@ func (l *localConfig) setNetworkType(value protocol.SignerNetworkType) {
 	l.networkType = value
 }
 
+func (l *localConfig) setMaxBlocksPerSegment(value uint32) {
+	l.maxBlocksPerSegment = value
+}
+
+func (l *localConfig) setPruningDepth(value uint32) {
+	l.pruningDepth = value
+}
+
+func (l *localConfig) setTransactionIndexDepth(value uint32) {
+	l.txIndexDepth = value
+}
+
 func getFileSize(t *testing.T, conf *localConfig) int64 {
 	blocksFile, err := os.Open(filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename))
 	require.NoError(t, err)
@@ -0,0 +1,91 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSystemBlockPersistence_ReopensWithoutScanningIndexedBlocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		ctrlRand := rand.NewControlledRand(t)
+
+		conf := newTempFileConfig()
+		defer conf.cleanDir()
+
+		writeRandomBlocksToFile(t, harness.Logger, conf, 5, ctrlRand)
+
+		// a full scan would stop at the corrupt block and lose the blocks following it
+		flipBitInFile(t, conf, getFileSize(t, conf)/2, 1)
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+
+		topBlockHeight, err := fsa.GetLastBlockHeight()
+		require.NoError(t, err)
+		require.EqualValues(t, 5, topBlockHeight, "expected blocks listed in the index file not to be scanned")
+	})
+}
+
+func TestFileSystemBlockPersistence_RebuildsTornIndexFileTail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		ctrlRand := rand.NewControlledRand(t)
+
+		conf := newTempFileConfig()
+		defer conf.cleanDir()
+
+		var blocks []*protocol.BlockPairContainer
+		for h := primitives.BlockHeight(1); h <= 5; h++ {
+			blocks = append(blocks, builders.BlockPair().WithHeight(h).WithTransactions(3).WithReceiptsForTransactions().Build())
+		}
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		for _, block := range blocks {
+			_, _, err := fsa.WriteNextBlock(block)
+			require.NoError(t, err)
+		}
+		closeAdapter()
+
+		indexFilename := filepath.Join(conf.BlockStorageFileSystemDataDir(), blocksFilename+".idx")
+		indexFile, err := os.Stat(indexFilename)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(indexFilename, indexFile.Size()-(ctrlRand.Int63n(30)+1))) // cut into the last record
+
+		reopened, closeReopened, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		requireCanReadAllBlocksInRandomOrder(t, reopened, blocks, ctrlRand)
+
+		last := blocks[4]
+		txHash := digest.CalcTxHash(last.TransactionsBlock.SignedTransactions[2].Transaction())
+		block, txIndex, err := reopened.GetBlockByTx(txHash)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, txIndex)
+		test.RequireCmpEqual(t, last, block, "expected a transaction of a block missing from the index file to be found")
+		closeReopened()
+
+		rebuiltIndexFile, err := os.Stat(indexFilename)
+		require.NoError(t, err)
+		require.Equal(t, indexFile.Size(), rebuiltIndexFile.Size(), "expected the torn record to be rewritten")
+	})
+}
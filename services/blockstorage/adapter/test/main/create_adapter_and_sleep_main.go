@@ -47,3 +47,15 @@ func (l *localConfig) BlockStorageFileSystemDataDir() string {
 func (l *localConfig) BlockStorageFileSystemMaxBlockSizeInBytes() uint32 {
 	return 1000000000
 }
+
+func (l *localConfig) BlockStorageFileSystemMaxBlocksPerSegment() uint32 {
+	return 0
+}
+
+func (l *localConfig) BlockStorageFileSystemPruningDepth() uint32 {
+	return 0
+}
+
+func (l *localConfig) BlockStorageFileSystemTransactionIndexDepth() uint32 {
+	return 0
+}
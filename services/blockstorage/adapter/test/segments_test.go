@@ -0,0 +1,267 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSystemBlockPersistence_RollsSegmentsAndReopensFromSegmentIndexes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		ctrlRand := rand.NewControlledRand(t)
+
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		defer conf.cleanDir()
+
+		blocks := writeRandomBlocksToFile(t, harness.Logger, conf, 10, ctrlRand)
+
+		for _, filename := range []string{"blocks", "blocks.idx", "blocks.1", "blocks.1.idx", "blocks.2", "blocks.2.idx", "blocks.3"} {
+			require.FileExists(t, filepath.Join(conf.BlockStorageFileSystemDataDir(), filename))
+		}
+
+		require.NoError(t, os.Remove(filepath.Join(conf.BlockStorageFileSystemDataDir(), "blocks.1.idx")))
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+
+		require.FileExists(t, filepath.Join(conf.BlockStorageFileSystemDataDir(), "blocks.1.idx"), "expected a missing segment index to be rebuilt")
+
+		lastBlock, err := fsa.GetLastBlock()
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, blocks[9], lastBlock, "expected last block to be read back after reopening")
+		requireCanReadAllBlocksInRandomOrder(t, fsa, blocks, ctrlRand)
+
+		var scanned []*protocol.BlockPairContainer
+		err = fsa.ScanBlocks(1, 4, func(first primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+			scanned = append(scanned, page...)
+			return true
+		})
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, blocks, scanned, "expected a scan to cross segment files")
+
+		nextBlock := builders.BlockPair().WithHeight(11).WithPrevBlock(blocks[9]).Build()
+		added, height, err := fsa.WriteNextBlock(nextBlock)
+		require.NoError(t, err)
+		require.True(t, added)
+		require.EqualValues(t, 11, height)
+	})
+}
+
+func TestFileSystemBlockPersistence_PrunesTransactionsOfOldSegments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		ctrlRand := rand.NewControlledRand(t)
+
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		conf.setPruningDepth(4)
+		defer conf.cleanDir()
+
+		// segments hold blocks 1-3, 4-6, 7-9 and 10-12, only the first two are at least 4 blocks below the top
+		blocks := builders.RandomizedBlockChainWithLimit(12, ctrlRand, 10, 10)
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		for _, block := range blocks {
+			_, _, err := fsa.WriteNextBlock(block)
+			require.NoError(t, err)
+		}
+		// segments are pruned in the background
+		require.True(t, test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
+			return fsa.GetSyncState().LowestServedBlockHeight == 7
+		}), "expected the first two segments to be pruned")
+		requirePrunedBelow(t, fsa.GetSyncState().LowestServedBlockHeight, fsa.GetBlock, blocks, 7)
+		closeAdapter()
+
+		reopened, closeReopened, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeReopened()
+		requirePrunedBelow(t, reopened.GetSyncState().LowestServedBlockHeight, reopened.GetBlock, blocks, 7)
+	})
+}
+
+func requirePrunedBelow(t *testing.T, lowestServed primitives.BlockHeight, getBlock func(primitives.BlockHeight) (*protocol.BlockPairContainer, error), blocks []*protocol.BlockPairContainer, expectedLowestServed primitives.BlockHeight) {
+	require.EqualValues(t, expectedLowestServed, lowestServed, "expected sync state to report the lowest block not pruned")
+
+	for _, expected := range blocks {
+		height := expected.TransactionsBlock.Header.BlockHeight()
+		block, err := getBlock(height)
+		require.NoError(t, err)
+
+		if height >= expectedLowestServed {
+			test.RequireCmpEqual(t, expected, block, "expected block %d to be kept whole", height)
+			continue
+		}
+		test.RequireCmpEqual(t, expected.TransactionsBlock.Header, block.TransactionsBlock.Header, "expected pruned block %d to keep its header", height)
+		test.RequireCmpEqual(t, expected.TransactionsBlock.BlockProof, block.TransactionsBlock.BlockProof, "expected pruned block %d to keep its proof", height)
+		test.RequireCmpEqual(t, expected.ResultsBlock.Header, block.ResultsBlock.Header, "expected pruned block %d to keep its header", height)
+		test.RequireCmpEqual(t, expected.ResultsBlock.BlockProof, block.ResultsBlock.BlockProof, "expected pruned block %d to keep its proof", height)
+		require.Empty(t, block.TransactionsBlock.SignedTransactions, "expected pruned block %d to drop its transactions", height)
+		require.Empty(t, block.ResultsBlock.TransactionReceipts, "expected pruned block %d to drop its receipts", height)
+	}
+}
+
+func TestFileSystemBlockPersistence_FindsTransactionsOfBlocksBelowTheTransactionIndexDepth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		conf.setTransactionIndexDepth(3)
+		defer conf.cleanDir()
+
+		var blocks []*protocol.BlockPairContainer
+		for h := primitives.BlockHeight(1); h <= 10; h++ {
+			blocks = append(blocks, builders.BlockPair().WithHeight(h).WithTransactions(3).WithReceiptsForTransactions().Build())
+		}
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		for _, block := range blocks {
+			_, _, err := fsa.WriteNextBlock(block)
+			require.NoError(t, err)
+		}
+
+		oldBlock := blocks[0]
+		txHash := digest.CalcTxHash(oldBlock.TransactionsBlock.SignedTransactions[1].Transaction())
+
+		requireTxFound := func(fsa adapter.BlockPersistence, msg string) {
+			block, txIndex, err := fsa.GetBlockByTx(txHash)
+			require.NoError(t, err)
+			require.EqualValues(t, 1, txIndex)
+			test.RequireCmpEqual(t, oldBlock, block, msg)
+		}
+		requireTxFound(fsa, "expected a transaction below the transaction index depth to be found in the transaction index")
+
+		block, _, err := fsa.GetBlockByTx([]byte("will-not-be-found"))
+		require.NoError(t, err)
+		require.Nil(t, block, "expected no block for a transaction which was not committed")
+		closeAdapter()
+
+		reopened, closeReopened, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		requireTxFound(reopened, "expected the transaction index to be kept across restarts")
+		closeReopened()
+
+		require.NoError(t, os.RemoveAll(filepath.Join(conf.BlockStorageFileSystemDataDir(), "blocks.txidx")))
+		rebuilt, closeRebuilt, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeRebuilt()
+		requireTxFound(rebuilt, "expected a missing transaction index to be rebuilt from the segment index files")
+	})
+}
+
+func TestFileSystemBlockPersistence_RebuildsTransactionIndexOfTruncatedBlocks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempFileConfig()
+		conf.setTransactionIndexDepth(1)
+		defer conf.cleanDir()
+
+		writeBlocks := func(blocks ...*protocol.BlockPairContainer) {
+			fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+			require.NoError(t, err)
+			defer closeAdapter()
+			for _, block := range blocks {
+				_, _, err := fsa.WriteNextBlock(block)
+				require.NoError(t, err)
+			}
+		}
+		firstBlock := builders.BlockPair().WithHeight(1).WithTransactions(3).WithReceiptsForTransactions().Build()
+		truncatedBlock := builders.BlockPair().WithHeight(2).WithTransactions(3).WithReceiptsForTransactions().Build()
+		writeBlocks(firstBlock)
+		size := getFileSize(t, conf)
+		writeBlocks(truncatedBlock)
+		truncateFile(t, conf, size)
+
+		replacingBlock := builders.BlockPair().WithHeight(2).WithTransactions(3).WithReceiptsForTransactions().Build()
+		writeBlocks(replacingBlock)
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+
+		block, _, err := fsa.GetBlockByTx(digest.CalcTxHash(firstBlock.TransactionsBlock.SignedTransactions[0].Transaction()))
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, firstBlock, block, "expected the transactions of blocks kept to be found")
+
+		block, _, err = fsa.GetBlockByTx(digest.CalcTxHash(truncatedBlock.TransactionsBlock.SignedTransactions[0].Transaction()))
+		require.NoError(t, err)
+		require.Nil(t, block, "expected the transactions of truncated blocks not to be found")
+
+		block, _, err = fsa.GetBlockByTx(digest.CalcTxHash(replacingBlock.TransactionsBlock.SignedTransactions[0].Transaction()))
+		require.NoError(t, err)
+		test.RequireCmpEqual(t, replacingBlock, block, "expected the transactions of the block written in place of a truncated one to be found")
+	})
+}
+
+func TestFileSystemBlockPersistence_FindsContractEventsOfBlocksBelowTheTransactionIndexDepth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		conf.setTransactionIndexDepth(3)
+		defer conf.cleanDir()
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		for h := primitives.BlockHeight(1); h <= 10; h++ {
+			contract := primitives.ContractName("Other")
+			if h%4 == 1 {
+				contract = "Token"
+			}
+			event, err := builders.EventBuilder(contract, "Transfer", uint64(h))
+			require.NoError(t, err)
+			receipt := builders.TransactionReceipt().Builder()
+			receipt.OutputEventsArray = builders.PackedEventsArrayEncode(event)
+			_, _, err = fsa.WriteNextBlock(builders.BlockPair().WithHeight(h).WithReceipt(receipt.Build()).Build())
+			require.NoError(t, err)
+		}
+
+		requireEventsFound := func(fsa adapter.BlockPersistence) {
+			heights, err := fsa.GetContractEventBlockHeights("Token", 1, 10)
+			require.NoError(t, err)
+			require.EqualValues(t, []primitives.BlockHeight{1, 5, 9}, heights, "expected the events of blocks below the transaction index depth to be found by scanning the segment index files")
+
+			heights, err = fsa.GetContractEventBlockHeights("Token", 2, 8)
+			require.NoError(t, err)
+			require.EqualValues(t, []primitives.BlockHeight{5}, heights, "expected only blocks in the range")
+
+			heights, err = fsa.GetContractEventBlockHeights("Missing", 1, 10)
+			require.NoError(t, err)
+			require.Empty(t, heights, "expected no blocks for a contract which emitted no events")
+		}
+		requireEventsFound(fsa)
+		closeAdapter()
+
+		reopened, closeReopened, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeReopened()
+		requireEventsFound(reopened)
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/filesystem"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyBlocks_AcceptsValidChain(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		defer conf.cleanDir()
+
+		writeBlocks(t, harness, conf, verifiableBlockChain(10))
+
+		var verifiedProofs []primitives.BlockHeight
+		report, err := filesystem.VerifyBlocks(conf, harness.Logger, func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+			verifiedProofs = append(verifiedProofs, block.TransactionsBlock.Header.BlockHeight())
+			return nil
+		}, false)
+		require.NoError(t, err)
+		require.NoError(t, report.Problem)
+		require.EqualValues(t, 4, report.NumSegments)
+		require.EqualValues(t, 10, report.NumBlocks)
+		require.EqualValues(t, 10, report.LastValidHeight)
+		require.Len(t, verifiedProofs, 10, "expected the proof of every block to be verified")
+	})
+}
+
+func TestVerifyBlocks_ReportsBrokenChainAndTruncatesAfterLastValidBlock(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		harness.AllowErrorsMatching("found invalid block record")
+		conf := newTempFileConfig()
+		conf.setMaxBlocksPerSegment(3)
+		defer conf.cleanDir()
+
+		// block 5 does not point to block 4, segments hold blocks 1-3, 4-6, 7-9 and 10
+		blocks := verifiableBlockChain(4)
+		blocks = append(blocks, verifiableBlock(5, nil))
+		for h := primitives.BlockHeight(6); h <= 10; h++ {
+			blocks = append(blocks, verifiableBlock(h, blocks[h-2]))
+		}
+		writeBlocks(t, harness, conf, blocks)
+
+		report, err := filesystem.VerifyBlocks(conf, harness.Logger, nil, false)
+		require.NoError(t, err)
+		require.Error(t, report.Problem)
+		require.EqualValues(t, 4, report.LastValidHeight)
+		require.EqualValues(t, 1, report.Segment)
+		require.False(t, report.Truncated)
+		require.FileExists(t, filepath.Join(conf.BlockStorageFileSystemDataDir(), "blocks.3"), "expected blocks files not to change without truncate")
+
+		report, err = filesystem.VerifyBlocks(conf, harness.Logger, nil, true)
+		require.NoError(t, err)
+		require.True(t, report.Truncated)
+		for _, filename := range []string{"blocks.2", "blocks.2.idx", "blocks.3", "blocks.3.idx"} {
+			require.NoFileExists(t, filepath.Join(conf.BlockStorageFileSystemDataDir(), filename))
+		}
+
+		report, err = filesystem.VerifyBlocks(conf, harness.Logger, nil, false)
+		require.NoError(t, err)
+		require.NoError(t, report.Problem, "expected truncated blocks files to be valid")
+		require.EqualValues(t, 4, report.LastValidHeight)
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+		lastHeight, err := fsa.GetLastBlockHeight()
+		require.NoError(t, err)
+		require.EqualValues(t, 4, lastHeight, "expected the node to start from the last valid block")
+		added, _, err := fsa.WriteNextBlock(verifiableBlock(5, blocks[3]))
+		require.NoError(t, err)
+		require.True(t, added, "expected blocks to be written following the last valid block")
+	})
+}
+
+func TestVerifyBlocks_ReportsInvalidBlockProof(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		harness.AllowErrorsMatching("found invalid block record")
+		conf := newTempFileConfig()
+		defer conf.cleanDir()
+
+		writeBlocks(t, harness, conf, verifiableBlockChain(5))
+
+		report, err := filesystem.VerifyBlocks(conf, harness.Logger, func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+			if block.TransactionsBlock.Header.BlockHeight() == 3 {
+				return errors.New("not signed by the committee")
+			}
+			return nil
+		}, false)
+		require.NoError(t, err)
+		require.Error(t, report.Problem)
+		require.EqualValues(t, 2, report.LastValidHeight)
+	})
+}
+
+func TestVerifyBlocks_ReportsCorruptBlockRecord(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		harness.AllowErrorsMatching("found invalid block record")
+		conf := newTempFileConfig()
+		defer conf.cleanDir()
+
+		writeBlocks(t, harness, conf, verifiableBlockChain(5))
+		flipBitInFile(t, conf, getFileSize(t, conf)-1, 1)
+
+		report, err := filesystem.VerifyBlocks(conf, harness.Logger, nil, true)
+		require.NoError(t, err)
+		require.Error(t, report.Problem)
+		require.EqualValues(t, 4, report.LastValidHeight)
+		require.True(t, report.Truncated)
+
+		fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+		require.NoError(t, err)
+		defer closeAdapter()
+		lastHeight, err := fsa.GetLastBlockHeight()
+		require.NoError(t, err)
+		require.EqualValues(t, 4, lastHeight)
+	})
+}
+
+func writeBlocks(t *testing.T, harness *with.LoggingHarness, conf *localConfig, blocks []*protocol.BlockPairContainer) {
+	fsa, closeAdapter, err := NewFilesystemAdapterDriver(harness.Logger, conf)
+	require.NoError(t, err)
+	defer closeAdapter()
+
+	for _, block := range blocks {
+		_, _, err := fsa.WriteNextBlock(block)
+		require.NoError(t, err)
+	}
+}
+
+// verifiableBlockChain builds blocks whose headers point to their previous block, their transactions block and the
+// merkle root of their receipts
+func verifiableBlockChain(numBlocks int) []*protocol.BlockPairContainer {
+	var blocks []*protocol.BlockPairContainer
+	var prev *protocol.BlockPairContainer
+	for h := primitives.BlockHeight(1); h <= primitives.BlockHeight(numBlocks); h++ {
+		prev = verifiableBlock(h, prev)
+		blocks = append(blocks, prev)
+	}
+	return blocks
+}
+
+func verifiableBlock(height primitives.BlockHeight, prev *protocol.BlockPairContainer) *protocol.BlockPairContainer {
+	builder := builders.BlockPair().WithHeight(height).WithTransactions(3).WithReceiptsForTransactions()
+	if prev != nil {
+		builder.WithPrevBlock(prev)
+	}
+	block := builder.Build()
+
+	receiptsMerkleRoot, _ := digest.CalcReceiptsMerkleRoot(block.ResultsBlock.TransactionReceipts)
+	block.ResultsBlock.Header.MutateTransactionsBlockHashPtr(digest.CalcTransactionsBlockHash(block.TransactionsBlock))
+	block.ResultsBlock.Header.MutateReceiptsMerkleRootHash(receiptsMerkleRoot)
+	return block
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package adapter
+
+import (
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/validators"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+)
+
+// BlockProofVerifier checks the consensus proof of a block against the block preceding it, which is nil for the first
+// block of the chain
+type BlockProofVerifier func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error
+
+// ValidateBlock checks a block read back from storage or an archive without a running node: it must follow prevBlock
+// in height and prev block hashes, its results block must point to its transactions block and, unless its receipts
+// were pruned, its receipts must match their merkle root. the block proof is checked if verifyProof is given
+func ValidateBlock(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer, verifyProof BlockProofVerifier) error {
+	height := block.TransactionsBlock.Header.BlockHeight()
+	var prevHeight primitives.BlockHeight
+	if prevBlock != nil {
+		prevHeight = prevBlock.TransactionsBlock.Header.BlockHeight()
+	}
+	if height != prevHeight+1 {
+		return fmt.Errorf("block height %d does not follow block height %d", height, prevHeight)
+	}
+	if block.ResultsBlock.Header.BlockHeight() != height {
+		return fmt.Errorf("results block height %d does not match transactions block height %d", block.ResultsBlock.Header.BlockHeight(), height)
+	}
+
+	if prevBlock != nil {
+		if prevTxHash := digest.CalcTransactionsBlockHash(prevBlock.TransactionsBlock); !block.TransactionsBlock.Header.PrevBlockHashPtr().Equal(prevTxHash) {
+			return fmt.Errorf("transactions prev block hash of block %d does not match block %d: %s", height, prevHeight, prevTxHash)
+		}
+		if prevRxHash := digest.CalcResultsBlockHash(prevBlock.ResultsBlock); !block.ResultsBlock.Header.PrevBlockHashPtr().Equal(prevRxHash) {
+			return fmt.Errorf("results prev block hash of block %d does not match block %d: %s", height, prevHeight, prevRxHash)
+		}
+	}
+
+	if txHash := digest.CalcTransactionsBlockHash(block.TransactionsBlock); !block.ResultsBlock.Header.TransactionsBlockHashPtr().Equal(txHash) {
+		return fmt.Errorf("results block %d does not point to its transactions block: %s", height, txHash)
+	}
+
+	// pruned blocks keep their headers, which are covered by the block hash signed in the proof
+	pruned := len(block.ResultsBlock.TransactionReceipts) == 0 && block.ResultsBlock.Header.NumTransactionReceipts() > 0
+	if !pruned {
+		err := validators.ValidateReceiptsMerkleRoot(&validators.BlockValidatorContext{
+			TransactionsBlock:      block.TransactionsBlock,
+			ResultsBlock:           block.ResultsBlock,
+			CalcReceiptsMerkleRoot: digest.CalcReceiptsMerkleRoot,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "invalid receipts of block %d", height)
+		}
+	}
+
+	if verifyProof != nil {
+		if err := verifyProof(block, prevBlock); err != nil {
+			return errors.Wrapf(err, "invalid block proof of block %d", height)
+		}
+	}
+	return nil
+}
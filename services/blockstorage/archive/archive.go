@@ -0,0 +1,194 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+// Package archive reads and writes block archives, a portable format for moving a range of blocks between environments
+// which does not depend on the internal format of any block persistence.
+//
+// An archive is a sequence of little endian records, each followed by a CRC32 (Castagnoli) checksum of its bytes:
+//
+//	header    magic "ORBA" uint32 | format version uint32 | network type uint32 | virtual chain id uint32
+//	block     height uint64 | num transactions uint32 | num receipts uint32 | num state diffs uint32 | chunks
+//	...
+//	manifest  num blocks uint64 | per block: height uint64 | offset uint64 | transactions block hash [32]byte | results block hash [32]byte
+//	footer    manifest offset uint64 | magic "ORBA" uint32 (not checksummed)
+//
+// The chunks of a block are the membuffers of its transactions block header, metadata and proof, of its results block
+// header and proof, and then of its transactions, receipts and state diffs. each chunk is preceded by its length as
+// uint32. blocks are listed in ascending consecutive heights. the manifest follows the last block so an archive is
+// written in a single pass, readers locate it through the fixed size footer at the end of the archive.
+package archive
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"hash/crc32"
+	"io"
+)
+
+const archiveMagic = uint32(0x4142524f) // "ORBA"
+const archiveFormatVersion = uint32(1)
+
+const maxChunkSize = 64 * 1024 * 1024
+const maxBlockSize = 256 * 1024 * 1024
+
+type archiveHeader struct {
+	Magic          uint32
+	FormatVersion  uint32
+	NetworkType    uint32
+	VirtualChainId uint32
+}
+
+type blockRecordHeader struct {
+	Height          uint64
+	NumTransactions uint32
+	NumReceipts     uint32
+	NumStateDiffs   uint32
+}
+
+type manifestEntryRecord struct {
+	Height                uint64
+	Offset                uint64
+	TransactionsBlockHash [32]byte
+	ResultsBlockHash      [32]byte
+}
+
+type archiveFooter struct {
+	ManifestOffset uint64
+	Magic          uint32
+}
+
+// Manifest lists the blocks of an archive with the hashes they are expected to have
+type Manifest struct {
+	NetworkType    protocol.SignerNetworkType
+	VirtualChainId primitives.VirtualChainId
+	Entries        []ManifestEntry
+}
+
+type ManifestEntry struct {
+	Height                primitives.BlockHeight
+	Offset                int64
+	TransactionsBlockHash primitives.Sha256
+	ResultsBlockHash      primitives.Sha256
+}
+
+func (m *Manifest) FirstHeight() primitives.BlockHeight {
+	if len(m.Entries) == 0 {
+		return 0
+	}
+	return m.Entries[0].Height
+}
+
+func (m *Manifest) LastHeight() primitives.BlockHeight {
+	if len(m.Entries) == 0 {
+		return 0
+	}
+	return m.Entries[len(m.Entries)-1].Height
+}
+
+func newChecksum() checksum {
+	return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+}
+
+type checksum interface {
+	io.Writer
+	Sum32() uint32
+}
+
+// checksummedWriter counts the bytes written through it and sums them until the checksum is written
+type checksummedWriter struct {
+	w        io.Writer
+	sum      checksum
+	position int64
+}
+
+func newChecksummedWriter(w io.Writer) *checksummedWriter {
+	return &checksummedWriter{w: w, sum: newChecksum()}
+}
+
+func (cw *checksummedWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.position += int64(n)
+	_, _ = cw.sum.Write(p[:n])
+	return n, err
+}
+
+func (cw *checksummedWriter) write(data interface{}) error {
+	return binary.Write(cw, binary.LittleEndian, data)
+}
+
+func (cw *checksummedWriter) writeChunk(chunk []byte) error {
+	if err := cw.write(uint32(len(chunk))); err != nil {
+		return err
+	}
+	_, err := cw.Write(chunk)
+	return err
+}
+
+// writeChecksum ends a record with the checksum of its bytes, and starts the checksum of the next record
+func (cw *checksummedWriter) writeChecksum() error {
+	sum32 := cw.sum.Sum32()
+	if err := cw.write(sum32); err != nil {
+		return err
+	}
+	cw.sum = newChecksum()
+	return nil
+}
+
+// checksummedReader is the reading counterpart of checksummedWriter
+type checksummedReader struct {
+	r         io.Reader
+	sum       checksum
+	position  int64
+	bytesRead int
+}
+
+func newChecksummedReader(r io.Reader, position int64) *checksummedReader {
+	return &checksummedReader{r: r, sum: newChecksum(), position: position}
+}
+
+func (cr *checksummedReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.position += int64(n)
+	cr.bytesRead += n
+	_, _ = cr.sum.Write(p[:n])
+	return n, err
+}
+
+func (cr *checksummedReader) read(data interface{}) error {
+	return binary.Read(cr, binary.LittleEndian, data)
+}
+
+func (cr *checksummedReader) readChunk(budget int) ([]byte, error) {
+	var size uint32
+	if err := cr.read(&size); err != nil {
+		return nil, err
+	}
+	if size > maxChunkSize || cr.bytesRead+int(size) > budget {
+		return nil, fmt.Errorf("chunk of %d bytes exceeds the size limit", size)
+	}
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(cr, chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// readChecksum ends a record by checking the checksum of its bytes, and starts the checksum of the next record
+func (cr *checksummedReader) readChecksum() error {
+	expected := cr.sum.Sum32()
+	var sum32 uint32
+	if err := cr.read(&sum32); err != nil {
+		return err
+	}
+	if sum32 != expected {
+		return fmt.Errorf("checksum mismatch at offset %d", cr.position)
+	}
+	cr.sum = newChecksum()
+	cr.bytesRead = 0
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package archive
+
+import (
+	"bytes"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const testNetworkType = protocol.NETWORK_TYPE_TEST_NET
+
+func TestExportImport_RoundTripsBlockRange(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	blocks := validBlockChain(10)
+	source := memory.NewBlockPersistence(logger, metric.NewRegistry(), blocks...)
+
+	archive := &bytes.Buffer{}
+	manifest, err := Export(archive, source, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, 1, 20)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, manifest.FirstHeight())
+	require.EqualValues(t, 10, manifest.LastHeight(), "expected the range to be capped at the last block")
+
+	read, err := ReadManifest(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	require.Equal(t, manifest, read)
+
+	var verifiedProofs int
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry())
+	numImported, err := Import(bytes.NewReader(archive.Bytes()), int64(archive.Len()), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+		verifiedProofs++
+		return nil
+	}, logger)
+	require.NoError(t, err)
+	require.Equal(t, 10, numImported)
+	require.Equal(t, 10, verifiedProofs, "expected the proof of every imported block to be verified")
+
+	for _, block := range blocks {
+		imported, err := target.GetBlock(block.TransactionsBlock.Header.BlockHeight())
+		require.NoError(t, err)
+		require.Equal(t, digest.CalcBlockHash(block.TransactionsBlock, block.ResultsBlock), digest.CalcBlockHash(imported.TransactionsBlock, imported.ResultsBlock))
+		require.Len(t, imported.TransactionsBlock.SignedTransactions, 3)
+		require.Len(t, imported.ResultsBlock.TransactionReceipts, 3)
+	}
+}
+
+func TestImport_SkipsBlocksAlreadyStored(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	blocks := validBlockChain(10)
+	archive := exportBlocks(t, blocks, 3, 10)
+
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry(), blocks[:6]...)
+	numImported, err := Import(bytes.NewReader(archive), int64(len(archive)), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, nil, logger)
+	require.NoError(t, err)
+	require.Equal(t, 4, numImported)
+	lastHeight, err := target.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 10, lastHeight)
+}
+
+func TestImport_RejectsArchiveForkingFromStoredChain(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	archive := exportBlocks(t, validBlockChain(10), 1, 10)
+
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry(), validBlockChain(5)...)
+	_, err := Import(bytes.NewReader(archive), int64(len(archive)), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, nil, logger)
+	require.Error(t, err, "expected blocks built separately to differ from the stored ones")
+}
+
+func TestImport_RejectsArchiveNotFollowingLastBlock(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	archive := exportBlocks(t, validBlockChain(10), 5, 10)
+
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry())
+	_, err := Import(bytes.NewReader(archive), int64(len(archive)), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, nil, logger)
+	require.Error(t, err)
+}
+
+func TestImport_RejectsArchiveOfAnotherVirtualChain(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	archive := exportBlocks(t, validBlockChain(3), 1, 3)
+
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry())
+	_, err := Import(bytes.NewReader(archive), int64(len(archive)), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID+1, nil, logger)
+	require.Error(t, err)
+}
+
+func TestImport_RejectsCorruptBlockAfterImportingPreviousBlocks(t *testing.T) {
+	logger := log.DefaultTestingLogger(t)
+	archive := exportBlocks(t, validBlockChain(5), 1, 5)
+	manifest, err := ReadManifest(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	archive[manifest.Entries[3].Offset+100] ^= 1
+
+	target := memory.NewBlockPersistence(logger, metric.NewRegistry())
+	_, err = Import(bytes.NewReader(archive), int64(len(archive)), target, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, nil, logger)
+	require.Error(t, err)
+	lastHeight, err := target.GetLastBlockHeight()
+	require.NoError(t, err)
+	require.EqualValues(t, 3, lastHeight, "expected the blocks preceding the corrupt block to be imported")
+}
+
+func TestReadManifest_RejectsTruncatedArchive(t *testing.T) {
+	archive := exportBlocks(t, validBlockChain(3), 1, 3)
+
+	_, err := ReadManifest(bytes.NewReader(archive), int64(len(archive)-1))
+	require.Error(t, err)
+}
+
+func exportBlocks(t *testing.T, blocks []*protocol.BlockPairContainer, from primitives.BlockHeight, to primitives.BlockHeight) []byte {
+	source := memory.NewBlockPersistence(log.DefaultTestingLogger(t), metric.NewRegistry(), blocks...)
+	archive := &bytes.Buffer{}
+	_, err := Export(archive, source, testNetworkType, builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, from, to)
+	require.NoError(t, err)
+	return archive.Bytes()
+}
+
+// validBlockChain builds blocks whose headers point to their previous block, their transactions block and the merkle
+// root of their receipts
+func validBlockChain(numBlocks int) []*protocol.BlockPairContainer {
+	var blocks []*protocol.BlockPairContainer
+	for h := primitives.BlockHeight(1); h <= primitives.BlockHeight(numBlocks); h++ {
+		builder := builders.BlockPair().WithHeight(h).WithTransactions(3).WithReceiptsForTransactions()
+		if len(blocks) > 0 {
+			builder.WithPrevBlock(blocks[len(blocks)-1])
+		}
+		block := builder.Build()
+
+		receiptsMerkleRoot, _ := digest.CalcReceiptsMerkleRoot(block.ResultsBlock.TransactionReceipts)
+		block.ResultsBlock.Header.MutateTransactionsBlockHashPtr(digest.CalcTransactionsBlockHash(block.TransactionsBlock))
+		block.ResultsBlock.Header.MutateReceiptsMerkleRootHash(receiptsMerkleRoot)
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package archive
+
+import (
+	"bufio"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const exportPageSize = 100
+
+// Export writes blocks from..to of a block persistence to w as an archive, to is capped at the last block of the
+// persistence. returns the manifest written at the end of the archive
+func Export(w io.Writer, persistence adapter.BlockPersistence, networkType protocol.SignerNetworkType, vchainId primitives.VirtualChainId, from primitives.BlockHeight, to primitives.BlockHeight) (*Manifest, error) {
+	if from == 0 || to < from {
+		return nil, errors.Errorf("invalid block range %d-%d", from, to)
+	}
+	lastHeight, err := persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	if to > lastHeight {
+		to = lastHeight
+	}
+	if from > to {
+		return nil, errors.Errorf("block %d is above the last block %d", from, lastHeight)
+	}
+
+	bw := bufio.NewWriterSize(w, 1024*1024)
+	cw := newChecksummedWriter(bw)
+
+	header := &archiveHeader{
+		Magic:          archiveMagic,
+		FormatVersion:  archiveFormatVersion,
+		NetworkType:    uint32(networkType),
+		VirtualChainId: uint32(vchainId),
+	}
+	if err := cw.write(header); err != nil {
+		return nil, errors.Wrap(err, "failed to write archive header")
+	}
+	if err := cw.writeChecksum(); err != nil {
+		return nil, errors.Wrap(err, "failed to write archive header")
+	}
+
+	manifest := &Manifest{NetworkType: networkType, VirtualChainId: vchainId}
+	var writeErr error
+	err = persistence.ScanBlocks(from, exportPageSize, func(first primitives.BlockHeight, page []*protocol.BlockPairContainer) (wantsMore bool) {
+		for _, block := range page {
+			height := block.TransactionsBlock.Header.BlockHeight()
+			if height > to {
+				return false
+			}
+			if height != from+primitives.BlockHeight(len(manifest.Entries)) {
+				writeErr = errors.Errorf("block persistence returned block %d, expected block %d", height, from+primitives.BlockHeight(len(manifest.Entries)))
+				return false
+			}
+			entry := ManifestEntry{
+				Height:                height,
+				Offset:                cw.position,
+				TransactionsBlockHash: digest.CalcTransactionsBlockHash(block.TransactionsBlock),
+				ResultsBlockHash:      digest.CalcResultsBlockHash(block.ResultsBlock),
+			}
+			if writeErr = writeBlock(cw, block); writeErr != nil {
+				writeErr = errors.Wrapf(writeErr, "failed to write block %d", height)
+				return false
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	if manifest.LastHeight() != to {
+		return nil, errors.Errorf("block persistence returned blocks up to %d, expected blocks up to %d", manifest.LastHeight(), to)
+	}
+
+	if err := writeManifest(cw, manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to write archive manifest")
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, errors.Wrap(err, "failed to write archive")
+	}
+	return manifest, nil
+}
+
+func writeBlock(cw *checksummedWriter, block *protocol.BlockPairContainer) error {
+	header := &blockRecordHeader{
+		Height:          uint64(block.TransactionsBlock.Header.BlockHeight()),
+		NumTransactions: uint32(len(block.TransactionsBlock.SignedTransactions)),
+		NumReceipts:     uint32(len(block.ResultsBlock.TransactionReceipts)),
+		NumStateDiffs:   uint32(len(block.ResultsBlock.ContractStateDiffs)),
+	}
+	if err := cw.write(header); err != nil {
+		return err
+	}
+
+	chunks := [][]byte{
+		block.TransactionsBlock.Header.Raw(),
+		block.TransactionsBlock.Metadata.Raw(),
+		block.TransactionsBlock.BlockProof.Raw(),
+		block.ResultsBlock.Header.Raw(),
+		block.ResultsBlock.BlockProof.Raw(),
+	}
+	for _, tx := range block.TransactionsBlock.SignedTransactions {
+		chunks = append(chunks, tx.Raw())
+	}
+	for _, receipt := range block.ResultsBlock.TransactionReceipts {
+		chunks = append(chunks, receipt.Raw())
+	}
+	for _, diff := range block.ResultsBlock.ContractStateDiffs {
+		chunks = append(chunks, diff.Raw())
+	}
+	for _, chunk := range chunks {
+		if err := cw.writeChunk(chunk); err != nil {
+			return err
+		}
+	}
+	return cw.writeChecksum()
+}
+
+func writeManifest(cw *checksummedWriter, manifest *Manifest) error {
+	manifestOffset := cw.position
+	if err := cw.write(uint64(len(manifest.Entries))); err != nil {
+		return err
+	}
+	for _, entry := range manifest.Entries {
+		record := &manifestEntryRecord{
+			Height: uint64(entry.Height),
+			Offset: uint64(entry.Offset),
+		}
+		copy(record.TransactionsBlockHash[:], entry.TransactionsBlockHash)
+		copy(record.ResultsBlockHash[:], entry.ResultsBlockHash)
+		if err := cw.write(record); err != nil {
+			return err
+		}
+	}
+	if err := cw.writeChecksum(); err != nil {
+		return err
+	}
+	return cw.write(&archiveFooter{ManifestOffset: uint64(manifestOffset), Magic: archiveMagic})
+}
@@ -0,0 +1,259 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// ReadManifest reads the header and manifest of an archive of the given size
+func ReadManifest(r io.ReaderAt, size int64) (*Manifest, error) {
+	manifest, _, err := readManifest(r, size)
+	return manifest, err
+}
+
+// Import writes the blocks of an archive which follow the last block of a block persistence through WriteNextBlock.
+// every block is checked against the manifest and validated against the block preceding it, including its proof if
+// verifyProof is given. blocks the persistence already holds are skipped once the last of them is found to be the
+// same block in the archive. returns the number of blocks written
+func Import(r io.ReaderAt, size int64, persistence adapter.BlockPersistence, networkType protocol.SignerNetworkType, vchainId primitives.VirtualChainId, verifyProof adapter.BlockProofVerifier, logger log.Logger) (int, error) {
+	manifest, manifestOffset, err := readManifest(r, size)
+	if err != nil {
+		return 0, err
+	}
+	if manifest.NetworkType != networkType || manifest.VirtualChainId != vchainId {
+		return 0, errors.Errorf("archive of network type %d virtual chain %d cannot be imported to network type %d virtual chain %d", manifest.NetworkType, manifest.VirtualChainId, networkType, vchainId)
+	}
+	if len(manifest.Entries) == 0 {
+		return 0, nil
+	}
+
+	prevBlock, err := persistence.GetLastBlock()
+	if err != nil {
+		return 0, err
+	}
+	lastHeight := primitives.BlockHeight(0)
+	if prevBlock != nil {
+		lastHeight = prevBlock.TransactionsBlock.Header.BlockHeight()
+	}
+	if manifest.FirstHeight() > lastHeight+1 {
+		return 0, errors.Errorf("archive starts at block %d which does not follow the last block %d", manifest.FirstHeight(), lastHeight)
+	}
+	if err := requireSameLastStoredBlock(manifest, persistence, lastHeight); err != nil {
+		return 0, err
+	}
+	if manifest.LastHeight() <= lastHeight {
+		logger.Info("all blocks of the archive are already stored", logfields.BlockHeight(lastHeight))
+		return 0, nil
+	}
+
+	entries := manifest.Entries[lastHeight+1-manifest.FirstHeight():]
+	offset := entries[0].Offset
+	cr := newChecksummedReader(bufio.NewReaderSize(io.NewSectionReader(r, offset, manifestOffset-offset), 1024*1024), offset)
+	for _, entry := range entries {
+		block, err := readBlock(cr)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to read block %d from archive", entry.Height)
+		}
+		if err := requireManifestEntry(block, entry, vchainId); err != nil {
+			return 0, err
+		}
+		if err := adapter.ValidateBlock(block, prevBlock, verifyProof); err != nil {
+			return 0, err
+		}
+
+		added, _, err := persistence.WriteNextBlock(block)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to write block %d", entry.Height)
+		}
+		if !added {
+			return 0, errors.Errorf("block persistence did not add block %d", entry.Height)
+		}
+		prevBlock = block
+	}
+
+	logger.Info("imported blocks archive", log.Int("num-blocks", len(entries)), logfields.BlockHeight(manifest.LastHeight()))
+	return len(entries), nil
+}
+
+// requireSameLastStoredBlock checks that the chain in the archive does not fork from the stored chain
+func requireSameLastStoredBlock(manifest *Manifest, persistence adapter.BlockPersistence, lastHeight primitives.BlockHeight) error {
+	height := lastHeight
+	if manifest.LastHeight() < height {
+		height = manifest.LastHeight()
+	}
+	if height < manifest.FirstHeight() {
+		return nil
+	}
+
+	block, err := persistence.GetBlock(height)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read stored block %d", height)
+	}
+	entry := manifest.Entries[height-manifest.FirstHeight()]
+	if !digest.CalcTransactionsBlockHash(block.TransactionsBlock).Equal(entry.TransactionsBlockHash) || !digest.CalcResultsBlockHash(block.ResultsBlock).Equal(entry.ResultsBlockHash) {
+		return errors.Errorf("stored block %d is not the block %d of the archive", height, height)
+	}
+	return nil
+}
+
+func requireManifestEntry(block *protocol.BlockPairContainer, entry ManifestEntry, vchainId primitives.VirtualChainId) error {
+	if block.TransactionsBlock.Header.BlockHeight() != entry.Height || block.ResultsBlock.Header.BlockHeight() != entry.Height {
+		return errors.Errorf("archive lists block %d where block %d is found", entry.Height, block.TransactionsBlock.Header.BlockHeight())
+	}
+	if block.TransactionsBlock.Header.VirtualChainId() != vchainId || block.ResultsBlock.Header.VirtualChainId() != vchainId {
+		return errors.Errorf("block %d of the archive belongs to virtual chain %d", entry.Height, block.TransactionsBlock.Header.VirtualChainId())
+	}
+	if !digest.CalcTransactionsBlockHash(block.TransactionsBlock).Equal(entry.TransactionsBlockHash) {
+		return errors.Errorf("transactions block hash of block %d does not match the archive manifest", entry.Height)
+	}
+	if !digest.CalcResultsBlockHash(block.ResultsBlock).Equal(entry.ResultsBlockHash) {
+		return errors.Errorf("results block hash of block %d does not match the archive manifest", entry.Height)
+	}
+	return nil
+}
+
+func readBlock(cr *checksummedReader) (*protocol.BlockPairContainer, error) {
+	header := &blockRecordHeader{}
+	if err := cr.read(header); err != nil {
+		return nil, err
+	}
+
+	var chunks [5][]byte
+	for i := range chunks {
+		chunk, err := cr.readChunk(maxBlockSize)
+		if err != nil {
+			return nil, err
+		}
+		chunks[i] = chunk
+	}
+	block := &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{
+			Header:     protocol.TransactionsBlockHeaderReader(chunks[0]),
+			Metadata:   protocol.TransactionsBlockMetadataReader(chunks[1]),
+			BlockProof: protocol.TransactionsBlockProofReader(chunks[2]),
+		},
+		ResultsBlock: &protocol.ResultsBlockContainer{
+			Header:     protocol.ResultsBlockHeaderReader(chunks[3]),
+			BlockProof: protocol.ResultsBlockProofReader(chunks[4]),
+		},
+	}
+
+	block.TransactionsBlock.SignedTransactions = make([]*protocol.SignedTransaction, 0, header.NumTransactions)
+	for i := uint32(0); i < header.NumTransactions; i++ {
+		chunk, err := cr.readChunk(maxBlockSize)
+		if err != nil {
+			return nil, err
+		}
+		block.TransactionsBlock.SignedTransactions = append(block.TransactionsBlock.SignedTransactions, protocol.SignedTransactionReader(chunk))
+	}
+	block.ResultsBlock.TransactionReceipts = make([]*protocol.TransactionReceipt, 0, header.NumReceipts)
+	for i := uint32(0); i < header.NumReceipts; i++ {
+		chunk, err := cr.readChunk(maxBlockSize)
+		if err != nil {
+			return nil, err
+		}
+		block.ResultsBlock.TransactionReceipts = append(block.ResultsBlock.TransactionReceipts, protocol.TransactionReceiptReader(chunk))
+	}
+	block.ResultsBlock.ContractStateDiffs = make([]*protocol.ContractStateDiff, 0, header.NumStateDiffs)
+	for i := uint32(0); i < header.NumStateDiffs; i++ {
+		chunk, err := cr.readChunk(maxBlockSize)
+		if err != nil {
+			return nil, err
+		}
+		block.ResultsBlock.ContractStateDiffs = append(block.ResultsBlock.ContractStateDiffs, protocol.ContractStateDiffReader(chunk))
+	}
+
+	if err := cr.readChecksum(); err != nil {
+		return nil, err
+	}
+	if primitives.BlockHeight(header.Height) != block.TransactionsBlock.Header.BlockHeight() {
+		return nil, errors.Errorf("block record of height %d holds block %d", header.Height, block.TransactionsBlock.Header.BlockHeight())
+	}
+	return block, nil
+}
+
+// readManifest returns the manifest of an archive and its offset, which is where the last block record ends
+func readManifest(r io.ReaderAt, size int64) (*Manifest, int64, error) {
+	footerSize := int64(binary.Size(archiveFooter{}))
+	headerSize := int64(binary.Size(archiveHeader{}) + 4)
+	if size < headerSize+footerSize {
+		return nil, 0, errors.New("archive is too short")
+	}
+
+	footer := &archiveFooter{}
+	if err := binary.Read(io.NewSectionReader(r, size-footerSize, footerSize), binary.LittleEndian, footer); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read archive footer")
+	}
+	if footer.Magic != archiveMagic || footer.ManifestOffset < uint64(headerSize) || footer.ManifestOffset > uint64(size-footerSize) {
+		return nil, 0, errors.New("invalid archive footer, the archive may be truncated")
+	}
+	manifestOffset := int64(footer.ManifestOffset)
+
+	cr := newChecksummedReader(io.NewSectionReader(r, 0, headerSize), 0)
+	header := &archiveHeader{}
+	if err := cr.read(header); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read archive header")
+	}
+	if err := cr.readChecksum(); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read archive header")
+	}
+	if header.Magic != archiveMagic {
+		return nil, 0, errors.Errorf("invalid archive magic number %v", header.Magic)
+	}
+	if header.FormatVersion != archiveFormatVersion {
+		return nil, 0, errors.Errorf("unsupported archive format version %d", header.FormatVersion)
+	}
+
+	cr = newChecksummedReader(bufio.NewReader(io.NewSectionReader(r, manifestOffset, size-footerSize-manifestOffset)), manifestOffset)
+	var numEntries uint64
+	if err := cr.read(&numEntries); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read archive manifest")
+	}
+	if numEntries > uint64(size)/uint64(binary.Size(manifestEntryRecord{})) {
+		return nil, 0, errors.Errorf("invalid archive manifest of %d blocks", numEntries)
+	}
+
+	manifest := &Manifest{
+		NetworkType:    protocol.SignerNetworkType(header.NetworkType),
+		VirtualChainId: primitives.VirtualChainId(header.VirtualChainId),
+		Entries:        make([]ManifestEntry, 0, numEntries),
+	}
+	prevOffset := headerSize - 1
+	for i := uint64(0); i < numEntries; i++ {
+		record := &manifestEntryRecord{}
+		if err := cr.read(record); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to read archive manifest")
+		}
+		if i == 0 && record.Height == 0 || i > 0 && record.Height != uint64(manifest.LastHeight())+1 {
+			return nil, 0, errors.Errorf("archive manifest lists block %d out of order", record.Height)
+		}
+		if int64(record.Offset) <= prevOffset || int64(record.Offset) >= manifestOffset {
+			return nil, 0, errors.Errorf("archive manifest lists block %d at invalid offset %d", record.Height, record.Offset)
+		}
+		prevOffset = int64(record.Offset)
+		manifest.Entries = append(manifest.Entries, ManifestEntry{
+			Height:                primitives.BlockHeight(record.Height),
+			Offset:                int64(record.Offset),
+			TransactionsBlockHash: append(primitives.Sha256{}, record.TransactionsBlockHash[:]...),
+			ResultsBlockHash:      append(primitives.Sha256{}, record.ResultsBlockHash[:]...),
+		})
+	}
+	if err := cr.readChecksum(); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read archive manifest")
+	}
+	return manifest, manifestOffset, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package blockstorage
+
+import (
+	"context"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+const blockHeaderSubscriptionBufferSize = 100
+
+type SubscribeBlockHeadersInput struct {
+	FromBlockHeight primitives.BlockHeight // zero for the blocks committed from now on
+}
+
+type BlockHeaders struct {
+	TransactionsBlockHeader *protocol.TransactionsBlockHeader
+	ResultsBlockHeader      *protocol.ResultsBlockHeader
+}
+
+// SubscribeBlockHeaders streams the headers of blocks in order of their height as they commit, starting with those of
+// committed blocks if asked to. the stream is closed once the context is done
+func (s *Service) SubscribeBlockHeaders(ctx context.Context, input *SubscribeBlockHeadersInput) (<-chan *BlockHeaders, error) {
+	lastHeight, err := s.persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	next := input.FromBlockHeight
+	if next == 0 {
+		next = lastHeight + 1
+	}
+	if next > lastHeight+1 {
+		return nil, errors.Errorf("block height %d is beyond the next block to commit %d", next, lastHeight+1)
+	}
+
+	headers := make(chan *BlockHeaders, blockHeaderSubscriptionBufferSize)
+	govnr.Once(logfields.GovnrErrorer(s.logger), func() {
+		defer close(headers)
+		for ; ; next++ {
+			if err := s.persistence.GetBlockTracker().WaitForBlock(ctx, next); err != nil {
+				return // the context is done
+			}
+
+			txBlock, err := s.persistence.GetTransactionsBlock(next)
+			if err != nil {
+				s.logger.Info("block header subscription failed reading the transactions block", log.Error(err), logfields.BlockHeight(next))
+				return
+			}
+			rsBlock, err := s.persistence.GetResultsBlock(next)
+			if err != nil {
+				s.logger.Info("block header subscription failed reading the results block", log.Error(err), logfields.BlockHeight(next))
+				return
+			}
+
+			select {
+			case headers <- &BlockHeaders{TransactionsBlockHeader: txBlock.Header, ResultsBlockHeader: rsBlock.Header}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return headers, nil
+}
@@ -81,13 +81,8 @@ func (s *Service) GetResultsBlockHeader(ctx context.Context, input *services.Get
 }
 
 func (s *Service) GetTransactionReceipt(ctx context.Context, input *services.GetTransactionReceiptInput) (*services.GetTransactionReceiptOutput, error) {
-	graceNano := s.config.BlockStorageTransactionReceiptQueryTimestampGrace().Nanoseconds()
-	txExpireNano := s.config.TransactionExpirationWindow().Nanoseconds()
-
-	start := input.TransactionTimestamp - primitives.TimestampNano(graceNano)
-	end := input.TransactionTimestamp + primitives.TimestampNano(graceNano+txExpireNano)
-
-	blockPair, txIdx, err := s.persistence.GetBlockByTx(input.Txhash, start, end)
+	// the transaction timestamp is not needed as committed transactions are indexed by hash
+	blockPair, txIdx, err := s.persistence.GetBlockByTx(input.Txhash)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +80,7 @@ func (s *Service) sourceHandleBlockAvailabilityRequest(ctx context.Context, mess
 
 }
 
-// support for syncing only for block range (1-inOrder)
+// support for syncing only for block range (lowestServed-inOrder), blocks below it were pruned
 func getServerSyncRange(syncState internodesync.SyncState,
 	requestFrom primitives.BlockHeight,
 	requestTo primitives.BlockHeight,
@@ -89,6 +89,7 @@ func getServerSyncRange(syncState internodesync.SyncState,
 ) (responseFrom primitives.BlockHeight, responseTo primitives.BlockHeight, err error) {
 
 	inOrderHeight := getBlockHeight(syncState.InOrderBlock)
+	lowestServedHeight := syncState.LowestServedBlockHeight
 	responseFrom = requestFrom
 	responseTo = requestTo
 
@@ -101,6 +102,10 @@ func getServerSyncRange(syncState internodesync.SyncState,
 			err = fmt.Errorf("server does not hold requested ascending range: from(%d) - to(%d) where storage inOrder blockHeight is (%d)", uint64(requestFrom), uint64(requestTo), uint64(inOrderHeight))
 			return
 		}
+		if requestFrom < lowestServedHeight { // server pruned range beginning
+			err = fmt.Errorf("server does not hold requested ascending range: from(%d) - to(%d) where storage lowest served blockHeight is (%d)", uint64(requestFrom), uint64(requestTo), uint64(lowestServedHeight))
+			return
+		}
 		responseTo = min(requestFrom+batchSize-1, requestTo, inOrderHeight)
 
 	} else if requestSyncBlocksOrder == gossipmessages.SYNC_BLOCKS_ORDER_DESCENDING {
@@ -120,6 +125,13 @@ func getServerSyncRange(syncState internodesync.SyncState,
 		if (responseFrom >= batchSize) && (responseFrom-batchSize+1 > responseTo) {
 			responseTo = responseFrom - batchSize + 1
 		}
+		if responseFrom < lowestServedHeight { // server pruned range
+			err = fmt.Errorf("server does not hold requested descending range: from(%d) - to(%d) where storage lowest served blockHeight is (%d)", uint64(requestFrom), uint64(requestTo), uint64(lowestServedHeight))
+			return
+		}
+		if responseTo < lowestServedHeight {
+			responseTo = lowestServedHeight
+		}
 	}
 	return
 }
@@ -0,0 +1,50 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package blockstorage
+
+import (
+	"github.com/orbs-network/orbs-network-go/services/blockstorage/internodesync"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestServerSyncRange_AscendingRejectsPrunedRange(t *testing.T) {
+	syncState := prunedSyncState(100, 40)
+
+	_, _, err := getServerSyncRange(syncState, 30, 50, gossipmessages.SYNC_BLOCKS_ORDER_ASCENDING, 10)
+	require.Error(t, err, "expected a range beginning below the lowest served block to be rejected")
+
+	from, to, err := getServerSyncRange(syncState, 40, 50, gossipmessages.SYNC_BLOCKS_ORDER_ASCENDING, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 40, from)
+	require.EqualValues(t, 49, to)
+}
+
+func TestServerSyncRange_DescendingStopsAtLowestServedBlock(t *testing.T) {
+	syncState := prunedSyncState(100, 40)
+
+	from, to, err := getServerSyncRange(syncState, 45, 1, gossipmessages.SYNC_BLOCKS_ORDER_DESCENDING, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 45, from)
+	require.EqualValues(t, 40, to, "expected the range to end at the lowest served block")
+
+	_, _, err = getServerSyncRange(syncState, 39, 1, gossipmessages.SYNC_BLOCKS_ORDER_DESCENDING, 10)
+	require.Error(t, err, "expected a range beginning below the lowest served block to be rejected")
+}
+
+func prunedSyncState(inOrderHeight primitives.BlockHeight, lowestServedHeight primitives.BlockHeight) internodesync.SyncState {
+	block := builders.BlockPair().WithHeight(inOrderHeight).Build()
+	return internodesync.SyncState{
+		TopBlock:                block,
+		InOrderBlock:            block,
+		LastSyncedBlock:         block,
+		LowestServedBlockHeight: lowestServedHeight,
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package blockstorage
+
+import (
+	"context"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// a query returns the events of whole blocks, so it may return a few more events than this
+const MaxContractEventsPerQuery = 1000
+
+const contractEventSubscriptionBufferSize = 100
+
+type ContractEventFilter struct {
+	ContractName primitives.ContractName
+	EventName    primitives.EventName // empty for every event of the contract
+}
+
+type ContractEvent struct {
+	BlockHeight         primitives.BlockHeight
+	BlockTimestamp      primitives.TimestampNano
+	Txhash              primitives.Sha256
+	ContractName        primitives.ContractName
+	EventName           primitives.EventName
+	OutputArgumentArray []byte // raw membuffer of protocol.ArgumentArray
+}
+
+type GetContractEventsInput struct {
+	Filter          ContractEventFilter
+	FromBlockHeight primitives.BlockHeight
+	ToBlockHeight   primitives.BlockHeight // zero for the last committed block
+}
+
+type GetContractEventsOutput struct {
+	Events []*ContractEvent
+	// the last block searched, lower than ToBlockHeight once MaxContractEventsPerQuery was reached. the next page of
+	// events starts at the block following it
+	LastBlockHeight primitives.BlockHeight
+}
+
+type SubscribeContractEventsInput struct {
+	Filter          ContractEventFilter
+	FromBlockHeight primitives.BlockHeight // zero for the blocks committed from now on
+}
+
+// GetContractEvents returns the events a contract emitted in a range of committed blocks
+func (s *Service) GetContractEvents(ctx context.Context, input *GetContractEventsInput) (*GetContractEventsOutput, error) {
+	lastHeight, err := s.persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	to := input.ToBlockHeight
+	if to == 0 || to > lastHeight {
+		to = lastHeight
+	}
+	if err := s.validateContractEventsRange(&input.Filter, input.FromBlockHeight, to); err != nil {
+		return nil, err
+	}
+
+	return s.readContractEvents(&input.Filter, input.FromBlockHeight, to)
+}
+
+// SubscribeContractEvents streams the events a contract emits in order of their blocks, starting with those of committed
+// blocks. the stream is closed once the context is done
+func (s *Service) SubscribeContractEvents(ctx context.Context, input *SubscribeContractEventsInput) (<-chan *ContractEvent, error) {
+	lastHeight, err := s.persistence.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	next := input.FromBlockHeight
+	if next == 0 {
+		next = lastHeight + 1
+	}
+	if err := s.validateContractEventsRange(&input.Filter, next, next); err != nil {
+		return nil, err
+	}
+
+	events := make(chan *ContractEvent, contractEventSubscriptionBufferSize)
+	govnr.Once(logfields.GovnrErrorer(s.logger), func() {
+		defer close(events)
+		for {
+			lastHeight, err := s.persistence.GetLastBlockHeight()
+			if err != nil {
+				s.logger.Info("contract event subscription failed reading the last committed block", log.Error(err))
+				return
+			}
+
+			if next > lastHeight {
+				if err := s.persistence.GetBlockTracker().WaitForBlock(ctx, next); err != nil {
+					return // the context is done
+				}
+				continue
+			}
+
+			out, err := s.readContractEvents(&input.Filter, next, lastHeight)
+			if err != nil {
+				s.logger.Info("contract event subscription failed reading events", log.Error(err))
+				return
+			}
+			for _, event := range out.Events {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			next = out.LastBlockHeight + 1
+		}
+	})
+
+	return events, nil
+}
+
+func (s *Service) validateContractEventsRange(filter *ContractEventFilter, from primitives.BlockHeight, to primitives.BlockHeight) error {
+	if filter.ContractName == "" {
+		return errors.New("missing contract name")
+	}
+	if from == 0 || from > to {
+		return errors.Errorf("invalid block range %d-%d", from, to)
+	}
+	if lowest := s.persistence.GetSyncState().LowestServedBlockHeight; from < lowest {
+		return errors.Errorf("blocks below height %d were pruned", lowest)
+	}
+	return nil
+}
+
+// the block persistence indexes the contracts emitting events as it writes blocks, so only blocks holding events of the
+// contract are read
+func (s *Service) readContractEvents(filter *ContractEventFilter, from primitives.BlockHeight, to primitives.BlockHeight) (*GetContractEventsOutput, error) {
+	heights, err := s.persistence.GetContractEventBlockHeights(filter.ContractName, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find blocks holding contract events")
+	}
+
+	output := &GetContractEventsOutput{Events: []*ContractEvent{}, LastBlockHeight: to}
+	for _, height := range heights {
+		if len(output.Events) >= MaxContractEventsPerQuery {
+			output.LastBlockHeight = height - 1
+			break
+		}
+
+		block, err := s.persistence.GetResultsBlock(height)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load results block at height %d", height)
+		}
+		for _, receipt := range block.TransactionReceipts {
+			for events := protocol.EventsArrayReader(receipt.RawOutputEventsArrayWithHeader()).EventsIterator(); events.HasNext(); {
+				event := events.NextEvents()
+				if event.ContractName() != filter.ContractName || (filter.EventName != "" && event.EventName() != filter.EventName) {
+					continue
+				}
+				output.Events = append(output.Events, &ContractEvent{
+					BlockHeight:         height,
+					BlockTimestamp:      block.Header.Timestamp(),
+					Txhash:              receipt.Txhash(),
+					ContractName:        event.ContractName(),
+					EventName:           event.EventName(),
+					OutputArgumentArray: event.RawOutputArgumentArrayWithHeader(),
+				})
+			}
+		}
+	}
+	return output, nil
+}
@@ -27,6 +27,14 @@ import (
 
 var LogTag = log.Service("block-storage")
 
+// BlockStorage is the block storage service of the protocol spec together with the queries this node serves beyond it
+type BlockStorage interface {
+	services.BlockStorage
+	GetContractEvents(ctx context.Context, input *GetContractEventsInput) (*GetContractEventsOutput, error)
+	SubscribeContractEvents(ctx context.Context, input *SubscribeContractEventsInput) (<-chan *ContractEvent, error)
+	SubscribeBlockHeaders(ctx context.Context, input *SubscribeBlockHeadersInput) (<-chan *BlockHeaders, error)
+}
+
 type Service struct {
 	govnr.TreeSupervisor
 	persistence             adapter.BlockPersistence
@@ -44,6 +44,8 @@ type SyncState struct {
 	TopBlock        *protocol.BlockPairContainer
 	InOrderBlock    *protocol.BlockPairContainer
 	LastSyncedBlock *protocol.BlockPairContainer
+	// blocks below this height were pruned and are not served to other nodes, zero when no block was pruned
+	LowestServedBlockHeight primitives.BlockHeight
 }
 
 func (s *SyncState) GetSyncStateBlockHeights() (topHeight primitives.BlockHeight, inOrderHeight primitives.BlockHeight, lastSyncedHeight primitives.BlockHeight) {
@@ -55,7 +57,7 @@ func (s *SyncState) String() string {
 		return "<nil>"
 	}
 	topHeight, inOrderHeight, lastSyncedHeight := s.GetSyncStateBlockHeights()
-	return fmt.Sprintf("{TopBlockHeight:%d,InOrderBlockHeight:%d,LastSyncedBlockHeight:%d}", uint64(topHeight), uint64(inOrderHeight), uint64(lastSyncedHeight))
+	return fmt.Sprintf("{TopBlockHeight:%d,InOrderBlockHeight:%d,LastSyncedBlockHeight:%d,LowestServedBlockHeight:%d}", uint64(topHeight), uint64(inOrderHeight), uint64(lastSyncedHeight), uint64(s.LowestServedBlockHeight))
 }
 
 func getBlockHeight(block *protocol.BlockPairContainer) primitives.BlockHeight {
@@ -64,7 +64,7 @@ func syncOneBlock(ctx context.Context, block *protocol.BlockPairContainer, commi
 	// notify the receiving service of a new block
 	requestedHeight, err := committer.commitBlockPair(ctx, block)
 	if err != nil {
-		panic(fmt.Sprintf("failed committing block at height %d", h))
+		panic(fmt.Sprintf("failed committing block at height %d, err=%s", h, err.Error()))
 	}
 	// if receiving service keep requesting the current height we are stuck
 	if h == requestedHeight {
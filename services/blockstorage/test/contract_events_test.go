@@ -0,0 +1,117 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetContractEvents_FindsEventsOfStoredAndCommittedBlocks(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		harness := newBlockStorageHarness(parent).
+			withSyncBroadcast(1).
+			expectValidateConsensusAlgos()
+		_, _, err := harness.storageAdapter.WriteNextBlock(blockWithEvents(t, 1, event(t, "Token", "Transfer", 10)))
+		require.NoError(t, err)
+		_, _, err = harness.storageAdapter.WriteNextBlock(blockWithEvents(t, 2, event(t, "Other", "Transfer", 20)))
+		require.NoError(t, err)
+		harness.start(ctx)
+
+		_, err = harness.commitBlock(ctx, blockWithEvents(t, 3, event(t, "Token", "Approval", 30), event(t, "Token", "Transfer", 40)))
+		require.NoError(t, err)
+
+		out, err := harness.blockStorage.GetContractEvents(ctx, &blockstorage.GetContractEventsInput{
+			Filter:          blockstorage.ContractEventFilter{ContractName: "Token", EventName: "Transfer"},
+			FromBlockHeight: 1,
+		})
+		require.NoError(t, err)
+
+		require.Len(t, out.Events, 2, "should find the transfers of both blocks")
+		require.EqualValues(t, 1, out.Events[0].BlockHeight)
+		require.EqualValues(t, 3, out.Events[1].BlockHeight)
+		require.EqualValues(t, "Transfer", out.Events[1].EventName)
+		require.EqualValues(t, 40, protocol.ArgumentArrayReader(out.Events[1].OutputArgumentArray).ArgumentsIterator().NextArguments().Uint64Value())
+		require.EqualValues(t, 3, out.LastBlockHeight, "should search up to the last committed block")
+	})
+}
+
+func TestGetContractEvents_RejectsInvalidRange(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		harness := newBlockStorageHarness(parent).
+			withSyncBroadcast(1).
+			expectValidateConsensusAlgos().
+			start(ctx)
+		_, err := harness.commitBlock(ctx, blockWithEvents(t, 1, event(t, "Token", "Transfer", 10)))
+		require.NoError(t, err)
+
+		_, err = harness.blockStorage.GetContractEvents(ctx, &blockstorage.GetContractEventsInput{
+			Filter:          blockstorage.ContractEventFilter{ContractName: "Token"},
+			FromBlockHeight: 2,
+		})
+		require.EqualError(t, err, "invalid block range 2-1", "a range beyond the last committed block was searched")
+
+		_, err = harness.blockStorage.GetContractEvents(ctx, &blockstorage.GetContractEventsInput{FromBlockHeight: 1})
+		require.Error(t, err, "events were searched without a contract name")
+	})
+}
+
+func TestSubscribeContractEvents_StreamsEventsOfCommittedBlocksInOrder(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		harness := newBlockStorageHarness(parent).
+			withSyncBroadcast(1).
+			expectValidateConsensusAlgos().
+			start(ctx)
+		_, err := harness.commitBlock(ctx, blockWithEvents(t, 1, event(t, "Token", "Transfer", 10)))
+		require.NoError(t, err)
+
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		events, err := harness.blockStorage.SubscribeContractEvents(subscriptionCtx, &blockstorage.SubscribeContractEventsInput{
+			Filter: blockstorage.ContractEventFilter{ContractName: "Token"},
+		})
+		require.NoError(t, err)
+
+		_, err = harness.commitBlock(ctx, blockWithEvents(t, 2, event(t, "Other", "Transfer", 20)))
+		require.NoError(t, err)
+		_, err = harness.commitBlock(ctx, blockWithEvents(t, 3, event(t, "Token", "Approval", 30)))
+		require.NoError(t, err)
+
+		select {
+		case e := <-events:
+			require.EqualValues(t, 3, e.BlockHeight, "should stream only events of blocks committed since subscribing")
+			require.EqualValues(t, "Approval", e.EventName)
+		case <-time.After(test.EVENTUALLY_ACCEPTANCE_TIMEOUT):
+			t.Fatal("event of committed block was not streamed")
+		}
+
+		cancel()
+		require.True(t, test.Eventually(test.EVENTUALLY_ACCEPTANCE_TIMEOUT, func() bool {
+			_, open := <-events
+			return !open
+		}), "stream was not closed once the subscription ended")
+	})
+}
+
+func event(t *testing.T, contract primitives.ContractName, eventName primitives.EventName, arg uint64) *protocol.EventBuilder {
+	builder, err := builders.EventBuilder(contract, eventName, arg)
+	require.NoError(t, err)
+	return builder
+}
+
+func blockWithEvents(t *testing.T, height primitives.BlockHeight, events ...*protocol.EventBuilder) *protocol.BlockPairContainer {
+	receipt := builders.TransactionReceipt().Builder()
+	receipt.OutputEventsArray = builders.PackedEventsArrayEncode(events...)
+	return builders.BlockPair().WithHeight(height).WithReceipt(receipt.Build()).WithBlockCreated(time.Now()).Build()
+}
@@ -8,12 +8,15 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestReturnTransactionBlockHeader(t *testing.T) {
@@ -179,3 +182,51 @@ func TestReturnResultsBlockHeaderFromNearFutureFailsWhenContextEnds(t *testing.T
 		require.EqualError(t, err, "aborted while waiting for block at height 5: context canceled", "expect a timeout as the requested block height never reached")
 	})
 }
+
+func TestSubscribeBlockHeaders_StreamsCommittedBlocksThenNewOnesInOrder(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		harness := newBlockStorageHarness(parent).
+			withSyncBroadcast(1).
+			expectValidateConsensusAlgos().
+			start(ctx)
+		_, err := harness.commitBlock(ctx, builders.BlockPair().WithHeight(1).Build())
+		require.NoError(t, err)
+
+		subscriptionCtx, cancel := context.WithCancel(ctx)
+		headers, err := harness.blockStorage.SubscribeBlockHeaders(subscriptionCtx, &blockstorage.SubscribeBlockHeadersInput{FromBlockHeight: 1})
+		require.NoError(t, err)
+
+		_, err = harness.commitBlock(ctx, builders.BlockPair().WithHeight(2).Build())
+		require.NoError(t, err)
+
+		for _, height := range []primitives.BlockHeight{1, 2} {
+			select {
+			case h := <-headers:
+				require.EqualValues(t, height, h.TransactionsBlockHeader.BlockHeight(), "headers should stream in order of height")
+				require.EqualValues(t, height, h.ResultsBlockHeader.BlockHeight())
+			case <-time.After(test.EVENTUALLY_ACCEPTANCE_TIMEOUT):
+				t.Fatalf("header of block %d was not streamed", height)
+			}
+		}
+
+		cancel()
+		require.True(t, test.Eventually(test.EVENTUALLY_ACCEPTANCE_TIMEOUT, func() bool {
+			_, open := <-headers
+			return !open
+		}), "stream was not closed once the subscription ended")
+	})
+}
+
+func TestSubscribeBlockHeaders_RejectsHeightBeyondNextBlock(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		harness := newBlockStorageHarness(parent).
+			withSyncBroadcast(1).
+			expectValidateConsensusAlgos().
+			start(ctx)
+
+		headers, err := harness.blockStorage.SubscribeBlockHeaders(ctx, &blockstorage.SubscribeBlockHeadersInput{FromBlockHeight: 3})
+
+		require.Error(t, err, "subscription beyond the next block to commit was accepted")
+		require.Nil(t, headers)
+	})
+}
@@ -39,7 +39,7 @@ type configForBlockStorageTests struct {
 	committeeGracePeriod  time.Duration
 	syncBlocksOrder       gossipmessages.SyncBlocksOrder
 	descendingEnabled     bool
-	queryGrace            time.Duration
+	futureGrace           time.Duration
 	queryExpirationWindow time.Duration
 	blockTrackerGrace     time.Duration
 }
@@ -76,8 +76,8 @@ func (c *configForBlockStorageTests) BlockSyncBlocksOrder() gossipmessages.SyncB
 	return c.syncBlocksOrder
 }
 
-func (c *configForBlockStorageTests) BlockStorageTransactionReceiptQueryTimestampGrace() time.Duration {
-	return c.queryGrace
+func (c *configForBlockStorageTests) TransactionPoolFutureTimestampGraceTimeout() time.Duration {
+	return c.futureGrace
 }
 
 func (c *configForBlockStorageTests) TransactionExpirationWindow() time.Duration {
@@ -208,11 +208,6 @@ func (d *harness) withBatchSize(size uint32) *harness {
 	return d
 }
 
-func (d *harness) withBlockStorageTransactionReceiptQueryTimestampGrace(value time.Duration) *harness {
-	d.config.queryGrace = value
-	return d
-}
-
 func (d *harness) withTransactionExpirationWindow(value time.Duration) *harness {
 	d.config.queryExpirationWindow = value
 	return d
@@ -253,7 +248,7 @@ func createConfig(nodeAddress primitives.NodeAddress) *configForBlockStorageTest
 	cfg.descendingEnabled = true
 	cfg.committeeGracePeriod = 1 * time.Minute
 
-	cfg.queryGrace = 5 * time.Second
+	cfg.futureGrace = 5 * time.Second
 	cfg.queryExpirationWindow = 30 * time.Minute
 	cfg.blockTrackerGrace = 1 * time.Hour
 
@@ -50,7 +50,7 @@ func TestReturnTransactionReceipt(t *testing.T) {
 			withValidateConsensusAlgos(1).
 			start(ctx)
 
-		txQueryGrace := harness.config.BlockStorageTransactionReceiptQueryTimestampGrace()
+		txQueryGrace := harness.config.TransactionPoolFutureTimestampGraceTimeout()
 		txExpirationWnd := harness.config.TransactionExpirationWindow()
 
 		// block1: txs with current time but block timestamps in the past before grace
@@ -75,12 +75,12 @@ func TestReturnTransactionReceipt(t *testing.T) {
 		block5 := builders.BlockPair().WithHeight(5).WithTransactions(10).WithReceiptsForTransactions().WithTimestampAheadBy(txExpirationWnd + txQueryGrace + 1).Build()
 		harness.commitBlock(ctx, block5)
 
+		// transactions are found by hash regardless of the timestamps of their blocks
+		requireTransactionFoundInBlock(ctx, t, harness, block1)
 		requireTransactionFoundInBlock(ctx, t, harness, block2)
 		requireTransactionFoundInBlock(ctx, t, harness, block3)
 		requireTransactionFoundInBlock(ctx, t, harness, block4)
-
-		requireTransactionNotFoundInBlock(ctx, t, harness, block1, block5)
-		requireTransactionNotFoundInBlock(ctx, t, harness, block5, block5)
+		requireTransactionFoundInBlock(ctx, t, harness, block5)
 	})
 }
 
@@ -94,15 +94,6 @@ func requireTransactionFoundInBlock(ctx context.Context, t *testing.T, harness *
 	require.EqualValues(t, block.ResultsBlock.Header.Timestamp(), out.BlockTimestamp, "receipt should have the timestamp of the block containing the transaction")
 }
 
-func requireTransactionNotFoundInBlock(ctx context.Context, t *testing.T, harness *harness, block *protocol.BlockPairContainer, lastCommittedBlock *protocol.BlockPairContainer) {
-	_, out, err := searchForTx(block.TransactionsBlock.SignedTransactions[3].Transaction(), harness, ctx)
-
-	require.NoError(t, err, "receipt should be found in this flow")
-	require.Nil(t, out.TransactionReceipt, "receipt should not be found in this flow")
-	require.EqualValues(t, lastCommittedBlock.ResultsBlock.Header.BlockHeight(), out.BlockHeight, "result should have the currently top committed block height")
-	require.EqualValues(t, lastCommittedBlock.ResultsBlock.Header.Timestamp(), out.BlockTimestamp, "result should have the timestamp of the currently top committed block")
-}
-
 func searchForTx(tx *protocol.Transaction, harness *harness, ctx context.Context) (primitives.Sha256, *services.GetTransactionReceiptOutput, error) {
 	// taking a transaction at 'random' (they were created at random)
 	txHash := digest.CalcTxHash(tx)
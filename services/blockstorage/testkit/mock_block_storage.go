@@ -0,0 +1,44 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package testkit
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+)
+
+type MockBlockStorage struct {
+	services.MockBlockStorage
+}
+
+func (s *MockBlockStorage) GetContractEvents(ctx context.Context, input *blockstorage.GetContractEventsInput) (*blockstorage.GetContractEventsOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*blockstorage.GetContractEventsOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockBlockStorage) SubscribeContractEvents(ctx context.Context, input *blockstorage.SubscribeContractEventsInput) (<-chan *blockstorage.ContractEvent, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(<-chan *blockstorage.ContractEvent), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockBlockStorage) SubscribeBlockHeaders(ctx context.Context, input *blockstorage.SubscribeBlockHeadersInput) (<-chan *blockstorage.BlockHeaders, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(<-chan *blockstorage.BlockHeaders), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
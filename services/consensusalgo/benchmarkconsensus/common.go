@@ -90,17 +90,22 @@ func (s *Service) validateBlockConsensus(blockPair *protocol.BlockPairContainer,
 		}
 	}
 
-	// block proof
+	return VerifyBlockProof(blockPair, s.config.BenchmarkConsensusConstantLeader())
+}
+
+// VerifyBlockProof checks that the proof of a committed block is signed by the constant leader, used to verify blocks
+// read back from storage without a running consensus algo
+func VerifyBlockProof(blockPair *protocol.BlockPairContainer, constantLeader primitives.NodeAddress) error {
 	blockProof := blockPair.ResultsBlock.BlockProof.BenchmarkConsensus()
 	signersIterator := blockProof.NodesIterator()
 	if !signersIterator.HasNext() {
 		return errors.New("BenchmarkConsensus: block proof not signed")
 	}
 	signer := signersIterator.NextNodes()
-	if !signer.SenderNodeAddress().Equal(s.config.BenchmarkConsensusConstantLeader()) {
+	if !signer.SenderNodeAddress().Equal(constantLeader) {
 		return errors.Errorf("BenchmarkConsensus: block proof not from leader: %s", signer.SenderNodeAddress())
 	}
-	signedData := s.signedDataForBlockProof(blockPair)
+	signedData := signedDataForBlockProof(blockPair)
 	if err := ethereumDigest.VerifyNodeSignature(signer.SenderNodeAddress(), signedData, signer.Signature()); err != nil {
 		return errors.Wrapf(err, "BenchmarkConsensus: block proof signature is invalid: %s", signer.Signature())
 	}
@@ -108,7 +113,7 @@ func (s *Service) validateBlockConsensus(blockPair *protocol.BlockPairContainer,
 	return nil
 }
 
-func (s *Service) signedDataForBlockProof(blockPair *protocol.BlockPairContainer) []byte {
+func signedDataForBlockProof(blockPair *protocol.BlockPairContainer) []byte {
 	return (&consensus.BenchmarkConsensusBlockRefBuilder{
 		PlaceholderType: consensus.BENCHMARK_CONSENSUS_VALID,
 		BlockHeight:     blockPair.TransactionsBlock.Header.BlockHeight(),
@@ -167,7 +167,7 @@ func (s *Service) leaderSignBlockProposal(ctx context.Context, transactionsBlock
 	}
 
 	// prepare signature over the block headers
-	signedData := s.signedDataForBlockProof(blockPair)
+	signedData := signedDataForBlockProof(blockPair)
 	sig, err := s.signer.Sign(ctx, signedData)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,86 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package leanhelixconsensus
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/validators"
+	"github.com/orbs-network/lean-helix-go/services/proofsvalidator"
+	"github.com/orbs-network/lean-helix-go/services/quorum"
+	"github.com/orbs-network/lean-helix-go/services/randomseed"
+	lhprimitives "github.com/orbs-network/lean-helix-go/spec/types/go/primitives"
+	lhprotocol "github.com/orbs-network/lean-helix-go/spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// VerifyBlockProof checks the proof of a committed block without a running consensus algo, used to verify blocks read
+// back from storage: the proof must commit to the hash of the block, be signed by a quorum of the given committee and
+// carry the random seed derived from the proof of the previous block.
+// the block contents are not checked against the merkle roots in its headers, as those are dropped from pruned blocks
+func VerifyBlockProof(logger log.Logger, instanceId lhprimitives.InstanceId, blockPair *protocol.BlockPairContainer, prevBlockPair *protocol.BlockPairContainer, committee []primitives.NodeAddress, weights []primitives.Weight) error {
+	if err := validLeanHelixBlockPair(blockPair); err != nil {
+		return err
+	}
+
+	blockProof := lhprotocol.BlockProofReader(blockPair.TransactionsBlock.BlockProof.LeanHelix())
+	blockRef := blockProof.BlockRef()
+	if blockRef.MessageType() != lhprotocol.LEAN_HELIX_COMMIT {
+		return errors.Errorf("LeanHelix: block proof is not a COMMIT, it is %v", blockRef.MessageType())
+	}
+	if blockRef.InstanceId() != instanceId {
+		return errors.Errorf("LeanHelix: mismatched instance id: expected=%v blockProof=%v", instanceId, blockRef.InstanceId())
+	}
+	blockHeight := lhprimitives.BlockHeight(getBlockHeight(blockPair))
+	if blockRef.BlockHeight() != blockHeight {
+		return errors.Errorf("LeanHelix: mismatched height: blockHeight=%v blockProof=%v", blockHeight, blockRef.BlockHeight())
+	}
+	err := validators.ValidateBlockHash(&validators.BlockValidatorContext{
+		TransactionsBlock: blockPair.TransactionsBlock,
+		ResultsBlock:      blockPair.ResultsBlock,
+		ExpectedBlockHash: primitives.Sha256(blockRef.BlockHash()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "LeanHelix: block proof does not commit to the block")
+	}
+
+	keyManager := NewKeyManager(logger, nil)
+	committeeMembers := toMembers(committee, weights)
+	signed := make(map[string]bool)
+	var senderIds []lhprimitives.MemberId
+	for senders := blockProof.NodesIterator(); senders.HasNext(); {
+		sender := senders.NextNodes()
+		if err := proofsvalidator.VerifyBlockRefMessage(blockRef, sender, keyManager); err != nil {
+			return errors.Wrap(err, "LeanHelix: invalid block proof signature")
+		}
+		memberId := sender.MemberId()
+		if signed[memberId.KeyForMap()] {
+			return errors.Errorf("LeanHelix: block proof signed more than once by %s", memberId)
+		}
+		if !proofsvalidator.IsInMembers(committeeMembers, memberId) {
+			return errors.Errorf("LeanHelix: block proof signed by %s which is not in the committee: %s", memberId, toMembersString(committee, weights))
+		}
+		signed[memberId.KeyForMap()] = true
+		senderIds = append(senderIds, memberId)
+	}
+	if isQuorum, sendersTotalWeight, q := quorum.IsQuorum(senderIds, committeeMembers); !isQuorum {
+		return errors.Errorf("LeanHelix: block proof signed by weight %d which is less than quorum %d", sendersTotalWeight, q)
+	}
+
+	if len(blockProof.RandomSeedSignature()) == 0 {
+		return errors.New("LeanHelix: block proof does not contain a random seed")
+	}
+	var prevBlockProof primitives.LeanHelixBlockProof
+	if prevBlockPair != nil && prevBlockPair.TransactionsBlock.BlockProof != nil {
+		prevBlockProof = prevBlockPair.TransactionsBlock.BlockProof.LeanHelix()
+	}
+	if err := randomseed.ValidateRandomSeed(keyManager, blockHeight, blockProof, lhprotocol.BlockProofReader(prevBlockProof)); err != nil {
+		return errors.Wrap(err, "LeanHelix: invalid random seed")
+	}
+	return nil
+}
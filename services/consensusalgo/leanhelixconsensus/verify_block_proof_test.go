@@ -0,0 +1,90 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package leanhelixconsensus
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	ethereumDigest "github.com/orbs-network/crypto-lib-go/crypto/ethereum/digest"
+	lhprimitives "github.com/orbs-network/lean-helix-go/spec/types/go/primitives"
+	lhprotocol "github.com/orbs-network/lean-helix-go/spec/types/go/protocol"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var verifyProofInstanceId = CalcInstanceId(protocol.NETWORK_TYPE_TEST_NET, 42)
+
+func TestVerifyBlockProof_AcceptsProofSignedByQuorumOfCommittee(t *testing.T) {
+	committee, weights := testKeys.NodeAddressesForTests()[:4], []primitives.Weight{1, 1, 1, 1}
+	prevBlock := blockWithLeanHelixProof(t, 1, nil, 0, 1, 2)
+	block := blockWithLeanHelixProof(t, 2, prevBlock, 1, 2, 3)
+
+	require.NoError(t, VerifyBlockProof(log.DefaultTestingLogger(t), verifyProofInstanceId, prevBlock, nil, committee, weights))
+	require.NoError(t, VerifyBlockProof(log.DefaultTestingLogger(t), verifyProofInstanceId, block, prevBlock, committee, weights))
+}
+
+func TestVerifyBlockProof_RejectsProofWithoutQuorum(t *testing.T) {
+	committee, weights := testKeys.NodeAddressesForTests()[:4], []primitives.Weight{1, 1, 1, 1}
+	block := blockWithLeanHelixProof(t, 1, nil, 0, 1)
+
+	require.Error(t, VerifyBlockProof(log.DefaultTestingLogger(t), verifyProofInstanceId, block, nil, committee, weights))
+}
+
+func TestVerifyBlockProof_RejectsProofSignedOutsideCommittee(t *testing.T) {
+	committee, weights := testKeys.NodeAddressesForTests()[:4], []primitives.Weight{1, 1, 1, 1}
+	block := blockWithLeanHelixProof(t, 1, nil, 0, 1, 4)
+
+	require.Error(t, VerifyBlockProof(log.DefaultTestingLogger(t), verifyProofInstanceId, block, nil, committee, weights))
+}
+
+func TestVerifyBlockProof_RejectsProofOfAnotherBlock(t *testing.T) {
+	committee, weights := testKeys.NodeAddressesForTests()[:4], []primitives.Weight{1, 1, 1, 1}
+	block := blockWithLeanHelixProof(t, 1, nil, 0, 1, 2)
+	block.ResultsBlock.Header.MutateTimestamp(block.ResultsBlock.Header.Timestamp() + 1)
+
+	require.Error(t, VerifyBlockProof(log.DefaultTestingLogger(t), verifyProofInstanceId, block, nil, committee, weights))
+}
+
+// blockWithLeanHelixProof builds a block committed by the test nodes of the given indexes
+func blockWithLeanHelixProof(t *testing.T, height primitives.BlockHeight, prevBlock *protocol.BlockPairContainer, signers ...int) *protocol.BlockPairContainer {
+	builder := builders.BlockPair().WithHeight(height)
+	if prevBlock != nil {
+		builder.WithPrevBlock(prevBlock)
+	}
+	block := builder.Build()
+
+	blockRef := &lhprotocol.BlockRefBuilder{
+		InstanceId:  verifyProofInstanceId,
+		MessageType: lhprotocol.LEAN_HELIX_COMMIT,
+		BlockHeight: lhprimitives.BlockHeight(height),
+		View:        0,
+		BlockHash:   lhprimitives.BlockHash(digest.CalcBlockHash(block.TransactionsBlock, block.ResultsBlock)),
+	}
+	var nodes []*lhprotocol.SenderSignatureBuilder
+	for _, i := range signers {
+		keyPair := testKeys.EcdsaSecp256K1KeyPairForTests(i)
+		sig, err := ethereumDigest.SignAsNode(keyPair.PrivateKey(), blockRef.Build().Raw())
+		require.NoError(t, err)
+		nodes = append(nodes, &lhprotocol.SenderSignatureBuilder{
+			MemberId:  lhprimitives.MemberId(keyPair.NodeAddress()),
+			Signature: lhprimitives.Signature(sig),
+		})
+	}
+	blockProof := (&lhprotocol.BlockProofBuilder{
+		BlockRef:            blockRef,
+		Nodes:               nodes,
+		RandomSeedSignature: NewKeyManager(nil, nil).AggregateRandomSeed(lhprimitives.BlockHeight(height), nil),
+	}).Build().Raw()
+
+	block.TransactionsBlock.BlockProof = CreateTransactionBlockProof(block, blockProof)
+	block.ResultsBlock.BlockProof = CreateResultsBlockProof(block, blockProof)
+	return block
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	quicgo "github.com/quic-go/quic-go"
+	"sync"
+	"time"
+)
+
+// messages of every topic are sent on a quic stream of their own by a sender of their own, so a topic whose packets
+// are lost or that is held back by flow control doesn't stall the others the way a single tcp connection would
+var topics = []gossipmessages.HeaderTopic{
+	gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY,
+	gossipmessages.HEADER_TOPIC_BLOCK_SYNC,
+	gossipmessages.HEADER_TOPIC_LEAN_HELIX,
+	gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS,
+}
+
+// data sent in the first flight of a resumed connection (0-RTT) is not protected against replay by tls, so only topics
+// whose messages are idempotent are sent before the handshake completes: a relayed transaction or withdrawal is applied
+// to the pool once however many times it arrives, a block sync request is answered again and a block already committed
+// is ignored. consensus messages wait for the handshake
+var replaySafeTopics = map[gossipmessages.HeaderTopic]bool{
+	gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY: true,
+	gossipmessages.HEADER_TOPIC_BLOCK_SYNC:        true,
+}
+
+type outgoingPeerMetrics struct {
+	dialErrors        *metric.Gauge
+	sendErrors        *metric.Gauge
+	sendQueueErrors   *metric.Gauge
+	activeCount       *metric.Gauge
+	resumedReconnects *metric.Gauge
+	zeroRttReconnects *metric.Gauge
+}
+
+func createOutgoingPeerMetrics(registry metric.Registry) *outgoingPeerMetrics {
+	return &outgoingPeerMetrics{
+		dialErrors:        registry.NewGauge("Gossip.Quic.OutgoingConnection.DialErrors.Count"),
+		sendErrors:        registry.NewGauge("Gossip.Quic.OutgoingConnection.SendErrors.Count"),
+		sendQueueErrors:   registry.NewGauge("Gossip.Quic.OutgoingConnection.SendQueueErrors.Count"),
+		activeCount:       registry.NewGauge("Gossip.Quic.OutgoingConnection.Active.Count"),
+		resumedReconnects: registry.NewGauge("Gossip.Quic.OutgoingConnection.ResumedReconnects.Count"),
+		zeroRttReconnects: registry.NewGauge("Gossip.Quic.OutgoingConnection.ZeroRttReconnects.Count"),
+	}
+}
+
+type outgoingPeer struct {
+	logger         log.Logger
+	config         timingsConfig
+	metrics        *outgoingPeerMetrics
+	networkAddress string
+	peerHexAddress string
+	tlsConfig      *tls.Config
+	quicConfig     *quicgo.Config
+
+	queues map[gossipmessages.HeaderTopic]chan *adapter.TransportData
+
+	sync.RWMutex
+	conn quicgo.EarlyConnection // nil while disconnected
+
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+func newOutgoingPeer(peer adapter.TransportPeer, parentLogger log.Logger, metrics *outgoingPeerMetrics, config timingsConfig, tlsConfig *tls.Config, quicConfig *quicgo.Config) *outgoingPeer {
+	networkAddress := fmt.Sprintf("%s:%d", peer.Endpoint(), peer.Port())
+	peerHexAddress := peer.HexOrbsAddress()
+
+	p := &outgoingPeer{
+		logger:         parentLogger.WithTags(log.String("peer-node-address", peerHexAddress[:6]), log.String("peer-network-address", networkAddress)),
+		config:         config,
+		metrics:        metrics,
+		networkAddress: networkAddress,
+		peerHexAddress: peerHexAddress,
+		tlsConfig:      tlsConfig,
+		quicConfig:     quicConfig,
+		queues:         make(map[gossipmessages.HeaderTopic]chan *adapter.TransportData),
+	}
+	for _, topic := range topics {
+		p.queues[topic] = make(chan *adapter.TransportData, tcp.SEND_QUEUE_MAX_MESSAGES)
+	}
+	return p
+}
+
+func topicOf(data *adapter.TransportData) gossipmessages.HeaderTopic {
+	if len(data.Payloads) > 0 {
+		if header := gossipmessages.HeaderReader(data.Payloads[0]); header.IsValid() {
+			if _, found := topicNames[header.Topic()]; found {
+				return header.Topic()
+			}
+		}
+	}
+	return gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY
+}
+
+var topicNames = map[gossipmessages.HeaderTopic]string{
+	gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY:   "TransactionRelay",
+	gossipmessages.HEADER_TOPIC_BLOCK_SYNC:          "BlockSync",
+	gossipmessages.HEADER_TOPIC_LEAN_HELIX:          "LeanHelix",
+	gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS: "BenchmarkConsensus",
+}
+
+func (p *outgoingPeer) connect(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	p.cancel = cancel
+
+	handle := govnr.Forever(ctx, fmt.Sprintf("QUIC client for %s", p.peerHexAddress), logfields.GovnrErrorer(p.logger), func() {
+		p.connectionMainLoop(ctx)
+	})
+	p.closed = handle.Done()
+	handle.MarkSupervised()
+
+	for _, topic := range topics {
+		topic := topic
+		govnr.Forever(ctx, fmt.Sprintf("QUIC %s sender for %s", topicNames[topic], p.peerHexAddress), logfields.GovnrErrorer(p.logger), func() {
+			p.sendLoop(ctx, topic)
+		}).MarkSupervised()
+	}
+}
+
+func (p *outgoingPeer) disconnect() chan struct{} {
+	p.cancel()
+	return p.closed
+}
+
+func (p *outgoingPeer) connectionMainLoop(ctx context.Context) {
+	for ctx.Err() == nil {
+		dialCtx, cancelDial := context.WithTimeout(ctx, p.config.GossipNetworkTimeout())
+		conn, err := quicgo.DialAddrEarly(dialCtx, p.networkAddress, p.tlsConfig, p.quicConfig)
+		cancelDial()
+		if err != nil {
+			p.metrics.dialErrors.Inc()
+			p.logger.Info("cannot connect to gossip peer endpoint", log.Error(err))
+			time.Sleep(p.config.GossipReconnectInterval())
+			continue
+		}
+
+		p.handleConnection(ctx, conn)
+	}
+}
+
+// a connection that was lost is reconnected immediately, resuming the tls session to save a round trip, and is used
+// before its handshake completes to send the messages of replay safe topics in 0-RTT
+func (p *outgoingPeer) handleConnection(ctx context.Context, conn quicgo.EarlyConnection) {
+	p.setConnection(conn)
+	defer p.setConnection(nil)
+
+	p.metrics.activeCount.Inc()
+	defer p.metrics.activeCount.Dec()
+
+	handshakeComplete := conn.HandshakeComplete()
+	for {
+		select {
+		case <-handshakeComplete:
+			handshakeComplete = nil
+			p.handshakeCompleted(conn)
+		case <-conn.Context().Done():
+			p.logger.Info("gossip peer connection closed, reconnecting", log.Error(context.Cause(conn.Context())))
+			return
+		case <-ctx.Done():
+			_ = conn.CloseWithError(0, "disconnecting")
+			return
+		}
+	}
+}
+
+// streams opened in 0-RTT fail if the peer rejected the early data, dropping their messages the way messages are
+// dropped while the peer is disconnected, and new streams are opened only once the connection is switched to 1-RTT
+func (p *outgoingPeer) handshakeCompleted(conn quicgo.EarlyConnection) {
+	if conn.Context().Err() != nil {
+		return // the handshake failed
+	}
+
+	state := conn.ConnectionState()
+	if state.TLS.DidResume {
+		p.metrics.resumedReconnects.Inc()
+	}
+	if state.Used0RTT {
+		p.metrics.zeroRttReconnects.Inc()
+	} else {
+		conn.NextConnection()
+	}
+	p.logger.Info("successful outgoing gossip transport connection", log.Stringable("local-address", conn.LocalAddr()))
+}
+
+func (p *outgoingPeer) setConnection(conn quicgo.EarlyConnection) {
+	p.Lock()
+	defer p.Unlock()
+	p.conn = conn
+}
+
+func (p *outgoingPeer) connection() quicgo.EarlyConnection {
+	p.RLock()
+	defer p.RUnlock()
+	return p.conn
+}
+
+func (p *outgoingPeer) isConnected() bool {
+	return p.connection() != nil
+}
+
+func (p *outgoingPeer) sendLoop(ctx context.Context, topic gossipmessages.HeaderTopic) {
+	var stream quicgo.SendStream
+	var streamConn quicgo.EarlyConnection
+
+	for {
+		var data *adapter.TransportData
+		select {
+		case data = <-p.queues[topic]:
+		case <-ctx.Done():
+			return
+		}
+
+		conn := p.connection()
+		if conn == nil {
+			continue // messages aren't held for a peer that is not connected
+		}
+		if conn != streamConn {
+			stream, streamConn = nil, conn
+		}
+
+		if stream == nil && !replaySafeTopics[topic] && !awaitHandshake(ctx, conn) {
+			continue // the connection was lost before its handshake completed
+		}
+
+		var err error
+		if stream == nil {
+			stream, err = conn.OpenUniStream()
+		}
+		if err == nil {
+			err = p.sendToStream(stream, data)
+		}
+		if err != nil {
+			if stream != nil {
+				stream.CancelWrite(0)
+				stream = nil
+			}
+			p.metrics.sendErrors.Inc()
+			p.logger.Info("failed sending transport data", log.Error(err), log.String("topic", topicNames[topic]))
+		}
+	}
+}
+
+func awaitHandshake(ctx context.Context, conn quicgo.EarlyConnection) bool {
+	select {
+	case <-conn.HandshakeComplete():
+		return conn.Context().Err() == nil
+	case <-conn.Context().Done():
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *outgoingPeer) sendToStream(stream quicgo.SendStream, data *adapter.TransportData) error {
+	if err := stream.SetWriteDeadline(time.Now().Add(p.config.GossipNetworkTimeout())); err != nil {
+		return err
+	}
+	_, err := stream.Write(tcp.EncodeFrame(data.Payloads, false))
+	return err
+}
+
+func (p *outgoingPeer) addDataToOutgoingPeerQueue(ctx context.Context, data *adapter.TransportData) {
+	select {
+	case p.queues[topicOf(data)] <- data:
+	default:
+		p.metrics.sendQueueErrors.Inc()
+		p.logger.Info("quic transport send queue error", log.Error(errors.Errorf("queue of topic %s is full", topicNames[topicOf(data)])), trace.LogFieldFrom(ctx))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package quic
+
+import (
+	"context"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
+	"github.com/orbs-network/scribe/log"
+	quicgo "github.com/quic-go/quic-go"
+	"io"
+	"net"
+	"sync"
+)
+
+type server struct {
+	sync.RWMutex
+	listener adapter.TransportListener
+
+	netListener *quicgo.EarlyListener
+	logger      log.Logger
+	metrics     incomingConnectionMetrics
+}
+
+type incomingConnectionMetrics struct {
+	acceptErrors      *metric.Gauge
+	handshakeErrors   *metric.Gauge
+	transportErrors   *metric.Gauge
+	activeConnections *metric.Gauge
+}
+
+func createServerMetrics(registry metric.Registry) incomingConnectionMetrics {
+	return incomingConnectionMetrics{
+		acceptErrors:      registry.NewGauge("Gossip.Quic.IncomingConnection.AcceptErrors.Count"),
+		handshakeErrors:   registry.NewGauge("Gossip.Quic.IncomingConnection.HandshakeErrors.Count"),
+		transportErrors:   registry.NewGauge("Gossip.Quic.IncomingConnection.TransportErrors.Count"),
+		activeConnections: registry.NewGauge("Gossip.Quic.IncomingConnection.Active.Count"),
+	}
+}
+
+func (s *server) getPort() int {
+	return s.netListener.Addr().(*net.UDPAddr).Port
+}
+
+func (s *server) mainLoop(parentCtx context.Context) {
+	ctx := trace.NewContext(parentCtx, "Gossip.Transport.QUIC.Server")
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
+
+	for {
+		conn, err := s.netListener.Accept(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("incoming connection accept stopped since server is shutting down")
+				return
+			}
+			s.metrics.acceptErrors.Inc()
+			logger.Info("incoming connection accept error", log.Error(err))
+			continue
+		}
+
+		govnr.Once(logfields.GovnrErrorer(logger), func() {
+			s.handleIncomingConnection(ctx, conn)
+		})
+	}
+}
+
+// peers open a stream per topic, each read by a goroutine of its own so that no topic waits for another
+func (s *server) handleIncomingConnection(ctx context.Context, conn quicgo.EarlyConnection) {
+	logger := s.logger.WithTags(log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
+	logger.Info("successful incoming gossip transport connection")
+	s.metrics.activeConnections.Inc()
+	defer s.metrics.activeConnections.Dec()
+
+	for {
+		stream, err := conn.AcceptUniStream(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				_ = conn.CloseWithError(0, "shutting down")
+			} else if !isClosed(conn.HandshakeComplete()) {
+				s.metrics.handshakeErrors.Inc() // a peer outside the topology or with a forged identity
+			}
+			logger.Info("incoming gossip transport connection closed", log.Error(err))
+			return
+		}
+
+		govnr.Once(logfields.GovnrErrorer(logger), func() {
+			s.receiveFromStream(ctx, conn, stream, logger)
+		})
+	}
+}
+
+// messages are passed on only once the handshake completes, along with the node address the peer proved it owns. 0-RTT
+// data replayed by an attacker, who cannot complete the handshake, is therefore never passed on
+func (s *server) receiveFromStream(ctx context.Context, conn quicgo.EarlyConnection, stream quicgo.ReceiveStream, logger log.Logger) {
+	select {
+	case <-conn.HandshakeComplete():
+	case <-conn.Context().Done():
+		stream.CancelRead(0)
+		return
+	}
+	peerNodeAddress, err := tcp.PeerNodeAddress(conn.ConnectionState().TLS)
+	if err != nil {
+		s.metrics.handshakeErrors.Inc()
+		logger.Info("gossip peer is not authenticated, closing stream", log.Error(err))
+		stream.CancelRead(0)
+		return
+	}
+	ctx = adapter.ContextWithPeerNodeAddress(ctx, peerNodeAddress)
+
+	for {
+		payloads, err := tcp.ReadFrame(stream)
+		if err != nil {
+			if err != io.EOF && conn.Context().Err() == nil {
+				s.metrics.transportErrors.Inc()
+				logger.Info("failed receiving transport data, closing stream", log.Error(err))
+			}
+			stream.CancelRead(0)
+			return
+		}
+
+		if len(payloads) > 0 {
+			ctxWithPeer := context.WithValue(ctx, "peer-ip", conn.RemoteAddr().String())
+			s.notifyListener(ctxWithPeer, payloads)
+		}
+	}
+}
+
+func (s *server) notifyListener(ctx context.Context, payloads [][]byte) {
+	listener := s.getListener()
+
+	if listener == nil {
+		return
+	}
+
+	listener.OnTransportMessageReceived(ctx, payloads)
+}
+
+func (s *server) getListener() adapter.TransportListener {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.listener
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
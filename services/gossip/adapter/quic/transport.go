@@ -0,0 +1,267 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	quicgo "github.com/quic-go/quic-go"
+	"sync"
+	"time"
+)
+
+const applicationProtocol = "orbs-gossip"
+
+// sessions of up to this many peers are kept for resumption, enough for any committee
+const SESSION_CACHE_SIZE = 1000
+
+var LogTag = log.String("adapter", "gossip-quic")
+
+type timingsConfig interface {
+	GossipNetworkTimeout() time.Duration
+	GossipReconnectInterval() time.Duration
+	GossipConnectionKeepAliveInterval() time.Duration
+}
+
+// QuicTransport is the gossip transport over quic. peers keep a single connection to each other over udp, which
+// carries the messages of every topic on a stream of its own, and which resumes its tls session when it reconnects
+// after a NAT timeout. a resumed connection sends the messages of idempotent topics in its first flight (0-RTT), see
+// replaySafeTopics
+type QuicTransport struct {
+	govnr.TreeSupervisor
+
+	logger      log.Logger
+	config      config.GossipTransportConfig
+	metrics     *outgoingPeerMetrics
+	certificate tls.Certificate
+	quicConfig  *quicgo.Config
+
+	// client sessions are kept across connections, this is what lets a reconnect resume its session
+	sessionCache tls.ClientSessionCache
+
+	sync.RWMutex
+	peerTopology  adapter.TransportPeers
+	outgoingPeers map[string]*outgoingPeer
+
+	server         *server
+	shutdownServer context.CancelFunc
+}
+
+// the signer proves to peers that this node owns its node address, see tcp.NewIdentityCertificate
+func NewQuicTransport(parentCtx context.Context, config config.GossipTransportConfig, signer signer.Signer, parentLogger log.Logger, registry metric.Registry) (*QuicTransport, error) {
+	logger := parentLogger.WithTags(LogTag)
+
+	certificate, err := tcp.NewIdentityCertificate(parentCtx, config.NodeAddress(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &QuicTransport{
+		logger:      logger,
+		config:      config,
+		metrics:     createOutgoingPeerMetrics(registry),
+		certificate: certificate,
+		quicConfig: &quicgo.Config{
+			HandshakeIdleTimeout: config.GossipNetworkTimeout(),
+			MaxIdleTimeout:       config.GossipNetworkTimeout(),
+			KeepAlivePeriod:      config.GossipConnectionKeepAliveInterval(),
+			MaxIncomingStreams:   -1, // only unidirectional streams are used
+		},
+		sessionCache:  tls.NewLRUClientSessionCache(SESSION_CACHE_SIZE),
+		peerTopology:  make(adapter.TransportPeers),
+		outgoingPeers: make(map[string]*outgoingPeer),
+	}
+
+	// connections are accepted before their handshake completes so that 0-RTT data is received and failed handshakes
+	// are counted, the data is passed on once the handshake completes, see server
+	serverQuicConfig := t.quicConfig.Clone()
+	serverQuicConfig.Allow0RTT = true
+	netListener, err := quicgo.ListenAddrEarly(fmt.Sprintf(":%d", config.GossipListenPort()), t.serverTlsConfig(), serverQuicConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "gossip transport failed to listen on udp port %d", config.GossipListenPort())
+	}
+	t.server = &server{
+		netListener: netListener,
+		logger:      parentLogger.WithTags(log.String("component", "quic-transport-server")),
+		metrics:     createServerMetrics(registry),
+	}
+	logger.Info("gossip transport server listening", log.Int("port", t.server.getPort()))
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	t.shutdownServer = cancel
+	t.Supervise(govnr.Forever(ctx, "QUIC server", logfields.GovnrErrorer(logger), func() {
+		t.server.mainLoop(ctx)
+	}))
+
+	return t, nil
+}
+
+// incoming connections are accepted only from peers in the topology this node connects to
+func (t *QuicTransport) serverTlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		NextProtos:   []string{applicationProtocol},
+		Certificates: []tls.Certificate{t.certificate},
+		ClientAuth:   tls.RequireAnyClientCert,
+		VerifyConnection: tcp.VerifyPeerIdentity(func(nodeAddress primitives.NodeAddress) error {
+			if !t.isInTopology(nodeAddress) {
+				return errors.Errorf("node %s is not in the topology", nodeAddress)
+			}
+			return nil
+		}),
+	}
+}
+
+// the server name keys the session cache, so every peer resumes its own session
+func (t *QuicTransport) clientTlsConfig(peerHexAddress string) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		NextProtos:         []string{applicationProtocol},
+		Certificates:       []tls.Certificate{t.certificate},
+		ServerName:         peerHexAddress,
+		InsecureSkipVerify: true, // the certificate chain is replaced by the node identity check of VerifyConnection
+		ClientSessionCache: t.sessionCache,
+		VerifyConnection: tcp.VerifyPeerIdentity(func(nodeAddress primitives.NodeAddress) error {
+			if hex.EncodeToString(nodeAddress) != peerHexAddress {
+				return errors.Errorf("peer is node %s instead of the expected %s", nodeAddress, peerHexAddress)
+			}
+			return nil
+		}),
+	}
+}
+
+func (t *QuicTransport) GetServerPort() int {
+	return t.server.getPort()
+}
+
+func (t *QuicTransport) IsServerListening() bool {
+	return t.server != nil
+}
+
+func (t *QuicTransport) RegisterListener(listener adapter.TransportListener, listenerNodeAddress primitives.NodeAddress) {
+	t.server.Lock()
+	defer t.server.Unlock()
+
+	t.server.listener = listener
+}
+
+func (t *QuicTransport) UpdateTopology(bgCtx context.Context, newTopology adapter.TransportPeers) {
+	t.Lock()
+	defer t.Unlock()
+	// If not in topology disconnect from outer world
+	if _, isInNewTopology := newTopology[t.config.NodeAddress().KeyForMap()]; !isInNewTopology {
+		t.disconnectAllUnderLock(bgCtx, t.peerTopology)
+		return
+	}
+
+	peersToRemove, peersToAdd := adapter.PeerDiff(t.peerTopology, newTopology)
+
+	t.disconnectAllUnderLock(bgCtx, peersToRemove)
+
+	for peerNodeAddress, peer := range peersToAdd {
+		t.peerTopology[peerNodeAddress] = peer
+		if t.config.NodeAddress().KeyForMap() != peerNodeAddress {
+			client := newOutgoingPeer(peer, t.logger, t.metrics, t.config, t.clientTlsConfig(peer.HexOrbsAddress()), t.quicConfig)
+			t.outgoingPeers[peerNodeAddress] = client
+			client.connect(bgCtx)
+		}
+	}
+}
+
+func (t *QuicTransport) disconnectAllUnderLock(ctx context.Context, peersToDisconnect adapter.TransportPeers) {
+	for key := range peersToDisconnect {
+		delete(t.peerTopology, key)
+		if client, found := t.outgoingPeers[key]; found {
+			select {
+			case <-client.disconnect():
+				delete(t.outgoingPeers, key)
+			case <-ctx.Done():
+				t.logger.Info("system shutdown while waiting for clients to disconnect")
+			}
+		}
+	}
+}
+
+func (t *QuicTransport) isInTopology(nodeAddress primitives.NodeAddress) bool {
+	t.RLock()
+	defer t.RUnlock()
+
+	_, found := t.peerTopology[nodeAddress.KeyForMap()]
+	return found
+}
+
+func (t *QuicTransport) Send(ctx context.Context, data *adapter.TransportData) error {
+	t.RLock()
+	defer t.RUnlock()
+
+	if data.TotalSize() > tcp.SEND_QUEUE_MAX_BYTES {
+		return tcp.DataExceedsCapacityError
+	}
+
+	switch data.RecipientMode {
+	case gossipmessages.RECIPIENT_LIST_MODE_BROADCAST:
+		for _, client := range t.outgoingPeers {
+			client.addDataToOutgoingPeerQueue(ctx, data)
+		}
+		return nil
+	case gossipmessages.RECIPIENT_LIST_MODE_LIST:
+		for _, recipientPublicKey := range data.RecipientNodeAddresses {
+			if client, found := t.outgoingPeers[recipientPublicKey.KeyForMap()]; found {
+				client.addDataToOutgoingPeerQueue(ctx, data)
+			} else {
+				err := errors.Errorf("unknown recipient public key: %s", recipientPublicKey.String())
+				t.logger.Error("failed sending gossip message", log.Error(err), log.Stringable("recipient-public-key", recipientPublicKey))
+			}
+		}
+		return nil
+	case gossipmessages.RECIPIENT_LIST_MODE_ALL_BUT_LIST:
+		panic("Not implemented")
+	}
+	return errors.Errorf("unknown recipient mode: %s", data.RecipientMode.String())
+}
+
+func (t *QuicTransport) GracefulShutdown(shutdownContext context.Context) {
+	t.logger.Info("Shutting down")
+
+	t.Lock()
+	for _, client := range t.outgoingPeers {
+		client.disconnect()
+	}
+	t.Unlock()
+
+	t.shutdownServer()
+	if err := t.server.netListener.Close(); err != nil {
+		t.logger.Error("Failed to close quic transport listener", log.Error(err))
+	}
+}
+
+func (t *QuicTransport) WaitUntilShutdown(shutdownContext context.Context) {
+	t.TreeSupervisor.WaitUntilShutdown(shutdownContext)
+
+	t.RLock()
+	defer t.RUnlock()
+	for _, client := range t.outgoingPeers {
+		select {
+		case <-client.closed:
+		case <-shutdownContext.Done():
+			t.logger.Error("failed shutting down within shutdown context")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package quic
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const HARNESS_TIMEOUT = 3 * time.Second
+
+func TestQuicTransport_ResumesSessionWithZeroRtt_WhenConnectionIsLost(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
+		h := newQuicHarness(ctx, harness)
+		h.receiver.UpdateTopology(ctx, h.topology)
+		h.sender.UpdateTopology(ctx, h.topology)
+
+		h.listener.ExpectReceive(h.message.Payloads)
+		require.True(t, h.eventuallySend(ctx, h.message), "receiver should get the message")
+
+		h.simulateNatTimeoutUntil(t, func() bool {
+			return h.sender.metrics.zeroRttReconnects.IntValue() > 0
+		}, "sender should resume its session in 0-RTT when it reconnects")
+		require.True(t, h.sender.metrics.resumedReconnects.IntValue() > 0, "sender should resume its session when it reconnects")
+
+		h.listener.Reset()
+		h.listener.ExpectReceive(h.message.Payloads)
+		require.True(t, h.eventuallySend(ctx, h.message), "receiver should get the message over the resumed connection")
+	})
+}
+
+func TestQuicTransport_SendsConsensusMessagesOverResumedConnection(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
+		h := newQuicHarness(ctx, harness)
+		h.receiver.UpdateTopology(ctx, h.topology)
+		h.sender.UpdateTopology(ctx, h.topology)
+
+		consensusMessage := h.messageOfTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX)
+		h.listener.ExpectReceive(consensusMessage.Payloads)
+		require.True(t, h.eventuallySend(ctx, consensusMessage), "receiver should get the message")
+
+		h.simulateNatTimeoutUntil(t, func() bool {
+			return h.sender.metrics.zeroRttReconnects.IntValue() > 0
+		}, "sender should resume its session in 0-RTT when it reconnects")
+
+		h.listener.Reset()
+		h.listener.ExpectReceive(consensusMessage.Payloads)
+		require.True(t, h.eventuallySend(ctx, consensusMessage), "receiver should get the message once the handshake completes")
+	})
+}
+
+func TestQuicTransport_SendsOnlyIdempotentTopicsInZeroRtt(t *testing.T) {
+	require.True(t, replaySafeTopics[gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY], "relayed transactions are added to the pool once")
+	require.True(t, replaySafeTopics[gossipmessages.HEADER_TOPIC_BLOCK_SYNC], "synced blocks are committed once")
+	require.False(t, replaySafeTopics[gossipmessages.HEADER_TOPIC_LEAN_HELIX], "consensus messages should wait for the handshake")
+	require.False(t, replaySafeTopics[gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS], "consensus messages should wait for the handshake")
+}
+
+func TestQuicTransport_RejectsPeersOutsideTopology(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
+		h := newQuicHarness(ctx, harness)
+		h.receiver.UpdateTopology(ctx, adapter.TransportPeers{
+			h.receiverAddress.KeyForMap(): h.topology[h.receiverAddress.KeyForMap()],
+		})
+		h.sender.UpdateTopology(ctx, h.topology)
+
+		h.listener.ExpectNotReceive()
+		require.True(t, test.Eventually(HARNESS_TIMEOUT, func() bool {
+			_ = h.sender.Send(ctx, h.message)
+			return h.receiver.server.metrics.handshakeErrors.IntValue() > 0
+		}), "receiver should refuse the connection of a node outside its topology")
+
+		require.NoError(t, test.ConsistentlyVerify(test.CONSISTENTLY_ADAPTER_TIMEOUT, h.listener), "receiver should not get messages from a node outside its topology")
+	})
+}
+
+func TestQuicTransport_SendsMessagesOfUnknownTopicsAsTransactionRelay(t *testing.T) {
+	require.Equal(t, gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, topicOf(&adapter.TransportData{}))
+	require.Equal(t, gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, topicOf(&adapter.TransportData{Payloads: [][]byte{{0x01}}}))
+	require.Equal(t, gossipmessages.HEADER_TOPIC_BLOCK_SYNC, topicOf(&adapter.TransportData{Payloads: [][]byte{(&gossipmessages.HeaderBuilder{
+		Topic:     gossipmessages.HEADER_TOPIC_BLOCK_SYNC,
+		BlockSync: gossipmessages.BLOCK_SYNC_RESPONSE,
+	}).Build().Raw()}}))
+}
+
+type quicHarness struct {
+	sender          *QuicTransport
+	receiver        *QuicTransport
+	receiverAddress primitives.NodeAddress
+	listener        *testkit.MockTransportListener
+	topology        adapter.TransportPeers
+	message         *adapter.TransportData
+}
+
+func newQuicHarness(ctx context.Context, harness *with.ConcurrencyHarness) *quicHarness {
+	senderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	receiverKeyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+
+	h := &quicHarness{
+		sender:          aTransport(ctx, harness, senderKeyPair),
+		receiver:        aTransport(ctx, harness, receiverKeyPair),
+		receiverAddress: receiverKeyPair.NodeAddress(),
+		message: &adapter.TransportData{
+			SenderNodeAddress:      senderKeyPair.NodeAddress(),
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{receiverKeyPair.NodeAddress()},
+			Payloads:               [][]byte{{0x71, 0x72, 0x73}},
+		},
+	}
+	h.listener = testkit.ListenTo(h.receiver, receiverKeyPair.NodeAddress())
+	h.topology = adapter.TransportPeers{
+		senderKeyPair.NodeAddress().KeyForMap():   adapter.NewGossipPeer(h.sender.GetServerPort(), "127.0.0.1", hex.EncodeToString(senderKeyPair.NodeAddress())),
+		receiverKeyPair.NodeAddress().KeyForMap(): adapter.NewGossipPeer(h.receiver.GetServerPort(), "127.0.0.1", hex.EncodeToString(receiverKeyPair.NodeAddress())),
+	}
+	return h
+}
+
+func aTransport(ctx context.Context, harness *with.ConcurrencyHarness, keyPair *keys.TestEcdsaSecp256K1KeyPair) *QuicTransport {
+	transport, err := NewQuicTransport(ctx, config.ForGossipAdapterTests(keyPair.NodeAddress()), signer.NewLocalSigner(keyPair.PrivateKey()), harness.Logger, metric.NewRegistry())
+	require.NoError(harness.T, err, "test could not start quic transport")
+	harness.Supervise(transport)
+	return transport
+}
+
+func (h *quicHarness) senderPeerOfReceiver() *outgoingPeer {
+	h.sender.RLock()
+	defer h.sender.RUnlock()
+	return h.sender.outgoingPeers[h.receiverAddress.KeyForMap()]
+}
+
+func (h *quicHarness) messageOfTopic(topic gossipmessages.HeaderTopic) *adapter.TransportData {
+	return &adapter.TransportData{
+		SenderNodeAddress:      h.message.SenderNodeAddress,
+		RecipientMode:          h.message.RecipientMode,
+		RecipientNodeAddresses: h.message.RecipientNodeAddresses,
+		Payloads:               [][]byte{(&gossipmessages.HeaderBuilder{Topic: topic}).Build().Raw()},
+	}
+}
+
+// closes the connection of the sender to the receiver whenever it is up, until cond holds
+func (h *quicHarness) simulateNatTimeoutUntil(t *testing.T, cond func() bool, msg string) {
+	peer := h.senderPeerOfReceiver()
+	require.True(t, test.Eventually(HARNESS_TIMEOUT, func() bool {
+		if cond() {
+			return true
+		}
+		if conn := peer.connection(); conn != nil && conn.Context().Err() == nil {
+			_ = conn.CloseWithError(0, "test simulates a NAT timeout")
+		}
+		return false
+	}), msg)
+}
+
+// messages sent before the connection is established are dropped, so keep sending until the listener got one
+func (h *quicHarness) eventuallySend(ctx context.Context, message *adapter.TransportData) bool {
+	return test.Eventually(HARNESS_TIMEOUT, func() bool {
+		if err := h.sender.Send(ctx, message); err != nil {
+			return false
+		}
+		ok, _ := h.listener.Verify()
+		return ok
+	})
+}
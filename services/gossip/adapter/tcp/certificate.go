@@ -0,0 +1,115 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"github.com/orbs-network/crypto-lib-go/crypto/ethereum/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"math/big"
+	"time"
+)
+
+// gossip runs over tls 1.3 in both transports, and a node authenticates by presenting a self signed certificate for an
+// ephemeral key which it signs with its node key. a peer that checks the signature against the node address it expects
+// knows that whoever holds the tls key owns the node address
+
+const certificateSignaturePrefix = "orbs-gossip-certificate"
+
+// an arbitrary oid under the private enterprise arc, holding the node address followed by its signature over the key
+var nodeIdentityExtensionId = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 58620, 1, 1}
+
+func NewIdentityCertificate(ctx context.Context, nodeAddress primitives.NodeAddress, signer signer.Signer) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed generating tls key")
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed encoding tls public key")
+	}
+	sig, err := signer.Sign(ctx, certificateSignedData(publicKey))
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed signing tls public key with node key")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: hex.EncodeToString(nodeAddress)},
+		NotBefore:    time.Now().Add(-time.Hour), // tolerate clock skew between nodes
+		NotAfter:     time.Now().Add(100 * 365 * 24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{{
+			Id:    nodeIdentityExtensionId,
+			Value: append(append([]byte{}, nodeAddress...), sig...),
+		}},
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed creating tls certificate")
+	}
+
+	return tls.Certificate{Certificate: [][]byte{raw}, PrivateKey: key}, nil
+}
+
+// VerifyIdentityCertificate returns the node address the certificate was signed for
+func VerifyIdentityCertificate(certificate *x509.Certificate) (primitives.NodeAddress, error) {
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(nodeIdentityExtensionId) {
+			continue
+		}
+		if len(extension.Value) <= digest.NODE_ADDRESS_SIZE_BYTES {
+			return nil, errors.Errorf("peer certificate node identity is too short: %d bytes", len(extension.Value))
+		}
+		nodeAddress := primitives.NodeAddress(extension.Value[:digest.NODE_ADDRESS_SIZE_BYTES])
+		sig := extension.Value[digest.NODE_ADDRESS_SIZE_BYTES:]
+		if err := digest.VerifyNodeSignature(nodeAddress, certificateSignedData(certificate.RawSubjectPublicKeyInfo), sig); err != nil {
+			return nil, errors.Wrapf(err, "peer certificate is not signed by node %s", nodeAddress)
+		}
+		return nodeAddress, nil
+	}
+	return nil, errors.New("peer certificate has no node identity")
+}
+
+func certificateSignedData(publicKey []byte) []byte {
+	hash := sha256.Sum256(append([]byte(certificateSignaturePrefix), publicKey...))
+	return hash[:]
+}
+
+// PeerNodeAddress returns the node address the peer of an established connection proved it owns
+func PeerNodeAddress(state tls.ConnectionState) (primitives.NodeAddress, error) {
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("peer presented no certificate")
+	}
+	return VerifyIdentityCertificate(state.PeerCertificates[0])
+}
+
+// peer certificates are self signed, so the usual chain verification is replaced by checking the node identity. this
+// runs on resumed connections too, with the certificate the peer presented when the session was first established
+func VerifyPeerIdentity(isPeerAllowed func(nodeAddress primitives.NodeAddress) error) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		nodeAddress, err := PeerNodeAddress(state)
+		if err != nil {
+			return err
+		}
+		return isPeerAllowed(nodeAddress)
+	}
+}
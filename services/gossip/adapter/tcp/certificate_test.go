@@ -0,0 +1,43 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"crypto/x509"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIdentityCertificate_ProvesOwnershipOfNodeAddress(t *testing.T) {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	certificate := anIdentityCertificate(t, keyPair.NodeAddress(), signer.NewLocalSigner(keyPair.PrivateKey()))
+
+	nodeAddress, err := VerifyIdentityCertificate(certificate)
+	require.NoError(t, err)
+	require.Equal(t, keyPair.NodeAddress(), nodeAddress)
+}
+
+func TestIdentityCertificate_RejectsCertificateNotSignedByTheNodeItClaims(t *testing.T) {
+	impersonatedKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	certificate := anIdentityCertificate(t, impersonatedKeyPair.NodeAddress(), signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(1).PrivateKey()))
+
+	_, err := VerifyIdentityCertificate(certificate)
+	require.Error(t, err, "certificate signed with a key other than that of the node address should be rejected")
+}
+
+func anIdentityCertificate(t *testing.T, nodeAddress []byte, signer signer.Signer) *x509.Certificate {
+	tlsCertificate, err := NewIdentityCertificate(context.Background(), nodeAddress, signer)
+	require.NoError(t, err, "test could not create certificate")
+	certificate, err := x509.ParseCertificate(tlsCertificate.Certificate[0])
+	require.NoError(t, err, "test could not parse certificate")
+	return certificate
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"crypto/tls"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"net"
+)
+
+// a versioned frame starts with a word that has this bit set in place of the number of payloads. a peer that only knows
+// the original frame format takes it for a message with too many payloads and disconnects rather than misreading it,
+// which is why frames are only versioned once the peer has agreed to read them in the handshake
+const FRAME_VERSION_MARKER = 0x80000000
+
+// in a compressed frame the version word is followed by the number of payloads, and every payload size is preceded
+// by the encoding of the payload
+const FRAME_VERSION_COMPRESSED = 2
+
+// payloads smaller than this are sent as is, as compressing them saves too little to be worth the cpu
+const COMPRESSION_THRESHOLD_BYTES = 1024
+
+type payloadEncoding uint32
+
+const (
+	PAYLOAD_ENCODING_NONE payloadEncoding = iota
+	PAYLOAD_ENCODING_SNAPPY
+)
+
+// the frame version of a connection is agreed on in its tls handshake by application protocol negotiation. each side
+// offers the protocols it speaks, newest first, and the server picks the newest one both speak. a plaintext connection
+// is only made to or from a node that predates tls, and such a node predates compression too
+const APPLICATION_PROTOCOL_COMPRESSED_FRAMES = "orbs-gossip/2"
+const APPLICATION_PROTOCOL_ORIGINAL_FRAMES = "orbs-gossip/1"
+
+var applicationProtocols = []string{APPLICATION_PROTOCOL_COMPRESSED_FRAMES, APPLICATION_PROTOCOL_ORIGINAL_FRAMES}
+
+func readsCompressedFrames(conn net.Conn) bool {
+	tlsConn, isTls := conn.(*tls.Conn)
+	return isTls && tlsConn.ConnectionState().NegotiatedProtocol == APPLICATION_PROTOCOL_COMPRESSED_FRAMES
+}
+
+// returns the payload unchanged when it is below the threshold or doesn't get any smaller
+func encodePayload(payload []byte) (payloadEncoding, []byte) {
+	if len(payload) < COMPRESSION_THRESHOLD_BYTES {
+		return PAYLOAD_ENCODING_NONE, payload
+	}
+	compressed := snappy.Encode(nil, payload)
+	if len(compressed) >= len(payload) {
+		return PAYLOAD_ENCODING_NONE, payload
+	}
+	return PAYLOAD_ENCODING_SNAPPY, compressed
+}
+
+func decodePayload(encoding payloadEncoding, data []byte) ([]byte, error) {
+	switch encoding {
+	case PAYLOAD_ENCODING_NONE:
+		return data, nil
+	case PAYLOAD_ENCODING_SNAPPY:
+		decodedSize, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading size of compressed payload")
+		}
+		if decodedSize > MAX_PAYLOAD_SIZE_BYTES {
+			return nil, errors.Errorf("received compressed payload too big: %d bytes", decodedSize)
+		}
+		return snappy.Decode(nil, data)
+	default:
+		return nil, errors.Errorf("received payload with unknown encoding %d", encoding)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bytes"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCompression_LargePayloadsAreCompressedAndDecodedBack(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x11, 0x22}, COMPRESSION_THRESHOLD_BYTES)
+
+	encoding, encoded := encodePayload(payload)
+	require.Equal(t, PAYLOAD_ENCODING_SNAPPY, encoding)
+	require.True(t, len(encoded) < len(payload), "compressed payload should be smaller")
+
+	decoded, err := decodePayload(encoding, encoded)
+	require.NoError(t, err)
+	require.Equal(t, payload, decoded)
+}
+
+func TestCompression_SmallPayloadsAreSentAsIs(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x11}, COMPRESSION_THRESHOLD_BYTES-1)
+
+	encoding, encoded := encodePayload(payload)
+	require.Equal(t, PAYLOAD_ENCODING_NONE, encoding)
+	require.Equal(t, payload, encoded)
+}
+
+func TestCompression_RejectsPayloadsThatDecompressTooBig(t *testing.T) {
+	tooBig := make([]byte, MAX_PAYLOAD_SIZE_BYTES+1)
+
+	_, err := decodePayload(PAYLOAD_ENCODING_SNAPPY, snappy.Encode(nil, tooBig))
+	require.Error(t, err, "payload should be rejected before it is decompressed")
+}
+
+func TestCompression_RejectsUnknownEncodings(t *testing.T) {
+	_, err := decodePayload(PAYLOAD_ENCODING_SNAPPY+1, []byte{0x11})
+	require.Error(t, err)
+}
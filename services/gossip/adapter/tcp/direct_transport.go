@@ -8,6 +8,7 @@ package tcp
 
 import (
 	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
@@ -32,12 +33,17 @@ type DirectTransport struct {
 	server              *transportServer
 }
 
-func NewDirectTransport(parentCtx context.Context, config config.GossipTransportConfig, parentLogger log.Logger, registry metric.Registry) *DirectTransport {
+// the signer proves to peers that this node owns its node address, see certificate.go
+func NewDirectTransport(parentCtx context.Context, config config.GossipTransportConfig, signer signer.Signer, parentLogger log.Logger, registry metric.Registry) *DirectTransport {
 	logger := parentLogger.WithTags(LogTag)
+	outgoingConnections := newOutgoingConnections(logger, registry, config)
+	handshaker := newHandshaker(config, signer, outgoingConnections.isInTopology)
+	outgoingConnections.handshaker = handshaker
+
 	t := &DirectTransport{
 		logger:              logger,
-		outgoingConnections: newOutgoingConnections(logger, registry, config),
-		server:              newServer(config, parentLogger.WithTags(log.String("component", "tcp-transport-server")), registry),
+		outgoingConnections: outgoingConnections,
+		server:              newServer(config, handshaker, parentLogger.WithTags(log.String("component", "tcp-transport-server")), registry),
 	}
 
 	t.Supervise(t.server)
@@ -51,6 +57,7 @@ func NewDirectTransport(parentCtx context.Context, config config.GossipTransport
 
 func (t *DirectTransport) UpdateTopology(bgCtx context.Context, newPeers adapter.TransportPeers) {
 	t.outgoingConnections.updateTopology(bgCtx, newPeers)
+	t.server.disconnectPeersOutside(t.outgoingConnections.isInTopology)
 }
 
 func (t *DirectTransport) RegisterListener(listener adapter.TransportListener, listenerNodeAddress primitives.NodeAddress) {
@@ -97,9 +104,3 @@ func (t *DirectTransport) waitFor(shutdownContext context.Context, closed chan s
 		t.logger.Error("failed shutting down within shutdown context")
 	}
 }
-
-func calcPaddingSize(size uint32) uint32 {
-	const contentAlignment = 4
-	alignedSize := (size + contentAlignment - 1) / contentAlignment * contentAlignment
-	return alignedSize - size
-}
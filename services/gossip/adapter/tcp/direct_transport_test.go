@@ -9,6 +9,7 @@ package tcp
 import (
 	"context"
 	"encoding/hex"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
@@ -26,10 +27,10 @@ import (
 )
 
 func TestDirectTransport_HandlesStartupWithEmptyPeerList(t *testing.T) {
-	address := keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress()
-	cfg := config.ForDirectTransportTests(address, 20*time.Hour /*disable keep alive*/, 1*time.Second)
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	cfg := config.ForDirectTransportTests(keyPair.NodeAddress(), 20*time.Hour /*disable keep alive*/, 1*time.Second)
 	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
-		transport := NewDirectTransport(ctx, cfg, harness.Logger, metric.NewRegistry())
+		transport := NewDirectTransport(ctx, cfg, signer.NewLocalSigner(keyPair.PrivateKey()), harness.Logger, metric.NewRegistry())
 		harness.Supervise(transport)
 		defer transport.GracefulShutdown(ctx)
 
@@ -283,9 +284,10 @@ func aVeryLargeMessage() [][]byte {
 }
 
 func aNode(ctx context.Context, logger log.Logger) *nodeHarness {
-	address := aKey()
+	keyPair := aKeyPair()
+	address := keyPair.NodeAddress()
 	cfg := config.ForDirectTransportTests(address, 20*time.Hour /*disable keep alive*/, 1*time.Second)
-	transport := NewDirectTransport(ctx, cfg, logger, metric.NewRegistry())
+	transport := NewDirectTransport(ctx, cfg, signer.NewLocalSigner(keyPair.PrivateKey()), logger, metric.NewRegistry())
 	listener := &testkit.MockTransportListener{}
 	transport.RegisterListener(listener, address)
 	return &nodeHarness{transport, address, listener}
@@ -293,10 +295,10 @@ func aNode(ctx context.Context, logger log.Logger) *nodeHarness {
 
 var currentNodeIndex = 1
 
-func aKey() primitives.NodeAddress {
-	address := keys.EcdsaSecp256K1KeyPairForTests(currentNodeIndex).NodeAddress()
+func aKeyPair() *keys.TestEcdsaSecp256K1KeyPair {
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(currentNodeIndex)
 	currentNodeIndex++
-	return address
+	return keyPair
 }
 
 func aTopologyContaining(nodes ...*nodeHarness) adapter.TransportPeers {
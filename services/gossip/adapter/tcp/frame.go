@@ -0,0 +1,141 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"github.com/orbs-network/membuffers/go"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// messages are sent in frames of the gossip wire protocol, which other transports carry too:
+// https://github.com/orbs-network/orbs-spec/blob/master/encoding/gossip/membuffers-over-tcp.md
+
+// EncodeFrame returns the frame of a message, compressed (see compression.go) if the peer agreed to read compressed frames
+func EncodeFrame(payloads [][]byte, compress bool) []byte {
+	return encodeFrame(payloads, compress, nil)
+}
+
+// onCompressed is called with the sizes of every payload that was compressed, before and after compression
+func encodeFrame(payloads [][]byte, compress bool, onCompressed func(size int, compressedSize int)) []byte {
+	var encodings []payloadEncoding
+	if compress {
+		encodings = make([]payloadEncoding, len(payloads))
+		encodedPayloads := make([][]byte, len(payloads))
+		for i, payload := range payloads {
+			encodings[i], encodedPayloads[i] = encodePayload(payload)
+			if encodings[i] != PAYLOAD_ENCODING_NONE && onCompressed != nil {
+				onCompressed(len(payload), len(encodedPayloads[i]))
+			}
+		}
+		payloads = encodedPayloads
+	}
+
+	size := 4
+	if compress {
+		size += 4 + 4*len(payloads) // frame version and payload encodings
+	}
+	for _, payload := range payloads {
+		size += 4 + len(payload) + int(CalcPaddingSize(uint32(len(payload))))
+	}
+
+	frame := make([]byte, size)
+	offset := 0
+	writeUint32 := func(value uint32) {
+		membuffers.WriteUint32(frame[offset:], value)
+		offset += 4
+	}
+
+	if compress {
+		writeUint32(FRAME_VERSION_MARKER | FRAME_VERSION_COMPRESSED)
+	}
+	writeUint32(uint32(len(payloads)))
+	for i, payload := range payloads {
+		if compress {
+			writeUint32(uint32(encodings[i]))
+		}
+		writeUint32(uint32(len(payload)))
+		offset += copy(frame[offset:], payload)
+		offset += int(CalcPaddingSize(uint32(len(payload)))) // already zero
+	}
+	return frame
+}
+
+// ReadFrame reads the next frame and returns the payloads of its message, none for a keep alive
+func ReadFrame(r io.Reader) ([][]byte, error) {
+	var res [][]byte
+	sizeBuffer := make([]byte, 4)
+	readUint32 := func() (uint32, error) {
+		if _, err := io.ReadFull(r, sizeBuffer); err != nil {
+			return 0, err
+		}
+		return membuffers.GetUint32(sizeBuffer), nil
+	}
+
+	// receive num payloads
+	numPayloads, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+
+	// receive num payloads of a versioned frame
+	versioned := numPayloads&FRAME_VERSION_MARKER != 0
+	if versioned {
+		if frameVersion := numPayloads &^ FRAME_VERSION_MARKER; frameVersion != FRAME_VERSION_COMPRESSED {
+			return nil, errors.Errorf("received frame of unsupported version %d", frameVersion)
+		}
+		numPayloads, err = readUint32()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if numPayloads > MAX_PAYLOADS_IN_MESSAGE {
+		return nil, errors.Errorf("received message with too many payloads: %d", numPayloads)
+	}
+
+	for i := uint32(0); i < numPayloads; i++ {
+		// receive payload encoding
+		encoding := PAYLOAD_ENCODING_NONE
+		if versioned {
+			rawEncoding, err := readUint32()
+			if err != nil {
+				return nil, err
+			}
+			encoding = payloadEncoding(rawEncoding)
+		}
+
+		// receive payload size
+		payloadSize, err := readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if payloadSize > MAX_PAYLOAD_SIZE_BYTES {
+			return nil, errors.Errorf("received message with a payload too big: %d bytes", payloadSize)
+		}
+
+		// receive payload data and padding
+		payload := make([]byte, payloadSize+CalcPaddingSize(payloadSize))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+
+		decoded, err := decodePayload(encoding, payload[:payloadSize])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, decoded)
+	}
+
+	return res, nil
+}
+
+func CalcPaddingSize(size uint32) uint32 {
+	const contentAlignment = 4
+	alignedSize := (size + contentAlignment - 1) / contentAlignment * contentAlignment
+	return alignedSize - size
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFrame_EncodesTheWireProtocol(t *testing.T) {
+	require.Equal(t, exampleWireProtocolEncoding_Payloads_0x11_0x2233(), EncodeFrame([][]byte{{0x11}, {0x22, 0x33}}, false))
+	require.Equal(t, exampleWireProtocolEncoding_KeepAlive(), EncodeFrame(nil, false))
+}
+
+func TestFrame_ReadsWhatWasEncoded(t *testing.T) {
+	payloads := [][]byte{bytes.Repeat([]byte{0x11}, 10*COMPRESSION_THRESHOLD_BYTES), {0x22, 0x33}}
+
+	for _, compress := range []bool{false, true} {
+		read, err := ReadFrame(bytes.NewReader(EncodeFrame(payloads, compress)))
+		require.NoError(t, err)
+		require.Equal(t, payloads, read, "payloads should be read back when compress is %t", compress)
+	}
+}
+
+func TestFrame_CompressedFrameIsSmaller(t *testing.T) {
+	payloads := [][]byte{bytes.Repeat([]byte{0x11}, 10*COMPRESSION_THRESHOLD_BYTES)}
+
+	require.True(t, len(EncodeFrame(payloads, true)) < len(EncodeFrame(payloads, false)))
+}
+
+func TestFrame_RejectsCorruptFrames(t *testing.T) {
+	_, err := ReadFrame(bytes.NewReader(exampleWireProtocolEncoding_CorruptNumPayloads()))
+	require.Error(t, err)
+
+	_, err = ReadFrame(bytes.NewReader(exampleWireProtocolEncoding_CorruptPayloadSize()))
+	require.Error(t, err)
+
+	_, err = ReadFrame(bytes.NewReader(exampleWireProtocolEncoding_UnsupportedFrameVersion()))
+	require.Error(t, err)
+}
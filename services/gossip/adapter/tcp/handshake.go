@@ -0,0 +1,181 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// every connection starts with a tls 1.3 handshake in which both sides present the identity certificate of their node,
+// see certificate.go, so each side knows the node address of the other and only the two of them can read or forge the
+// traffic that follows. nodes that predate tls speak the wire protocol in plaintext, and the transport security setting
+// decides whether they are still talked to during a rolling upgrade
+const (
+	TransportSecurityTls                = "tls"                  // dial and accept tls only
+	TransportSecurityTlsAcceptPlaintext = "tls-accept-plaintext" // dial tls, accept plaintext from nodes that dial plaintext
+	TransportSecurityPlaintextAcceptTls = "plaintext-accept-tls" // dial plaintext so that nodes predating tls can be reached, accept tls too
+)
+
+// a tls connection starts with the record header of the client hello: handshake content type and a legacy version of
+// 3.1. read as the number of payloads of a plaintext message these bytes make over 66 thousand, which gossip never sends
+var tlsClientHelloPrefix = []byte{0x16, 0x03, 0x01}
+
+type handshakeConfig interface {
+	NodeAddress() primitives.NodeAddress
+	GossipNetworkTimeout() time.Duration
+	GossipTransportSecurity() string
+}
+
+type handshaker struct {
+	config handshakeConfig
+	signer signer.Signer
+
+	// incoming connections are only accepted from the nodes this returns true for
+	isPeerAllowed func(nodeAddress primitives.NodeAddress) bool
+
+	sync.Mutex
+	certificate *tls.Certificate // created on the first handshake rather than on boot, when the signer might not be up yet
+}
+
+func newHandshaker(config handshakeConfig, signer signer.Signer, isPeerAllowed func(nodeAddress primitives.NodeAddress) bool) *handshaker {
+	switch config.GossipTransportSecurity() {
+	case TransportSecurityTls, TransportSecurityTlsAcceptPlaintext, TransportSecurityPlaintextAcceptTls:
+	default:
+		panic(fmt.Sprintf("unknown gossip transport security %s", config.GossipTransportSecurity()))
+	}
+
+	return &handshaker{
+		config:        config,
+		signer:        signer,
+		isPeerAllowed: isPeerAllowed,
+	}
+}
+
+// initiate runs the handshake of an outgoing connection, failing unless the peer proves it owns the expected address
+func (h *handshaker) initiate(ctx context.Context, conn net.Conn, peerHexAddress string) (net.Conn, error) {
+	if h.config.GossipTransportSecurity() == TransportSecurityPlaintextAcceptTls {
+		return conn, nil
+	}
+
+	certificate, err := h.identityCertificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		NextProtos:         applicationProtocols,
+		Certificates:       []tls.Certificate{*certificate},
+		InsecureSkipVerify: true, // the certificate chain is replaced by the node identity check of VerifyConnection
+		VerifyConnection: VerifyPeerIdentity(func(nodeAddress primitives.NodeAddress) error {
+			if hex.EncodeToString(nodeAddress) != peerHexAddress {
+				return errors.Errorf("peer is node %s instead of the expected %s", nodeAddress, peerHexAddress)
+			}
+			return nil
+		}),
+	})
+	if err := h.handshake(ctx, tlsConn); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// accept runs the handshake of an incoming connection, returning the address the peer proved it owns, or no address
+// for a plaintext connection the transport security allows
+func (h *handshaker) accept(ctx context.Context, conn net.Conn) (net.Conn, primitives.NodeAddress, error) {
+	if h.config.GossipTransportSecurity() != TransportSecurityTls {
+		peekedConn, isTls, err := peekTls(conn, h.config.GossipNetworkTimeout())
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isTls {
+			return peekedConn, nil, nil
+		}
+		conn = peekedConn
+	}
+
+	certificate, err := h.identityCertificate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		NextProtos:   applicationProtocols,
+		Certificates: []tls.Certificate{*certificate},
+		ClientAuth:   tls.RequireAnyClientCert,
+		VerifyConnection: VerifyPeerIdentity(func(nodeAddress primitives.NodeAddress) error {
+			if !h.isPeerAllowed(nodeAddress) {
+				return errors.Errorf("node %s is not in the topology", nodeAddress)
+			}
+			return nil
+		}),
+	})
+	if err := h.handshake(ctx, tlsConn); err != nil {
+		return nil, nil, err
+	}
+	peerNodeAddress, err := PeerNodeAddress(tlsConn.ConnectionState())
+	if err != nil {
+		return nil, nil, err
+	}
+	return tlsConn, peerNodeAddress, nil
+}
+
+func (h *handshaker) handshake(ctx context.Context, tlsConn *tls.Conn) error {
+	handshakeCtx, cancel := context.WithTimeout(ctx, h.config.GossipNetworkTimeout())
+	defer cancel()
+
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		return errors.Wrap(err, "tls handshake failed")
+	}
+	return nil
+}
+
+func (h *handshaker) identityCertificate(ctx context.Context) (*tls.Certificate, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.certificate == nil {
+		certificate, err := NewIdentityCertificate(ctx, h.config.NodeAddress(), h.signer)
+		if err != nil {
+			return nil, err
+		}
+		h.certificate = &certificate
+	}
+	return h.certificate, nil
+}
+
+// peekedConn reads the bytes peeked at the start of the connection before the rest of it
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *peekedConn) Read(buffer []byte) (int, error) {
+	return c.reader.Read(buffer)
+}
+
+func peekTls(conn net.Conn, timeout time.Duration) (net.Conn, bool, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, false, err
+	}
+	reader := bufio.NewReader(conn)
+	prefix, err := reader.Peek(len(tlsClientHelloPrefix))
+	if err != nil {
+		return nil, false, err
+	}
+	return &peekedConn{Conn: conn, reader: reader}, bytes.Equal(prefix, tlsClientHelloPrefix), nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHandshake_EstablishesEncryptedChannelBetweenAuthenticatedPeers(t *testing.T) {
+	initiatorKeyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	h := runHandshake(t, aPeerHandshaker(initiatorKeyPair), hex.EncodeToString(responderKeyPair.NodeAddress()), aPeerHandshaker(responderKeyPair))
+	defer h.close()
+
+	require.NoError(t, h.initiatorErr, "initiator should complete the handshake")
+	require.NoError(t, h.responderErr, "responder should complete the handshake")
+	require.Equal(t, initiatorKeyPair.NodeAddress(), h.initiatorAddress, "responder should learn the address of the initiator")
+	require.IsType(t, &tls.Conn{}, h.initiatorConn, "initiator should talk tls")
+
+	requireReadsWhatOtherSideWrote(t, h.initiatorConn, h.responderConn)
+}
+
+func TestHandshake_InitiatorRejectsResponderThatIsNotTheExpectedNode(t *testing.T) {
+	expectedKeyPair := keys.EcdsaSecp256K1KeyPairForTests(2)
+
+	h := runHandshake(t, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(1)), hex.EncodeToString(expectedKeyPair.NodeAddress()), aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)))
+	defer h.close()
+
+	require.Error(t, h.initiatorErr, "initiator should not accept a responder with an unexpected address")
+	require.Error(t, h.responderErr, "responder should fail once initiator hangs up")
+}
+
+func TestHandshake_ResponderRejectsInitiatorImpersonatingAnotherNode(t *testing.T) {
+	impersonatedKeyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	cfg := config.ForDirectTransportTests(impersonatedKeyPair.NodeAddress(), TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT)
+	impersonator := newHandshaker(cfg, signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(2).PrivateKey()), nil)
+
+	h := runHandshake(t, impersonator, hex.EncodeToString(responderKeyPair.NodeAddress()), aPeerHandshaker(responderKeyPair))
+	defer h.close()
+
+	require.Error(t, h.responderErr, "responder should not accept an initiator signing with a key other than that of its node address")
+	requireClosedByOtherSide(t, h.initiatorConn)
+}
+
+func TestHandshake_ResponderRejectsInitiatorThatIsNotAllowed(t *testing.T) {
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	cfg := config.ForDirectTransportTests(responderKeyPair.NodeAddress(), TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT)
+	responder := newHandshaker(cfg, signer.NewLocalSigner(responderKeyPair.PrivateKey()), func(nodeAddress primitives.NodeAddress) bool {
+		return false
+	})
+
+	h := runHandshake(t, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(1)), hex.EncodeToString(responderKeyPair.NodeAddress()), responder)
+	defer h.close()
+
+	require.Error(t, h.responderErr, "responder should not accept an initiator it does not allow")
+	requireClosedByOtherSide(t, h.initiatorConn)
+}
+
+func TestHandshake_ResponderAcceptingPlaintextTalksToInitiatorThatPredatesTls(t *testing.T) {
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	initiator := aPeerHandshakerWithSecurity(keys.EcdsaSecp256K1KeyPairForTests(1), TransportSecurityPlaintextAcceptTls)
+
+	h := runHandshake(t, initiator, hex.EncodeToString(responderKeyPair.NodeAddress()), aPeerHandshakerWithSecurity(responderKeyPair, TransportSecurityTlsAcceptPlaintext))
+	defer h.close()
+
+	require.NoError(t, h.initiatorErr, "initiator should connect in plaintext")
+	message := writeRandomMessage(h.initiatorConn)
+	<-h.responded
+	require.NoError(t, h.responderErr, "responder should accept plaintext")
+	require.Nil(t, h.initiatorAddress, "responder should not take the address of an initiator that did not authenticate")
+
+	requireReads(t, h.responderConn, message)
+}
+
+func TestHandshake_ResponderAcceptingPlaintextStillAuthenticatesTlsInitiator(t *testing.T) {
+	initiatorKeyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+
+	h := runHandshake(t, aPeerHandshaker(initiatorKeyPair), hex.EncodeToString(responderKeyPair.NodeAddress()), aPeerHandshakerWithSecurity(responderKeyPair, TransportSecurityPlaintextAcceptTls))
+	defer h.close()
+
+	require.NoError(t, h.initiatorErr, "initiator should complete the handshake")
+	require.NoError(t, h.responderErr, "responder should complete the handshake")
+	require.Equal(t, initiatorKeyPair.NodeAddress(), h.initiatorAddress, "responder should learn the address of the initiator")
+
+	requireReadsWhatOtherSideWrote(t, h.initiatorConn, h.responderConn)
+}
+
+func TestHandshake_ResponderRequiringTlsRejectsPlaintextInitiator(t *testing.T) {
+	responderKeyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	initiator := aPeerHandshakerWithSecurity(keys.EcdsaSecp256K1KeyPairForTests(1), TransportSecurityPlaintextAcceptTls)
+
+	h := runHandshake(t, initiator, hex.EncodeToString(responderKeyPair.NodeAddress()), aPeerHandshaker(responderKeyPair))
+	defer h.close()
+
+	_, _ = h.initiatorConn.Write(exampleWireProtocolEncoding_KeepAlive())
+	<-h.responded
+	require.Error(t, h.responderErr, "responder should not accept plaintext")
+}
+
+func TestHandshake_PanicsOnUnknownTransportSecurity(t *testing.T) {
+	require.Panics(t, func() {
+		aPeerHandshakerWithSecurity(keys.EcdsaSecp256K1KeyPairForTests(0), "none")
+	})
+}
+
+type handshakeResult struct {
+	initiatorConn    net.Conn
+	initiatorErr     error
+	responderConn    net.Conn
+	initiatorAddress primitives.NodeAddress
+	responderErr     error
+	responded        chan struct{}
+}
+
+// the side that fails hangs up, the way transport connections do, so the other side fails fast instead of timing out.
+// a responder waiting for plaintext traffic before it can tell the initiator does not talk tls is not waited for
+func runHandshake(t *testing.T, initiator *handshaker, expectedResponderHexAddress string, responder *handshaker) *handshakeResult {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "test could not listen")
+	defer listener.Close()
+
+	res := &handshakeResult{responded: make(chan struct{})}
+	go func() {
+		defer close(res.responded)
+		conn, err := listener.Accept()
+		if err != nil {
+			res.responderErr = err
+			return
+		}
+		res.responderConn, res.initiatorAddress, res.responderErr = responder.accept(context.Background(), conn)
+		if res.responderErr != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err, "test could not connect")
+	res.initiatorConn, res.initiatorErr = initiator.initiate(context.Background(), conn, expectedResponderHexAddress)
+	if res.initiatorErr != nil {
+		_ = conn.Close()
+	}
+
+	if _, isTls := res.initiatorConn.(*tls.Conn); isTls || res.initiatorErr != nil {
+		<-res.responded
+	}
+	return res
+}
+
+func (h *handshakeResult) close() {
+	if h.initiatorConn != nil {
+		_ = h.initiatorConn.Close()
+	}
+	if h.responderConn != nil {
+		_ = h.responderConn.Close()
+	}
+}
+
+func requireReadsWhatOtherSideWrote(t *testing.T, writer net.Conn, reader net.Conn) {
+	requireReads(t, reader, writeRandomMessage(writer))
+}
+
+func writeRandomMessage(writer net.Conn) []byte {
+	message := make([]byte, 100*1024) // spans several tls records
+	_, _ = rand.Read(message)
+	go func() {
+		_, _ = writer.Write(message)
+	}()
+	return message
+}
+
+func requireReads(t *testing.T, reader net.Conn, message []byte) {
+	received := make([]byte, len(message))
+	_, err := io.ReadFull(reader, received)
+	require.NoError(t, err, "should read what the other side wrote")
+	require.Equal(t, message, received)
+}
+
+// in tls 1.3 the client completes its handshake before the server checks the client certificate, so an initiator the
+// responder rejects only finds out when it reads
+func requireClosedByOtherSide(t *testing.T, conn net.Conn) {
+	if conn == nil {
+		return
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(HARNESS_PEER_READ_TIMEOUT))
+	_, err := conn.Read(make([]byte, 1))
+	require.Error(t, err, "connection should be closed by the other side")
+	require.False(t, isTimeout(err), "connection should be closed by the other side rather than time out")
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
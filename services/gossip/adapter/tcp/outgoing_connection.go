@@ -10,7 +10,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/govnr"
-	"github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
@@ -32,7 +31,9 @@ type outgoingConnection struct {
 	metricRegistry metric.Registry
 	config         timingsConfig
 	sharedMetrics  *outgoingConnectionMetrics // TODO this is smelly, see how we can restructure metrics so that an outgoing connection doesn't have to share the parent metrics
-	queue          *transportQueue
+	handshaker     *handshaker
+	queue          *priorityQueue
+	networkAddress string
 	peerHexAddress string
 	cancel         context.CancelFunc
 
@@ -42,14 +43,13 @@ type outgoingConnection struct {
 	closed chan struct{}
 }
 
-func newOutgoingConnection(peer adapter.TransportPeer, parentLogger log.Logger, metricFactory metric.Registry, sharedMetrics *outgoingConnectionMetrics, transportConfig timingsConfig) *outgoingConnection {
+func newOutgoingConnection(peer adapter.TransportPeer, parentLogger log.Logger, metricFactory metric.Registry, sharedMetrics *outgoingConnectionMetrics, transportConfig timingsConfig, handshaker *handshaker) *outgoingConnection {
 	networkAddress := fmt.Sprintf("%s:%d", peer.Endpoint(), peer.Port())
 	peerHexAddress := peer.HexOrbsAddress()
 
 	logger := parentLogger.WithTags(log.String("peer-node-address", peerHexAddress[:6]), log.String("peer-network-address", networkAddress))
 
-	queue := NewTransportQueue(SEND_QUEUE_MAX_BYTES, SEND_QUEUE_MAX_MESSAGES, metricFactory, peerHexAddress, logger)
-	queue.networkAddress = networkAddress
+	queue := newPriorityQueue(metricFactory, peerHexAddress, logger)
 	queue.Disable() // until connection is established
 
 	sendErrors, sendQueueErrors := generateMetrics(peerHexAddress, metricFactory, logger)
@@ -57,9 +57,11 @@ func newOutgoingConnection(peer adapter.TransportPeer, parentLogger log.Logger,
 	client := &outgoingConnection{
 		logger:          logger,
 		sharedMetrics:   sharedMetrics,
+		handshaker:      handshaker,
 		metricRegistry:  metricFactory,
 		config:          transportConfig,
 		queue:           queue,
+		networkAddress:  networkAddress,
 		peerHexAddress:  peerHexAddress,
 		sendErrors:      sendErrors,
 		sendQueueErrors: sendQueueErrors,
@@ -112,7 +114,7 @@ func (c *outgoingConnection) connectionMainLoop(parentCtx context.Context) {
 		logger := c.logger.WithTags(trace.LogFieldFrom(ctx))
 
 		logger.Info("attempting outgoing transport connection")
-		conn, err := net.DialTimeout("tcp", c.queue.networkAddress, c.config.GossipNetworkTimeout())
+		conn, err := net.DialTimeout("tcp", c.networkAddress, c.config.GossipNetworkTimeout())
 
 		if err != nil {
 			logger.Info("cannot connect to gossip peer endpoint", log.Error(err))
@@ -120,7 +122,16 @@ func (c *outgoingConnection) connectionMainLoop(parentCtx context.Context) {
 			continue
 		}
 
-		if !c.handleOutgoingConnection(ctx, conn) {
+		securedConn, err := c.handshaker.initiate(ctx, conn, c.peerHexAddress)
+		if err != nil {
+			c.sharedMetrics.handshakeErrors.Inc()
+			logger.Info("gossip peer handshake failed", log.Error(err))
+			_ = conn.Close()
+			time.Sleep(c.config.GossipReconnectInterval())
+			continue
+		}
+
+		if !c.handleOutgoingConnection(ctx, securedConn) {
 			return
 		}
 	}
@@ -139,10 +150,15 @@ func (c *outgoingConnection) handleOutgoingConnection(ctx context.Context, conn
 
 	defer conn.Close() // we only exit this function when this connection has errored, or if we're disconnecting, so we can safely defer close()
 
+	compress := readsCompressedFrames(conn)
+	if compress {
+		logger.Info("gossip peer reads compressed frames, compressing large payloads")
+	}
+
 	for {
 		if data := c.popMessageFromQueue(ctx); data != nil {
 			// got data from queue
-			err := c.sendToSocket(ctx, conn, data)
+			err := c.sendToSocket(ctx, conn, data, compress)
 			if err != nil {
 				logger.Info("connection closing due to socket error")
 				return c.reconnectAfterSocketError(logger, err)
@@ -179,7 +195,9 @@ func (c *outgoingConnection) onDisconnect(logger log.Logger) bool {
 	logger.Info("client loop stopped since a disconnect was requested (topology change or system shutdown)")
 	c.metricRegistry.Remove(c.sendErrors)
 	c.metricRegistry.Remove(c.sendQueueErrors)
-	c.metricRegistry.Remove(c.queue.usagePercentageMetric)
+	for _, usageMetric := range c.queue.usageMetrics() {
+		c.metricRegistry.Remove(usageMetric)
+	}
 	return false
 }
 
@@ -205,43 +223,12 @@ func (c *outgoingConnection) addDataToOutgoingPeerQueue(ctx context.Context, dat
 	}
 }
 
-func (c *outgoingConnection) sendToSocket(ctx context.Context, conn net.Conn, data *adapter.TransportData) error {
-	timeout := c.config.GossipNetworkTimeout()
-	zeroBuffer := make([]byte, 4)
-	sizeBuffer := make([]byte, 4)
-
-	// send num payloads
-	membuffers.WriteUint32(sizeBuffer, uint32(len(data.Payloads)))
-	err := write(ctx, conn, sizeBuffer, timeout)
-	if err != nil {
-		return err
-	}
-
-	for _, payload := range data.Payloads {
-		// send payload size
-		membuffers.WriteUint32(sizeBuffer, uint32(len(payload)))
-		err := write(ctx, conn, sizeBuffer, timeout)
-		if err != nil {
-			return err
-		}
-
-		// send payload data
-		err = write(ctx, conn, payload, timeout)
-		if err != nil {
-			return err
-		}
-
-		// send padding
-		paddingSize := calcPaddingSize(uint32(len(payload)))
-		if paddingSize > 0 {
-			err = write(ctx, conn, zeroBuffer[:paddingSize], timeout)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+func (c *outgoingConnection) sendToSocket(ctx context.Context, conn net.Conn, data *adapter.TransportData, compress bool) error {
+	frame := encodeFrame(data.Payloads, compress, func(size int, compressedSize int) {
+		c.sharedMetrics.compressionRatio.Record(int64(compressedSize * 100 / size))
+		c.sharedMetrics.compressionSaved.Add(int64(size - compressedSize))
+	})
+	return write(ctx, conn, frame, c.config.GossipNetworkTimeout())
 }
 
 func (c *outgoingConnection) sendKeepAlive(ctx context.Context, conn net.Conn) error {
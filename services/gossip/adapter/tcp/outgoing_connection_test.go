@@ -7,13 +7,19 @@
 package tcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"github.com/orbs-network/membuffers/go"
+	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net"
 	"testing"
 	"time"
@@ -62,6 +68,50 @@ func TestOutgoingConnection_ReconnectsWhenServerDisconnects_AndSendKeepAlive(t *
 	})
 }
 
+func TestOutgoingConnection_CompressesLargePayloads_ToServerThatReadsCompressedFrames(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			server := newServerStub(t)
+			defer server.Close()
+
+			client := server.createClientAndConnect(ctx, t, parent.Logger, 20*time.Hour) // so that we don't send keep alives
+			waitForQueueEnabled(t, client)
+
+			large := bytes.Repeat([]byte{0x11}, 10*COMPRESSION_THRESHOLD_BYTES)
+			require.True(t, test.Eventually(HARNESS_OUTGOING_CONNECTIONS_INIT_TIMEOUT, func() bool {
+				client.addDataToOutgoingPeerQueue(ctx, &adapter.TransportData{Payloads: [][]byte{large, {0x22}}})
+				require.Equal(t, [][]byte{large, {0x22}}, server.receivePayloads(t, ctx, parent.Logger), "server should receive the payloads client sent")
+				return client.sharedMetrics.compressionSaved.IntValue() > 0
+			}), "client should compress large payloads to a server that agreed to read compressed frames")
+
+			<-client.disconnect()
+		})
+	})
+}
+
+func TestOutgoingConnection_SendsOriginalFrames_OverPlaintextToServerThatPredatesTls(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			server := newServerStub(t)
+			server.clientSecurity = TransportSecurityPlaintextAcceptTls
+			defer server.Close()
+
+			client := server.createClientAndConnect(ctx, t, parent.Logger, 20*time.Hour) // so that we don't send keep alives
+			waitForQueueEnabled(t, client)
+
+			client.addDataToOutgoingPeerQueue(ctx, &adapter.TransportData{Payloads: [][]byte{bytes.Repeat([]byte{0x11}, 10*COMPRESSION_THRESHOLD_BYTES)}})
+
+			numPayloads := make([]byte, 4)
+			_, err := io.ReadFull(&connReader{ctx: ctx, conn: server.conn, timeout: HARNESS_PEER_READ_TIMEOUT}, numPayloads)
+			require.NoError(t, err, "server should receive the frame client sent")
+			require.EqualValues(t, 1, membuffers.GetUint32(numPayloads), "client should send the number of payloads first, as in frames that are not versioned")
+			require.Zero(t, client.sharedMetrics.compressionSaved.IntValue(), "client should not compress")
+
+			<-client.disconnect()
+		})
+	})
+}
+
 type timeouts struct {
 	keepAliveInterval time.Duration
 }
@@ -82,10 +132,13 @@ type serverStub struct {
 	listener net.Listener
 	conn     net.Conn
 	port     int
+	keyPair  *keys.TestEcdsaSecp256K1KeyPair
+
+	clientSecurity string // the transport security of clients created by the stub
 }
 
 func newServerStub(t testing.TB) *serverStub {
-	s := &serverStub{}
+	s := &serverStub{keyPair: keys.EcdsaSecp256K1KeyPairForTests(1), clientSecurity: TransportSecurityTls}
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err, "test peer server could not listen")
 	s.listener = listener
@@ -101,6 +154,10 @@ func (s *serverStub) Close() {
 func (s *serverStub) acceptClientConnection(t testing.TB) {
 	conn, err := s.listener.Accept()
 	require.NoError(t, err, "test peer server could not accept connection")
+	if s.clientSecurity != TransportSecurityPlaintextAcceptTls { // otherwise the stub plays a server that predates tls
+		conn, _, err = aPeerHandshaker(s.keyPair).accept(context.Background(), conn)
+		require.NoError(t, err, "test peer server could not complete the handshake")
+	}
 	_ = conn.SetReadDeadline(time.Now().Add(HARNESS_PEER_READ_TIMEOUT))
 	s.conn = conn
 }
@@ -110,10 +167,17 @@ func (s *serverStub) readSomeBytes() int {
 	return bytesRead
 }
 
+func (s *serverStub) receivePayloads(t testing.TB, ctx context.Context, logger log.Logger) [][]byte {
+	cfg := config.ForDirectTransportTests(s.keyPair.NodeAddress(), TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT)
+	payloads, err := newServer(cfg, nil, logger, metric.NewRegistry()).receiveTransportData(ctx, s.conn)
+	require.NoError(t, err, "test peer server could not receive transport data")
+	return payloads
+}
+
 func (s *serverStub) createClientAndConnect(ctx context.Context, t testing.TB, logger log.Logger, keepAliveInterval time.Duration) *outgoingConnection {
 	registry := metric.NewRegistry()
-	peer := adapter.NewGossipPeer(s.port, "127.0.0.1", "012345")
-	client := newOutgoingConnection(peer, logger, registry, createOutgoingConnectionMetrics(registry), &timeouts{keepAliveInterval: keepAliveInterval})
+	peer := adapter.NewGossipPeer(s.port, "127.0.0.1", hex.EncodeToString(s.keyPair.NodeAddress()))
+	client := newOutgoingConnection(peer, logger, registry, createOutgoingConnectionMetrics(registry), &timeouts{keepAliveInterval: keepAliveInterval}, aPeerHandshakerWithSecurity(keys.EcdsaSecp256K1KeyPairForTests(0), s.clientSecurity))
 	client.connect(ctx)
 	s.acceptClientConnection(t)
 	return client
@@ -17,8 +17,11 @@ type outgoingConnectionMetrics struct {
 	KeepaliveErrors *metric.Gauge
 	sendQueueErrors *metric.Gauge
 	activeCount     *metric.Gauge
+	handshakeErrors *metric.Gauge
 
-	messageSize *metric.Histogram
+	messageSize      *metric.Histogram
+	compressionRatio *metric.Histogram
+	compressionSaved *metric.Gauge
 }
 
 type outgoingConnections struct {
@@ -30,6 +33,7 @@ type outgoingConnections struct {
 	config            timingsConfig
 	metricRegistry    metric.Registry
 	nodeAddress       primitives.NodeAddress
+	handshaker        *handshaker
 }
 
 func newOutgoingConnections(logger log.Logger, registry metric.Registry, config config.GossipTransportConfig) *outgoingConnections {
@@ -52,7 +56,11 @@ func createOutgoingConnectionMetrics(registry metric.Registry) *outgoingConnecti
 		KeepaliveErrors: registry.NewGauge("Gossip.OutgoingConnection.KeepaliveErrors.Count"),
 		sendQueueErrors: registry.NewGauge("Gossip.OutgoingConnection.SendQueueErrors.Count"),
 		activeCount:     registry.NewGauge("Gossip.OutgoingConnection.Active.Count"),
-		messageSize:     registry.NewHistogram("Gossip.OutgoingConnection.MessageSize.Bytes", MAX_PAYLOAD_SIZE_BYTES),
+		handshakeErrors: registry.NewGauge("Gossip.OutgoingConnection.HandshakeErrors.Count"),
+
+		messageSize:      registry.NewHistogram("Gossip.OutgoingConnection.MessageSize.Bytes", MAX_PAYLOAD_SIZE_BYTES),
+		compressionRatio: registry.NewHistogram("Gossip.OutgoingConnection.CompressionRatio.Percent", 100),
+		compressionSaved: registry.NewGauge("Gossip.OutgoingConnection.CompressionSaved.Bytes"),
 	}
 }
 
@@ -99,7 +107,7 @@ func (c *outgoingConnections) updateTopology(bgCtx context.Context, newTopology
 func (c *outgoingConnections) connectForeverUnderLock(bgCtx context.Context, peerNodeAddress string, peer adapter.TransportPeer) {
 	if c.nodeAddress.KeyForMap() != peerNodeAddress {
 		c.peerTopology[peerNodeAddress] = peer
-		client := newOutgoingConnection(peer, c.logger, c.metricRegistry, c.metrics, c.config)
+		client := newOutgoingConnection(peer, c.logger, c.metricRegistry, c.metrics, c.config, c.handshaker)
 		c.activeConnections[peerNodeAddress] = client
 		client.connect(bgCtx)
 	}
@@ -122,6 +130,15 @@ func (c *outgoingConnections) disconnectAllUnderLock(ctx context.Context, peersT
 	}
 }
 
+// incoming connections are accepted only from peers in the topology this node connects to
+func (c *outgoingConnections) isInTopology(nodeAddress primitives.NodeAddress) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	_, found := c.peerTopology[nodeAddress.KeyForMap()]
+	return found
+}
+
 var DataExceedsCapacityError = errors.Errorf("Data exceeds allowed size %d", SEND_QUEUE_MAX_BYTES)
 
 // TODO(https://github.com/orbs-network/orbs-network-go/issues/182): we are not currently respecting any intents given in ctx (added in context refactor)
@@ -0,0 +1,150 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/scribe/log"
+)
+
+// messages waiting to be sent to a peer are queued by the class of their topic, and a class is only sent once the
+// classes above it are empty. this way a burst of block sync chunks or relayed transactions can't hold back consensus
+type queueClass int
+
+const (
+	QUEUE_CLASS_CONSENSUS queueClass = iota
+	QUEUE_CLASS_BLOCK_SYNC
+	QUEUE_CLASS_TRANSACTION_RELAY
+)
+
+var queueClassNames = []string{"Consensus", "BlockSync", "TransactionRelay"}
+
+// every class has capacity of its own, so filling up one of them doesn't make the others drop messages
+var queueClassCapacities = []struct {
+	maxBytes    int
+	maxMessages int
+}{
+	QUEUE_CLASS_CONSENSUS:         {maxBytes: SEND_QUEUE_MAX_BYTES, maxMessages: SEND_QUEUE_MAX_MESSAGES},
+	QUEUE_CLASS_BLOCK_SYNC:        {maxBytes: SEND_QUEUE_MAX_BYTES, maxMessages: SEND_QUEUE_MAX_MESSAGES / 10}, // chunks are big and requested again if lost
+	QUEUE_CLASS_TRANSACTION_RELAY: {maxBytes: SEND_QUEUE_MAX_BYTES / 2, maxMessages: SEND_QUEUE_MAX_MESSAGES},
+}
+
+func (c queueClass) String() string {
+	return queueClassNames[c]
+}
+
+type priorityQueue struct {
+	classes []*transportQueue // by priority, highest first
+	pushed  chan struct{}     // wakes a waiting pop, holding a single signal for any number of pushes
+}
+
+func newPriorityQueue(metricFactory metric.Registry, peerNodeAddress string, logger log.Logger) *priorityQueue {
+	q := &priorityQueue{
+		pushed: make(chan struct{}, 1),
+	}
+	for class, capacity := range queueClassCapacities {
+		name := fmt.Sprintf("%s.%s", queueClass(class), peerNodeAddress)
+		q.classes = append(q.classes, NewTransportQueue(capacity.maxBytes, capacity.maxMessages, metricFactory, name, logger))
+	}
+	return q
+}
+
+func queueClassOf(data *adapter.TransportData) queueClass {
+	if len(data.Payloads) == 0 {
+		return QUEUE_CLASS_TRANSACTION_RELAY
+	}
+	header := gossipmessages.HeaderReader(data.Payloads[0])
+	if !header.IsValid() {
+		return QUEUE_CLASS_TRANSACTION_RELAY
+	}
+
+	switch header.Topic() {
+	case gossipmessages.HEADER_TOPIC_LEAN_HELIX, gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS:
+		return QUEUE_CLASS_CONSENSUS
+	case gossipmessages.HEADER_TOPIC_BLOCK_SYNC:
+		return QUEUE_CLASS_BLOCK_SYNC
+	default:
+		return QUEUE_CLASS_TRANSACTION_RELAY
+	}
+}
+
+func (q *priorityQueue) Push(data *adapter.TransportData) error {
+	err := q.classes[queueClassOf(data)].Push(data)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.pushed <- struct{}{}:
+	default: // a pop is already due to wake up
+	}
+	return nil
+}
+
+// Pop returns the oldest message of the highest class that isn't empty, waiting for one to be pushed if all are
+func (q *priorityQueue) Pop(ctx context.Context) *adapter.TransportData {
+	for {
+		for _, class := range q.classes {
+			select {
+			case res := <-class.channel:
+				class.releaseBytes(res)
+				return res
+			default:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.pushed:
+		}
+	}
+}
+
+func (q *priorityQueue) Clear(ctx context.Context) {
+	for _, class := range q.classes {
+		class.Clear(ctx)
+	}
+}
+
+func (q *priorityQueue) Disable() {
+	for _, class := range q.classes {
+		class.Disable()
+	}
+}
+
+func (q *priorityQueue) Enable() {
+	for _, class := range q.classes {
+		class.Enable()
+	}
+}
+
+func (q *priorityQueue) disabled() bool {
+	for _, class := range q.classes {
+		if class.disabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *priorityQueue) OnNewConnection(ctx context.Context) {
+	q.Clear(ctx)
+	q.Enable()
+}
+
+func (q *priorityQueue) usageMetrics() []*metric.Gauge {
+	var res []*metric.Gauge
+	for _, class := range q.classes {
+		res = append(res, class.usagePercentageMetric)
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package tcp
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPriorityQueue_PopsConsensusBeforeBlockSyncBeforeTransactionRelay(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			q := newPriorityQueue(metric.NewRegistry(), someAddress, parent.Logger)
+			q.Enable()
+
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)))
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, 0x02)))
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x03)))
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX, 0x04)))
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS, 0x05)))
+
+			for _, expected := range []byte{0x04, 0x05, 0x02, 0x01, 0x03} {
+				require.EqualValues(t, []byte{expected}, q.Pop(ctx).SenderNodeAddress)
+			}
+		})
+	})
+}
+
+func TestPriorityQueue_FullClassDoesNotBlockOtherClasses(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			q := newPriorityQueue(metric.NewRegistry(), someAddress, parent.Logger)
+			q.Enable()
+
+			var err error
+			for i := 0; err == nil; i++ {
+				err = q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, 0x01))
+				require.True(t, i <= queueClassCapacities[QUEUE_CLASS_BLOCK_SYNC].maxMessages, "block sync class should fill up")
+			}
+
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX, 0x02)), "consensus class should not be affected by block sync class being full")
+			require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x03)), "transaction relay class should not be affected by block sync class being full")
+			require.EqualValues(t, []byte{0x02}, q.Pop(ctx).SenderNodeAddress)
+		})
+	})
+}
+
+func TestPriorityQueue_PopWhenEmptyWaitsUntilPushToAnyClass(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			q := newPriorityQueue(metric.NewRegistry(), someAddress, parent.Logger)
+			q.Enable()
+
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				_ = q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01))
+			}()
+
+			require.EqualValues(t, []byte{0x01}, q.Pop(ctx).SenderNodeAddress)
+		})
+	})
+}
+
+func TestPriorityQueue_MessagesWithoutValidHeaderAreQueuedLast(t *testing.T) {
+	require.Equal(t, QUEUE_CLASS_TRANSACTION_RELAY, queueClassOf(&adapter.TransportData{}))
+	require.Equal(t, QUEUE_CLASS_TRANSACTION_RELAY, queueClassOf(&adapter.TransportData{Payloads: [][]byte{{0x01}}}))
+}
+
+func TestPriorityQueue_DisablesAllClasses(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		q := newPriorityQueue(metric.NewRegistry(), someAddress, parent.Logger)
+
+		q.Disable()
+		require.True(t, q.disabled())
+		require.Error(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_LEAN_HELIX, 0x01)))
+		require.Error(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)))
+
+		q.Enable()
+		require.False(t, q.disabled())
+		require.NoError(t, q.Push(aMessageOfTopic(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, 0x01)))
+	})
+}
+
+func aMessageOfTopic(topic gossipmessages.HeaderTopic, sender byte) *adapter.TransportData {
+	header := &gossipmessages.HeaderBuilder{
+		Topic:         topic,
+		RecipientMode: gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+	}
+	switch topic {
+	case gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY:
+		header.TransactionRelay = gossipmessages.TRANSACTION_RELAY_FORWARDED_TRANSACTIONS
+	case gossipmessages.HEADER_TOPIC_BLOCK_SYNC:
+		header.BlockSync = gossipmessages.BLOCK_SYNC_RESPONSE
+	case gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS:
+		header.BenchmarkConsensus = consensus.BENCHMARK_CONSENSUS_COMMIT
+	}
+	return &adapter.TransportData{
+		SenderNodeAddress: []byte{sender},
+		Payloads:          [][]byte{header.Build().Raw()},
+	}
+}
@@ -17,10 +17,9 @@ import (
 )
 
 type transportQueue struct {
-	channel        chan *adapter.TransportData // replace this buffered channel with github.com/phf/go-queue if we don't want maxSizeMessages (and its pre allocation)
-	networkAddress string
-	maxBytes       int
-	maxMessages    int
+	channel     chan *adapter.TransportData // replace this buffered channel with github.com/phf/go-queue if we don't want maxSizeMessages (and its pre allocation)
+	maxBytes    int
+	maxMessages int
 
 	protected struct {
 		sync.Mutex
@@ -30,7 +29,7 @@ type transportQueue struct {
 	usagePercentageMetric *metric.Gauge
 }
 
-func NewTransportQueue(maxSizeBytes int, maxSizeMessages int, metricFactory metric.Registry, peerNodeAddress string, logger log.Logger) *transportQueue {
+func NewTransportQueue(maxSizeBytes int, maxSizeMessages int, metricFactory metric.Registry, queueName string, logger log.Logger) *transportQueue {
 	q := &transportQueue{
 		channel:     make(chan *adapter.TransportData, maxSizeMessages),
 		maxBytes:    maxSizeBytes,
@@ -39,13 +38,13 @@ func NewTransportQueue(maxSizeBytes int, maxSizeMessages int, metricFactory metr
 	q.protected.bytesLeft = maxSizeBytes
 
 	// round-about way to remove old queue metric if exists
-	queueUsageName := fmt.Sprintf("Gossip.OutgoingConnection.QueueUsage.%s.Percent", peerNodeAddress)
+	queueUsageName := fmt.Sprintf("Gossip.OutgoingConnection.QueueUsage.%s.Percent", queueName)
 	queueUsageMetric := metricFactory.Get(queueUsageName)
 	if queueUsageMetric != nil {
 		logger.Info("TransportQueue ctor issue", log.Error(errors.Errorf("Metric %s still existed when new connection created", queueUsageName)))
 	}
 	metricFactory.Remove(queueUsageMetric)
-	q.usagePercentageMetric = metricFactory.NewGaugeWithPrometheusName(queueUsageName, fmt.Sprintf("Gossip.OutgoingConnection.Queue.Usage.%s.Percent", peerNodeAddress))
+	q.usagePercentageMetric = metricFactory.NewGaugeWithPrometheusName(queueUsageName, fmt.Sprintf("Gossip.OutgoingConnection.Queue.Usage.%s.Percent", queueName))
 
 	return q
 }
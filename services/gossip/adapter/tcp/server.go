@@ -10,13 +10,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/orbs-network/govnr"
-	"github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/scribe/log"
-	"github.com/pkg/errors"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -39,21 +38,28 @@ type transportServer struct {
 	logger         log.Logger
 	metrics        incomingConnectionMetrics
 	config         serverConfig
+	handshaker     *handshaker
 	shutdownServer context.CancelFunc
+
+	// authenticated incoming connections by the node address of their peer, closed once the peer leaves the topology
+	peerConnections map[string]map[net.Conn]bool
 }
 
 type incomingConnectionMetrics struct {
 	acceptSuccesses   *metric.Gauge
 	acceptErrors      *metric.Gauge
 	transportErrors   *metric.Gauge
+	handshakeErrors   *metric.Gauge
 	activeConnections *metric.Gauge
 }
 
-func newServer(config serverConfig, logger log.Logger, registry metric.Registry) *transportServer {
+func newServer(config serverConfig, handshaker *handshaker, logger log.Logger, registry metric.Registry) *transportServer {
 	server := &transportServer{
-		config:  config,
-		logger:  logger,
-		metrics: createServerMetrics(registry),
+		config:          config,
+		handshaker:      handshaker,
+		logger:          logger,
+		metrics:         createServerMetrics(registry),
+		peerConnections: make(map[string]map[net.Conn]bool),
 	}
 
 	return server
@@ -64,6 +70,7 @@ func createServerMetrics(registry metric.Registry) incomingConnectionMetrics {
 		acceptSuccesses:   registry.NewGauge("Gossip.IncomingConnection.ListeningOnTCPPortSuccess.Count"),
 		acceptErrors:      registry.NewGauge("Gossip.IncomingConnection.ListeningOnTCPPortErrors.Count"),
 		transportErrors:   registry.NewGauge("Gossip.IncomingConnection.TransportErrors.Count"),
+		handshakeErrors:   registry.NewGauge("Gossip.IncomingConnection.HandshakeErrors.Count"),
 		activeConnections: registry.NewGauge("Gossip.IncomingConnection.Active.Count"),
 	}
 }
@@ -148,16 +155,34 @@ func (t *transportServer) mainLoop(parentCtx context.Context, listener net.Liste
 }
 
 func (t *transportServer) handleIncomingConnection(ctx context.Context, conn net.Conn) {
-	t.logger.Info("successful incoming gossip transport connection", log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): add a white list for IPs we're willing to accept connections from
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): make sure each IP from the white list connects only once
+	defer func() { _ = conn.Close() }()
+
+	securedConn, peerNodeAddress, err := t.handshaker.accept(ctx, conn)
+	if err != nil {
+		t.metrics.handshakeErrors.Inc()
+		t.logger.Info("gossip peer handshake failed, disconnecting", log.Error(err), log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
+		return
+	}
+	if peerNodeAddress != nil {
+		ctx = adapter.ContextWithPeerNodeAddress(ctx, peerNodeAddress)
+		t.addPeerConnection(peerNodeAddress, conn)
+		defer t.removePeerConnection(peerNodeAddress, conn)
+	}
+	rawConn := conn
+	conn = securedConn
+
+	t.logger.Info("successful incoming gossip transport connection", log.String("peer", conn.RemoteAddr().String()), log.Stringable("peer-node-address", peerNodeAddress), trace.LogFieldFrom(ctx))
+	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): make sure each node connects only once
 	t.metrics.activeConnections.Inc()
 	defer t.metrics.activeConnections.Dec()
 
-	defer func() { _ = conn.Close() }()
 	for {
 		payloads, err := t.receiveTransportData(ctx, conn)
 		if err != nil {
+			if !t.isPeerConnection(peerNodeAddress, rawConn) { // closed since the peer left the topology
+				t.logger.Info("gossip peer left the topology, disconnected", log.Stringable("peer-node-address", peerNodeAddress), trace.LogFieldFrom(ctx))
+				return
+			}
 			t.metrics.transportErrors.Inc()
 			t.logger.Info("failed receiving transport data, disconnecting", log.Error(err), log.String("peer", conn.RemoteAddr().String()), trace.LogFieldFrom(ctx))
 
@@ -172,51 +197,78 @@ func (t *transportServer) handleIncomingConnection(ctx context.Context, conn net
 	}
 }
 
-func (t *transportServer) receiveTransportData(ctx context.Context, conn net.Conn) ([][]byte, error) {
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): think about timeout policy on receive, we might not want it
-	timeout := t.config.GossipNetworkTimeout()
-	var res [][]byte
+func (t *transportServer) addPeerConnection(peerNodeAddress primitives.NodeAddress, conn net.Conn) {
+	t.Lock()
+	defer t.Unlock()
 
-	// receive num payloads
-	sizeBuffer, err := readTotal(ctx, conn, 4, timeout)
-	if err != nil {
-		return nil, err
+	connections, found := t.peerConnections[peerNodeAddress.KeyForMap()]
+	if !found {
+		connections = make(map[net.Conn]bool)
+		t.peerConnections[peerNodeAddress.KeyForMap()] = connections
 	}
-	numPayloads := membuffers.GetUint32(sizeBuffer)
+	connections[conn] = true
+}
+
+func (t *transportServer) removePeerConnection(peerNodeAddress primitives.NodeAddress, conn net.Conn) {
+	t.Lock()
+	defer t.Unlock()
 
-	if numPayloads > MAX_PAYLOADS_IN_MESSAGE {
-		return nil, errors.Errorf("received message with too many payloads: %d", numPayloads)
+	connections := t.peerConnections[peerNodeAddress.KeyForMap()]
+	delete(connections, conn)
+	if len(connections) == 0 {
+		delete(t.peerConnections, peerNodeAddress.KeyForMap())
 	}
+}
 
-	for i := uint32(0); i < numPayloads; i++ {
-		// receive payload size
-		sizeBuffer, err := readTotal(ctx, conn, 4, timeout)
-		if err != nil {
-			return nil, err
-		}
-		payloadSize := membuffers.GetUint32(sizeBuffer)
-		if payloadSize > MAX_PAYLOAD_SIZE_BYTES {
-			return nil, errors.Errorf("received message with a payload too big: %d bytes", payloadSize)
-		}
+// plaintext connections have no peer address, and are never closed by topology changes
+func (t *transportServer) isPeerConnection(peerNodeAddress primitives.NodeAddress, conn net.Conn) bool {
+	if peerNodeAddress == nil {
+		return true
+	}
 
-		// receive payload data
-		payload, err := readTotal(ctx, conn, payloadSize, timeout)
-		if err != nil {
-			return nil, err
+	t.RLock()
+	defer t.RUnlock()
+
+	return t.peerConnections[peerNodeAddress.KeyForMap()][conn]
+}
+
+// closes the incoming connections of the peers that are no longer allowed, which were only checked on handshake
+func (t *transportServer) disconnectPeersOutside(isPeerAllowed func(nodeAddress primitives.NodeAddress) bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	for key, connections := range t.peerConnections {
+		if isPeerAllowed(primitives.NodeAddress(key)) {
+			continue
 		}
-		res = append(res, payload)
-
-		// receive padding
-		paddingSize := calcPaddingSize(uint32(len(payload)))
-		if paddingSize > 0 {
-			_, err := readTotal(ctx, conn, paddingSize, timeout)
-			if err != nil {
-				return nil, err
-			}
+		delete(t.peerConnections, key)
+		for conn := range connections {
+			_ = conn.Close() // the raw connection, so its reader fails at once instead of waiting on a tls close
 		}
 	}
+}
 
-	return res, nil
+func (t *transportServer) receiveTransportData(ctx context.Context, conn net.Conn) ([][]byte, error) {
+	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): think about timeout policy on receive, we might not want it
+	return ReadFrame(&connReader{ctx: ctx, conn: conn, timeout: t.config.GossipNetworkTimeout()})
+}
+
+// connReader gives up once ctx is closed or a read times out
+type connReader struct {
+	ctx     context.Context
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (r *connReader) Read(buffer []byte) (int, error) {
+	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): consider whether the right approach is to poll context this way or have a single watchdog goroutine that closes all active connections when context is cancelled
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := r.conn.SetReadDeadline(time.Now().Add(r.timeout)); err != nil {
+		return 0, err
+	}
+	return r.conn.Read(buffer)
 }
 
 func (t *transportServer) notifyListener(ctx context.Context, payloads [][]byte) {
@@ -260,30 +312,3 @@ func (t *transportServer) GracefulShutdown(shutdownContext context.Context) {
 	}
 	t.shutdownServer()
 }
-
-func readTotal(ctx context.Context, conn net.Conn, totalSize uint32, timeout time.Duration) ([]byte, error) {
-	// TODO(https://github.com/orbs-network/orbs-network-go/issues/182): consider whether the right approach is to poll context this way or have a single watchdog goroutine that closes all active connections when context is cancelled
-	// make sure context is still open
-	err := ctx.Err()
-	if err != nil {
-		return nil, err
-	}
-
-	buffer := make([]byte, totalSize)
-	totalRead := uint32(0)
-	for totalRead < totalSize {
-		err := conn.SetReadDeadline(time.Now().Add(timeout))
-		if err != nil {
-			return nil, err
-		}
-		read, err := conn.Read(buffer[totalRead:])
-		totalRead += uint32(read)
-		if totalRead == totalSize {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-	}
-	return buffer, nil
-}
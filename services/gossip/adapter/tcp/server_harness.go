@@ -8,14 +8,20 @@ package tcp
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
+	"github.com/golang/snappy"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/membuffers/go"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/scribe/log"
 	"github.com/stretchr/testify/require"
 	"net"
@@ -34,23 +40,31 @@ type directHarness struct {
 	config    config.GossipTransportConfig
 	transport *DirectTransport
 
-	peerTalkerConnection net.Conn
-	listenerMock         *testkit.MockTransportListener
+	peerTalkerConnection  net.Conn
+	peerTalkerNodeAddress primitives.NodeAddress
+	listenerMock          *testkit.MockTransportListener
 }
 
 func newDirectHarnessWithConnectedPeers(t *testing.T, ctx context.Context, parent *with.ConcurrencyHarness) *directHarness {
-	address := keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress()
-	cfg := config.ForDirectTransportTests(address, TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT) // this gossipPeers is just a stub, it's mostly a client gossipPeers and this is a server harness
-	transport := makeTransport(ctx, parent.Logger, cfg)
+	keyPair := keys.EcdsaSecp256K1KeyPairForTests(0)
+	cfg := config.ForDirectTransportTests(keyPair.NodeAddress(), TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT) // this gossipPeers is just a stub, it's mostly a client gossipPeers and this is a server harness
+	transport := makeTransport(ctx, parent.Logger, cfg, signer.NewLocalSigner(keyPair.PrivateKey()))
+
+	peerKeyPair := keys.EcdsaSecp256K1KeyPairForTests(1)
+	transport.UpdateTopology(ctx, adapter.TransportPeers{
+		keyPair.NodeAddress().KeyForMap():     adapter.NewGossipPeer(transport.GetServerPort(), "127.0.0.1", hex.EncodeToString(keyPair.NodeAddress())),
+		peerKeyPair.NodeAddress().KeyForMap(): adapter.NewGossipPeer(0, "127.0.0.1", hex.EncodeToString(peerKeyPair.NodeAddress())), // the test peer only talks, the transport never reaches it
+	})
 
-	peerTalkerConnection := establishPeerClient(t, transport.GetServerPort()) // establish connection from test to server port ( test harness ==> SUT )
+	peerTalkerConnection := establishPeerClient(t, ctx, transport.GetServerPort(), peerKeyPair, keyPair.NodeAddress()) // establish connection from test to server port ( test harness ==> SUT )
 
 	h := &directHarness{
-		ConcurrencyHarness:   parent,
-		config:               cfg,
-		transport:            transport,
-		listenerMock:         &testkit.MockTransportListener{},
-		peerTalkerConnection: peerTalkerConnection,
+		ConcurrencyHarness:    parent,
+		config:                cfg,
+		transport:             transport,
+		listenerMock:          &testkit.MockTransportListener{},
+		peerTalkerConnection:  peerTalkerConnection,
+		peerTalkerNodeAddress: peerKeyPair.NodeAddress(),
 	}
 
 	h.Supervise(transport)
@@ -58,10 +72,10 @@ func newDirectHarnessWithConnectedPeers(t *testing.T, ctx context.Context, paren
 	return h
 }
 
-func makeTransport(ctx context.Context, logger log.Logger, cfg config.GossipTransportConfig) *DirectTransport {
+func makeTransport(ctx context.Context, logger log.Logger, cfg config.GossipTransportConfig, signer signer.Signer) *DirectTransport {
 	registry := metric.NewRegistry()
 
-	transport := NewDirectTransport(ctx, cfg, logger, registry)
+	transport := NewDirectTransport(ctx, cfg, signer, logger, registry)
 	// to synchronize tests, wait until server is ready
 	test.Eventually(test.EVENTUALLY_ADAPTER_TIMEOUT, func() bool {
 		return transport.IsServerListening()
@@ -69,12 +83,41 @@ func makeTransport(ctx context.Context, logger log.Logger, cfg config.GossipTran
 	return transport
 }
 
-func establishPeerClient(t *testing.T, serverPort int) net.Conn {
-	peerTalkerConnection, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", serverPort))
+// the returned connection has gone through the handshake, so the test writes the plain wire protocol to it
+func establishPeerClient(t *testing.T, ctx context.Context, serverPort int, peerKeyPair *keys.TestEcdsaSecp256K1KeyPair, serverAddress primitives.NodeAddress) net.Conn {
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", serverPort))
 	require.NoError(t, err, "test should be able connect to local transport")
+
+	peerTalkerConnection, err := aPeerHandshaker(peerKeyPair).initiate(ctx, conn, hex.EncodeToString(serverAddress))
+	require.NoError(t, err, "test should complete the handshake with local transport")
+
+	require.True(t, readsCompressedFrames(peerTalkerConnection), "local transport should agree to read compressed frames in the handshake")
 	return peerTalkerConnection
 }
 
+func aPeerHandshaker(keyPair *keys.TestEcdsaSecp256K1KeyPair) *handshaker {
+	return aPeerHandshakerWithSecurity(keyPair, TransportSecurityTls)
+}
+
+func aPeerHandshakerWithSecurity(keyPair *keys.TestEcdsaSecp256K1KeyPair, security string) *handshaker {
+	cfg := &transportSecurity{
+		GossipTransportConfig: config.ForDirectTransportTests(keyPair.NodeAddress(), TEST_KEEP_ALIVE_INTERVAL, TEST_NETWORK_TIMEOUT),
+		security:              security,
+	}
+	return newHandshaker(cfg, signer.NewLocalSigner(keyPair.PrivateKey()), func(nodeAddress primitives.NodeAddress) bool {
+		return true
+	})
+}
+
+type transportSecurity struct {
+	config.GossipTransportConfig
+	security string
+}
+
+func (c *transportSecurity) GossipTransportSecurity() string {
+	return c.security
+}
+
 func (h *directHarness) cleanupConnectedPeers() {
 	h.peerTalkerConnection.Close()
 }
@@ -136,6 +179,28 @@ func exampleWireProtocolEncoding_CorruptPayloadSize() []byte {
 	return concatSlices(field_NumPayloads, field_FirstPayloadSize, field_FirstPayloadData, field_FirstPayloadPadding)
 }
 
+func exampleWireProtocolEncoding_CompressedPayloads(large []byte) []byte {
+	// encoding payloads: [][]byte{large, {0x11}}, the large one compressed
+	field_FrameVersion := []byte{0x02, 0x00, 0x00, 0x80} // little endian, marked as versioned
+	field_NumPayloads := []byte{0x02, 0x00, 0x00, 0x00}  // little endian
+	field_FirstPayloadEncoding := []byte{0x01, 0x00, 0x00, 0x00}
+	field_FirstPayloadData := snappy.Encode(nil, large)
+	field_FirstPayloadSize := make([]byte, 4)
+	membuffers.WriteUint32(field_FirstPayloadSize, uint32(len(field_FirstPayloadData)))
+	field_FirstPayloadPadding := make([]byte, CalcPaddingSize(uint32(len(field_FirstPayloadData))))
+	field_SecondPayloadEncoding := []byte{0x00, 0x00, 0x00, 0x00}
+	field_SecondPayloadSize := []byte{0x01, 0x00, 0x00, 0x00} // little endian
+	field_SecondPayloadData := []byte{0x11}
+	field_SecondPayloadPadding := []byte{0x00, 0x00, 0x00} // round payload data to 4 bytes
+	return concatSlices(field_FrameVersion, field_NumPayloads, field_FirstPayloadEncoding, field_FirstPayloadSize, field_FirstPayloadData, field_FirstPayloadPadding, field_SecondPayloadEncoding, field_SecondPayloadSize, field_SecondPayloadData, field_SecondPayloadPadding)
+}
+
+func exampleWireProtocolEncoding_UnsupportedFrameVersion() []byte {
+	field_FrameVersion := []byte{0x09, 0x00, 0x00, 0x80} // little endian, marked as versioned
+	field_NumPayloads := []byte{0x00, 0x00, 0x00, 0x00}  // little endian
+	return concatSlices(field_FrameVersion, field_NumPayloads)
+}
+
 func exampleWireProtocolEncoding_KeepAlive() []byte {
 	// encoding payloads: [][]byte{} (this is how a keep alive looks like = zero payloads)
 	field_NumPayloads := []byte{0x00, 0x00, 0x00, 0x00} // little endian
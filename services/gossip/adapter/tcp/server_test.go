@@ -7,10 +7,15 @@
 package tcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
+	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/stretchr/testify/require"
 	"net"
@@ -125,6 +130,47 @@ func TestDirectIncoming_TransportListenerDoesNotReceiveCorruptData_PayloadSize(t
 	})
 }
 
+func TestDirectIncoming_TransportListenerReceivesCompressedData(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
+		defer h.cleanupConnectedPeers()
+		defer h.transport.GracefulShutdown(ctx)
+
+		large := bytes.Repeat([]byte{0x22, 0x33}, COMPRESSION_THRESHOLD_BYTES)
+		h.transport.RegisterListener(h.listenerMock, nil)
+		h.expectTransportListenerCalled([][]byte{large, {0x11}})
+
+		buffer := exampleWireProtocolEncoding_CompressedPayloads(large)
+		written, err := h.peerTalkerConnection.Write(buffer)
+		require.NoError(t, err, "test peer could not write to local transport")
+		require.Equal(t, len(buffer), written)
+
+		h.verifyTransportListenerCalled(t)
+	})
+}
+
+func TestDirectIncoming_TransportListenerDoesNotReceiveCorruptData_FrameVersion(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
+		defer h.cleanupConnectedPeers()
+		defer h.transport.GracefulShutdown(ctx)
+
+		h.transport.RegisterListener(h.listenerMock, nil)
+		h.expectTransportListenerNotCalled()
+
+		buffer := exampleWireProtocolEncoding_UnsupportedFrameVersion()
+		written, err := h.peerTalkerConnection.Write(buffer)
+		require.NoError(t, err, "test peer could not write to local transport")
+		require.Equal(t, len(buffer), written)
+
+		buffer = []byte{0} // dummy buffer just to see when the connection closes
+		_, err = h.peerTalkerConnection.Read(buffer)
+		require.Error(t, err, "test peer should be disconnected from local transport")
+
+		h.verifyTransportListenerNotCalled(t)
+	})
+}
+
 func TestDirectIncoming_TransportListenerIgnoresKeepAlives(t *testing.T) {
 	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
 		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
@@ -150,6 +196,56 @@ func TestDirectIncoming_TransportListenerIgnoresKeepAlives(t *testing.T) {
 	})
 }
 
+func TestDirectIncoming_RejectsPeersOutsideTopology(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
+		defer h.cleanupConnectedPeers()
+		defer h.transport.GracefulShutdown(ctx)
+
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", h.transport.GetServerPort()))
+		require.NoError(t, err, "test peer should be able connect to local transport")
+		defer conn.Close()
+
+		securedConn, err := aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(2)).initiate(ctx, conn, hex.EncodeToString(h.config.NodeAddress()))
+		if err == nil {
+			requireClosedByOtherSide(t, securedConn)
+		}
+	})
+}
+
+func TestDirectIncoming_TransportListenerReceivesAuthenticatedPeerNodeAddress(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
+		defer h.cleanupConnectedPeers()
+		defer h.transport.GracefulShutdown(ctx)
+
+		h.transport.RegisterListener(h.listenerMock, nil)
+		h.listenerMock.When("OnTransportMessageReceived", mock.AnyIf("context holds the address of the test peer", func(i interface{}) bool {
+			peerNodeAddress, found := adapter.PeerNodeAddressFromContext(i.(context.Context))
+			return found && bytes.Equal(peerNodeAddress, h.peerTalkerNodeAddress)
+		}), mock.Any).Return().Times(1)
+
+		_, err := h.peerTalkerConnection.Write(exampleWireProtocolEncoding_Payloads_0x11_0x2233())
+		require.NoError(t, err, "test peer could not write to local transport")
+
+		h.verifyTransportListenerCalled(t)
+	})
+}
+
+func TestDirectIncoming_DisconnectsPeersThatLeaveTopology(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
+		h := newDirectHarnessWithConnectedPeers(t, ctx, parent)
+		defer h.cleanupConnectedPeers()
+		defer h.transport.GracefulShutdown(ctx)
+
+		h.transport.UpdateTopology(ctx, adapter.TransportPeers{
+			h.config.NodeAddress().KeyForMap(): adapter.NewGossipPeer(h.transport.GetServerPort(), "127.0.0.1", hex.EncodeToString(h.config.NodeAddress())),
+		})
+
+		requireClosedByOtherSide(t, h.peerTalkerConnection)
+	})
+}
+
 func TestDirectIncoming_TimeoutDuringReceiveCausesDisconnect(t *testing.T) {
 	with.Concurrency(t, func(ctx context.Context, parent *with.ConcurrencyHarness) {
 
@@ -191,7 +287,7 @@ func TestDirectServer_PanicsOnPortAlreadyInUse(t *testing.T) {
 			port: uint16(port),
 		}
 
-		server := newServer(cfg, harness.Logger, metric.NewRegistry())
+		server := newServer(cfg, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)), harness.Logger, metric.NewRegistry())
 		harness.Supervise(server)
 
 		require.Panics(t, func() {
@@ -206,7 +302,7 @@ func TestDirectServer_ShutsDownWithinTimeout_WhenHasZeroConnections(t *testing.T
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		server := newServer(cfg, harness.Logger, metric.NewRegistry())
+		server := newServer(cfg, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)), harness.Logger, metric.NewRegistry())
 		server.startSupervisedMainLoop(ctx)
 
 		require.True(t, test.Eventually(100*time.Millisecond, func() bool {
@@ -223,7 +319,7 @@ func TestDirectServer_KeepsAcceptingNewConnections_AfterAllClientConnectionsAreC
 	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
 		cfg := &serverCfg{}
 
-		server := newServer(cfg, harness.Logger, metric.NewRegistry())
+		server := newServer(cfg, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)), harness.Logger, metric.NewRegistry())
 		harness.Supervise(server)
 		server.startSupervisedMainLoop(ctx)
 		defer server.GracefulShutdown(context.Background())
@@ -248,7 +344,7 @@ func TestDirectServer_DoesNotHang_WhenWaitingForConnectionsToClose_OnShutDown(t
 	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
 		cfg := &serverCfg{}
 
-		server := newServer(cfg, harness.Logger, metric.NewRegistry())
+		server := newServer(cfg, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)), harness.Logger, metric.NewRegistry())
 		harness.Supervise(server)
 		server.startSupervisedMainLoop(ctx)
 
@@ -269,7 +365,7 @@ func TestDirectServer_DoesNotAcceptNewConnections_AfterShutdown(t *testing.T) {
 	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
 		cfg := &serverCfg{}
 
-		server := newServer(cfg, harness.Logger, metric.NewRegistry())
+		server := newServer(cfg, aPeerHandshaker(keys.EcdsaSecp256K1KeyPairForTests(0)), harness.Logger, metric.NewRegistry())
 		harness.Supervise(server)
 		server.startSupervisedMainLoop(ctx)
 
@@ -9,10 +9,12 @@ package test
 import (
 	"context"
 	"encoding/hex"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/quic"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/tcp"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/testkit"
 	"github.com/orbs-network/orbs-network-go/test"
@@ -28,11 +30,13 @@ import (
 
 func TestContract_SendBroadcast(t *testing.T) {
 	t.Run("TCP_DirectTransport", broadcastTest(aDirectTransport))
+	t.Run("QUIC_Transport", broadcastTest(aQuicTransport))
 	t.Run("MemoryTransport", broadcastTest(aMemoryTransport))
 }
 
 func TestContract_SendToList(t *testing.T) {
 	t.Run("TCP_DirectTransport", sendToListTest(aDirectTransport))
+	t.Run("QUIC_Transport", sendToListTest(aQuicTransport))
 	t.Run("MemoryTransport", sendToListTest(aMemoryTransport))
 }
 
@@ -177,10 +181,10 @@ func aDirectTransport(ctx context.Context, harness *with.ConcurrencyHarness) *tr
 
 
 	transports := []*tcp.DirectTransport{
-		tcp.NewDirectTransport(ctx, configs[0], logger, metric.NewRegistry()),
-		tcp.NewDirectTransport(ctx, configs[1], logger, metric.NewRegistry()),
-		tcp.NewDirectTransport(ctx, configs[2], logger, metric.NewRegistry()),
-		tcp.NewDirectTransport(ctx, configs[3], logger, metric.NewRegistry()),
+		tcp.NewDirectTransport(ctx, configs[0], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(0).PrivateKey()), logger, metric.NewRegistry()),
+		tcp.NewDirectTransport(ctx, configs[1], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(1).PrivateKey()), logger, metric.NewRegistry()),
+		tcp.NewDirectTransport(ctx, configs[2], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(2).PrivateKey()), logger, metric.NewRegistry()),
+		tcp.NewDirectTransport(ctx, configs[3], signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(3).PrivateKey()), logger, metric.NewRegistry()),
 	}
 
 	test.Eventually(1*time.Second, func() bool {
@@ -216,6 +220,36 @@ func aDirectTransport(ctx context.Context, harness *with.ConcurrencyHarness) *tr
 	return res
 }
 
+func aQuicTransport(ctx context.Context, harness *with.ConcurrencyHarness) *transportContractContext {
+	res := &transportContractContext{}
+	logger := harness.Logger.WithTags(log.String("adapter", "transport"))
+
+	var transports []*quic.QuicTransport
+	for i := 0; i < 4; i++ {
+		keyPair := keys.EcdsaSecp256K1KeyPairForTests(i)
+		res.nodeAddresses = append(res.nodeAddresses, keyPair.NodeAddress())
+
+		transport, err := quic.NewQuicTransport(ctx, config.ForGossipAdapterTests(keyPair.NodeAddress()), signer.NewLocalSigner(keyPair.PrivateKey()), logger, metric.NewRegistry())
+		require.NoError(harness.T, err, "quic transport should start")
+		transports = append(transports, transport)
+		res.listeners = append(res.listeners, testkit.ListenTo(transport, keyPair.NodeAddress()))
+	}
+
+	res.topology = make(adapter.TransportPeers)
+	for i, transport := range transports {
+		res.topology[res.nodeAddresses[i].KeyForMap()] = adapter.NewGossipPeer(transport.GetServerPort(), "127.0.0.1", hex.EncodeToString(res.nodeAddresses[i]))
+	}
+
+	for _, t := range transports {
+		t.UpdateTopology(ctx, res.topology)
+		harness.Supervise(t)
+
+		res.transports = append(res.transports, t)
+	}
+
+	return res
+}
+
 // Continuously retry to send a message and verify mock listeners.
 // When Transport.Send() is called we get no guarantee for delivery.
 // the returned error is not intended to reflect success in neither sending or
@@ -30,11 +30,27 @@ type Transport interface {
 	UpdateTopology(bgCtx context.Context, newPeers TransportPeers)
 }
 
+// transports that authenticate their peers pass the node address of the peer a message came from in its ctx, see
+// ContextWithPeerNodeAddress
 type TransportListener interface {
 	fmt.Stringer // TODO smelly
 	OnTransportMessageReceived(ctx context.Context, payloads [][]byte)
 }
 
+type peerNodeAddressContextKey struct{}
+
+// ContextWithPeerNodeAddress marks the messages received with the returned context as coming from a peer which proved
+// it owns nodeAddress
+func ContextWithPeerNodeAddress(ctx context.Context, nodeAddress primitives.NodeAddress) context.Context {
+	return context.WithValue(ctx, peerNodeAddressContextKey{}, nodeAddress)
+}
+
+// PeerNodeAddressFromContext returns false when the message came over a connection whose peer is not authenticated
+func PeerNodeAddressFromContext(ctx context.Context) (primitives.NodeAddress, bool) {
+	nodeAddress, ok := ctx.Value(peerNodeAddressContextKey{}).(primitives.NodeAddress)
+	return nodeAddress, ok
+}
+
 func (d *TransportData) TotalSize() (res int) {
 	for _, payload := range d.Payloads {
 		res += len(payload)
@@ -0,0 +1,80 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package codec
+
+import (
+	"encoding/binary"
+	"github.com/orbs-network/crypto-lib-go/crypto/ethereum/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/pkg/errors"
+)
+
+// RECIPIENT_LIST_MODE_RELAY marks a broadcast that every node passes on to some of its peers rather than one the sender
+// delivers to every peer itself. TODO: the protocol spec has no such mode yet, it takes the value following the last
+// mode until it is added to orbs-spec along with the relay trailer below. until then only nodes configured with a relay
+// fanout, which is 0 by default, send it
+const RECIPIENT_LIST_MODE_RELAY = gossipmessages.RecipientsListMode(3)
+
+// a relayed message is the message as it was broadcast, followed by a payload holding the hops it may still travel and
+// the signature of the node it originated from:
+//
+//	ttl (4 bytes, little endian)
+//	origin node address (20 bytes)
+//	signature of the origin over the RelayedMessageHash of the message (remaining bytes)
+//
+// the ttl is decreased on every hop, so it is not signed
+const relayTrailerFixedSize = 4 + digest.NODE_ADDRESS_SIZE_BYTES
+
+type RelayedMessage struct {
+	Payloads  [][]byte // of the message as it was broadcast
+	Origin    primitives.NodeAddress
+	Signature primitives.EcdsaSecp256K1Sig
+	Ttl       uint32
+}
+
+// RelayedMessageHash identifies a relayed message regardless of the hops it travelled. every payload is hashed along
+// with its length, so payloads split differently don't hash the same
+func RelayedMessageHash(payloads [][]byte) primitives.Sha256 {
+	data := make([][]byte, 0, 2*len(payloads))
+	for _, payload := range payloads {
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(payload)))
+		data = append(data, size, payload)
+	}
+	return hash.CalcSha256(data...)
+}
+
+func EncodeRelayedMessage(message *RelayedMessage) [][]byte {
+	trailer := make([]byte, relayTrailerFixedSize+len(message.Signature))
+	binary.LittleEndian.PutUint32(trailer, message.Ttl)
+	copy(trailer[4:relayTrailerFixedSize], message.Origin)
+	copy(trailer[relayTrailerFixedSize:], message.Signature)
+
+	res := make([][]byte, 0, len(message.Payloads)+1)
+	res = append(res, message.Payloads...)
+	return append(res, trailer)
+}
+
+func DecodeRelayedMessage(payloads [][]byte) (*RelayedMessage, error) {
+	if len(payloads) < 2 {
+		return nil, errors.New("wrong num of payloads")
+	}
+
+	trailer := payloads[len(payloads)-1]
+	if len(trailer) <= relayTrailerFixedSize {
+		return nil, errors.New("relay trailer is corrupted and cannot be decoded")
+	}
+
+	return &RelayedMessage{
+		Payloads:  payloads[:len(payloads)-1],
+		Origin:    primitives.NodeAddress(trailer[4:relayTrailerFixedSize]),
+		Signature: primitives.EcdsaSecp256K1Sig(trailer[relayTrailerFixedSize:]),
+		Ttl:       binary.LittleEndian.Uint32(trailer),
+	}, nil
+}
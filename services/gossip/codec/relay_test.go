@@ -0,0 +1,44 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package codec
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRelay_RelayedMessage(t *testing.T) {
+	message := &RelayedMessage{
+		Payloads:  [][]byte{{0x01, 0x02}, {0x03}},
+		Origin:    primitives.NodeAddress{0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17},
+		Signature: primitives.EcdsaSecp256K1Sig{0x18, 0x19},
+		Ttl:       3,
+	}
+
+	decoded, err := DecodeRelayedMessage(EncodeRelayedMessage(message))
+	require.NoError(t, err, "decode should not fail")
+	require.Equal(t, message, decoded, "decoded encoded should equal to original")
+}
+
+func TestRelay_RelayedMessageWithCorruptTrailer(t *testing.T) {
+	_, err := DecodeRelayedMessage([][]byte{{0x01, 0x02}, {0x03}})
+	require.Error(t, err, "decode should fail and return error")
+}
+
+func TestRelay_RelayedMessageWithoutSignature(t *testing.T) {
+	_, err := DecodeRelayedMessage(EncodeRelayedMessage(&RelayedMessage{Payloads: [][]byte{{0x01, 0x02}}, Origin: primitives.NodeAddress{0x04}, Ttl: 3}))
+	require.Error(t, err, "decode should fail and return error")
+}
+
+func TestRelay_MessageHashIsOfPayloads(t *testing.T) {
+	h := RelayedMessageHash([][]byte{{0x01, 0x02}, {0x03}})
+
+	require.Equal(t, h, RelayedMessageHash([][]byte{{0x01, 0x02}, {0x03}}), "identical messages should hash the same")
+	require.NotEqual(t, h, RelayedMessageHash([][]byte{{0x01, 0x02}, {0x04}}), "different messages should hash differently")
+	require.NotEqual(t, h, RelayedMessageHash([][]byte{{0x01}, {0x02, 0x03}}), "payloads split differently should hash differently")
+}
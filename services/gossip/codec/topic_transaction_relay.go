@@ -7,6 +7,10 @@
 package codec
 
 import (
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/keys"
+	"github.com/orbs-network/crypto-lib-go/crypto/signature"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/pkg/errors"
@@ -54,3 +58,59 @@ func DecodeForwardedTransactions(payloads [][]byte) (*gossipmessages.ForwardedTr
 		SignedTransactions: txs,
 	}, nil
 }
+
+// TRANSACTION_RELAY_WITHDRAWN_TRANSACTION tells the other nodes that the signer of a pending transaction withdrew it.
+// TODO: the protocol spec has no such message yet, it takes the value following the last message until it is added to
+// orbs-spec along with WithdrawnTransactionMessage. nodes that don't know it ignore it
+const TRANSACTION_RELAY_WITHDRAWN_TRANSACTION = gossipmessages.TransactionsRelayMessageType(2)
+
+// WithdrawnTransactionMessage carries the withdrawal of a pending transaction, signed by the signer of the transaction
+// rather than by the node sending it, so every node verifies it on its own
+type WithdrawnTransactionMessage struct {
+	TxHash            primitives.Sha256
+	ReplacementTxHash primitives.Sha256 // empty when the transaction is cancelled
+	SignerPublicKey   primitives.Ed25519PublicKey
+	Signature         primitives.Ed25519Sig
+}
+
+func EncodeWithdrawnTransaction(header *gossipmessages.Header, message *WithdrawnTransactionMessage) ([][]byte, error) {
+	if len(message.TxHash) == 0 {
+		return nil, errors.New("missing TxHash")
+	}
+	if len(message.SignerPublicKey) == 0 {
+		return nil, errors.New("missing SignerPublicKey")
+	}
+	if len(message.Signature) == 0 {
+		return nil, errors.New("missing Signature")
+	}
+
+	return [][]byte{header.Raw(), message.TxHash, message.ReplacementTxHash, message.SignerPublicKey, message.Signature}, nil
+}
+
+func DecodeWithdrawnTransaction(payloads [][]byte) (*WithdrawnTransactionMessage, error) {
+	if len(payloads) != 4 {
+		return nil, errors.New("wrong num of payloads")
+	}
+	if len(payloads[0]) != hash.SHA256_HASH_SIZE_BYTES {
+		return nil, errors.New("TxHash is corrupted and cannot be decoded")
+	}
+	if len(payloads[1]) != 0 && len(payloads[1]) != hash.SHA256_HASH_SIZE_BYTES {
+		return nil, errors.New("ReplacementTxHash is corrupted and cannot be decoded")
+	}
+	if len(payloads[2]) != keys.ED25519_PUBLIC_KEY_SIZE_BYTES {
+		return nil, errors.New("SignerPublicKey is corrupted and cannot be decoded")
+	}
+	if len(payloads[3]) != signature.ED25519_SIGNATURE_SIZE_BYTES {
+		return nil, errors.New("Signature is corrupted and cannot be decoded")
+	}
+
+	message := &WithdrawnTransactionMessage{
+		TxHash:          primitives.Sha256(payloads[0]),
+		SignerPublicKey: primitives.Ed25519PublicKey(payloads[2]),
+		Signature:       primitives.Ed25519Sig(payloads[3]),
+	}
+	if len(payloads[1]) != 0 {
+		message.ReplacementTxHash = primitives.Sha256(payloads[1])
+	}
+	return message, nil
+}
@@ -7,8 +7,12 @@
 package codec
 
 import (
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/signature"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/stretchr/testify/require"
@@ -56,3 +60,25 @@ func TestTransactionRelay_ForwardedTransactionsMessageDoNotFailWhenSenderContain
 	test.RequireCmpEqual(t, message, decoded, "decoded encoded should equal to original")
 	test.RequireDoesNotContainNil(t, decoded)
 }
+
+func TestTransactionRelay_WithdrawnTransactionMessage(t *testing.T) {
+	for _, replacementTxHash := range []primitives.Sha256{nil, hash.CalcSha256([]byte("replacement"))} {
+		message := &WithdrawnTransactionMessage{
+			TxHash:            hash.CalcSha256([]byte("transaction")),
+			ReplacementTxHash: replacementTxHash,
+			SignerPublicKey:   keys.Ed25519KeyPairForTests(1).PublicKey(),
+			Signature:         make([]byte, signature.ED25519_SIGNATURE_SIZE_BYTES),
+		}
+
+		payloads, err := EncodeWithdrawnTransaction((&gossipmessages.HeaderBuilder{}).Build(), message)
+		require.NoError(t, err, "encode should not fail")
+		decoded, err := DecodeWithdrawnTransaction(payloads[1:])
+		require.NoError(t, err, "decode should not fail")
+		test.RequireCmpEqual(t, message, decoded, "decoded encoded should equal to original")
+	}
+}
+
+func TestTransactionRelay_CorruptWithdrawnTransactionMessage(t *testing.T) {
+	_, err := DecodeWithdrawnTransaction(builders.EmptyPayloads(4))
+	require.Error(t, err, "decode should fail and return error")
+}
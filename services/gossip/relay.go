@@ -0,0 +1,271 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package gossip
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/ethereum/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/pkg/errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// a relayed message is dropped if it arrives again within this window, so it is remembered for at least one window and
+// at most two. it only has to outlast the few hops a message travels, and a broadcast repeated with the same content,
+// like a block availability request retried when no peer answered, should not be taken for a duplicate
+const RELAY_DUPLICATE_SUPPRESSION_WINDOW = 5 * time.Second
+
+type relayConfig interface {
+	NodeAddress() primitives.NodeAddress
+	GossipRelayTtl() uint32
+	GossipRelayFanoutTransactionRelay() uint32
+	GossipRelayFanoutBlockSync() uint32
+}
+
+type relayMetrics struct {
+	originated *metric.Counter
+	forwarded  *metric.Counter
+	duplicates *metric.Counter
+}
+
+// relay disseminates broadcasts of topics that have a fanout: rather than sending them to every peer, which costs the
+// sender a message per node, a node sends them to a few random peers which pass them on until their ttl runs out.
+// random peers give no guarantee that every node receives a message, so consensus messages are never relayed.
+// a relayed message is signed by the node it originated from, which every node verifies before it passes it on
+type relay struct {
+	config  relayConfig
+	signer  signer.Signer
+	metrics relayMetrics
+	seen    *seenMessages
+
+	sync.RWMutex
+	peers []primitives.NodeAddress
+}
+
+func newRelay(config relayConfig, signer signer.Signer, metricRegistry metric.Registry) *relay {
+	return &relay{
+		config: config,
+		signer: signer,
+		metrics: relayMetrics{
+			originated: metricRegistry.NewCounter("Gossip.Relay.Originated.Count"),
+			forwarded:  metricRegistry.NewCounter("Gossip.Relay.Forwarded.Count"),
+			duplicates: metricRegistry.NewCounter("Gossip.Relay.Duplicates.Count"),
+		},
+		seen: newSeenMessages(RELAY_DUPLICATE_SUPPRESSION_WINDOW),
+	}
+}
+
+func (r *relay) fanout(topic gossipmessages.HeaderTopic) int {
+	switch topic {
+	case gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY:
+		return int(r.config.GossipRelayFanoutTransactionRelay())
+	case gossipmessages.HEADER_TOPIC_BLOCK_SYNC:
+		return int(r.config.GossipRelayFanoutBlockSync())
+	}
+	return 0
+}
+
+func isConsensusTopic(topic gossipmessages.HeaderTopic) bool {
+	return topic == gossipmessages.HEADER_TOPIC_LEAN_HELIX || topic == gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS
+}
+
+// broadcastMode is the recipient mode of the header of a broadcast of the topic
+func (r *relay) broadcastMode(topic gossipmessages.HeaderTopic) gossipmessages.RecipientsListMode {
+	if r.fanout(topic) > 0 {
+		return codec.RECIPIENT_LIST_MODE_RELAY
+	}
+	return gossipmessages.RECIPIENT_LIST_MODE_BROADCAST
+}
+
+func (r *relay) updateTopology(servicePeers []*services.GossipPeer) {
+	peers := make([]primitives.NodeAddress, 0, len(servicePeers))
+	for _, peer := range servicePeers {
+		if !peer.Address.Equal(r.config.NodeAddress()) {
+			peers = append(peers, peer.Address)
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.peers = peers
+}
+
+// originate returns the transport data of a new relayed message. until the topology is known it is sent to every peer
+func (r *relay) originate(ctx context.Context, topic gossipmessages.HeaderTopic, payloads [][]byte) (*adapter.TransportData, error) {
+	messageHash := codec.RelayedMessageHash(payloads)
+	signature, err := r.signer.Sign(ctx, messageHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign relayed message")
+	}
+	r.seen.add(messageHash) // so it isn't relayed again when it comes back
+	r.metrics.originated.Inc()
+
+	data := &adapter.TransportData{
+		SenderNodeAddress: r.config.NodeAddress(),
+		RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		Payloads: codec.EncodeRelayedMessage(&codec.RelayedMessage{
+			Payloads:  payloads,
+			Origin:    r.config.NodeAddress(),
+			Signature: signature,
+			Ttl:       r.config.GossipRelayTtl(),
+		}),
+	}
+	if recipients := r.randomPeers(r.fanout(topic)); len(recipients) > 0 {
+		data.RecipientMode = gossipmessages.RECIPIENT_LIST_MODE_LIST
+		data.RecipientNodeAddresses = recipients
+	}
+	return data, nil
+}
+
+// received decodes a relayed message and verifies it was signed by its origin, a node in the topology. a message that
+// was already seen returns nil, so it isn't dispatched twice. only verified messages are remembered, so a forged copy
+// of a message does not suppress the genuine one
+func (r *relay) received(topic gossipmessages.HeaderTopic, relayedPayloads [][]byte) (*codec.RelayedMessage, error) {
+	if isConsensusTopic(topic) {
+		return nil, errors.New("consensus messages are not relayed")
+	}
+
+	message, err := codec.DecodeRelayedMessage(relayedPayloads)
+	if err != nil {
+		return nil, err
+	}
+
+	messageHash := codec.RelayedMessageHash(message.Payloads)
+	if r.seen.contains(messageHash) {
+		r.metrics.duplicates.Inc()
+		return nil, nil
+	}
+
+	if !r.isKnownNode(message.Origin) {
+		return nil, errors.Errorf("relayed message originated at node %s which is not in the topology", message.Origin)
+	}
+	if err := digest.VerifyNodeSignature(message.Origin, messageHash, message.Signature); err != nil {
+		return nil, errors.Wrapf(err, "relayed message is not signed by its origin %s", message.Origin)
+	}
+
+	if !r.seen.add(messageHash) {
+		r.metrics.duplicates.Inc()
+		return nil, nil
+	}
+	return message, nil
+}
+
+// forward returns the transport data to pass a received message on with, if it has hops left to travel
+func (r *relay) forward(ctx context.Context, topic gossipmessages.HeaderTopic, message *codec.RelayedMessage) *adapter.TransportData {
+	if message.Ttl <= 1 || ctx.Err() != nil {
+		return nil
+	}
+	recipients := r.randomPeers(r.fanout(topic))
+	if len(recipients) == 0 { // a node whose fanout of the topic is 0 does not take part in relaying it
+		return nil
+	}
+
+	r.metrics.forwarded.Inc()
+	return &adapter.TransportData{
+		SenderNodeAddress:      r.config.NodeAddress(),
+		RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+		RecipientNodeAddresses: recipients,
+		Payloads: codec.EncodeRelayedMessage(&codec.RelayedMessage{
+			Payloads:  message.Payloads,
+			Origin:    message.Origin,
+			Signature: message.Signature,
+			Ttl:       message.Ttl - 1,
+		}),
+	}
+}
+
+func (r *relay) isKnownNode(nodeAddress primitives.NodeAddress) bool {
+	if nodeAddress.Equal(r.config.NodeAddress()) {
+		return true
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+	for _, peer := range r.peers {
+		if peer.Equal(nodeAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *relay) randomPeers(count int) []primitives.NodeAddress {
+	r.RLock()
+	defer r.RUnlock()
+
+	if count <= 0 {
+		return nil
+	}
+	if count > len(r.peers) {
+		count = len(r.peers)
+	}
+
+	res := make([]primitives.NodeAddress, count)
+	for i, peerIndex := range rand.Perm(len(r.peers))[:count] {
+		res[i] = r.peers[peerIndex]
+	}
+	return res
+}
+
+// seenMessages remembers message hashes in two generations, the older one being dropped as a whole every window
+type seenMessages struct {
+	sync.Mutex
+	window    time.Duration
+	rotatedAt time.Time
+	current   map[string]struct{}
+	previous  map[string]struct{}
+}
+
+func newSeenMessages(window time.Duration) *seenMessages {
+	return &seenMessages{
+		window:    window,
+		rotatedAt: time.Now(),
+		current:   make(map[string]struct{}),
+		previous:  make(map[string]struct{}),
+	}
+}
+
+// add returns false if the hash was already seen
+func (s *seenMessages) add(messageHash primitives.Sha256) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.containsUnderLock(messageHash) {
+		return false
+	}
+	s.current[messageHash.KeyForMap()] = struct{}{}
+	return true
+}
+
+func (s *seenMessages) contains(messageHash primitives.Sha256) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.containsUnderLock(messageHash)
+}
+
+func (s *seenMessages) containsUnderLock(messageHash primitives.Sha256) bool {
+	if time.Since(s.rotatedAt) > s.window {
+		s.previous, s.current = s.current, make(map[string]struct{})
+		s.rotatedAt = time.Now()
+	}
+
+	key := messageHash.KeyForMap()
+	if _, found := s.current[key]; found {
+		return true
+	}
+	_, found := s.previous[key]
+	return found
+}
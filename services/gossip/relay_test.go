@@ -0,0 +1,223 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package gossip
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/ethereum/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// the relaying node holds the first key pair of the tests, its peers the ones following it
+const NODE_OUTSIDE_TOPOLOGY = 20
+
+type relayConf struct {
+	ttl    uint32
+	fanout uint32
+}
+
+func (c *relayConf) NodeAddress() primitives.NodeAddress {
+	return keys.EcdsaSecp256K1KeyPairForTests(0).NodeAddress()
+}
+
+func (c *relayConf) GossipRelayTtl() uint32 {
+	return c.ttl
+}
+
+func (c *relayConf) GossipRelayFanoutTransactionRelay() uint32 {
+	return c.fanout
+}
+
+func (c *relayConf) GossipRelayFanoutBlockSync() uint32 {
+	return 0
+}
+
+func TestRelay_BroadcastModeDependsOnTopicFanout(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	require.Equal(t, codec.RECIPIENT_LIST_MODE_RELAY, r.broadcastMode(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY), "a topic with a fanout should be relayed")
+	require.Equal(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, r.broadcastMode(gossipmessages.HEADER_TOPIC_BLOCK_SYNC), "a topic without a fanout should be broadcast directly")
+	require.Equal(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, r.broadcastMode(gossipmessages.HEADER_TOPIC_LEAN_HELIX), "consensus should be broadcast directly")
+	require.Equal(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, r.broadcastMode(gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS), "consensus should be broadcast directly")
+}
+
+func TestRelay_OriginatesSignedMessageToFanoutRandomPeers(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	data, err := r.originate(context.Background(), gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aMessage())
+	require.NoError(t, err)
+
+	require.Equal(t, gossipmessages.RECIPIENT_LIST_MODE_LIST, data.RecipientMode)
+	require.Len(t, data.RecipientNodeAddresses, 2, "message should be sent to fanout peers")
+	require.NotEqual(t, data.RecipientNodeAddresses[0], data.RecipientNodeAddresses[1], "message should be sent to distinct peers")
+	for _, recipient := range data.RecipientNodeAddresses {
+		require.NotEqual(t, r.config.NodeAddress(), recipient, "message should not be sent to self")
+	}
+
+	message, err := codec.DecodeRelayedMessage(data.Payloads)
+	require.NoError(t, err)
+	require.Equal(t, r.config.NodeAddress(), message.Origin, "message should name this node as its origin")
+	require.EqualValues(t, 3, message.Ttl)
+	require.NoError(t, digest.VerifyNodeSignature(message.Origin, codec.RelayedMessageHash(aMessage()), message.Signature), "message should be signed by this node")
+}
+
+func TestRelay_OriginatesToEveryPeerUntilTopologyIsKnown(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 0)
+
+	data, err := r.originate(context.Background(), gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aMessage())
+	require.NoError(t, err)
+
+	require.Equal(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, data.RecipientMode)
+}
+
+func TestRelay_ForwardsWithDecreasedTtl(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 1, 3))
+	require.NoError(t, err)
+	require.Equal(t, aMessage(), message.Payloads, "message should be dispatched without its relay trailer")
+
+	forward := r.forward(context.Background(), gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, message)
+	require.NotNil(t, forward, "message should be forwarded")
+	require.Len(t, forward.RecipientNodeAddresses, 2, "message should be forwarded to fanout peers")
+
+	forwarded, err := codec.DecodeRelayedMessage(forward.Payloads)
+	require.NoError(t, err)
+	require.Equal(t, message.Origin, forwarded.Origin, "forwarded message should keep its origin")
+	require.Equal(t, message.Signature, forwarded.Signature, "forwarded message should keep the signature of its origin")
+	require.EqualValues(t, 2, forwarded.Ttl, "forwarded message should have one hop less to travel")
+}
+
+func TestRelay_DoesNotForwardWhenTtlRunsOut(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 1, 1))
+	require.NoError(t, err)
+	require.NotNil(t, message, "message should be dispatched")
+	require.Nil(t, r.forward(context.Background(), gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, message), "message should not be forwarded")
+}
+
+func TestRelay_DoesNotForwardTopicWithoutFanout(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_BLOCK_SYNC, aRelayedMessage(t, 1, 1, 3))
+	require.NoError(t, err)
+	require.NotNil(t, message, "message should be dispatched")
+	require.Nil(t, r.forward(context.Background(), gossipmessages.HEADER_TOPIC_BLOCK_SYNC, message), "message should not be forwarded")
+}
+
+func TestRelay_RejectsRelayedConsensusMessages(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	for _, topic := range []gossipmessages.HeaderTopic{gossipmessages.HEADER_TOPIC_LEAN_HELIX, gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS} {
+		message, err := r.received(topic, aRelayedMessage(t, 1, 1, 3))
+		require.Error(t, err, "consensus message of topic %s should not be relayed", topic)
+		require.Nil(t, message, "consensus message of topic %s should not be dispatched", topic)
+	}
+}
+
+func TestRelay_RejectsMessageNotSignedByItsOrigin(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 2, 3))
+	require.Error(t, err, "message signed by another node should be rejected")
+	require.Nil(t, message, "message signed by another node should not be dispatched")
+}
+
+func TestRelay_RejectsMessageOriginatedOutsideTopology(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, NODE_OUTSIDE_TOPOLOGY, NODE_OUTSIDE_TOPOLOGY, 3))
+	require.Error(t, err, "message of a node outside the topology should be rejected")
+	require.Nil(t, message, "message of a node outside the topology should not be dispatched")
+}
+
+func TestRelay_ForgedCopyDoesNotSuppressGenuineMessage(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	_, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 2, 3))
+	require.Error(t, err, "forged copy should be rejected")
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 1, 3))
+	require.NoError(t, err)
+	require.NotNil(t, message, "genuine message should be dispatched")
+	require.EqualValues(t, 0, r.metrics.duplicates.IntValue())
+}
+
+func TestRelay_SuppressesDuplicates(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 1, 3))
+	require.NoError(t, err)
+	require.NotNil(t, message, "first copy should be dispatched")
+
+	message, err = r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aRelayedMessage(t, 1, 1, 2))
+	require.NoError(t, err)
+	require.Nil(t, message, "second copy should not be dispatched")
+	require.EqualValues(t, 1, r.metrics.duplicates.IntValue())
+}
+
+func TestRelay_SuppressesOwnMessagesComingBack(t *testing.T) {
+	r := aRelay(&relayConf{ttl: 3, fanout: 2}, 5)
+
+	data, err := r.originate(context.Background(), gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, aMessage())
+	require.NoError(t, err)
+	message, err := r.received(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY, data.Payloads)
+	require.NoError(t, err)
+	require.Nil(t, message, "own message should not be dispatched")
+}
+
+func TestSeenMessages_ForgetsHashesAfterTwoWindows(t *testing.T) {
+	seen := newSeenMessages(10 * time.Millisecond)
+	messageHash := codec.RelayedMessageHash(aMessage())
+
+	require.True(t, seen.add(messageHash), "hash should be new")
+	require.False(t, seen.add(messageHash), "hash should be seen")
+
+	time.Sleep(15 * time.Millisecond)
+	require.True(t, seen.contains(messageHash), "hash should still be seen in the following window")
+
+	time.Sleep(15 * time.Millisecond)
+	require.True(t, seen.add(messageHash), "hash should be forgotten")
+}
+
+func aRelay(conf *relayConf, numOfPeers int) *relay {
+	r := newRelay(conf, signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(0).PrivateKey()), metric.NewRegistry())
+	if numOfPeers > 0 {
+		peers := []*services.GossipPeer{{Address: conf.NodeAddress()}}
+		for i := 1; i <= numOfPeers; i++ {
+			peers = append(peers, &services.GossipPeer{Address: keys.EcdsaSecp256K1KeyPairForTests(i).NodeAddress()})
+		}
+		r.updateTopology(peers)
+	}
+	return r
+}
+
+func aMessage() [][]byte {
+	return [][]byte{{0x01, 0x02}, {0x03}}
+}
+
+// aRelayedMessage returns aMessage as relayed from the node of the origin key pair, signed by the node of the signer key pair
+func aRelayedMessage(t *testing.T, origin int, signedBy int, ttl uint32) [][]byte {
+	sig, err := signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(signedBy).PrivateKey()).Sign(context.Background(), codec.RelayedMessageHash(aMessage()))
+	require.NoError(t, err, "test could not sign message")
+	return codec.EncodeRelayedMessage(&codec.RelayedMessage{
+		Payloads:  aMessage(),
+		Origin:    keys.EcdsaSecp256K1KeyPairForTests(origin).NodeAddress(),
+		Signature: sig,
+		Ttl:       ttl,
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package gossip
+
+import (
+	"context"
+	lh "github.com/orbs-network/lean-helix-go/services/interfaces"
+	lhprotocol "github.com/orbs-network/lean-helix-go/spec/types/go/protocol"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/consensus"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/pkg/errors"
+)
+
+// transports that authenticate their peers pass on the node address a message came from, and a message naming another
+// node as its sender is forged. a plaintext connection from a node that predates tls has no address, so its messages
+// can't be checked here
+func validateClaimedSender(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) error {
+	peerNodeAddress, authenticated := adapter.PeerNodeAddressFromContext(ctx)
+	if !authenticated {
+		return nil
+	}
+	return validateSenderIs(peerNodeAddress, header, payloads)
+}
+
+// validateSenderIs checks a message names the node that sent it as its sender. a relayed message comes from the peer
+// that passed it on, so it is checked against the node that signed it as its origin instead
+func validateSenderIs(sender primitives.NodeAddress, header *gossipmessages.Header, payloads [][]byte) error {
+	claimedSender, err := claimedSenderOf(header, payloads)
+	if err != nil {
+		return err
+	}
+	if claimedSender != nil && !claimedSender.Equal(sender) {
+		return errors.Errorf("message claims to be sent by node %s but came from node %s", claimedSender, sender)
+	}
+	return nil
+}
+
+// returns nil for messages that don't name their sender
+func claimedSenderOf(header *gossipmessages.Header, payloads [][]byte) (primitives.NodeAddress, error) {
+	switch header.Topic() {
+	case gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY:
+		if header.TransactionRelay() == codec.TRANSACTION_RELAY_WITHDRAWN_TRANSACTION {
+			return nil, nil // a withdrawal is signed by the signer of the transaction instead
+		}
+		return senderSignatureAt(payloads, 1)
+	case gossipmessages.HEADER_TOPIC_BLOCK_SYNC:
+		return senderSignatureAt(payloads, 2)
+	case gossipmessages.HEADER_TOPIC_BENCHMARK_CONSENSUS:
+		if header.BenchmarkConsensus() == consensus.BENCHMARK_CONSENSUS_COMMITTED {
+			return senderSignatureAt(payloads, 2)
+		}
+		return nil, nil // a commit carries the block proof signed by the leader instead
+	case gossipmessages.HEADER_TOPIC_LEAN_HELIX:
+		if len(payloads) < 2 || !lhprotocol.LeanhelixContentReader(payloads[1]).IsValid() {
+			return nil, errors.New("lean helix message content is missing or corrupt")
+		}
+		message := lh.ToConsensusMessage(&lh.ConsensusRawMessage{Content: payloads[1]})
+		if message == nil {
+			return nil, errors.New("lean helix message is of unknown type")
+		}
+		return primitives.NodeAddress(message.SenderMemberId()), nil
+	}
+	return nil, nil
+}
+
+func senderSignatureAt(payloads [][]byte, index int) (primitives.NodeAddress, error) {
+	if len(payloads) <= index {
+		return nil, errors.New("message sender is missing")
+	}
+	sender := gossipmessages.SenderSignatureReader(payloads[index])
+	if !sender.IsValid() {
+		return nil, errors.New("message sender is corrupt")
+	}
+	return sender.SenderNodeAddress(), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package gossip
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateClaimedSender_AcceptsMessageFromTheNodeItNames(t *testing.T) {
+	ctx := adapter.ContextWithPeerNodeAddress(context.Background(), primitives.NodeAddress{0x1})
+
+	header, payloads := aBlockAvailabilityRequestSentBy(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, primitives.NodeAddress{0x1})
+
+	require.NoError(t, validateClaimedSender(ctx, header, payloads))
+}
+
+func TestValidateClaimedSender_RejectsMessageNamingAnotherNode(t *testing.T) {
+	ctx := adapter.ContextWithPeerNodeAddress(context.Background(), primitives.NodeAddress{0x2})
+
+	header, payloads := aBlockAvailabilityRequestSentBy(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, primitives.NodeAddress{0x1})
+
+	require.Error(t, validateClaimedSender(ctx, header, payloads))
+}
+
+func TestValidateClaimedSender_RejectsMessageWithoutSender(t *testing.T) {
+	ctx := adapter.ContextWithPeerNodeAddress(context.Background(), primitives.NodeAddress{0x1})
+
+	header, payloads := aBlockAvailabilityRequestSentBy(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, primitives.NodeAddress{0x1})
+
+	require.Error(t, validateClaimedSender(ctx, header, payloads[:2]))
+}
+
+func TestValidateClaimedSender_DoesNotCheckMessagesOfUnauthenticatedPeers(t *testing.T) {
+	header, payloads := aBlockAvailabilityRequestSentBy(t, gossipmessages.RECIPIENT_LIST_MODE_BROADCAST, primitives.NodeAddress{0x1})
+
+	require.NoError(t, validateClaimedSender(context.Background(), header, payloads))
+}
+
+func TestValidateSenderIs_AcceptsRelayedMessageNamingItsOrigin(t *testing.T) {
+	header, payloads := aBlockAvailabilityRequestSentBy(t, codec.RECIPIENT_LIST_MODE_RELAY, primitives.NodeAddress{0x1})
+
+	require.NoError(t, validateSenderIs(primitives.NodeAddress{0x1}, header, payloads))
+}
+
+func TestValidateSenderIs_RejectsRelayedMessageNamingAnotherNodeThanItsOrigin(t *testing.T) {
+	header, payloads := aBlockAvailabilityRequestSentBy(t, codec.RECIPIENT_LIST_MODE_RELAY, primitives.NodeAddress{0x1})
+
+	require.Error(t, validateSenderIs(primitives.NodeAddress{0x2}, header, payloads))
+}
+
+func aBlockAvailabilityRequestSentBy(t *testing.T, recipientMode gossipmessages.RecipientsListMode, sender primitives.NodeAddress) (*gossipmessages.Header, [][]byte) {
+	header := (&gossipmessages.HeaderBuilder{
+		Topic:          gossipmessages.HEADER_TOPIC_BLOCK_SYNC,
+		BlockSync:      gossipmessages.BLOCK_SYNC_AVAILABILITY_REQUEST,
+		RecipientMode:  recipientMode,
+		VirtualChainId: 42,
+	}).Build()
+	payloads, err := codec.EncodeBlockAvailabilityRequest(header, &gossipmessages.BlockAvailabilityRequestMessage{
+		SignedBatchRange: (&gossipmessages.BlockSyncRangeBuilder{FirstBlockHeight: 1, LastBlockHeight: 10}).Build(),
+		Sender:           (&gossipmessages.SenderSignatureBuilder{SenderNodeAddress: sender, Signature: []byte{0x3}}).Build(),
+	})
+	require.NoError(t, err, "test could not encode message")
+	return header, payloads
+}
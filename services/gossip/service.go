@@ -9,10 +9,12 @@ package gossip
 import (
 	"context"
 	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/orbs-network/orbs-spec/types/go/services"
@@ -26,14 +28,18 @@ var LogTag = log.Service("gossip")
 type Config interface {
 	NodeAddress() primitives.NodeAddress
 	VirtualChainId() primitives.VirtualChainId
+	GossipRelayTtl() uint32
+	GossipRelayFanoutTransactionRelay() uint32
+	GossipRelayFanoutBlockSync() uint32
 }
 
 type gossipListeners struct {
 	sync.RWMutex
-	transactionHandlers        []gossiptopics.TransactionRelayHandler
-	leanHelixHandlers          []gossiptopics.LeanHelixHandler
-	benchmarkConsensusHandlers []gossiptopics.BenchmarkConsensusHandler
-	blockSyncHandlers          []gossiptopics.BlockSyncHandler
+	transactionHandlers          []gossiptopics.TransactionRelayHandler
+	withdrawnTransactionHandlers []WithdrawnTransactionHandler
+	leanHelixHandlers            []gossiptopics.LeanHelixHandler
+	benchmarkConsensusHandlers   []gossiptopics.BenchmarkConsensusHandler
+	blockSyncHandlers            []gossiptopics.BlockSyncHandler
 }
 
 type service struct {
@@ -44,12 +50,13 @@ type service struct {
 	transport       adapter.Transport
 	handlers        gossipListeners
 	headerValidator *headerValidator
+	relay           *relay
 
 	messageDispatcher             *gossipMessageDispatcher
 	forwarededTransactionFailures *metric.Gauge
 }
 
-func NewGossip(ctx context.Context, transport adapter.Transport, config Config, parent log.Logger, metricRegistry metric.Registry) *service {
+func NewGossip(ctx context.Context, transport adapter.Transport, config Config, signer signer.Signer, parent log.Logger, metricRegistry metric.Registry) *service {
 	logger := parent.WithTags(LogTag)
 	dispatcher := newMessageDispatcher(metricRegistry, logger)
 	s := &service{
@@ -58,6 +65,7 @@ func NewGossip(ctx context.Context, transport adapter.Transport, config Config,
 		logger:          logger,
 		handlers:        gossipListeners{},
 		headerValidator: newHeaderValidator(config, parent),
+		relay:           newRelay(config, signer, metricRegistry),
 
 		messageDispatcher:             dispatcher,
 		forwarededTransactionFailures: metricRegistry.NewGauge("Gossip.Topic.TransactionRelay.Errors.Count"),
@@ -72,6 +80,7 @@ func NewGossip(ctx context.Context, transport adapter.Transport, config Config,
 }
 
 func (s *service) UpdateTopology(bgCtx context.Context, input *services.UpdateTopologyInput) (*services.UpdateTopologyOutput, error)  {
+	s.relay.updateTopology(input.Peers)
 	s.transport.UpdateTopology(bgCtx, adapter.NewGossipPeers(input.Peers))
 	return &services.UpdateTopologyOutput{}, nil
 }
@@ -97,9 +106,50 @@ func (s *service) OnTransportMessageReceived(ctx context.Context, payloads [][]b
 		return
 	}
 
+	if header.RecipientMode() == codec.RECIPIENT_LIST_MODE_RELAY {
+		message, err := s.relay.received(header.Topic(), payloads)
+		if err != nil {
+			logger.Error("dropping a relayed message that isn't valid", log.Error(err), log.Stringable("message-header", header))
+			return
+		}
+		if message == nil {
+			return // already seen
+		}
+		if err := validateSenderIs(message.Origin, header, message.Payloads); err != nil {
+			logger.Error("dropping a relayed message with a forged sender", log.Error(err), log.Stringable("message-header", header))
+			return
+		}
+		if forward := s.relay.forward(ctx, header.Topic(), message); forward != nil {
+			if err := s.transport.Send(ctx, forward); err != nil {
+				logger.Info("failed relaying a message", log.Error(err), log.Stringable("message-header", header))
+			}
+		}
+		payloads = message.Payloads
+	} else if err := validateClaimedSender(ctx, header, payloads); err != nil {
+		logger.Error("dropping a received message with a forged sender", log.Error(err), log.Stringable("message-header", header))
+		return
+	}
+
 	s.messageDispatcher.dispatch(ctx, logger, header, payloads[1:])
 }
 
+// broadcast sends a message to every node, relaying it through peers if its header says so (see relay.go)
+func (s *service) broadcast(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) error {
+	if header.RecipientMode() == codec.RECIPIENT_LIST_MODE_RELAY {
+		data, err := s.relay.originate(ctx, header.Topic(), payloads)
+		if err != nil {
+			return err
+		}
+		return s.transport.Send(ctx, data)
+	}
+
+	return s.transport.Send(ctx, &adapter.TransportData{
+		SenderNodeAddress: s.config.NodeAddress(),
+		RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		Payloads:          payloads,
+	})
+}
+
 func (s *service) String() string {
 	return fmt.Sprintf("Gossip service for node %s: %p", s.config.NodeAddress(), s)
 }
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
+	"github.com/orbs-network/orbs-network-go/services/gossip/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
+	"github.com/orbs-network/orbs-network-go/test"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/orbs-spec/types/go/services/gossiptopics"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type relayConf struct {
+	conf
+	nodeAddress primitives.NodeAddress
+	ttl         uint32
+	fanout      uint32
+}
+
+func (c *relayConf) NodeAddress() primitives.NodeAddress {
+	return c.nodeAddress
+}
+
+func (c *relayConf) GossipRelayTtl() uint32 {
+	return c.ttl
+}
+
+func (c *relayConf) GossipRelayFanoutTransactionRelay() uint32 {
+	return c.fanout
+}
+
+func TestRelayedBroadcastIsHandledOnceByEveryNode(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
+		// every node relays to all of its peers, so each gets the message once from the sender and again from every other node
+		nodes, _ := aRelayingNetwork(ctx, harness, 4)
+
+		nodes[0].handler.Never("HandleForwardedTransactions", mock.Any, mock.Any)
+		for _, node := range nodes[1:] {
+			node.handler.When("HandleForwardedTransactions", mock.Any, mock.Any).Return(&gossiptopics.EmptyOutput{}, nil).Times(1)
+		}
+
+		_, err := nodes[0].gossip.BroadcastForwardedTransactions(ctx, &gossiptopics.ForwardedTransactionsInput{
+			Message: aForwardedTransactionsMessageSentBy(nodes[0].nodeAddress),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, test.EventuallyVerify(1*time.Second, handlersOf(nodes)...), "every other node should handle the broadcast")
+		require.NoError(t, test.ConsistentlyVerify(100*time.Millisecond, handlersOf(nodes)...), "no node should handle the broadcast twice")
+	})
+}
+
+func TestRelayedBroadcastNamingAnotherSenderThanItsOriginIsDropped(t *testing.T) {
+	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
+		harness.AllowErrorsMatching("dropping a relayed message with a forged sender")
+		nodes, transport := aRelayingNetwork(ctx, harness, 3)
+		for _, node := range nodes {
+			node.handler.Never("HandleForwardedTransactions", mock.Any, mock.Any)
+		}
+
+		// the second node signs a relayed message as its origin, claiming it was sent by the third
+		header := (&gossipmessages.HeaderBuilder{
+			Topic:            gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY,
+			TransactionRelay: gossipmessages.TRANSACTION_RELAY_FORWARDED_TRANSACTIONS,
+			RecipientMode:    codec.RECIPIENT_LIST_MODE_RELAY,
+			VirtualChainId:   42,
+		}).Build()
+		payloads, err := codec.EncodeForwardedTransactions(header, aForwardedTransactionsMessageSentBy(nodes[2].nodeAddress))
+		require.NoError(t, err)
+		signature, err := nodes[1].signer.Sign(ctx, codec.RelayedMessageHash(payloads))
+		require.NoError(t, err)
+
+		require.NoError(t, transport.Send(ctx, &adapter.TransportData{
+			SenderNodeAddress:      nodes[1].nodeAddress,
+			RecipientMode:          gossipmessages.RECIPIENT_LIST_MODE_LIST,
+			RecipientNodeAddresses: []primitives.NodeAddress{nodes[0].nodeAddress},
+			Payloads:               codec.EncodeRelayedMessage(&codec.RelayedMessage{Payloads: payloads, Origin: nodes[1].nodeAddress, Signature: signature, Ttl: 2}),
+		}))
+
+		require.NoError(t, test.ConsistentlyVerify(100*time.Millisecond, handlersOf(nodes)...), "no node should handle a message with a forged sender")
+	})
+}
+
+type relayingNode struct {
+	nodeAddress primitives.NodeAddress
+	signer      signer.Signer
+	gossip      gossiptopics.TransactionRelay
+	handler     *gossiptopics.MockTransactionRelayHandler
+}
+
+func aRelayingNetwork(ctx context.Context, harness *with.ConcurrencyHarness, numOfNodes int) ([]*relayingNode, adapter.Transport) {
+	var nodeAddresses []primitives.NodeAddress
+	var peers []*services.GossipPeer
+	for i := 0; i < numOfNodes; i++ {
+		nodeAddress := keys.EcdsaSecp256K1KeyPairForTests(i).NodeAddress()
+		nodeAddresses = append(nodeAddresses, nodeAddress)
+		peers = append(peers, &services.GossipPeer{Address: nodeAddress})
+	}
+
+	transport := memory.NewTransport(ctx, harness.Logger, nodeAddresses)
+	harness.Supervise(transport)
+
+	var nodes []*relayingNode
+	for i, nodeAddress := range nodeAddresses {
+		node := &relayingNode{
+			nodeAddress: nodeAddress,
+			signer:      signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(i).PrivateKey()),
+			handler:     &gossiptopics.MockTransactionRelayHandler{},
+		}
+		cfg := &relayConf{nodeAddress: nodeAddress, ttl: 2, fanout: uint32(numOfNodes - 1)}
+		g := gossip.NewGossip(ctx, transport, cfg, node.signer, harness.Logger, metric.NewRegistry())
+		harness.Supervise(g)
+		_, err := g.UpdateTopology(ctx, &services.UpdateTopologyInput{Peers: peers})
+		require.NoError(harness.T, err)
+
+		g.RegisterTransactionRelayHandler(node.handler)
+		node.gossip = g
+		nodes = append(nodes, node)
+	}
+	return nodes, transport
+}
+
+func aForwardedTransactionsMessageSentBy(sender primitives.NodeAddress) *gossipmessages.ForwardedTransactionsMessage {
+	return &gossipmessages.ForwardedTransactionsMessage{
+		Sender: (&gossipmessages.SenderSignatureBuilder{
+			SenderNodeAddress: sender,
+			Signature:         []byte{0x04, 0x05, 0x06},
+		}).Build(),
+		SignedTransactions: transactionpool.Transactions{builders.TransferTransaction().Build()},
+	}
+}
+
+func handlersOf(nodes []*relayingNode) []mock.HasVerify {
+	var mocks []mock.HasVerify
+	for _, node := range nodes {
+		mocks = append(mocks, node.handler)
+	}
+	return mocks
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
@@ -12,6 +13,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
@@ -32,13 +34,29 @@ func (c *conf) VirtualChainId() primitives.VirtualChainId {
 	return 42
 }
 
+func (c *conf) GossipRelayTtl() uint32 {
+	return 0
+}
+
+func (c *conf) GossipRelayFanoutTransactionRelay() uint32 {
+	return 0
+}
+
+func (c *conf) GossipRelayFanoutBlockSync() uint32 {
+	return 0
+}
+
+func aSigner() signer.Signer {
+	return signer.NewLocalSigner(keys.EcdsaSecp256K1KeyPairForTests(0).PrivateKey())
+}
+
 func TestDifferentTopicsDoNotBlockEachOtherForSamePeer(t *testing.T) {
 	with.Concurrency(t, func(ctx context.Context, harness *with.ConcurrencyHarness) {
 		nodeAddresses := []primitives.NodeAddress{{0x01}, {0x02}}
 		cfg := &conf{}
 
 		transport := memory.NewTransport(ctx, harness.Logger, nodeAddresses)
-		g := gossip.NewGossip(ctx, transport, cfg, harness.Logger, metric.NewRegistry())
+		g := gossip.NewGossip(ctx, transport, cfg, aSigner(), harness.Logger, metric.NewRegistry())
 
 		harness.Supervise(transport)
 		harness.Supervise(g)
@@ -86,7 +104,7 @@ func TestGossipDispatcherPropagatesTracingContext(t *testing.T) {
 		transport := memory.NewTransport(ctx, harness.Logger, nodeAddresses)
 		defer transport.GracefulShutdown(ctx)
 
-		g := gossip.NewGossip(ctx, transport, cfg, harness.Logger, metric.NewRegistry())
+		g := gossip.NewGossip(ctx, transport, cfg, aSigner(), harness.Logger, metric.NewRegistry())
 		trh := &gossiptopics.MockTransactionRelayHandler{}
 		g.RegisterTransactionRelayHandler(trh)
 
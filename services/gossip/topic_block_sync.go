@@ -55,18 +55,14 @@ func (s *service) BroadcastBlockAvailabilityRequest(ctx context.Context, input *
 	header := (&gossipmessages.HeaderBuilder{
 		Topic:          gossipmessages.HEADER_TOPIC_BLOCK_SYNC,
 		BlockSync:      gossipmessages.BLOCK_SYNC_AVAILABILITY_REQUEST,
-		RecipientMode:  gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		RecipientMode:  s.relay.broadcastMode(gossipmessages.HEADER_TOPIC_BLOCK_SYNC),
 		VirtualChainId: s.config.VirtualChainId(),
 	}).Build()
 	payloads, err := codec.EncodeBlockAvailabilityRequest(header, input.Message)
 	if err != nil {
 		return nil, err
 	}
-	return nil, s.transport.Send(ctx, &adapter.TransportData{
-		SenderNodeAddress: s.config.NodeAddress(),
-		RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
-		Payloads:          payloads,
-	})
+	return nil, s.broadcast(ctx, header, payloads)
 }
 
 func (s *service) receivedBlockSyncAvailabilityRequest(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) {
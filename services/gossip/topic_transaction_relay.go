@@ -10,13 +10,23 @@ import (
 	"context"
 	"github.com/orbs-network/crypto-lib-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
-	"github.com/orbs-network/orbs-network-go/services/gossip/adapter"
 	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/gossipmessages"
 	"github.com/orbs-network/orbs-spec/types/go/services/gossiptopics"
 	"github.com/orbs-network/scribe/log"
 )
 
+// WithdrawnTransactionRelay extends the transaction relay topic with the withdrawal of pending transactions, which the
+// protocol spec has no message for yet
+type WithdrawnTransactionRelay interface {
+	BroadcastWithdrawnTransaction(ctx context.Context, message *codec.WithdrawnTransactionMessage) error
+	RegisterWithdrawnTransactionHandler(handler WithdrawnTransactionHandler)
+}
+
+type WithdrawnTransactionHandler interface {
+	HandleWithdrawnTransaction(ctx context.Context, message *codec.WithdrawnTransactionMessage) error
+}
+
 func (s *service) RegisterTransactionRelayHandler(handler gossiptopics.TransactionRelayHandler) {
 	s.handlers.Lock()
 	defer s.handlers.Unlock()
@@ -24,10 +34,19 @@ func (s *service) RegisterTransactionRelayHandler(handler gossiptopics.Transacti
 	s.handlers.transactionHandlers = append(s.handlers.transactionHandlers, handler)
 }
 
+func (s *service) RegisterWithdrawnTransactionHandler(handler WithdrawnTransactionHandler) {
+	s.handlers.Lock()
+	defer s.handlers.Unlock()
+
+	s.handlers.withdrawnTransactionHandlers = append(s.handlers.withdrawnTransactionHandlers, handler)
+}
+
 func (s *service) receivedTransactionRelayMessage(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) {
 	switch header.TransactionRelay() {
 	case gossipmessages.TRANSACTION_RELAY_FORWARDED_TRANSACTIONS:
 		s.receivedForwardedTransactions(ctx, header, payloads)
+	case codec.TRANSACTION_RELAY_WITHDRAWN_TRANSACTION:
+		s.receivedWithdrawnTransaction(ctx, header, payloads)
 	}
 }
 
@@ -40,7 +59,7 @@ func (s *service) BroadcastForwardedTransactions(ctx context.Context, input *gos
 	header := (&gossipmessages.HeaderBuilder{
 		Topic:            gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY,
 		TransactionRelay: gossipmessages.TRANSACTION_RELAY_FORWARDED_TRANSACTIONS,
-		RecipientMode:    gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
+		RecipientMode:    s.relay.broadcastMode(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY),
 		VirtualChainId:   s.config.VirtualChainId(),
 	}).Build()
 
@@ -49,11 +68,7 @@ func (s *service) BroadcastForwardedTransactions(ctx context.Context, input *gos
 		return nil, err
 	}
 
-	return nil, s.transport.Send(ctx, &adapter.TransportData{
-		SenderNodeAddress: s.config.NodeAddress(),
-		RecipientMode:     gossipmessages.RECIPIENT_LIST_MODE_BROADCAST,
-		Payloads:          payloads,
-	})
+	return nil, s.broadcast(ctx, header, payloads)
 }
 
 func (s *service) receivedForwardedTransactions(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) {
@@ -80,3 +95,48 @@ func (s *service) receivedForwardedTransactions(ctx context.Context, header *gos
 		}
 	}
 }
+
+func (s *service) BroadcastWithdrawnTransaction(ctx context.Context, message *codec.WithdrawnTransactionMessage) error {
+	s.logger.Info("broadcasting withdrawn transaction",
+		trace.LogFieldFrom(ctx),
+		log.Stringable("transaction", message.TxHash),
+		log.Stringable("replacement", message.ReplacementTxHash))
+
+	header := (&gossipmessages.HeaderBuilder{
+		Topic:            gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY,
+		TransactionRelay: codec.TRANSACTION_RELAY_WITHDRAWN_TRANSACTION,
+		RecipientMode:    s.relay.broadcastMode(gossipmessages.HEADER_TOPIC_TRANSACTION_RELAY),
+		VirtualChainId:   s.config.VirtualChainId(),
+	}).Build()
+
+	payloads, err := codec.EncodeWithdrawnTransaction(header, message)
+	if err != nil {
+		return err
+	}
+
+	return s.broadcast(ctx, header, payloads)
+}
+
+func (s *service) receivedWithdrawnTransaction(ctx context.Context, header *gossipmessages.Header, payloads [][]byte) {
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
+	message, err := codec.DecodeWithdrawnTransaction(payloads)
+	if err != nil {
+		logger.Info("DecodeWithdrawnTransaction failed", log.Error(err))
+		s.forwarededTransactionFailures.Inc()
+		return
+	}
+
+	logger.Info("received withdrawn transaction",
+		log.Stringable("transaction", message.TxHash),
+		log.Stringable("replacement", message.ReplacementTxHash))
+
+	s.handlers.RLock()
+	defer s.handlers.RUnlock()
+
+	for _, l := range s.handlers.withdrawnTransactionHandlers {
+		if err := l.HandleWithdrawnTransaction(ctx, message); err != nil {
+			logger.Info("HandleWithdrawnTransaction failed", log.Error(err))
+			s.forwarededTransactionFailures.Inc()
+		}
+	}
+}
@@ -217,6 +217,15 @@ func getCommittee(refTime primitives.TimestampSeconds, committees []CommitteeTer
 	return &committees[i]
 }
 
+// CommitteeAt returns the committee term in effect at a reference time, used to verify block proofs without a running
+// management service
+func (d *VirtualChainManagementData) CommitteeAt(refTime primitives.TimestampSeconds) (*CommitteeTerm, error) {
+	if len(d.Committees) == 0 {
+		return nil, errors.New("management data has no committee terms")
+	}
+	return getCommittee(refTime, d.Committees), nil
+}
+
 func (s *service) GetCommittee(ctx context.Context, input *services.GetCommitteeInput) (*services.GetCommitteeOutput, error) {
 	data, err := s.getData(ctx, input.Reference)
 	if err != nil {
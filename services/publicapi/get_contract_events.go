@@ -0,0 +1,109 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type GetContractEventsInput struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	ContractName    primitives.ContractName
+	EventName       primitives.EventName // empty for every event of the contract
+	FromBlockHeight primitives.BlockHeight
+	ToBlockHeight   primitives.BlockHeight // zero for the last committed block
+}
+
+type GetContractEventsOutput struct {
+	RequestStatus   protocol.RequestStatus
+	Events          []*blockstorage.ContractEvent
+	LastBlockHeight primitives.BlockHeight // the next page of events starts at the block following it
+}
+
+type SubscribeContractEventsInput struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	ContractName    primitives.ContractName
+	EventName       primitives.EventName   // empty for every event of the contract
+	FromBlockHeight primitives.BlockHeight // zero for the blocks committed from now on
+}
+
+// GetContractEvents returns the events a contract emitted in a range of committed blocks, read through the event index
+// of block storage instead of whole blocks
+func (s *service) GetContractEvents(parentCtx context.Context, input *GetContractEventsInput) (*GetContractEventsOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.GetContractEvents")
+
+	if input == nil {
+		err := errors.Errorf("client request is nil")
+		s.logger.Info("get contract events received missing input", log.Error(err))
+		return nil, err
+	}
+
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), log.Stringable("contract", input.ContractName))
+
+	if _, err := validateRequest(s.config, input.ProtocolVersion, input.VirtualChainId); err != nil {
+		logger.Info("get contract events received input failed", log.Error(err))
+		return &GetContractEventsOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	logger.Info("get contract events request received", logfields.BlockHeight(input.FromBlockHeight))
+
+	out, err := s.blockStorage.GetContractEvents(ctx, &blockstorage.GetContractEventsInput{
+		Filter:          blockstorage.ContractEventFilter{ContractName: input.ContractName, EventName: input.EventName},
+		FromBlockHeight: input.FromBlockHeight,
+		ToBlockHeight:   input.ToBlockHeight,
+	})
+	if err != nil {
+		logger.Info("get contract events failed in BlockStorage", log.Error(err))
+		return &GetContractEventsOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	return &GetContractEventsOutput{
+		RequestStatus:   protocol.REQUEST_STATUS_COMPLETED,
+		Events:          out.Events,
+		LastBlockHeight: out.LastBlockHeight,
+	}, nil
+}
+
+// SubscribeContractEvents streams the events a contract emits as their blocks commit, from a committed block onwards if
+// asked to. the stream is closed once the context is done
+func (s *service) SubscribeContractEvents(parentCtx context.Context, input *SubscribeContractEventsInput) (<-chan *blockstorage.ContractEvent, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.SubscribeContractEvents")
+
+	if input == nil {
+		err := errors.Errorf("client request is nil")
+		s.logger.Info("subscribe contract events received missing input", log.Error(err))
+		return nil, err
+	}
+
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), log.Stringable("contract", input.ContractName))
+
+	if _, err := validateRequest(s.config, input.ProtocolVersion, input.VirtualChainId); err != nil {
+		logger.Info("subscribe contract events received input failed", log.Error(err))
+		return nil, err
+	}
+
+	logger.Info("subscribe contract events request received", logfields.BlockHeight(input.FromBlockHeight))
+
+	events, err := s.blockStorage.SubscribeContractEvents(ctx, &blockstorage.SubscribeContractEventsInput{
+		Filter:          blockstorage.ContractEventFilter{ContractName: input.ContractName, EventName: input.EventName},
+		FromBlockHeight: input.FromBlockHeight,
+	})
+	if err != nil {
+		logger.Info("subscribe contract events failed in BlockStorage", log.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
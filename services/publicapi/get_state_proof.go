@@ -0,0 +1,107 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type GetStateProofInput struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	BlockHeight     primitives.BlockHeight // zero for the last committed block
+	ContractName    primitives.ContractName
+	Key             []byte
+}
+
+type GetStateProofOutput struct {
+	RequestStatus       protocol.RequestStatus
+	BlockHeight         primitives.BlockHeight
+	StateMerkleRootHash primitives.Sha256
+	Value               []byte
+	Proof               *statestorage.StateProof
+
+	// the block following BlockHeight carries StateMerkleRootHash as its pre-execution state root, so its header and
+	// block proof let a client trust the state root. nil until that block is committed
+	NextResultsBlockHeader *protocol.ResultsBlockHeader
+	NextResultsBlockProof  *protocol.ResultsBlockProof
+}
+
+func (s *service) GetStateProof(parentCtx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.GetStateProof")
+
+	if input == nil {
+		err := errors.Errorf("client request is nil")
+		s.logger.Info("get state proof received missing input", log.Error(err))
+		return nil, err
+	}
+
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), log.Stringable("contract", input.ContractName))
+
+	if _, err := validateRequest(s.config, input.ProtocolVersion, input.VirtualChainId); err != nil {
+		logger.Info("get state proof received input failed", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	if input.ContractName == "" {
+		err := errors.Errorf("missing contract name")
+		logger.Info("get state proof received input failed", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	logger.Info("get state proof request received", logfields.BlockHeight(input.BlockHeight))
+
+	proofOutput, err := s.stateStorage.GetStateProof(ctx, &statestorage.GetStateProofInput{
+		BlockHeight:  input.BlockHeight,
+		ContractName: input.ContractName,
+		Key:          input.Key,
+	})
+	if err != nil {
+		logger.Info("get state proof failed to get proof from state storage", log.Error(err))
+		return &GetStateProofOutput{RequestStatus: protocol.REQUEST_STATUS_NOT_FOUND, BlockHeight: input.BlockHeight}, err
+	}
+
+	output := &GetStateProofOutput{
+		RequestStatus:       protocol.REQUEST_STATUS_COMPLETED,
+		BlockHeight:         proofOutput.BlockHeight,
+		StateMerkleRootHash: proofOutput.StateMerkleRootHash,
+		Value:               proofOutput.Value,
+		Proof:               proofOutput.Proof,
+	}
+
+	if header, proof := s.getNextResultsBlockHeader(ctx, proofOutput.BlockHeight); header != nil {
+		output.NextResultsBlockHeader = header
+		output.NextResultsBlockProof = proof
+	}
+
+	return output, nil
+}
+
+func (s *service) getNextResultsBlockHeader(ctx context.Context, height primitives.BlockHeight) (*protocol.ResultsBlockHeader, *protocol.ResultsBlockProof) {
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
+
+	// asking block storage for a block it does not have yet would wait for it, so check first
+	last, err := s.blockStorage.GetLastCommittedBlockHeight(ctx, &services.GetLastCommittedBlockHeightInput{})
+	if err != nil || last.LastCommittedBlockHeight <= height {
+		return nil, nil
+	}
+
+	next, err := s.blockStorage.GetResultsBlockHeader(ctx, &services.GetResultsBlockHeaderInput{BlockHeight: height + 1})
+	if err != nil {
+		logger.Info("get state proof failed to get next results block header", log.Error(err), logfields.BlockHeight(height+1))
+		return nil, nil
+	}
+	return next.ResultsBlockHeader, next.ResultsBlockProof
+}
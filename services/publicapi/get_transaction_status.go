@@ -20,7 +20,19 @@ import (
 	"time"
 )
 
+type GetTransactionStatusAtBlockHeightInput struct {
+	ClientRequest *client.GetTransactionStatusRequest
+	BlockHeight   primitives.BlockHeight // zero for the last committed block
+}
+
 func (s *service) GetTransactionStatus(parentCtx context.Context, input *services.GetTransactionStatusInput) (*services.GetTransactionStatusOutput, error) {
+	return s.GetTransactionStatusAtBlockHeight(parentCtx, &GetTransactionStatusAtBlockHeightInput{ClientRequest: input.ClientRequest})
+}
+
+// GetTransactionStatusAtBlockHeight reports the status of a transaction as of an older block. a transaction committed
+// in a later block was not committed yet as of that block, so it is reported pending along with the height and the
+// timestamp of that block
+func (s *service) GetTransactionStatusAtBlockHeight(parentCtx context.Context, input *GetTransactionStatusAtBlockHeightInput) (*services.GetTransactionStatusOutput, error) {
 	ctx := trace.NewContext(parentCtx, "PublicApi.GetTransactionStatus")
 
 	if input.ClientRequest == nil {
@@ -38,9 +50,32 @@ func (s *service) GetTransactionStatus(parentCtx context.Context, input *service
 		return toGetTxStatusOutput(s.config, &txOutput{transactionStatus: txStatus}), err
 	}
 
-	logger.Info("get transaction status request received")
+	logger.Info("get transaction status request received", logfields.BlockHeight(input.BlockHeight))
+
+	out, err := s.getTransactionStatus(ctx, s.config, txHash, tx.TransactionTimestamp())
+	if err != nil || input.BlockHeight == 0 {
+		return out, err
+	}
 
-	return s.getTransactionStatus(ctx, s.config, txHash, tx.TransactionTimestamp())
+	response := out.ClientResponse
+	if response.TransactionStatus() != protocol.TRANSACTION_STATUS_COMMITTED || response.RequestResult().BlockHeight() <= input.BlockHeight {
+		return out, nil
+	}
+	header, err := s.blockStorage.GetTransactionsBlockHeader(ctx, &services.GetTransactionsBlockHeaderInput{BlockHeight: input.BlockHeight})
+	if err != nil {
+		logger.Info("get transaction status failed to read the block it is pinned to", log.Error(err), logfields.BlockHeight(input.BlockHeight))
+		return nil, err
+	}
+	// the older timestamp of the pinned block does not tell the node is out of sync, as it holds the later block
+	response = (&client.GetTransactionStatusResponseBuilder{
+		RequestResult: &client.RequestResultBuilder{
+			RequestStatus:  translateTransactionStatusToRequestStatus(protocol.TRANSACTION_STATUS_PENDING, protocol.EXECUTION_RESULT_RESERVED),
+			BlockHeight:    input.BlockHeight,
+			BlockTimestamp: header.TransactionsBlockHeader.Timestamp(),
+		},
+		TransactionStatus: protocol.TRANSACTION_STATUS_PENDING,
+	}).Build()
+	return &services.GetTransactionStatusOutput{ClientResponse: response}, nil
 }
 
 func (s *service) getTransactionStatus(ctx context.Context, config config.PublicApiConfig, txHash primitives.Sha256, txTimestamp primitives.TimestampNano) (*services.GetTransactionStatusOutput, error) {
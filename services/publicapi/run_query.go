@@ -11,6 +11,7 @@ import (
 	"github.com/orbs-network/crypto-lib-go/crypto/digest"
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
 	"github.com/orbs-network/orbs-spec/types/go/services"
@@ -19,7 +20,18 @@ import (
 	"time"
 )
 
+type RunQueryAtBlockHeightInput struct {
+	ClientRequest *client.RunQueryRequest
+	BlockHeight   primitives.BlockHeight // zero for the last committed block
+}
+
 func (s *service) RunQuery(parentCtx context.Context, input *services.RunQueryInput) (*services.RunQueryOutput, error) {
+	return s.RunQueryAtBlockHeight(parentCtx, &RunQueryAtBlockHeightInput{ClientRequest: input.ClientRequest})
+}
+
+// RunQueryAtBlockHeight runs a query against the state of an older block, which the node only keeps for the recent
+// blocks unless state storage runs in archive mode
+func (s *service) RunQueryAtBlockHeight(parentCtx context.Context, input *RunQueryAtBlockHeightInput) (*services.RunQueryOutput, error) {
 	s.metrics.queriesPerSecond.Measure(1)
 	ctx := trace.NewContext(parentCtx, "PublicApi.RunQuery")
 
@@ -38,13 +50,13 @@ func (s *service) RunQuery(parentCtx context.Context, input *services.RunQueryIn
 		return toRunQueryOutput(&queryOutput{requestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}), err
 	}
 
-	logger.Info("run query request received")
+	logger.Info("run query request received", logfields.BlockHeight(input.BlockHeight))
 
 	start := time.Now()
 	defer s.metrics.runQueryTime.RecordSince(start)
 
 	callOutput, err := s.virtualMachine.ProcessQuery(ctx, &services.ProcessQueryInput{
-		BlockHeight: 0, // recent block height
+		BlockHeight: input.BlockHeight,
 		SignedQuery: input.ClientRequest.SignedQuery(),
 	})
 	if err != nil {
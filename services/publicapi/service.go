@@ -12,6 +12,9 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
@@ -21,11 +24,25 @@ import (
 
 var LogTag = log.Service("public-api")
 
+// PublicApi is the public api service of the protocol spec together with the queries this node serves beyond it
+type PublicApi interface {
+	services.PublicApi
+	GetStateProof(ctx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error)
+	RunQueryAtBlockHeight(ctx context.Context, input *RunQueryAtBlockHeightInput) (*services.RunQueryOutput, error)
+	GetTransactionStatusAtBlockHeight(ctx context.Context, input *GetTransactionStatusAtBlockHeightInput) (*services.GetTransactionStatusOutput, error)
+	UpdatePendingTransaction(ctx context.Context, input *UpdatePendingTransactionInput) (*UpdatePendingTransactionOutput, error)
+	SubscribeTransactionStatus(ctx context.Context, input *SubscribeTransactionStatusInput) (<-chan *TransactionStatusEvent, error)
+	GetContractEvents(ctx context.Context, input *GetContractEventsInput) (*GetContractEventsOutput, error)
+	SubscribeContractEvents(ctx context.Context, input *SubscribeContractEventsInput) (<-chan *blockstorage.ContractEvent, error)
+	SubscribeBlockHeaders(ctx context.Context, input *SubscribeBlockHeadersInput) (<-chan *blockstorage.BlockHeaders, error)
+}
+
 type service struct {
 	config          config.PublicApiConfig
-	transactionPool services.TransactionPool
+	transactionPool transactionpool.TransactionPool
 	virtualMachine  services.VirtualMachine
-	blockStorage    services.BlockStorage
+	blockStorage    blockstorage.BlockStorage
+	stateStorage    statestorage.StateStorage
 	logger          log.Logger
 
 	waiter *waiter
@@ -63,17 +80,19 @@ func newMetrics(factory metric.Factory, sendTransactionTimeout time.Duration, ge
 
 func NewPublicApi(
 	config config.PublicApiConfig,
-	transactionPool services.TransactionPool,
+	transactionPool transactionpool.TransactionPool,
 	virtualMachine services.VirtualMachine,
-	blockStorage services.BlockStorage,
+	blockStorage blockstorage.BlockStorage,
+	stateStorage statestorage.StateStorage,
 	logger log.Logger,
 	metricFactory metric.Factory,
-) services.PublicApi {
+) PublicApi {
 	s := &service{
 		config:          config,
 		transactionPool: transactionPool,
 		virtualMachine:  virtualMachine,
 		blockStorage:    blockStorage,
+		stateStorage:    stateStorage,
 		logger:          logger.WithTags(LogTag),
 
 		waiter:  newWaiter(),
@@ -0,0 +1,51 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type SubscribeBlockHeadersInput struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	FromBlockHeight primitives.BlockHeight // zero for the blocks committed from now on
+}
+
+// SubscribeBlockHeaders streams the headers of blocks as they commit, from a committed block onwards if asked to. the
+// stream is closed once the context is done
+func (s *service) SubscribeBlockHeaders(parentCtx context.Context, input *SubscribeBlockHeadersInput) (<-chan *blockstorage.BlockHeaders, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.SubscribeBlockHeaders")
+
+	if input == nil {
+		err := errors.Errorf("client request is nil")
+		s.logger.Info("subscribe block headers received missing input", log.Error(err))
+		return nil, err
+	}
+
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
+
+	if _, err := validateRequest(s.config, input.ProtocolVersion, input.VirtualChainId); err != nil {
+		logger.Info("subscribe block headers received input failed", log.Error(err))
+		return nil, err
+	}
+
+	logger.Info("subscribe block headers request received", logfields.BlockHeight(input.FromBlockHeight))
+
+	headers, err := s.blockStorage.SubscribeBlockHeaders(ctx, &blockstorage.SubscribeBlockHeadersInput{FromBlockHeight: input.FromBlockHeight})
+	if err != nil {
+		logger.Info("subscribe block headers failed in BlockStorage", log.Error(err))
+		return nil, err
+	}
+	return headers, nil
+}
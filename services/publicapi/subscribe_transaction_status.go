@@ -0,0 +1,117 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+const MaxTransactionStatusSubscriptionSize = 100
+
+type SubscribeTransactionStatusInput struct {
+	Txhashes []primitives.Sha256
+}
+
+type TransactionStatusEvent struct {
+	Txhash             primitives.Sha256
+	RequestStatus      protocol.RequestStatus
+	TransactionStatus  protocol.TransactionStatus
+	TransactionReceipt *protocol.TransactionReceipt
+	BlockHeight        primitives.BlockHeight
+	BlockTimestamp     primitives.TimestampNano
+}
+
+// SubscribeTransactionStatus streams the status of each transaction as it is now, followed by its final status once
+// the transaction pool reports it committed or rejected. results of committed transactions are reported only by the
+// node the transaction was sent to. the stream is closed once every transaction reached a final status, or the context
+// or the subscription timeout is done
+func (s *service) SubscribeTransactionStatus(parentCtx context.Context, input *SubscribeTransactionStatusInput) (<-chan *TransactionStatusEvent, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.SubscribeTransactionStatus")
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx))
+
+	if input == nil || len(input.Txhashes) == 0 {
+		err := errors.Errorf("no transactions to subscribe to")
+		logger.Info("subscribe transaction status received input failed", log.Error(err))
+		return nil, err
+	}
+	if len(input.Txhashes) > MaxTransactionStatusSubscriptionSize {
+		err := errors.Errorf("subscribing to %d transactions exceeds the limit of %d", len(input.Txhashes), MaxTransactionStatusSubscriptionSize)
+		logger.Info("subscribe transaction status received input failed", log.Error(err))
+		return nil, err
+	}
+
+	var cancel context.CancelFunc
+	if timeout := s.config.PublicApiTransactionStatusSubscriptionTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	logger.Info("subscribe transaction status request received", log.Int("transactions", len(input.Txhashes)))
+
+	events := make(chan *TransactionStatusEvent, 2*len(input.Txhashes)) // room for the current and the final status of each
+	var wg sync.WaitGroup
+	for _, txHash := range input.Txhashes {
+		waitResult := s.waiter.add(txHash.KeyForMap()) // before the current status is read, so no result is missed in between
+
+		current, err := s.transactionPool.GetCommittedTransactionReceipt(ctx, &services.GetCommittedTransactionReceiptInput{Txhash: txHash})
+		if err != nil {
+			s.waiter.deleteByChannel(waitResult)
+			cancel()
+			logger.Info("subscribe transaction status failed reading status from TransactionPool", log.Error(err))
+			return nil, err
+		}
+		events <- toTransactionStatusEvent(txHash, poolOutputToTxOutput(current))
+		if current.TransactionStatus == protocol.TRANSACTION_STATUS_COMMITTED {
+			s.waiter.deleteByChannel(waitResult)
+			continue
+		}
+
+		wg.Add(1)
+		go func(txHash primitives.Sha256) {
+			defer wg.Done()
+			result, err := s.waiter.wait(ctx, waitResult)
+			if err != nil {
+				return
+			}
+			select {
+			case events <- toTransactionStatusEvent(txHash, result.(*txOutput)):
+			case <-ctx.Done():
+			}
+		}(txHash)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+func toTransactionStatusEvent(txHash primitives.Sha256, out *txOutput) *TransactionStatusEvent {
+	event := &TransactionStatusEvent{
+		Txhash:             txHash,
+		RequestStatus:      translateTransactionStatusToRequestStatus(out.transactionStatus, protocol.EXECUTION_RESULT_RESERVED),
+		TransactionStatus:  out.transactionStatus,
+		TransactionReceipt: out.transactionReceipt,
+		BlockHeight:        out.blockHeight,
+		BlockTimestamp:     out.blockTimestamp,
+	}
+	if out.transactionReceipt != nil {
+		event.RequestStatus = translateTransactionStatusToRequestStatus(out.transactionStatus, out.transactionReceipt.ExecutionResult())
+	}
+	return event
+}
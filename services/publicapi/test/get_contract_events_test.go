@@ -0,0 +1,66 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetContractEvents_ReturnsEventsFromBlockStorage(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.bksMock.When("GetContractEvents", mock.Any, mock.Any).Return(&blockstorage.GetContractEventsOutput{
+				Events:          []*blockstorage.ContractEvent{{BlockHeight: 4, ContractName: "Token", EventName: "Transfer"}},
+				LastBlockHeight: 7,
+			}, nil).Times(1)
+
+			result, err := harness.papi.GetContractEvents(ctx, &publicapi.GetContractEventsInput{
+				VirtualChainId:  builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID,
+				ContractName:    "Token",
+				EventName:       "Transfer",
+				FromBlockHeight: 1,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+			require.Len(t, result.Events, 1)
+			require.EqualValues(t, 7, result.LastBlockHeight)
+		})
+	})
+}
+
+func TestGetContractEvents_RejectsWrongVirtualChain(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.bksMock.Never("GetContractEvents", mock.Any, mock.Any)
+
+			result, err := harness.papi.GetContractEvents(ctx, &publicapi.GetContractEventsInput{
+				VirtualChainId:  builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID + 1,
+				ContractName:    "Token",
+				FromBlockHeight: 1,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			require.Error(t, err, "request of another virtual chain was served")
+			require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+		})
+	})
+}
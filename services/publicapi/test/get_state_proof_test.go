@@ -0,0 +1,115 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetStateProof_IncludesNextBlockHeaderWhenCommitted(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.stateStorageHasProof(8)
+			harness.prepareGetLastBlock(builders.BlockPair().WithHeight(9).Build())
+			harness.prepareGetResultsBlockHeader(9)
+
+			result, err := harness.papi.GetStateProof(ctx, aGetStateProofInput())
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+			require.EqualValues(t, 8, result.BlockHeight, "got wrong block height")
+			require.EqualValues(t, "bar", result.Value, "got wrong value")
+			require.NotNil(t, result.Proof, "got empty proof")
+			require.EqualValues(t, 9, result.NextResultsBlockHeader.BlockHeight(), "got wrong next block header")
+		})
+	})
+}
+
+func TestGetStateProof_OmitsNextBlockHeaderForLastCommittedBlock(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.stateStorageHasProof(8)
+			harness.prepareGetLastBlock(builders.BlockPair().WithHeight(8).Build())
+			harness.bksMock.Never("GetResultsBlockHeader", mock.Any, mock.Any)
+
+			result, err := harness.papi.GetStateProof(ctx, aGetStateProofInput())
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, result.RequestStatus, "got wrong status")
+			require.Nil(t, result.NextResultsBlockHeader, "next block is not committed yet")
+		})
+	})
+}
+
+func TestGetStateProof_StateStorageFails(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.stateStorageHasNoProof()
+			harness.bksMock.Never("GetLastCommittedBlockHeight", mock.Any, mock.Any)
+
+			result, err := harness.papi.GetStateProof(ctx, aGetStateProofInput())
+
+			harness.verifyMocks(t) // contract test
+
+			require.Error(t, err, "error did not happen when it should")
+			require.Equal(t, protocol.REQUEST_STATUS_NOT_FOUND, result.RequestStatus, "got wrong status")
+		})
+	})
+}
+
+func TestGetStateProof_RejectsBadRequest(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.stsMock.Never("GetStateProof", mock.Any, mock.Any)
+
+			input := aGetStateProofInput()
+			input.VirtualChainId++
+			result, err := harness.papi.GetStateProof(ctx, input)
+			require.Error(t, err, "error did not happen when it should")
+			require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+
+			input = aGetStateProofInput()
+			input.ContractName = ""
+			result, err = harness.papi.GetStateProof(ctx, input)
+			require.Error(t, err, "error did not happen when it should")
+			require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+
+			harness.verifyMocks(t) // contract test
+		})
+	})
+}
+
+func aGetStateProofInput() *publicapi.GetStateProofInput {
+	return &publicapi.GetStateProofInput{
+		ProtocolVersion: config.MAXIMAL_CONSENSUS_BLOCK_PROTOCOL_VERSION,
+		VirtualChainId:  builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID,
+		BlockHeight:     8,
+		ContractName:    "foo",
+		Key:             []byte("bar"),
+	}
+}
@@ -8,9 +8,12 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	"github.com/orbs-network/orbs-network-go/test"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/protocol/client"
 	"github.com/orbs-network/orbs-spec/types/go/services"
@@ -90,3 +93,58 @@ func TestGetTransactionStatus_GetTxFromBlockStorage(t *testing.T) {
 		})
 	})
 }
+
+func TestGetTransactionStatusAtBlockHeight_ReportsTxCommittedInLaterBlockAsPending(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+
+			pinnedTimestamp := primitives.TimestampNano(time.Now().Add(-time.Hour).UnixNano())
+			harness.transactionIsCommittedInPoolAtBlockHeight(5)
+			harness.prepareGetTransactionsBlockHeader(3, pinnedTimestamp)
+			result, err := harness.papi.GetTransactionStatusAtBlockHeight(ctx, &publicapi.GetTransactionStatusAtBlockHeightInput{
+				ClientRequest: (&client.GetTransactionStatusRequestBuilder{
+					TransactionRef: builders.TransactionRef().Builder(),
+				}).Build(),
+				BlockHeight: 3,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			// value test
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.TRANSACTION_STATUS_PENDING, result.ClientResponse.TransactionStatus(), "tx committed after the pinned block should be pending")
+			require.Equal(t, protocol.REQUEST_STATUS_IN_PROCESS, result.ClientResponse.RequestResult().RequestStatus(), "got wrong request status")
+			require.EqualValues(t, 3, result.ClientResponse.RequestResult().BlockHeight(), "should report the pinned block")
+			require.Equal(t, pinnedTimestamp, result.ClientResponse.RequestResult().BlockTimestamp(), "should report the timestamp of the pinned block")
+			require.Equal(t, 0, len(result.ClientResponse.TransactionReceipt().Raw()), "pending tx should have no receipt")
+		})
+	})
+}
+
+func TestGetTransactionStatusAtBlockHeight_ReportsTxCommittedInPinnedBlockAsCommitted(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+
+			harness.transactionIsCommittedInPoolAtBlockHeight(3)
+			harness.bksMock.Never("GetTransactionsBlockHeader", mock.Any, mock.Any)
+			result, err := harness.papi.GetTransactionStatusAtBlockHeight(ctx, &publicapi.GetTransactionStatusAtBlockHeightInput{
+				ClientRequest: (&client.GetTransactionStatusRequestBuilder{
+					TransactionRef: builders.TransactionRef().Builder(),
+				}).Build(),
+				BlockHeight: 3,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			// value test
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.TRANSACTION_STATUS_COMMITTED, result.ClientResponse.TransactionStatus(), "got wrong status")
+			require.EqualValues(t, 3, result.ClientResponse.RequestResult().BlockHeight(), "should report the block of the tx")
+			require.NotNil(t, result.ClientResponse.TransactionReceipt(), "got empty receipt")
+		})
+	})
+}
@@ -11,8 +11,13 @@ import (
 	"github.com/orbs-network/go-mock"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	bkstestkit "github.com/orbs-network/orbs-network-go/services/blockstorage/testkit"
 	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/testkit"
+	txpooltestkit "github.com/orbs-network/orbs-network-go/services/transactionpool/testkit"
 	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/scribe/log"
@@ -23,28 +28,31 @@ import (
 )
 
 type harness struct {
-	papi    services.PublicApi
-	txpMock *services.MockTransactionPool
-	bksMock *services.MockBlockStorage
+	papi    publicapi.PublicApi
+	txpMock *txpooltestkit.MockTransactionPool
+	bksMock *bkstestkit.MockBlockStorage
 	vmMock  *services.MockVirtualMachine
+	stsMock *testkit.MockStateStorage
 }
 
 func newPublicApiHarness(logger log.Logger, txTimeout time.Duration, outOfSyncWarningTime time.Duration) *harness {
 	cfg := config.ForPublicApiTests(uint32(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID), txTimeout, outOfSyncWarningTime)
 	txpMock := makeTxMock()
 	vmMock := &services.MockVirtualMachine{}
-	bksMock := &services.MockBlockStorage{}
-	papi := publicapi.NewPublicApi(cfg, txpMock, vmMock, bksMock, logger, metric.NewRegistry())
+	bksMock := &bkstestkit.MockBlockStorage{}
+	stsMock := &testkit.MockStateStorage{}
+	papi := publicapi.NewPublicApi(cfg, txpMock, vmMock, bksMock, stsMock, logger, metric.NewRegistry())
 	return &harness{
 		papi:    papi,
 		txpMock: txpMock,
 		bksMock: bksMock,
 		vmMock:  vmMock,
+		stsMock: stsMock,
 	}
 }
 
-func makeTxMock() *services.MockTransactionPool {
-	txpMock := &services.MockTransactionPool{}
+func makeTxMock() *txpooltestkit.MockTransactionPool {
+	txpMock := &txpooltestkit.MockTransactionPool{}
 	txpMock.When("RegisterTransactionResultsHandler", mock.Any).Return(nil)
 	return txpMock
 }
@@ -82,6 +90,16 @@ func (h *harness) transactionIsCommittedInPool() {
 	h.bksMock.Never("GetTransactionReceipt", mock.Any)
 }
 
+func (h *harness) transactionIsCommittedInPoolAtBlockHeight(height primitives.BlockHeight) {
+	h.txpMock.When("GetCommittedTransactionReceipt", mock.Any, mock.Any).Return(&services.GetCommittedTransactionReceiptOutput{
+		TransactionStatus:  protocol.TRANSACTION_STATUS_COMMITTED,
+		TransactionReceipt: builders.TransactionReceipt().Build(),
+		BlockHeight:        height,
+		BlockTimestamp:     primitives.TimestampNano(time.Now().UnixNano()),
+	}).Times(1)
+	h.bksMock.Never("GetTransactionReceipt", mock.Any)
+}
+
 func (h *harness) transactionIsNotInPool() {
 	h.txpMock.When("GetCommittedTransactionReceipt", mock.Any, mock.Any).Return(&services.GetCommittedTransactionReceiptOutput{
 		TransactionStatus: protocol.TRANSACTION_STATUS_NO_RECORD_FOUND,
@@ -104,6 +122,18 @@ func (h *harness) runTransactionSuccess() {
 		})
 }
 
+func (h *harness) runQueryAtBlockHeightSuccess(height primitives.BlockHeight) {
+	h.vmMock.When("ProcessQuery", mock.Any, mock.AnyIf("query at requested height", func(i interface{}) bool {
+		input, ok := i.(*services.ProcessQueryInput)
+		return ok && input.BlockHeight == height
+	})).Times(1).
+		Return(&services.ProcessQueryOutput{
+			CallResult:           protocol.EXECUTION_RESULT_SUCCESS,
+			OutputArgumentArray:  nil,
+			ReferenceBlockHeight: height,
+		})
+}
+
 func (h *harness) transactionHasProof() {
 	h.transactionIsCommittedInPool()
 	h.bksMock.When("GenerateReceiptProof", mock.Any, mock.Any).Return(
@@ -150,6 +180,35 @@ func (h *harness) prepareGetLastBlock(lastCommittedBlockPair *protocol.BlockPair
 	}
 }
 
+func (h *harness) stateStorageHasProof(height primitives.BlockHeight) {
+	h.stsMock.When("GetStateProof", mock.Any, mock.Any).Return(&statestorage.GetStateProofOutput{
+		BlockHeight:         height,
+		StateMerkleRootHash: []byte{0x01},
+		Value:               []byte("bar"),
+		Proof:               &statestorage.StateProof{},
+	}, nil).Times(1)
+}
+
+func (h *harness) stateStorageHasNoProof() {
+	h.stsMock.When("GetStateProof", mock.Any, mock.Any).Return(nil, errors.Errorf("unsupported block height")).Times(1)
+}
+
+func (h *harness) prepareGetResultsBlockHeader(height primitives.BlockHeight) {
+	h.bksMock.When("GetResultsBlockHeader", mock.Any, mock.Any).Return(&services.GetResultsBlockHeaderOutput{
+		ResultsBlockHeader: (&protocol.ResultsBlockHeaderBuilder{BlockHeight: height}).Build(),
+		ResultsBlockProof:  (&protocol.ResultsBlockProofBuilder{}).Build(),
+	}).Times(1)
+}
+
+func (h *harness) prepareGetTransactionsBlockHeader(height primitives.BlockHeight, timestamp primitives.TimestampNano) {
+	h.bksMock.When("GetTransactionsBlockHeader", mock.Any, mock.AnyIf("header at requested height", func(i interface{}) bool {
+		input, ok := i.(*services.GetTransactionsBlockHeaderInput)
+		return ok && input.BlockHeight == height
+	})).Return(&services.GetTransactionsBlockHeaderOutput{
+		TransactionsBlockHeader: (&protocol.TransactionsBlockHeaderBuilder{BlockHeight: height, Timestamp: timestamp}).Build(),
+	}).Times(1)
+}
+
 func (h *harness) getBlockFails() {
 	h.bksMock.When("GetBlockPair", mock.Any, mock.Any).Return(nil, errors.Errorf("someErr")).Times(1)
 }
@@ -165,4 +224,7 @@ func (h *harness) verifyMocks(t *testing.T) {
 	ok, errCalled = h.vmMock.Verify()
 	require.True(t, ok, "virtual machine mock called incorrectly")
 	require.NoError(t, errCalled, "error happened when it should not")
+	ok, errCalled = h.stsMock.Verify()
+	require.True(t, ok, "state storage mock called incorrectly")
+	require.NoError(t, errCalled, "error happened when it should not")
 }
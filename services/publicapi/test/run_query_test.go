@@ -8,6 +8,7 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
@@ -38,3 +39,26 @@ func TestRunQuery_CallsVirtualMachine(t *testing.T) {
 		})
 	})
 }
+
+func TestRunQueryAtBlockHeight_PassesBlockHeightToVirtualMachine(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Millisecond, time.Minute)
+
+			harness.runQueryAtBlockHeightSuccess(7)
+
+			result, err := harness.papi.RunQueryAtBlockHeight(ctx, &publicapi.RunQueryAtBlockHeightInput{
+				ClientRequest: (&client.RunQueryRequestBuilder{
+					SignedQuery: builders.Query().Builder(),
+				}).Build(),
+				BlockHeight: 7,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.EXECUTION_RESULT_SUCCESS, result.ClientResponse.QueryResult().ExecutionResult(), "got wrong status")
+			require.EqualValues(t, 7, result.ClientResponse.RequestResult().BlockHeight(), "query should report the block height it ran against")
+		})
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSubscribeBlockHeaders_StreamsHeadersFromBlockStorage(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			stream := make(chan *blockstorage.BlockHeaders, 1)
+			harness.bksMock.When("SubscribeBlockHeaders", mock.Any, &blockstorage.SubscribeBlockHeadersInput{FromBlockHeight: 3}).Return((<-chan *blockstorage.BlockHeaders)(stream), nil).Times(1)
+
+			headers, err := harness.papi.SubscribeBlockHeaders(ctx, &publicapi.SubscribeBlockHeadersInput{
+				VirtualChainId:  builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID,
+				FromBlockHeight: 3,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.NotNil(t, headers)
+		})
+	})
+}
+
+func TestSubscribeBlockHeaders_RejectsWrongVirtualChain(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.bksMock.Never("SubscribeBlockHeaders", mock.Any, mock.Any)
+
+			headers, err := harness.papi.SubscribeBlockHeaders(ctx, &publicapi.SubscribeBlockHeadersInput{
+				VirtualChainId: builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID + 1,
+			})
+
+			harness.verifyMocks(t) // contract test
+
+			require.Error(t, err, "request of another virtual chain was served")
+			require.Nil(t, headers)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSubscribeTransactionStatus_StreamsPendingThenCommitted(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.transactionIsPendingInPool()
+
+			tx := builders.Transaction().Build().Transaction()
+			txHash := digest.CalcTxHash(tx)
+			events, err := harness.papi.SubscribeTransactionStatus(ctx, &publicapi.SubscribeTransactionStatusInput{Txhashes: []primitives.Sha256{txHash}})
+			require.NoError(t, err, "error happened when it should not")
+
+			first := <-events
+			require.Equal(t, protocol.TRANSACTION_STATUS_PENDING, first.TransactionStatus, "first event should carry the current status")
+			require.Equal(t, protocol.REQUEST_STATUS_IN_PROCESS, first.RequestStatus)
+
+			harness.papi.HandleTransactionResults(ctx, &handlers.HandleTransactionResultsInput{
+				BlockHeight:         3,
+				TransactionReceipts: []*protocol.TransactionReceipt{builders.TransactionReceipt().WithTransaction(tx).Build()},
+			})
+
+			second := <-events
+			require.Equal(t, protocol.TRANSACTION_STATUS_COMMITTED, second.TransactionStatus, "second event should carry the final status")
+			require.Equal(t, protocol.REQUEST_STATUS_COMPLETED, second.RequestStatus)
+			require.EqualValues(t, 3, second.BlockHeight)
+			require.True(t, txHash.Equal(second.Txhash), "event of the wrong transaction")
+
+			_, open := <-events
+			require.False(t, open, "stream was not closed once the transaction reached a final status")
+
+			harness.verifyMocks(t) // contract test
+		})
+	})
+}
+
+func TestSubscribeTransactionStatus_ClosesStreamOfCommittedTransaction(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.transactionIsCommittedInPool()
+
+			events, err := harness.papi.SubscribeTransactionStatus(ctx, &publicapi.SubscribeTransactionStatusInput{
+				Txhashes: []primitives.Sha256{digest.CalcTxHash(builders.Transaction().Build().Transaction())},
+			})
+			require.NoError(t, err, "error happened when it should not")
+
+			event := <-events
+			require.Equal(t, protocol.TRANSACTION_STATUS_COMMITTED, event.TransactionStatus)
+
+			_, open := <-events
+			require.False(t, open, "stream of a committed transaction was not closed")
+		})
+	})
+}
+
+func TestSubscribeTransactionStatus_RejectsTooManyTransactions(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, time.Second, time.Minute)
+			harness.txpMock.Never("GetCommittedTransactionReceipt", mock.Any, mock.Any)
+
+			txHashes := make([]primitives.Sha256, publicapi.MaxTransactionStatusSubscriptionSize+1)
+			events, err := harness.papi.SubscribeTransactionStatus(ctx, &publicapi.SubscribeTransactionStatusInput{Txhashes: txHashes})
+
+			require.Error(t, err, "subscription over the limit was accepted")
+			require.Nil(t, events)
+			harness.verifyMocks(t)
+		})
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUpdatePendingTransaction_CancellationIsInProcess(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.txpMock.When("UpdatePendingTransaction", mock.Any, mock.Any).Return(&transactionpool.UpdatePendingTransactionOutput{
+				TransactionStatus: protocol.TRANSACTION_STATUS_RESERVED,
+			}, nil).Times(1)
+
+			result, err := harness.papi.UpdatePendingTransaction(ctx, anUpdatePendingTransactionInput(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID))
+
+			harness.verifyMocks(t) // contract test
+
+			require.NoError(t, err, "error happened when it should not")
+			require.Equal(t, protocol.REQUEST_STATUS_IN_PROCESS, result.RequestStatus, "a cancelled transaction may still be committed by a node the withdrawal did not reach yet")
+			require.Equal(t, protocol.TRANSACTION_STATUS_RESERVED, result.TransactionStatus, "got wrong transaction status")
+			require.True(t, result.Withdrawn, "expected the transaction to be withdrawn")
+		})
+	})
+}
+
+func TestUpdatePendingTransaction_RejectsWrongVirtualChain(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.txpMock.Never("UpdatePendingTransaction", mock.Any, mock.Any)
+
+			result, err := harness.papi.UpdatePendingTransaction(ctx, anUpdatePendingTransactionInput(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID+1))
+
+			harness.verifyMocks(t) // contract test
+
+			require.Error(t, err, "error did not happen when it should")
+			require.Equal(t, protocol.REQUEST_STATUS_BAD_REQUEST, result.RequestStatus, "got wrong status")
+			require.Equal(t, protocol.TRANSACTION_STATUS_REJECTED_VIRTUAL_CHAIN_MISMATCH, result.TransactionStatus, "got wrong transaction status")
+		})
+	})
+}
+
+func TestUpdatePendingTransaction_CancellationInAProposedBlockIsInProcess(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+			harness := newPublicApiHarness(parent.Logger, 1*time.Second, 1*time.Minute)
+
+			harness.txpMock.When("UpdatePendingTransaction", mock.Any, mock.Any).Return(&transactionpool.UpdatePendingTransactionOutput{
+				TransactionStatus: protocol.TRANSACTION_STATUS_PENDING,
+			}, &transactionpool.ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_PENDING}).Times(1)
+
+			result, err := harness.papi.UpdatePendingTransaction(ctx, anUpdatePendingTransactionInput(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID))
+
+			harness.verifyMocks(t) // contract test
+
+			require.Error(t, err, "cancelling a proposed transaction should fail")
+			require.Equal(t, protocol.REQUEST_STATUS_IN_PROCESS, result.RequestStatus, "got wrong status")
+			require.False(t, result.Withdrawn, "a proposed transaction was withdrawn")
+			require.Equal(t, protocol.TRANSACTION_STATUS_PENDING, result.TransactionStatus, "got wrong transaction status")
+		})
+	})
+}
+
+func anUpdatePendingTransactionInput(vcid primitives.VirtualChainId) *publicapi.UpdatePendingTransactionInput {
+	return &publicapi.UpdatePendingTransactionInput{
+		VirtualChainId:  vcid,
+		TxHash:          make([]byte, 32),
+		SignerPublicKey: make([]byte, 32),
+		Signature:       make([]byte, 64),
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package testkit
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/blockstorage"
+	"github.com/orbs-network/orbs-network-go/services/publicapi"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+)
+
+type MockPublicApi struct {
+	services.MockPublicApi
+}
+
+func (s *MockPublicApi) GetStateProof(ctx context.Context, input *publicapi.GetStateProofInput) (*publicapi.GetStateProofOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*publicapi.GetStateProofOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) RunQueryAtBlockHeight(ctx context.Context, input *publicapi.RunQueryAtBlockHeightInput) (*services.RunQueryOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*services.RunQueryOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) GetTransactionStatusAtBlockHeight(ctx context.Context, input *publicapi.GetTransactionStatusAtBlockHeightInput) (*services.GetTransactionStatusOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*services.GetTransactionStatusOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) UpdatePendingTransaction(ctx context.Context, input *publicapi.UpdatePendingTransactionInput) (*publicapi.UpdatePendingTransactionOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*publicapi.UpdatePendingTransactionOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) SubscribeTransactionStatus(ctx context.Context, input *publicapi.SubscribeTransactionStatusInput) (<-chan *publicapi.TransactionStatusEvent, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(<-chan *publicapi.TransactionStatusEvent), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) GetContractEvents(ctx context.Context, input *publicapi.GetContractEventsInput) (*publicapi.GetContractEventsOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*publicapi.GetContractEventsOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) SubscribeContractEvents(ctx context.Context, input *publicapi.SubscribeContractEventsInput) (<-chan *blockstorage.ContractEvent, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(<-chan *blockstorage.ContractEvent), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockPublicApi) SubscribeBlockHeaders(ctx context.Context, input *publicapi.SubscribeBlockHeadersInput) (<-chan *blockstorage.BlockHeaders, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(<-chan *blockstorage.BlockHeaders), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
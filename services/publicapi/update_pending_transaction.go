@@ -0,0 +1,93 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package publicapi
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+type UpdatePendingTransactionInput struct {
+	ProtocolVersion primitives.ProtocolVersion
+	VirtualChainId  primitives.VirtualChainId
+	TxHash          primitives.Sha256
+	SignerPublicKey primitives.Ed25519PublicKey
+	Signature       primitives.Ed25519Sig       // over transactionpool.PendingTransactionUpdateDigest
+	Replacement     *protocol.SignedTransaction // nil to cancel the transaction
+}
+
+type UpdatePendingTransactionOutput struct {
+	RequestStatus     protocol.RequestStatus
+	TransactionStatus protocol.TransactionStatus // of the replacement, TRANSACTION_STATUS_RESERVED for a cancellation
+	Withdrawn         bool                       // the transaction was withdrawn from the pool of this node
+	BlockHeight       primitives.BlockHeight
+	BlockTimestamp    primitives.TimestampNano
+}
+
+// UpdatePendingTransaction lets the signer of a pending transaction cancel it, or replace it with another transaction
+// it signed. a client waiting for the withdrawn transaction gets TRANSACTION_STATUS_NO_RECORD_FOUND. the withdrawal
+// reaches the other nodes after it is made here, and a node which proposed the transaction for a block before then may
+// still commit it, so the request is in process until the transaction expires or is committed
+func (s *service) UpdatePendingTransaction(parentCtx context.Context, input *UpdatePendingTransactionInput) (*UpdatePendingTransactionOutput, error) {
+	ctx := trace.NewContext(parentCtx, "PublicApi.UpdatePendingTransaction")
+
+	if input == nil {
+		err := errors.Errorf("client request is nil")
+		s.logger.Info("update pending transaction received missing input", log.Error(err))
+		return nil, err
+	}
+
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), logfields.Transaction(input.TxHash), log.String("flow", "checkpoint"))
+
+	if txStatus, err := validateRequest(s.config, input.ProtocolVersion, input.VirtualChainId); err != nil {
+		logger.Info("update pending transaction received input failed", log.Error(err))
+		return &UpdatePendingTransactionOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST, TransactionStatus: txStatus}, err
+	}
+
+	if len(input.TxHash) != 32 {
+		err := errors.Errorf("invalid transaction hash of %d bytes", len(input.TxHash))
+		logger.Info("update pending transaction received input failed", log.Error(err))
+		return &UpdatePendingTransactionOutput{RequestStatus: protocol.REQUEST_STATUS_BAD_REQUEST}, err
+	}
+
+	logger.Info("update pending transaction request received")
+
+	out, err := s.transactionPool.UpdatePendingTransaction(ctx, &transactionpool.UpdatePendingTransactionInput{
+		Update: &transactionpool.PendingTransactionUpdate{
+			TxHash:          input.TxHash,
+			SignerPublicKey: input.SignerPublicKey,
+			Signature:       input.Signature,
+			Replacement:     input.Replacement,
+		},
+	})
+	if out == nil {
+		logger.Info("updating pending transaction in TransactionPool failed", log.Error(err))
+		return &UpdatePendingTransactionOutput{RequestStatus: protocol.REQUEST_STATUS_SYSTEM_ERROR}, err
+	}
+	if err != nil {
+		logger.Info("updating pending transaction in TransactionPool failed", log.Error(err))
+	}
+
+	requestStatus := translateTransactionStatusToRequestStatus(out.TransactionStatus, protocol.EXECUTION_RESULT_RESERVED)
+	if err == nil && input.Replacement == nil {
+		requestStatus = protocol.REQUEST_STATUS_IN_PROCESS
+	}
+
+	return &UpdatePendingTransactionOutput{
+		RequestStatus:     requestStatus,
+		TransactionStatus: out.TransactionStatus,
+		Withdrawn:         err == nil,
+		BlockHeight:       out.BlockHeight,
+		BlockTimestamp:    out.BlockTimestamp,
+	}, err
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const stateFormatMagic = uint32(0x54415453) // "STAT"
+const stateFormatVersion = 0
+
+var headerKey = []byte("h")
+var metadataKey = []byte("m")
+var recordKeyPrefix = []byte("r")
+var archiveMarkerKey = []byte("a")
+var archivedRecordKeyPrefix = []byte("H")
+var archivedMetadataKeyPrefix = []byte("M")
+
+type stateHeader struct {
+	Magic       uint32
+	Version     uint32
+	NetworkType uint32
+	ChainId     uint32
+}
+
+func newStateHeader(networkType, vchainId uint32) *stateHeader {
+	return &stateHeader{
+		Magic:       stateFormatMagic,
+		Version:     stateFormatVersion,
+		NetworkType: networkType,
+		ChainId:     vchainId,
+	}
+}
+
+func (sh *stateHeader) encode() []byte {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, sh) // writing to a bytes.Buffer never fails
+	return buf.Bytes()
+}
+
+func (sh *stateHeader) decode(raw []byte) error {
+	err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, sh)
+	if err != nil {
+		return errors.Wrap(err, "failed reading state header")
+	}
+
+	if sh.Magic != stateFormatMagic {
+		return fmt.Errorf("invalid state magic number %v", sh.Magic)
+	}
+
+	if sh.Version != stateFormatVersion {
+		return fmt.Errorf("invalid state format version %d", sh.Version)
+	}
+
+	return nil
+}
+
+type stateMetadata struct {
+	height      primitives.BlockHeight
+	ts          primitives.TimestampNano
+	refTime     primitives.TimestampSeconds
+	prevRefTime primitives.TimestampSeconds
+	proposer    primitives.NodeAddress
+	merkleRoot  primitives.Sha256
+}
+
+type fixedMetadata struct {
+	Height      uint64
+	Ts          uint64
+	RefTime     uint32
+	PrevRefTime uint32
+}
+
+func (m *stateMetadata) encode() []byte {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, &fixedMetadata{
+		Height:      uint64(m.height),
+		Ts:          uint64(m.ts),
+		RefTime:     uint32(m.refTime),
+		PrevRefTime: uint32(m.prevRefTime),
+	})
+	writeChunk(buf, m.proposer)
+	writeChunk(buf, m.merkleRoot)
+	return buf.Bytes()
+}
+
+func (m *stateMetadata) decode(raw []byte) error {
+	r := bytes.NewReader(raw)
+	fixed := &fixedMetadata{}
+	if err := binary.Read(r, binary.LittleEndian, fixed); err != nil {
+		return errors.Wrap(err, "failed reading state metadata")
+	}
+	proposer, err := readChunk(r)
+	if err != nil {
+		return errors.Wrap(err, "failed reading state metadata proposer")
+	}
+	root, err := readChunk(r)
+	if err != nil {
+		return errors.Wrap(err, "failed reading state metadata merkle root")
+	}
+
+	m.height = primitives.BlockHeight(fixed.Height)
+	m.ts = primitives.TimestampNano(fixed.Ts)
+	m.refTime = primitives.TimestampSeconds(fixed.RefTime)
+	m.prevRefTime = primitives.TimestampSeconds(fixed.PrevRefTime)
+	m.proposer = proposer
+	m.merkleRoot = root
+	return nil
+}
+
+func writeChunk(w io.Writer, chunk []byte) {
+	_ = binary.Write(w, binary.LittleEndian, uint32(len(chunk)))
+	_, _ = w.Write(chunk)
+}
+
+func readChunk(r io.Reader) ([]byte, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(r, chunk); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// record keys are laid out as prefix|len(contract)|contract|key so that all keys of a contract are adjacent and a
+// contract name can never be confused with a prefix of another contract's key
+func encodeRecordKey(contract primitives.ContractName, key string) []byte {
+	buf := make([]byte, 0, len(recordKeyPrefix)+2+len(contract)+len(key))
+	buf = append(buf, recordKeyPrefix...)
+	buf = append(buf, byte(len(contract)>>8), byte(len(contract)))
+	buf = append(buf, contract...)
+	buf = append(buf, key...)
+	return buf
+}
+
+func decodeRecordKey(raw []byte) (primitives.ContractName, string, error) {
+	if len(raw) < len(recordKeyPrefix)+2 || !bytes.HasPrefix(raw, recordKeyPrefix) {
+		return "", "", fmt.Errorf("invalid state record key %x", raw)
+	}
+	raw = raw[len(recordKeyPrefix):]
+	contractLength := int(raw[0])<<8 | int(raw[1])
+	raw = raw[2:]
+	if len(raw) < contractLength {
+		return "", "", fmt.Errorf("invalid state record key, contract name length %d exceeds key", contractLength)
+	}
+	return primitives.ContractName(raw[:contractLength]), string(raw[contractLength:]), nil
+}
+
+// archived record keys are laid out as prefix|len(contract)|contract|len(key)|key|height with a big endian height, so
+// all revisions of a key are adjacent and ordered by block height
+func encodeArchivedRecordKeyPrefix(contract primitives.ContractName, key string) []byte {
+	buf := make([]byte, 0, len(archivedRecordKeyPrefix)+2+len(contract)+4+len(key)+8)
+	buf = append(buf, archivedRecordKeyPrefix...)
+	buf = append(buf, byte(len(contract)>>8), byte(len(contract)))
+	buf = append(buf, contract...)
+	buf = append(buf, byte(len(key)>>24), byte(len(key)>>16), byte(len(key)>>8), byte(len(key)))
+	buf = append(buf, key...)
+	return buf
+}
+
+func encodeArchivedRecordKey(contract primitives.ContractName, key string, height primitives.BlockHeight) []byte {
+	return appendHeight(encodeArchivedRecordKeyPrefix(contract, key), height)
+}
+
+func encodeArchivedMetadataKey(height primitives.BlockHeight) []byte {
+	return appendHeight(append([]byte{}, archivedMetadataKeyPrefix...), height)
+}
+
+func appendHeight(buf []byte, height primitives.BlockHeight) []byte {
+	var raw [8]byte
+	binary.BigEndian.PutUint64(raw[:], uint64(height))
+	return append(buf, raw[:]...)
+}
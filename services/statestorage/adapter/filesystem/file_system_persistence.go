@@ -0,0 +1,342 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const stateDirname = "state"
+
+type metrics struct {
+	sizeOnDisk    *metric.Gauge
+	blockHeight   *metric.Gauge
+	lastWriteTime *metric.Gauge
+}
+
+func newMetrics(m metric.Factory) *metrics {
+	return &metrics{
+		sizeOnDisk:    m.NewGauge("StateStoragePersistence.FileSystemSize.Bytes"),
+		blockHeight:   m.NewGauge("StateStoragePersistence.BlockHeight"),
+		lastWriteTime: m.NewGauge("StateStoragePersistence.LastWriteTime"),
+	}
+}
+
+// StatePersistence keeps the full state snapshot in a LevelDB key/value store. Every Write commits the state diff
+// together with the snapshot metadata as a single synced batch, so a crash leaves the store at the last fully written height.
+// In archive mode the same batch also keeps every revision of the written keys and the metadata of every height
+type StatePersistence struct {
+	config  config.FilesystemStatePersistenceConfig
+	logger  log.Logger
+	metrics *metrics
+	db      *leveldb.DB
+
+	mutex    sync.RWMutex
+	metadata *stateMetadata
+	archive  bool
+}
+
+func NewStatePersistence(conf config.FilesystemStatePersistenceConfig, parent log.Logger, metricFactory metric.Factory) (*StatePersistence, error) {
+	logger := parent.WithTags(log.String("adapter", "state-storage"))
+
+	dir := stateDirName(conf)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify data directory exists %s", dir)
+	}
+
+	db, err := leveldb.OpenFile(dir, nil) // also obtains an exclusive lock on the directory
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open state database %s", dir)
+	}
+
+	if err := validateHeader(db, conf, logger); err != nil {
+		closeSilently(db, logger)
+		return nil, err
+	}
+
+	metadata, err := readMetadata(db)
+	if err != nil {
+		closeSilently(db, logger)
+		return nil, err
+	}
+
+	if err := validateArchive(db, conf, metadata.height, logger); err != nil {
+		closeSilently(db, logger)
+		return nil, err
+	}
+
+	sp := &StatePersistence{
+		config:   conf,
+		logger:   logger,
+		metrics:  newMetrics(metricFactory),
+		db:       db,
+		metadata: metadata,
+		archive:  conf.StateStorageArchiveMode(),
+	}
+	sp.reportSize()
+	sp.metrics.blockHeight.Update(int64(metadata.height))
+
+	logger.Info("opened state database", log.String("dir", dir), logfields.BlockHeight(metadata.height))
+	return sp, nil
+}
+
+func validateHeader(db *leveldb.DB, conf config.FilesystemStatePersistenceConfig, logger log.Logger) error {
+	raw, err := db.Get(headerKey, nil)
+	if err == leveldb.ErrNotFound {
+		logger.Info("creating new state database", log.String("dir", stateDirName(conf)))
+		header := newStateHeader(uint32(conf.NetworkType()), uint32(conf.VirtualChainId()))
+		return errors.Wrap(db.Put(headerKey, header.encode(), &opt.WriteOptions{Sync: true}), "error writing state database header")
+	}
+	if err != nil {
+		return errors.Wrap(err, "error reading state database header")
+	}
+
+	header := &stateHeader{}
+	if err := header.decode(raw); err != nil {
+		return err
+	}
+
+	if header.NetworkType != uint32(conf.NetworkType()) {
+		return fmt.Errorf("state database network type mismatch. found network type %d expected %d", header.NetworkType, conf.NetworkType())
+	}
+
+	if header.ChainId != uint32(conf.VirtualChainId()) {
+		return fmt.Errorf("state database virtual chain id mismatch. found vchain id %d expected %d", header.ChainId, conf.VirtualChainId())
+	}
+
+	return nil
+}
+
+// an archive is only complete if it was kept since genesis, so archive mode can not be turned on for an existing database
+func validateArchive(db *leveldb.DB, conf config.FilesystemStatePersistenceConfig, height primitives.BlockHeight, logger log.Logger) error {
+	hasMarker, err := db.Has(archiveMarkerKey, nil)
+	if err != nil {
+		return errors.Wrap(err, "error reading state database archive marker")
+	}
+
+	if !conf.StateStorageArchiveMode() {
+		if hasMarker {
+			logger.Info("state archive mode is disabled, archived state is no longer complete", logfields.BlockHeight(height))
+			return errors.Wrap(db.Delete(archiveMarkerKey, &opt.WriteOptions{Sync: true}), "error removing state database archive marker")
+		}
+		return nil
+	}
+
+	if hasMarker {
+		return nil
+	}
+	if height > 0 {
+		return fmt.Errorf("state archive mode requires a state database kept since genesis but %s is at block height %d, remove it to rebuild the state from block storage", stateDirName(conf), height)
+	}
+	return errors.Wrap(db.Put(archiveMarkerKey, []byte{}, &opt.WriteOptions{Sync: true}), "error writing state database archive marker")
+}
+
+func readMetadata(db *leveldb.DB) (*stateMetadata, error) {
+	raw, err := db.Get(metadataKey, nil)
+	if err == leveldb.ErrNotFound {
+		// TODO(https://github.com/orbs-network/orbs-network-go/issues/582) - this is our hard coded Genesis block (height 0), same as the in-memory adapter
+		_, merkleRoot := merkle.NewForest()
+		return &stateMetadata{
+			proposer:   []byte{},
+			merkleRoot: merkleRoot,
+		}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading state metadata")
+	}
+
+	metadata := &stateMetadata{}
+	if err := metadata.decode(raw); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (sp *StatePersistence) Write(height primitives.BlockHeight, ts primitives.TimestampNano, refTime primitives.TimestampSeconds, prevRefTime primitives.TimestampSeconds, proposer primitives.NodeAddress, root primitives.Sha256, diff adapter.ChainState) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	metadata := &stateMetadata{
+		height:      height,
+		ts:          ts,
+		refTime:     refTime,
+		prevRefTime: prevRefTime,
+		proposer:    proposer,
+		merkleRoot:  root,
+	}
+
+	if sp.archive && height != sp.metadata.height+1 {
+		return fmt.Errorf("state archive must be written in order, expected block height %d but got %d", sp.metadata.height+1, height)
+	}
+
+	batch := new(leveldb.Batch)
+	for contract, records := range diff {
+		for key, value := range records {
+			if isZeroValue(value) {
+				batch.Delete(encodeRecordKey(contract, key))
+			} else {
+				batch.Put(encodeRecordKey(contract, key), value)
+			}
+			if sp.archive {
+				batch.Put(encodeArchivedRecordKey(contract, key, height), value) // a zero value marks the key as removed
+			}
+		}
+	}
+	batch.Put(metadataKey, metadata.encode())
+	if sp.archive {
+		batch.Put(encodeArchivedMetadataKey(height), metadata.encode())
+	}
+
+	if err := sp.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return errors.Wrapf(err, "failed to write state for block height %d", height)
+	}
+	sp.metadata = metadata
+
+	sp.metrics.blockHeight.Update(int64(height))
+	sp.metrics.lastWriteTime.Update(time.Now().Unix())
+	sp.reportSize()
+	return nil
+}
+
+func (sp *StatePersistence) Read(contract primitives.ContractName, key string) ([]byte, bool, error) {
+	value, err := sp.db.Get(encodeRecordKey(contract, key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to read state key %s of contract %s", key, contract)
+	}
+	return value, true, nil
+}
+
+func (sp *StatePersistence) ReadMetadata() (primitives.BlockHeight, primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error) {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	m := sp.metadata
+	return m.height, m.ts, m.refTime, m.prevRefTime, m.proposer, m.merkleRoot, nil
+}
+
+func (sp *StatePersistence) ReadArchivedRecord(height primitives.BlockHeight, contract primitives.ContractName, key string) ([]byte, bool, error) {
+	if err := sp.checkArchived(height); err != nil {
+		return nil, false, err
+	}
+
+	iter := sp.db.NewIterator(&util.Range{Start: encodeArchivedRecordKey(contract, key, 0), Limit: encodeArchivedRecordKey(contract, key, height+1)}, nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		return nil, false, errors.Wrapf(iter.Error(), "failed to read archived state key %s of contract %s", key, contract)
+	}
+	value := append([]byte{}, iter.Value()...)
+	return value, !isZeroValue(value), nil
+}
+
+func (sp *StatePersistence) ReadArchivedMetadata(height primitives.BlockHeight) (primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error) {
+	if err := sp.checkArchived(height); err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+
+	m := &stateMetadata{}
+	if height == 0 {
+		_, m.merkleRoot = merkle.NewForest()
+		m.proposer = []byte{}
+		return m.ts, m.refTime, m.prevRefTime, m.proposer, m.merkleRoot, nil
+	}
+
+	raw, err := sp.db.Get(encodeArchivedMetadataKey(height), nil)
+	if err != nil {
+		return 0, 0, 0, nil, nil, errors.Wrapf(err, "failed to read archived state metadata for block height %d", height)
+	}
+	if err := m.decode(raw); err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+	return m.ts, m.refTime, m.prevRefTime, m.proposer, m.merkleRoot, nil
+}
+
+func (sp *StatePersistence) checkArchived(height primitives.BlockHeight) error {
+	if !sp.archive {
+		return fmt.Errorf("state archive is disabled")
+	}
+
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+	if height > sp.metadata.height {
+		return fmt.Errorf("block height %d is not archived yet, archived up to %d", height, sp.metadata.height)
+	}
+	return nil
+}
+
+func (sp *StatePersistence) ScanRecords(f adapter.RecordCursorFunc) error {
+	snapshot, err := sp.db.GetSnapshot()
+	if err != nil {
+		return errors.Wrap(err, "failed to take state snapshot")
+	}
+	defer snapshot.Release()
+
+	iter := snapshot.NewIterator(util.BytesPrefix(recordKeyPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		contract, key, err := decodeRecordKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		if !f(contract, key, append([]byte{}, iter.Value()...)) {
+			break
+		}
+	}
+	return errors.Wrap(iter.Error(), "failed to scan state records")
+}
+
+func (sp *StatePersistence) GracefulShutdown(shutdownContext context.Context) {
+	logger := sp.logger.WithTags(log.String("dir", stateDirName(sp.config)))
+	if err := sp.db.Close(); err != nil {
+		logger.Error("failed to close state database", log.Error(err))
+		return
+	}
+	logger.Info("closed state database")
+}
+
+func (sp *StatePersistence) reportSize() {
+	sizes, err := sp.db.SizeOf([]util.Range{*util.BytesPrefix(recordKeyPrefix)})
+	if err != nil {
+		sp.logger.Error("failed to read state database size for metrics", log.Error(err))
+		return
+	}
+	sp.metrics.sizeOnDisk.Update(sizes.Sum())
+}
+
+func stateDirName(conf config.FilesystemStatePersistenceConfig) string {
+	return filepath.Join(conf.StateStorageFileSystemDataDir(), stateDirname)
+}
+
+func closeSilently(db *leveldb.DB, logger log.Logger) {
+	if err := db.Close(); err != nil {
+		logger.Error("failed to close state database", log.Error(err))
+	}
+}
+
+func isZeroValue(value []byte) bool {
+	return len(value) == 0
+}
@@ -0,0 +1,259 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package filesystem
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStatePersistence_ReopensAtLastWrittenHeight(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		require.NoError(t, sp.Write(1, 10, 100, 99, []byte{0x01}, []byte{0xaa}, adapter.ChainState{"c1": {"k1": []byte("v1"), "k2": []byte("v2")}}))
+		require.NoError(t, sp.Write(2, 20, 200, 100, []byte{0x02}, []byte{0xbb}, adapter.ChainState{"c1": {"k1": []byte("v3")}, "c2": {"k1": []byte("v4")}}))
+		shutdown(sp)
+
+		sp = openPersistence(t, harness.Logger, conf)
+		defer shutdown(sp)
+
+		h, ts, ref, prevRef, proposer, root, err := sp.ReadMetadata()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, h)
+		require.EqualValues(t, 20, ts)
+		require.EqualValues(t, 200, ref)
+		require.EqualValues(t, 100, prevRef)
+		require.EqualValues(t, []byte{0x02}, proposer)
+		require.EqualValues(t, []byte{0xbb}, root)
+
+		requireRecord(t, sp, "c1", "k1", "v3")
+		requireRecord(t, sp, "c1", "k2", "v2")
+		requireRecord(t, sp, "c2", "k1", "v4")
+	})
+}
+
+func TestStatePersistence_ZeroValueRemovesKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		defer shutdown(sp)
+
+		require.NoError(t, sp.Write(1, 0, 0, 0, []byte{}, []byte{}, adapter.ChainState{"foo": {"foo": []byte("bar")}}))
+		requireRecord(t, sp, "foo", "foo", "bar")
+
+		require.NoError(t, sp.Write(2, 0, 0, 0, []byte{}, []byte{}, adapter.ChainState{"foo": {"foo": []byte{}}}))
+		_, ok, err := sp.Read("foo", "foo")
+		require.NoError(t, err)
+		require.False(t, ok, "writing zero value to state did not remove key")
+	})
+}
+
+func TestStatePersistence_ScanRecordsDoesNotMixContracts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		defer shutdown(sp)
+
+		require.NoError(t, sp.Write(1, 0, 0, 0, []byte{}, []byte{}, adapter.ChainState{
+			"ab": {"c": []byte("1")},
+			"a":  {"bc": []byte("2")},
+		}))
+
+		scanned := adapter.ChainState{}
+		require.NoError(t, sp.ScanRecords(func(contract primitives.ContractName, key string, value []byte) bool {
+			if scanned[contract] == nil {
+				scanned[contract] = adapter.ContractState{}
+			}
+			scanned[contract][key] = value
+			return true
+		}))
+
+		require.Equal(t, adapter.ChainState{"ab": {"c": []byte("1")}, "a": {"bc": []byte("2")}}, scanned)
+	})
+}
+
+func TestStatePersistence_DetectsVirtualChainMismatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		shutdown(openPersistence(t, harness.Logger, conf))
+
+		conf.chainId++
+		_, err := NewStatePersistence(conf, harness.Logger, metric.NewRegistry())
+		require.Error(t, err, "expected error when trying to open a state database from a different virtual chain")
+	})
+}
+
+func TestStatePersistence_RejectsConcurrentOpen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		defer shutdown(sp)
+
+		_, err := NewStatePersistence(conf, harness.Logger, metric.NewRegistry())
+		require.Error(t, err, "expected error when opening a state database that is already in use")
+	})
+}
+
+func TestStatePersistence_ArchiveReadsEveryWrittenHeight(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		conf.archiveMode = true
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		require.NoError(t, sp.Write(1, 10, 100, 99, []byte{0x01}, []byte{0xaa}, adapter.ChainState{"c1": {"k1": []byte("v1")}}))
+		require.NoError(t, sp.Write(2, 20, 200, 100, []byte{0x02}, []byte{0xbb}, adapter.ChainState{"c1": {"k2": []byte("v2")}}))
+		require.NoError(t, sp.Write(3, 30, 300, 200, []byte{0x03}, []byte{0xcc}, adapter.ChainState{"c1": {"k1": []byte{}, "k2": []byte("v3")}}))
+		shutdown(sp)
+
+		sp = openPersistence(t, harness.Logger, conf)
+		defer shutdown(sp)
+
+		requireArchivedRecord(t, sp, 0, "c1", "k1", "")
+		requireArchivedRecord(t, sp, 1, "c1", "k1", "v1")
+		requireArchivedRecord(t, sp, 2, "c1", "k1", "v1")
+		requireArchivedRecord(t, sp, 3, "c1", "k1", "")
+		requireArchivedRecord(t, sp, 1, "c1", "k2", "")
+		requireArchivedRecord(t, sp, 2, "c1", "k2", "v2")
+		requireArchivedRecord(t, sp, 3, "c1", "k2", "v3")
+
+		ts, ref, prevRef, proposer, root, err := sp.ReadArchivedMetadata(2)
+		require.NoError(t, err)
+		require.EqualValues(t, 20, ts)
+		require.EqualValues(t, 200, ref)
+		require.EqualValues(t, 100, prevRef)
+		require.EqualValues(t, []byte{0x02}, proposer)
+		require.EqualValues(t, []byte{0xbb}, root)
+
+		_, _, err = sp.ReadArchivedRecord(4, "c1", "k1")
+		require.Error(t, err, "expected error when reading a height that was not written yet")
+
+		require.Error(t, sp.Write(5, 0, 0, 0, []byte{}, []byte{}, adapter.ChainState{}), "expected error when skipping a block height in archive mode")
+	})
+}
+
+func TestStatePersistence_ArchiveModeRequiresDatabaseKeptSinceGenesis(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping Integration tests in short mode")
+	}
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		conf := newTempDirConfig()
+		defer conf.cleanDir()
+
+		sp := openPersistence(t, harness.Logger, conf)
+		require.NoError(t, sp.Write(1, 0, 0, 0, []byte{}, []byte{}, adapter.ChainState{"foo": {"foo": []byte("bar")}}))
+		shutdown(sp)
+
+		conf.archiveMode = true
+		_, err := NewStatePersistence(conf, harness.Logger, metric.NewRegistry())
+		require.Error(t, err, "expected error when turning on archive mode for a database that was not archived since genesis")
+	})
+}
+
+func requireArchivedRecord(t *testing.T, sp *StatePersistence, height primitives.BlockHeight, contract primitives.ContractName, key string, expected string) {
+	value, ok, err := sp.ReadArchivedRecord(height, contract, key)
+	require.NoError(t, err)
+	require.Equal(t, expected != "", ok, "unexpected existence of key %s of contract %s at block height %d", key, contract, height)
+	require.EqualValues(t, expected, string(value))
+}
+
+func requireRecord(t *testing.T, sp *StatePersistence, contract primitives.ContractName, key string, expected string) {
+	value, ok, err := sp.Read(contract, key)
+	require.NoError(t, err)
+	require.True(t, ok, "expected key %s of contract %s to exist", key, contract)
+	require.EqualValues(t, expected, value)
+}
+
+func openPersistence(t *testing.T, logger log.Logger, conf *localConfig) *StatePersistence {
+	sp, err := NewStatePersistence(conf, logger, metric.NewRegistry())
+	require.NoError(t, err)
+	return sp
+}
+
+func shutdown(sp *StatePersistence) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	sp.GracefulShutdown(ctx)
+}
+
+type localConfig struct {
+	dir         string
+	chainId     primitives.VirtualChainId
+	networkType protocol.SignerNetworkType
+	archiveMode bool
+}
+
+func newTempDirConfig() *localConfig {
+	dirName, err := ioutil.TempDir("", "state_persistence_test")
+	if err != nil {
+		panic(err)
+	}
+	return &localConfig{
+		dir:         dirName,
+		chainId:     0xFF,
+		networkType: protocol.NETWORK_TYPE_TEST_NET,
+	}
+}
+
+func (l *localConfig) StateStorageFileSystemDataDir() string {
+	return l.dir
+}
+
+func (l *localConfig) VirtualChainId() primitives.VirtualChainId {
+	return l.chainId
+}
+
+func (l *localConfig) NetworkType() protocol.SignerNetworkType {
+	return l.networkType
+}
+
+func (l *localConfig) StateStorageArchiveMode() bool {
+	return l.archiveMode
+}
+
+func (l *localConfig) cleanDir() {
+	_ = os.RemoveAll(l.dir) // ignore errors - nothing to do
+}
@@ -30,6 +30,19 @@ func newMetrics(m metric.Factory) *metrics {
 	}
 }
 
+type archivedRecord struct {
+	height primitives.BlockHeight
+	value  []byte
+}
+
+type archivedMetadata struct {
+	ts          primitives.TimestampNano
+	refTime     primitives.TimestampSeconds
+	prevRefTime primitives.TimestampSeconds
+	proposer    primitives.NodeAddress
+	merkleRoot  primitives.Sha256
+}
+
 type InMemoryStatePersistence struct {
 	metrics     *metrics
 	mutex       sync.RWMutex
@@ -40,6 +53,19 @@ type InMemoryStatePersistence struct {
 	prevRefTime primitives.TimestampSeconds
 	proposer    primitives.NodeAddress
 	merkleRoot  primitives.Sha256
+
+	archive          bool
+	archivedRecords  map[primitives.ContractName]map[string][]*archivedRecord // ordered by height
+	archivedMetadata []*archivedMetadata                                      // indexed by height
+}
+
+// NewArchiveStatePersistence also keeps every written state diff so the state of any block height can be read back
+func NewArchiveStatePersistence(metricFactory metric.Factory) *InMemoryStatePersistence {
+	sp := NewStatePersistence(metricFactory)
+	sp.archive = true
+	sp.archivedRecords = make(map[primitives.ContractName]map[string][]*archivedRecord)
+	sp.archivedMetadata = []*archivedMetadata{{proposer: sp.proposer, merkleRoot: sp.merkleRoot}}
+	return sp
 }
 
 func NewStatePersistence(metricFactory metric.Factory) *InMemoryStatePersistence {
@@ -73,6 +99,12 @@ func (sp *InMemoryStatePersistence) Write(height primitives.BlockHeight, ts prim
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
 
+	if sp.archive {
+		if err := sp._archive(height, ts, refTime, prevRefTime, proposer, root, diff); err != nil {
+			return err
+		}
+	}
+
 	sp.height = height
     sp.refTime = refTime
     sp.prevRefTime = prevRefTime
@@ -89,6 +121,23 @@ func (sp *InMemoryStatePersistence) Write(height primitives.BlockHeight, ts prim
 	return nil
 }
 
+func (sp *InMemoryStatePersistence) _archive(height primitives.BlockHeight, ts primitives.TimestampNano, refTime primitives.TimestampSeconds, prevRefTime primitives.TimestampSeconds, proposer primitives.NodeAddress, root primitives.Sha256, diff adapter.ChainState) error {
+	if expected := primitives.BlockHeight(len(sp.archivedMetadata)); height != expected {
+		return fmt.Errorf("state archive must be written in order, expected block height %d but got %d", expected, height)
+	}
+
+	for contract, records := range diff {
+		if _, ok := sp.archivedRecords[contract]; !ok {
+			sp.archivedRecords[contract] = make(map[string][]*archivedRecord)
+		}
+		for key, value := range records {
+			sp.archivedRecords[contract][key] = append(sp.archivedRecords[contract][key], &archivedRecord{height: height, value: value})
+		}
+	}
+	sp.archivedMetadata = append(sp.archivedMetadata, &archivedMetadata{ts: ts, refTime: refTime, prevRefTime: prevRefTime, proposer: proposer, merkleRoot: root})
+	return nil
+}
+
 func (sp *InMemoryStatePersistence) _writeOneRecord(c primitives.ContractName, key string, value []byte) {
 	if _, ok := sp.fullState[c]; !ok {
 		sp.fullState[c] = map[string][]byte{}
@@ -117,6 +166,58 @@ func (sp *InMemoryStatePersistence) ReadMetadata() (primitives.BlockHeight, prim
 	return sp.height, sp.ts, sp.refTime, sp.prevRefTime, sp.proposer, sp.merkleRoot, nil
 }
 
+func (sp *InMemoryStatePersistence) ScanRecords(f adapter.RecordCursorFunc) error {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	for contract, records := range sp.fullState {
+		for key, value := range records {
+			if !f(contract, key, value) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (sp *InMemoryStatePersistence) ReadArchivedRecord(height primitives.BlockHeight, contract primitives.ContractName, key string) ([]byte, bool, error) {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	if err := sp._checkArchived(height); err != nil {
+		return nil, false, err
+	}
+
+	records := sp.archivedRecords[contract][key]
+	i := sort.Search(len(records), func(i int) bool { return records[i].height > height }) - 1
+	if i < 0 {
+		return nil, false, nil
+	}
+	return records[i].value, !isZeroValue(records[i].value), nil
+}
+
+func (sp *InMemoryStatePersistence) ReadArchivedMetadata(height primitives.BlockHeight) (primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error) {
+	sp.mutex.RLock()
+	defer sp.mutex.RUnlock()
+
+	if err := sp._checkArchived(height); err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+
+	m := sp.archivedMetadata[height]
+	return m.ts, m.refTime, m.prevRefTime, m.proposer, m.merkleRoot, nil
+}
+
+func (sp *InMemoryStatePersistence) _checkArchived(height primitives.BlockHeight) error {
+	if !sp.archive {
+		return fmt.Errorf("state archive is disabled")
+	}
+	if height >= primitives.BlockHeight(len(sp.archivedMetadata)) {
+		return fmt.Errorf("block height %d is not archived yet, archived up to %d", height, len(sp.archivedMetadata)-1)
+	}
+	return nil
+}
+
 func (sp *InMemoryStatePersistence) Dump() string {
 	sp.mutex.RLock()
 	defer sp.mutex.RUnlock()
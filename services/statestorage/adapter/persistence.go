@@ -14,8 +14,20 @@ import (
 type ContractState map[string][]byte
 type ChainState map[primitives.ContractName]ContractState
 
+// A Callback function provided by consumers of the full persisted state. It is invoked once for every non-zero
+// record until it returns false to signal no more records are required or until there are no more records.
+type RecordCursorFunc func(contract primitives.ContractName, key string, value []byte) (wantsMore bool)
+
 type StatePersistence interface {
 	Write(height primitives.BlockHeight, ts primitives.TimestampNano, refTime primitives.TimestampSeconds, prevRefTime primitives.TimestampSeconds, proposer primitives.NodeAddress, root primitives.Sha256, diff ChainState) error
 	Read(contract primitives.ContractName, key string) ([]byte, bool, error)
 	ReadMetadata() (primitives.BlockHeight, primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error)
+	ScanRecords(f RecordCursorFunc) error
+}
+
+// StateArchive is implemented by persistence adapters that can keep the state of every written block height and not
+// only the latest one. An archive only serves heights if it was kept since genesis
+type StateArchive interface {
+	ReadArchivedRecord(height primitives.BlockHeight, contract primitives.ContractName, key string) ([]byte, bool, error)
+	ReadArchivedMetadata(height primitives.BlockHeight) (primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error)
 }
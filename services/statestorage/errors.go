@@ -0,0 +1,27 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package statestorage
+
+import (
+	"fmt"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+)
+
+// ErrStateDivergence is returned when the pre-execution state root of a committed results block does not match
+// the state root this node computed for the previous height
+type ErrStateDivergence struct {
+	BlockHeight       primitives.BlockHeight
+	ExpectedStateRoot primitives.Sha256
+	ActualStateRoot   primitives.Sha256
+}
+
+func (e *ErrStateDivergence) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("state diverged at block height %d: block pre-execution state root is %s but local state root is %s", e.BlockHeight, e.ExpectedStateRoot, e.ActualStateRoot)
+}
@@ -21,6 +21,7 @@ import (
 type merkleRevisions interface {
 	Update(rootMerkle primitives.Sha256, diffs merkle.TrieDiffs) (primitives.Sha256, error)
 	Forget(rootHash primitives.Sha256)
+	GetProof(rootHash primitives.Sha256, path []byte) (*merkle.TrieProof, error)
 }
 
 type revisionDiff struct {
@@ -31,11 +32,14 @@ type revisionDiff struct {
 	ref        primitives.TimestampSeconds
 	prevRef    primitives.TimestampSeconds
 	proposer   primitives.NodeAddress
+	numKeys    primitives.StorageKeys
+	size       uint64
 }
 
 type rollingRevisions struct {
 	logger               log.Logger
 	persist              adapter.StatePersistence
+	archive              adapter.StateArchive // nil unless archive mode is on
 	transientRevisions   int
 	revisions            []*revisionDiff
 	merkle               merkleRevisions
@@ -53,9 +57,11 @@ type rollingRevisions struct {
 	persistedProposer    primitives.NodeAddress
 	persistedRefTime     primitives.TimestampSeconds
 	persistedPrevRefTime primitives.TimestampSeconds
+	persistedNumKeys     primitives.StorageKeys
+	persistedSize        uint64
 }
 
-func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, transientRevisions int, merkle merkleRevisions) *rollingRevisions {
+func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, archive adapter.StateArchive, transientRevisions int, merkle merkleRevisions) *rollingRevisions {
 	h, ts, ref, prevRef, pa, r, err := persist.ReadMetadata()
 	if err != nil {
 		panic(fmt.Sprintf("could not load state metadata, err=%s", err.Error()))
@@ -64,6 +70,7 @@ func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, tr
 	result := &rollingRevisions{
 		logger:               logger,
 		persist:              persist,
+		archive:              archive,
 		transientRevisions:   transientRevisions,
 		merkle:               merkle,
 		currentHeight:        h,
@@ -80,9 +87,51 @@ func newRollingRevisions(logger log.Logger, persist adapter.StatePersistence, tr
 		persistedPrevRefTime: prevRef,
 	}
 
+	if h > 0 {
+		if err := result.restoreMerkleRoot(); err != nil {
+			panic(fmt.Sprintf("could not restore state merkle root for persisted height %d, err=%s", h, err.Error()))
+		}
+	}
+
 	return result
 }
 
+// persisted state outlives the in-memory merkle forest. the trie for the persisted height is rebuilt from the full
+// state and must match the root stored with it or the persisted state cannot be trusted
+func (ls *rollingRevisions) restoreMerkleRoot() error {
+	_, emptyRoot := merkle.NewForest()
+	diffs := make(merkle.TrieDiffs, 0)
+	size := uint64(0)
+	err := ls.persist.ScanRecords(func(contract primitives.ContractName, key string, value []byte) bool {
+		diffs = append(diffs, &merkle.TrieDiff{
+			Key:   hash.CalcSha256([]byte(contract), []byte(key)),
+			Value: hash.CalcSha256(value),
+		})
+		size += uint64(len(value))
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to scan persisted state")
+	}
+
+	root, err := ls.merkle.Update(emptyRoot, diffs)
+	if err != nil {
+		return errors.Wrap(err, "failed to rebuild merkle tree")
+	}
+	ls.merkle.Forget(emptyRoot)
+
+	if !root.Equal(ls.persistedRoot) {
+		return errors.Errorf("rebuilt merkle root %s does not match persisted merkle root %s", root, ls.persistedRoot)
+	}
+	ls.currentNumKeys = primitives.StorageKeys(len(diffs))
+	ls.currentSize = size
+	ls.persistedNumKeys = ls.currentNumKeys
+	ls.persistedSize = ls.currentSize
+
+	ls.logger.Info("restored merkle tree from persisted state", logfields.BlockHeight(ls.persistedHeight), log.Int("number-of-keys", len(diffs)))
+	return nil
+}
+
 func (ls *rollingRevisions) getCurrentHeight() primitives.BlockHeight {
 	return ls.currentHeight
 }
@@ -130,6 +179,8 @@ func (ls *rollingRevisions) addRevision(height primitives.BlockHeight, ts primit
 		ref:        refTime,
 		prevRef:    ls.currentRefTime, // one back
 		proposer:   proposer,
+		numKeys:    newNumKeys,
+		size:       newSize,
 	})
 	ls.currentHeight = height
 	ls.currentTs = ts
@@ -199,11 +250,36 @@ func (ls *rollingRevisions) evictRevisions() error {
 		ls.persistedPrevRefTime = d.prevRef
 		ls.persistedProposer = d.proposer
 		ls.persistedRoot = d.merkleRoot
+		ls.persistedNumKeys = d.numKeys
+		ls.persistedSize = d.size
 		ls.revisions = ls.revisions[1:]
 	}
 	return nil
 }
 
+func (ls *rollingRevisions) getTransientRevisionsCount() int {
+	return len(ls.revisions)
+}
+
+// drops all revisions that were not yet written to persistence, rolling the current state back to the persisted snapshot
+func (ls *rollingRevisions) discardTransientRevisions() {
+	for _, r := range ls.revisions {
+		ls.merkle.Forget(r.merkleRoot)
+	}
+	ls.revisions = nil
+
+	ls.currentHeight = ls.persistedHeight
+	ls.currentTs = ls.persistedTs
+	ls.currentRefTime = ls.persistedRefTime
+	ls.prevRefTime = ls.persistedPrevRefTime
+	ls.currentProposer = ls.persistedProposer
+	ls.currentMerkleRoot = ls.persistedRoot
+	ls.currentNumKeys = ls.persistedNumKeys
+	ls.currentSize = ls.persistedSize
+
+	ls.logger.Info("rollingRevisions discarded transient revisions", logfields.BlockHeight(ls.currentHeight))
+}
+
 func (ls *rollingRevisions) getRevisionRecord(height primitives.BlockHeight, contract primitives.ContractName, key string) ([]byte, bool, error) {
 	if ls.currentHeight < height {
 		return nil, false, errors.Errorf("requested height %d is too new. most recent available block height is %d", height, ls.currentHeight)
@@ -219,6 +295,9 @@ func (ls *rollingRevisions) getRevisionRecord(height primitives.BlockHeight, con
 	}
 
 	if ls.persistedHeight > height {
+		if ls.archive != nil {
+			return ls.archive.ReadArchivedRecord(height, contract, key)
+		}
 		return nil, false, errors.Errorf("requested height %d is too old. oldest available block height is %d", height, ls.persistedHeight)
 	}
 	return ls.persist.Read(contract, key)
@@ -231,6 +310,11 @@ func (ls *rollingRevisions) getRevisionHash(height primitives.BlockHeight) (prim
 		}
 	}
 
+	if height < ls.persistedHeight && ls.archive != nil {
+		_, _, _, _, root, err := ls.archive.ReadArchivedMetadata(height)
+		return root, err
+	}
+
 	if height != ls.persistedHeight {
 		return nil, fmt.Errorf("could not locate merkle hash for height %d. oldest available block height is %d", height, ls.persistedHeight)
 	}
@@ -238,6 +322,52 @@ func (ls *rollingRevisions) getRevisionHash(height primitives.BlockHeight) (prim
 	return ls.persistedRoot, nil
 }
 
+func (ls *rollingRevisions) getRevisionBlockInfo(height primitives.BlockHeight) (primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, error) {
+	for i := len(ls.revisions) - 1; i >= 0; i-- {
+		if r := ls.revisions[i]; r.height == height {
+			return r.ts, r.ref, r.prevRef, r.proposer, nil
+		}
+	}
+
+	if height == ls.persistedHeight {
+		return ls.persistedTs, ls.persistedRefTime, ls.persistedPrevRefTime, ls.persistedProposer, nil
+	}
+
+	if height < ls.persistedHeight && ls.archive != nil {
+		ts, ref, prevRef, proposer, _, err := ls.archive.ReadArchivedMetadata(height)
+		return ts, ref, prevRef, proposer, err
+	}
+
+	return 0, 0, 0, nil, fmt.Errorf("could not locate block info for height %d. oldest available block height is %d", height, ls.persistedHeight)
+}
+
+func (ls *rollingRevisions) isArchive() bool {
+	return ls.archive != nil
+}
+
+func (ls *rollingRevisions) getRevisionProof(height primitives.BlockHeight, contract primitives.ContractName, key string) (*merkle.TrieProof, error) {
+	root, err := ls.getRevisionHash(height)
+	if err != nil {
+		return nil, err
+	}
+	return ls.merkle.GetProof(root, hash.CalcSha256([]byte(contract), []byte(key)))
+}
+
+func (ls *rollingRevisions) getPersistedMetadata() *StateSnapshotMetadata {
+	return &StateSnapshotMetadata{
+		BlockHeight:          ls.persistedHeight,
+		BlockTimestamp:       ls.persistedTs,
+		ReferenceTime:        ls.persistedRefTime,
+		PrevReferenceTime:    ls.persistedPrevRefTime,
+		BlockProposerAddress: ls.persistedProposer,
+		StateMerkleRootHash:  ls.persistedRoot,
+	}
+}
+
+func (ls *rollingRevisions) scanPersistedRecords(f adapter.RecordCursorFunc) error {
+	return ls.persist.ScanRecords(f)
+}
+
 func (ls *rollingRevisions) getRevisionRecordCurrentSize(contract primitives.ContractName, key string) (int, error) {
 	for i := len(ls.revisions) - 1; i >= 0; i-- {
 		if record, exists := ls.revisions[i].diff[contract][key]; exists {
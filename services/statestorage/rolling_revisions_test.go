@@ -226,7 +226,7 @@ func newDriver(logger log.Logger, persistence adapter.StatePersistence, layers i
 		m.When("Forget", mock.Any).Return(nil).Times(1)
 	}
 	d := &driver{
-		inner: newRollingRevisions(logger, persistence, layers, m),
+		inner: newRollingRevisions(logger, persistence, nil, layers, m),
 	}
 	return d
 }
@@ -286,6 +286,9 @@ func (spm *StatePersistenceMock) Read(contract primitives.ContractName, key stri
 func (spm *StatePersistenceMock) ReadMetadata() (primitives.BlockHeight, primitives.TimestampNano, primitives.TimestampSeconds, primitives.TimestampSeconds, primitives.NodeAddress, primitives.Sha256, error) {
 	return 0, 0, 0, 0, []byte{}, primitives.Sha256{}, nil
 }
+func (spm *StatePersistenceMock) ScanRecords(f adapter.RecordCursorFunc) error {
+	return nil
+}
 
 type MerkleMock struct {
 	mock.Mock
@@ -310,3 +313,7 @@ func (mm *MerkleMock) Update(rootMerkle primitives.Sha256, diffs merkle.TrieDiff
 func (mm *MerkleMock) Forget(rootHash primitives.Sha256) {
 	mm.Mock.Called(rootHash)
 }
+func (mm *MerkleMock) GetProof(rootHash primitives.Sha256, path []byte) (*merkle.TrieProof, error) {
+	ret := mm.Mock.Called(rootHash, path)
+	return ret.Get(0).(*merkle.TrieProof), ret.Error(1)
+}
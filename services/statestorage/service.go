@@ -7,6 +7,7 @@
 package statestorage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
@@ -26,21 +27,51 @@ import (
 
 var LogTag = log.Service("state-storage")
 
+// policies for handling a committed block whose pre-execution state root does not match the local state. none of them
+// fetches blocks from peers: the diverged block is already in this node's block storage, which is what replays come from
+const (
+	DivergencePolicyHalt   = "halt"   // refuse to commit the block, the node stays at the last height it agrees on
+	DivergencePolicyReplay = "replay" // discard transient revisions and replay this node's own stored blocks on top of the persisted snapshot, halting if the replay diverges too. recovers from corrupt in-memory state, not from diverged stored blocks
+	DivergencePolicyLog    = "log"    // report the divergence and commit the block anyway
+)
+
+// StateStorage is the state storage service of the protocol spec together with the queries this node serves beyond it
+type StateStorage interface {
+	services.StateStorage
+	GetStateProof(ctx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error)
+	GetBlockInfo(ctx context.Context, input *GetBlockInfoInput) (*GetBlockInfoOutput, error)
+	ExportStateSnapshot(ctx context.Context, w StateSnapshotWriter) error
+}
+
+type GetBlockInfoInput struct {
+	BlockHeight primitives.BlockHeight
+}
+
+type GetBlockInfoOutput struct {
+	BlockHeight          primitives.BlockHeight
+	BlockTimestamp       primitives.TimestampNano
+	CurrentReferenceTime primitives.TimestampSeconds
+	PrevReferenceTime    primitives.TimestampSeconds
+	BlockProposerAddress primitives.NodeAddress
+}
+
 type metrics struct {
-	readKeys       *metric.Rate
-	writeKeys      *metric.Rate
-	blockHeight    *metric.Gauge
-	currentNumKeys *metric.Gauge
-	currentSizeMB  *metric.Gauge
+	readKeys        *metric.Rate
+	writeKeys       *metric.Rate
+	blockHeight     *metric.Gauge
+	currentNumKeys  *metric.Gauge
+	currentSizeMB   *metric.Gauge
+	stateDivergence *metric.Counter
 }
 
 func newMetrics(m metric.Factory) *metrics {
 	return &metrics{
-		readKeys:       m.NewRate("StateStorage.ReadRequestedKeys"),
-		writeKeys:      m.NewRate("StateStorage.WriteRequestedKeys"),
-		blockHeight:    m.NewGauge("StateStorage.BlockHeight"),
-		currentNumKeys: m.NewGaugeWithValue("StateStorage.CurrentNumKeys", 0),
-		currentSizeMB:  m.NewGaugeWithValue("StateStorage.CurrentSizeMB", 0),
+		readKeys:        m.NewRate("StateStorage.ReadRequestedKeys"),
+		writeKeys:       m.NewRate("StateStorage.WriteRequestedKeys"),
+		blockHeight:     m.NewGauge("StateStorage.BlockHeight"),
+		currentNumKeys:  m.NewGaugeWithValue("StateStorage.CurrentNumKeys", 0),
+		currentSizeMB:   m.NewGaugeWithValue("StateStorage.CurrentSizeMB", 0),
+		stateDivergence: m.NewCounter("StateStorage.StateRootDivergence.Count"),
 	}
 }
 
@@ -51,25 +82,44 @@ type service struct {
 	logger         log.Logger
 	metrics        *metrics
 
-	mutex     sync.RWMutex
-	revisions *rollingRevisions
+	mutex         sync.RWMutex
+	revisions     *rollingRevisions
+	trackedHeight primitives.BlockHeight
+
+	// the height whose divergence made the revisions replay, until a replayed block commits past it
+	replayedDivergenceHeight primitives.BlockHeight
 }
 
-func NewStateStorage(config config.StateStorageConfig, persistence adapter.StatePersistence, heightReporter adapter.BlockHeightReporter, parent log.Logger, metricFactory metric.Factory) services.StateStorage {
+func NewStateStorage(config config.StateStorageConfig, persistence adapter.StatePersistence, heightReporter adapter.BlockHeightReporter, parent log.Logger, metricFactory metric.Factory) StateStorage {
+	switch config.StateStorageDivergencePolicy() {
+	case DivergencePolicyHalt, DivergencePolicyReplay, DivergencePolicyLog:
+	default:
+		panic(fmt.Sprintf("unknown state divergence policy %s", config.StateStorageDivergencePolicy()))
+	}
+
 	forest, _ := merkle.NewForest()
 	logger := parent.WithTags(LogTag)
 	if heightReporter == nil {
 		heightReporter = synchronization.NopHeightReporter{}
 	}
+	var archive adapter.StateArchive
+	if config.StateStorageArchiveMode() {
+		var ok bool
+		if archive, ok = persistence.(adapter.StateArchive); !ok {
+			panic(fmt.Sprintf("state archive mode is on but state persistence %T does not keep an archive", persistence))
+		}
+	}
+	revisions := newRollingRevisions(logger, persistence, archive, int(config.StateStorageHistorySnapshotNum()), forest)
 	return &service{
 		config:         config,
-		blockTracker:   synchronization.NewBlockTracker(logger, 0, uint16(config.BlockTrackerGraceDistance())),
+		blockTracker:   synchronization.NewBlockTracker(logger, uint64(revisions.getCurrentHeight()), uint16(config.BlockTrackerGraceDistance())),
 		heightReporter: heightReporter,
 		logger:         logger,
 		metrics:        newMetrics(metricFactory),
 
-		mutex:     sync.RWMutex{},
-		revisions: newRollingRevisions(logger, persistence, int(config.StateStorageHistorySnapshotNum()), forest),
+		mutex:         sync.RWMutex{},
+		revisions:     revisions,
+		trackedHeight: revisions.getCurrentHeight(),
 	}
 }
 
@@ -95,7 +145,34 @@ func (s *service) CommitStateDiff(ctx context.Context, input *services.CommitSta
 		return &services.CommitStateDiffOutput{NextDesiredBlockHeight: currentHeight + 1}, nil
 	}
 
-	// TODO(v1) assert input.ResultsBlockHeader.PreExecutionStateRootHash() == s.revisions.getRevisionHash(commitBlockHeight - 1)
+	if err := s.verifyPreExecutionStateRoot(input.ResultsBlockHeader); err != nil {
+		divergence, ok := err.(*ErrStateDivergence)
+		if !ok {
+			return nil, err
+		}
+		s.metrics.stateDivergence.Inc()
+		logger.Error("committed block does not match local state", log.Error(divergence), logfields.BlockHeight(commitBlockHeight), log.String("divergence-policy", s.config.StateStorageDivergencePolicy()))
+
+		switch s.config.StateStorageDivergencePolicy() {
+		case DivergencePolicyLog:
+			// commit anyway
+		case DivergencePolicyReplay:
+			// blocks are replayed from local block storage, so a replay that diverges again would only loop
+			if s.replayedDivergenceHeight != 0 {
+				logger.Error("replayed blocks diverged again, halting", logfields.BlockHeight(commitBlockHeight), log.Uint64("replayed-divergence-height", uint64(s.replayedDivergenceHeight)))
+				return nil, divergence
+			}
+			if s.revisions.getTransientRevisionsCount() > 0 {
+				s.replayedDivergenceHeight = commitBlockHeight
+				s.revisions.discardTransientRevisions()
+				return &services.CommitStateDiffOutput{NextDesiredBlockHeight: s.revisions.getCurrentHeight() + 1}, nil
+			}
+			logger.Error("persisted state snapshot diverged and cannot be recovered by replaying blocks", logfields.BlockHeight(commitBlockHeight))
+			return nil, divergence
+		default:
+			return nil, divergence
+		}
+	}
 
 	err := s.revisions.addRevision(commitBlockHeight, commitTimestamp, commitRefTime, commitPorposerAddress, inflateChainState(input.ContractStateDiffs))
 	if err != nil {
@@ -104,8 +181,14 @@ func (s *service) CommitStateDiff(ctx context.Context, input *services.CommitSta
 
 	s.metrics.writeKeys.Measure(int64(len(input.ContractStateDiffs)))
 
-	s.blockTracker.IncrementTo(commitBlockHeight)
-	s.heightReporter.IncrementTo(commitBlockHeight)
+	if commitBlockHeight >= s.replayedDivergenceHeight {
+		s.replayedDivergenceHeight = 0 // the replay got past the divergence
+	}
+	if commitBlockHeight > s.trackedHeight { // replayed heights were already reported
+		s.trackedHeight = commitBlockHeight
+		s.blockTracker.IncrementTo(commitBlockHeight)
+		s.heightReporter.IncrementTo(commitBlockHeight)
+	}
 	s.metrics.blockHeight.Update(int64(commitBlockHeight))
 	s.metrics.currentNumKeys.Update(int64(s.revisions.getCurrentNumKeys()))
 	s.metrics.currentSizeMB.Update(int64(s.revisions.getCurrentSize()))
@@ -128,9 +211,8 @@ func (s *service) ReadKeys(ctx context.Context, input *services.ReadKeysInput) (
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	currentHeight := s.revisions.getCurrentHeight()
-	if input.BlockHeight+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
-		return nil, errors.Errorf("unsupported block height: block %v too old. currently at %v. keeping %v back", input.BlockHeight, currentHeight, primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()))
+	if err := s.verifyHeightIsKept(input.BlockHeight); err != nil {
+		return nil, err
 	}
 
 	records := make([]*protocol.StateRecord, 0, len(input.Keys))
@@ -182,9 +264,8 @@ func (s *service) GetStateHash(ctx context.Context, input *services.GetStateHash
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	currentHeight := s.revisions.getCurrentHeight()
-	if input.BlockHeight+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
-		return nil, errors.Errorf("unsupported block height: block %v too old. currently at %v. keeping %v back", input.BlockHeight, currentHeight, primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()))
+	if err := s.verifyHeightIsKept(input.BlockHeight); err != nil {
+		return nil, err
 	}
 
 	value, err := s.revisions.getRevisionHash(input.BlockHeight)
@@ -196,6 +277,126 @@ func (s *service) GetStateHash(ctx context.Context, input *services.GetStateHash
 	return output, nil
 }
 
+func (s *service) GetStateProof(ctx context.Context, input *GetStateProofInput) (*GetStateProofOutput, error) {
+	if input.ContractName == "" {
+		return nil, errors.Errorf("missing contract name")
+	}
+
+	height := input.BlockHeight
+	if height == 0 {
+		s.mutex.RLock()
+		height = s.revisions.getCurrentHeight()
+		s.mutex.RUnlock()
+	} else {
+		timeoutCtx, cancel := context.WithTimeout(ctx, s.config.BlockTrackerGraceTimeout())
+		defer cancel()
+		if err := s.blockTracker.WaitForBlock(timeoutCtx, height); err != nil {
+			return nil, errors.Wrapf(err, "unsupported block height: block %d is not yet committed", height)
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	// proofs need the merkle trie of the height, which is only kept for the recent revisions even in archive mode
+	currentHeight := s.revisions.getCurrentHeight()
+	if height+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
+		return nil, errors.Errorf("unsupported block height: block %v too old. currently at %v. keeping %v back", height, currentHeight, primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()))
+	}
+
+	value, ok, err := s.revisions.getRevisionRecord(height, input.ContractName, string(input.Key))
+	if err != nil {
+		return nil, errors.Wrap(err, "persistence layer error")
+	}
+	if !ok {
+		value = newZeroValue()
+	}
+
+	root, err := s.revisions.getRevisionHash(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find a merkle root for block height %d", height)
+	}
+
+	trieProof, err := s.revisions.getRevisionProof(height, input.ContractName, string(input.Key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate merkle proof for block height %d", height)
+	}
+
+	proof, err := newStateProof(trieProof)
+	if err != nil {
+		return nil, err
+	}
+	// the proof is copied out of crypto-lib-go and verified by hashing of our own, see state_proof.go. a proof that
+	// doesn't verify here means the library changed under us, and is never served
+	if err := proof.Verify(root, input.ContractName, input.Key, value); err != nil {
+		return nil, errors.Wrapf(err, "merkle proof of block height %d does not verify, crypto-lib-go proof layout or hashing changed", height)
+	}
+
+	return &GetStateProofOutput{
+		BlockHeight:         height,
+		StateMerkleRootHash: root,
+		Value:               value,
+		Proof:               proof,
+	}, nil
+}
+
+func (s *service) GetBlockInfo(ctx context.Context, input *GetBlockInfoInput) (*GetBlockInfoOutput, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.config.BlockTrackerGraceTimeout())
+	defer cancel()
+	if err := s.blockTracker.WaitForBlock(timeoutCtx, input.BlockHeight); err != nil {
+		return nil, errors.Wrapf(err, "unsupported block height: block %d is not yet committed", input.BlockHeight)
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if err := s.verifyHeightIsKept(input.BlockHeight); err != nil {
+		return nil, err
+	}
+
+	ts, ref, prevRef, proposer, err := s.revisions.getRevisionBlockInfo(input.BlockHeight)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find block info for block height %d", input.BlockHeight)
+	}
+
+	return &GetBlockInfoOutput{
+		BlockHeight:          input.BlockHeight,
+		BlockTimestamp:       ts,
+		CurrentReferenceTime: ref,
+		PrevReferenceTime:    prevRef,
+		BlockProposerAddress: proposer,
+	}, nil
+}
+
+// without an archive only the last StateStorageHistorySnapshotNum heights can be read
+func (s *service) verifyHeightIsKept(height primitives.BlockHeight) error {
+	if s.revisions.isArchive() {
+		return nil
+	}
+
+	currentHeight := s.revisions.getCurrentHeight()
+	if height+primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()) <= currentHeight {
+		return errors.Errorf("unsupported block height: block %v too old. currently at %v. keeping %v back", height, currentHeight, primitives.BlockHeight(s.config.StateStorageHistorySnapshotNum()))
+	}
+	return nil
+}
+
+func (s *service) verifyPreExecutionStateRoot(header *protocol.ResultsBlockHeader) error {
+	localRoot, err := s.revisions.getRevisionHash(header.BlockHeight() - 1)
+	if err != nil {
+		return errors.Wrapf(err, "could not find a merkle root to verify block height %d", header.BlockHeight())
+	}
+
+	if !bytes.Equal(header.PreExecutionStateMerkleRootHash(), localRoot) {
+		return &ErrStateDivergence{
+			BlockHeight:       header.BlockHeight(),
+			ExpectedStateRoot: header.PreExecutionStateMerkleRootHash(),
+			ActualStateRoot:   localRoot,
+		}
+	}
+	return nil
+}
+
 func inflateChainState(csd []*protocol.ContractStateDiff) adapter.ChainState {
 	result := make(adapter.ChainState)
 	for _, stateDiffs := range csd {
@@ -0,0 +1,111 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package snapshot
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// Exporter writes snapshots of the persisted state of a running node, which lags the last committed block by the
+// number of state revisions kept in memory
+type Exporter struct {
+	stateStorage statestorage.StateStorage
+	blockStorage services.BlockStorage
+	logger       log.Logger
+}
+
+func NewExporter(stateStorage statestorage.StateStorage, blockStorage services.BlockStorage, parent log.Logger) *Exporter {
+	return &Exporter{
+		stateStorage: stateStorage,
+		blockStorage: blockStorage,
+		logger:       parent.WithTags(log.String("flow", "state-snapshot")),
+	}
+}
+
+func (e *Exporter) Export(ctx context.Context, w io.Writer) (*Header, error) {
+	writer := &exportWriter{
+		blockStorage: e.blockStorage,
+		writer:       NewWriter(w),
+	}
+
+	if err := e.stateStorage.ExportStateSnapshot(ctx, writer); err != nil {
+		return nil, err
+	}
+	if writer.header == nil {
+		return nil, errors.New("state storage exported a state snapshot without metadata")
+	}
+	if err := writer.writer.Close(); err != nil {
+		return nil, err
+	}
+
+	e.logger.Info("exported state snapshot", logfields.BlockHeight(writer.header.BlockHeight), log.Uint64("number-of-records", writer.writer.count))
+	return writer.header, nil
+}
+
+type exportWriter struct {
+	blockStorage services.BlockStorage
+	writer       *Writer
+	header       *Header
+}
+
+func (w *exportWriter) WriteMetadata(ctx context.Context, metadata *statestorage.StateSnapshotMetadata) error {
+	next := metadata.BlockHeight + 1
+
+	// asking block storage for a block it does not have yet would wait for it, so check first
+	last, err := w.blockStorage.GetLastCommittedBlockHeight(ctx, &services.GetLastCommittedBlockHeightInput{})
+	if err != nil {
+		return errors.Wrap(err, "failed to read last committed block height")
+	}
+	if last.LastCommittedBlockHeight < next {
+		return errors.Errorf("block %d which carries the state root of the snapshot is not yet committed", next)
+	}
+
+	var block *protocol.BlockPairContainer
+	if metadata.BlockHeight > 0 {
+		if block, err = w.readBlockHeaders(ctx, metadata.BlockHeight); err != nil {
+			return err
+		}
+	}
+	nextBlock, err := w.readBlockHeaders(ctx, next)
+	if err != nil {
+		return err
+	}
+
+	w.header = &Header{
+		StateSnapshotMetadata: *metadata,
+		Block:                 block,
+		NextBlock:             nextBlock,
+	}
+	return w.writer.WriteHeader(w.header)
+}
+
+func (w *exportWriter) readBlockHeaders(ctx context.Context, height primitives.BlockHeight) (*protocol.BlockPairContainer, error) {
+	tx, err := w.blockStorage.GetTransactionsBlockHeader(ctx, &services.GetTransactionsBlockHeaderInput{BlockHeight: height})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read transactions block header %d", height)
+	}
+	rx, err := w.blockStorage.GetResultsBlockHeader(ctx, &services.GetResultsBlockHeaderInput{BlockHeight: height})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read results block header %d", height)
+	}
+	return &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{Header: tx.TransactionsBlockHeader, BlockProof: tx.TransactionsBlockProof},
+		ResultsBlock:      &protocol.ResultsBlockContainer{Header: rx.ResultsBlockHeader, BlockProof: rx.ResultsBlockProof},
+	}, nil
+}
+
+func (w *exportWriter) WriteRecord(contract primitives.ContractName, key string, value []byte) error {
+	return w.writer.WriteRecord(contract, key, value)
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package snapshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+	"io"
+)
+
+const snapshotFormatMagic = uint32(0x50414e53) // "SNAP"
+const snapshotFormatVersion = 1
+
+const maxChunkSize = 64 * 1024 * 1024
+
+const (
+	recordTag  = byte(1)
+	trailerTag = byte(0)
+)
+
+// Header holds everything a node needs besides the records to continue from the snapshot height. The results block
+// following the snapshot height carries the state root of the snapshot as its pre-execution state root, and its block
+// proof is verified against the block of the snapshot height. Both blocks hold only their headers and block proofs;
+// Block is nil for a snapshot of the empty state of block height 0
+type Header struct {
+	statestorage.StateSnapshotMetadata
+	Block     *protocol.BlockPairContainer
+	NextBlock *protocol.BlockPairContainer
+}
+
+type fixedHeader struct {
+	Magic       uint32
+	Version     uint32
+	Height      uint64
+	Ts          uint64
+	RefTime     uint32
+	PrevRefTime uint32
+}
+
+// Writer encodes a snapshot as a header followed by a stream of records and a trailer holding the record count,
+// so a truncated snapshot is never mistaken for a complete one
+type Writer struct {
+	w     *bufio.Writer
+	count uint64
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) WriteHeader(h *Header) error {
+	err := binary.Write(w.w, binary.LittleEndian, &fixedHeader{
+		Magic:       snapshotFormatMagic,
+		Version:     snapshotFormatVersion,
+		Height:      uint64(h.BlockHeight),
+		Ts:          uint64(h.BlockTimestamp),
+		RefTime:     uint32(h.ReferenceTime),
+		PrevRefTime: uint32(h.PrevReferenceTime),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed writing snapshot header")
+	}
+	if err := writeChunks(w.w, h.BlockProposerAddress, h.StateMerkleRootHash); err != nil {
+		return err
+	}
+	if err := writeBlockHeaders(w.w, h.Block); err != nil {
+		return err
+	}
+	return writeBlockHeaders(w.w, h.NextBlock)
+}
+
+func (w *Writer) WriteRecord(contract primitives.ContractName, key string, value []byte) error {
+	if err := w.w.WriteByte(recordTag); err != nil {
+		return errors.Wrap(err, "failed writing snapshot record")
+	}
+	if err := writeChunks(w.w, []byte(contract), []byte(key), value); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Close writes the trailer and flushes, it does not close the underlying writer
+func (w *Writer) Close() error {
+	if err := w.w.WriteByte(trailerTag); err != nil {
+		return errors.Wrap(err, "failed writing snapshot trailer")
+	}
+	if err := binary.Write(w.w, binary.LittleEndian, w.count); err != nil {
+		return errors.Wrap(err, "failed writing snapshot trailer")
+	}
+	return errors.Wrap(w.w.Flush(), "failed flushing snapshot")
+}
+
+type Reader struct {
+	r     *bufio.Reader
+	count uint64
+	done  bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+func (r *Reader) ReadHeader() (*Header, error) {
+	fixed := &fixedHeader{}
+	if err := binary.Read(r.r, binary.LittleEndian, fixed); err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot header")
+	}
+
+	if fixed.Magic != snapshotFormatMagic {
+		return nil, fmt.Errorf("invalid snapshot magic number %v", fixed.Magic)
+	}
+
+	if fixed.Version != snapshotFormatVersion {
+		return nil, fmt.Errorf("invalid snapshot format version %d", fixed.Version)
+	}
+
+	chunks, err := readChunks(r.r, 2)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot header")
+	}
+	block, err := readBlockHeaders(r.r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot block")
+	}
+	nextBlock, err := readBlockHeaders(r.r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading snapshot next block")
+	}
+
+	return &Header{
+		StateSnapshotMetadata: statestorage.StateSnapshotMetadata{
+			BlockHeight:          primitives.BlockHeight(fixed.Height),
+			BlockTimestamp:       primitives.TimestampNano(fixed.Ts),
+			ReferenceTime:        primitives.TimestampSeconds(fixed.RefTime),
+			PrevReferenceTime:    primitives.TimestampSeconds(fixed.PrevRefTime),
+			BlockProposerAddress: chunks[0],
+			StateMerkleRootHash:  chunks[1],
+		},
+		Block:     block,
+		NextBlock: nextBlock,
+	}, nil
+}
+
+// Next returns the next record, or ok=false once the trailer was read and matched the number of records
+func (r *Reader) Next() (contract primitives.ContractName, key string, value []byte, ok bool, err error) {
+	if r.done {
+		return "", "", nil, false, nil
+	}
+
+	tag, err := r.r.ReadByte()
+	if err != nil {
+		return "", "", nil, false, errors.Wrap(err, "failed reading snapshot record, snapshot may be truncated")
+	}
+
+	switch tag {
+	case recordTag:
+		chunks, err := readChunks(r.r, 3)
+		if err != nil {
+			return "", "", nil, false, errors.Wrap(err, "failed reading snapshot record, snapshot may be truncated")
+		}
+		r.count++
+		return primitives.ContractName(chunks[0]), string(chunks[1]), chunks[2], true, nil
+	case trailerTag:
+		var count uint64
+		if err := binary.Read(r.r, binary.LittleEndian, &count); err != nil {
+			return "", "", nil, false, errors.Wrap(err, "failed reading snapshot trailer")
+		}
+		if count != r.count {
+			return "", "", nil, false, fmt.Errorf("snapshot trailer expects %d records but %d were read", count, r.count)
+		}
+		r.done = true
+		return "", "", nil, false, nil
+	default:
+		return "", "", nil, false, fmt.Errorf("invalid snapshot record tag %d", tag)
+	}
+}
+
+// a block is written as the headers and block proofs of its transactions and results blocks, empty for a nil block
+func writeBlockHeaders(w io.Writer, block *protocol.BlockPairContainer) error {
+	if block == nil {
+		return writeChunks(w, nil, nil, nil, nil)
+	}
+	return writeChunks(w, block.TransactionsBlock.Header.Raw(), block.TransactionsBlock.BlockProof.Raw(), block.ResultsBlock.Header.Raw(), block.ResultsBlock.BlockProof.Raw())
+}
+
+func readBlockHeaders(r io.Reader) (*protocol.BlockPairContainer, error) {
+	chunks, err := readChunks(r, 4)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks[0]) == 0 && len(chunks[2]) == 0 {
+		return nil, nil
+	}
+	return &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{
+			Header:     protocol.TransactionsBlockHeaderReader(chunks[0]),
+			BlockProof: protocol.TransactionsBlockProofReader(chunks[1]),
+		},
+		ResultsBlock: &protocol.ResultsBlockContainer{
+			Header:     protocol.ResultsBlockHeaderReader(chunks[2]),
+			BlockProof: protocol.ResultsBlockProofReader(chunks[3]),
+		},
+	}, nil
+}
+
+func writeChunks(w io.Writer, chunks ...[]byte) error {
+	for _, chunk := range chunks {
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(chunk))); err != nil {
+			return errors.Wrap(err, "failed writing snapshot chunk")
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return errors.Wrap(err, "failed writing snapshot chunk")
+		}
+	}
+	return nil
+}
+
+func readChunks(r io.Reader, n int) ([][]byte, error) {
+	chunks := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, err
+		}
+		if size > maxChunkSize {
+			return nil, fmt.Errorf("snapshot chunk size %d exceeds max chunk size %d", size, maxChunkSize)
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	blockStorageAdapter "github.com/orbs-network/orbs-network-go/services/blockstorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"io"
+)
+
+type Config interface {
+	VirtualChainId() primitives.VirtualChainId
+}
+
+// Import loads a snapshot into an empty state persistence. Nothing is written before the block following the snapshot
+// height passes verifyProof against the block of the snapshot height, and the merkle root of the records matches both
+// the snapshot header and the pre-execution state root of that block
+func Import(conf Config, r io.Reader, persistence adapter.StatePersistence, verifyProof blockStorageAdapter.BlockProofVerifier, parent log.Logger) (*Header, error) {
+	logger := parent.WithTags(log.String("flow", "state-snapshot"))
+	if verifyProof == nil {
+		return nil, errors.New("state snapshot can only be imported with a block proof verifier")
+	}
+
+	height, _, _, _, _, _, err := persistence.ReadMetadata()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read state metadata")
+	}
+	if height != 0 {
+		return nil, fmt.Errorf("state snapshot can only be imported into an empty state, found state of block height %d", height)
+	}
+
+	reader := NewReader(r)
+	header, err := reader.ReadHeader()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyHeader(conf, header, verifyProof); err != nil {
+		return nil, err
+	}
+
+	state := adapter.ChainState{}
+	diffs := make(merkle.TrieDiffs, 0)
+	for {
+		contract, key, value, ok, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if len(value) == 0 {
+			return nil, fmt.Errorf("state snapshot holds a zero value for key %x of contract %s", key, contract)
+		}
+		if _, exists := state[contract]; !exists {
+			state[contract] = adapter.ContractState{}
+		}
+		state[contract][key] = value
+		diffs = append(diffs, &merkle.TrieDiff{
+			Key:   hash.CalcSha256([]byte(contract), []byte(key)),
+			Value: hash.CalcSha256(value),
+		})
+	}
+
+	forest, emptyRoot := merkle.NewForest()
+	root, err := forest.Update(emptyRoot, diffs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate state merkle root")
+	}
+	if !bytes.Equal(root, header.StateMerkleRootHash) {
+		return nil, fmt.Errorf("state snapshot records have merkle root %s but the snapshot header states %s", root, header.StateMerkleRootHash)
+	}
+
+	err = persistence.Write(header.BlockHeight, header.BlockTimestamp, header.ReferenceTime, header.PrevReferenceTime, header.BlockProposerAddress, header.StateMerkleRootHash, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write state snapshot of block height %d", header.BlockHeight)
+	}
+
+	logger.Info("imported state snapshot", logfields.BlockHeight(header.BlockHeight), log.Int("number-of-records", len(diffs)))
+	return header, nil
+}
+
+func verifyHeader(conf Config, header *Header, verifyProof blockStorageAdapter.BlockProofVerifier) error {
+	if header.NextBlock == nil {
+		return errors.Errorf("state snapshot is missing block %d", header.BlockHeight+1)
+	}
+	if header.Block == nil && header.BlockHeight != 0 {
+		return errors.Errorf("state snapshot is missing block %d", header.BlockHeight)
+	}
+	next := header.NextBlock.ResultsBlock.Header
+	if next.VirtualChainId() != conf.VirtualChainId() {
+		return fmt.Errorf("state snapshot virtual chain id mismatch. found vchain id %d expected %d", next.VirtualChainId(), conf.VirtualChainId())
+	}
+	if next.BlockHeight() != header.BlockHeight+1 {
+		return fmt.Errorf("state snapshot of block height %d carries results block header of block %d", header.BlockHeight, next.BlockHeight())
+	}
+	if header.Block != nil {
+		if err := verifyMetadata(header); err != nil {
+			return err
+		}
+	}
+	if err := blockStorageAdapter.ValidateBlock(header.NextBlock, header.Block, verifyProof); err != nil {
+		return errors.Wrap(err, "state snapshot block does not verify")
+	}
+	if !bytes.Equal(next.PreExecutionStateMerkleRootHash(), header.StateMerkleRootHash) {
+		return fmt.Errorf("state snapshot merkle root %s does not match pre-execution state root %s of block %d", header.StateMerkleRootHash, next.PreExecutionStateMerkleRootHash(), next.BlockHeight())
+	}
+	return nil
+}
+
+// the metadata of the state is taken from the results block header it was committed with
+func verifyMetadata(header *Header) error {
+	block := header.Block.ResultsBlock.Header
+	if block.BlockHeight() != header.BlockHeight {
+		return fmt.Errorf("state snapshot of block height %d carries results block header of block %d", header.BlockHeight, block.BlockHeight())
+	}
+	if block.Timestamp() != header.BlockTimestamp || block.ReferenceTime() != header.ReferenceTime || !block.BlockProposerAddress().Equal(header.BlockProposerAddress) {
+		return fmt.Errorf("state snapshot metadata does not match results block header of block %d", header.BlockHeight)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
+	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const vchainId = primitives.VirtualChainId(42)
+
+var state = adapter.ChainState{
+	"foo": {"k1": []byte("v1"), "k2": []byte("v2")},
+	"bar": {"k1": []byte("v3")},
+}
+
+func TestExportImport_RestoresStateAndMetadata(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			root := rootOf(state)
+			h := newSourceNode(t, harness, 7, root)
+			block, next := blocksOf(7, root)
+			h.blockStorageHasBlocks(block, next)
+
+			buf := &bytes.Buffer{}
+			exported, err := NewExporter(h.stateStorage, h.blockStorage, harness.Logger).Export(ctx, buf)
+			require.NoError(t, err)
+			require.EqualValues(t, 7, exported.BlockHeight)
+
+			target := memory.NewStatePersistence(metric.NewRegistry())
+			var verifiedBlock, verifiedPrevBlock *protocol.BlockPairContainer
+			imported, err := Import(&localConfig{vchainId}, buf, target, func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+				verifiedBlock, verifiedPrevBlock = block, prevBlock
+				return nil
+			}, harness.Logger)
+			require.NoError(t, err)
+			require.EqualValues(t, 8, imported.NextBlock.ResultsBlock.Header.BlockHeight())
+			require.EqualValues(t, 8, verifiedBlock.TransactionsBlock.Header.BlockHeight(), "expected the proof of the next block to be verified")
+			require.EqualValues(t, 7, verifiedPrevBlock.TransactionsBlock.Header.BlockHeight(), "expected the next block to be verified against the block of the snapshot")
+
+			height, ts, ref, prevRef, proposer, importedRoot, err := target.ReadMetadata()
+			require.NoError(t, err)
+			require.EqualValues(t, 7, height)
+			require.EqualValues(t, 700, ts)
+			require.EqualValues(t, 70, ref)
+			require.EqualValues(t, 60, prevRef)
+			require.EqualValues(t, []byte{0x07}, proposer)
+			require.EqualValues(t, root, importedRoot)
+
+			for contract, records := range state {
+				for key, expected := range records {
+					value, ok, err := target.Read(contract, key)
+					require.NoError(t, err)
+					require.True(t, ok)
+					require.EqualValues(t, expected, value)
+				}
+			}
+		})
+	})
+}
+
+func TestImport_RejectsRootNotMatchingNextBlockHeader(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		block, next := blocksOf(7, hash.CalcSha256([]byte("other root")))
+		buf := encodeSnapshot(t, rootOf(state), block, next, state)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		_, err := Import(&localConfig{vchainId}, buf, target, acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail when the next block does not carry the snapshot root")
+		requireEmpty(t, target)
+	})
+}
+
+func TestImport_RejectsRecordsNotMatchingRoot(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		tampered := adapter.ChainState{"foo": {"k1": []byte("v1"), "k2": []byte("tampered")}, "bar": {"k1": []byte("v3")}}
+		block, next := blocksOf(7, root)
+		buf := encodeSnapshot(t, root, block, next, tampered)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		_, err := Import(&localConfig{vchainId}, buf, target, acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail when the records do not hash to the snapshot root")
+		requireEmpty(t, target)
+	})
+}
+
+func TestImport_RejectsTruncatedSnapshot(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		block, next := blocksOf(7, root)
+		buf := encodeSnapshot(t, root, block, next, state)
+		truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-3])
+
+		_, err := Import(&localConfig{vchainId}, truncated, memory.NewStatePersistence(metric.NewRegistry()), acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail for a truncated snapshot")
+	})
+}
+
+func TestImport_RejectsOtherVirtualChain(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		block, next := blocksOf(7, root)
+		buf := encodeSnapshot(t, root, block, next, state)
+
+		_, err := Import(&localConfig{vchainId + 1}, buf, memory.NewStatePersistence(metric.NewRegistry()), acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail for a snapshot of another virtual chain")
+	})
+}
+
+func TestImport_RejectsNonEmptyState(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		require.NoError(t, target.Write(1, 0, 0, 0, []byte{}, root, state))
+
+		block, next := blocksOf(7, root)
+		_, err := Import(&localConfig{vchainId}, encodeSnapshot(t, root, block, next, state), target, acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail into a state that is not empty")
+	})
+}
+
+func TestImport_RejectsNextBlockWithInvalidProof(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		block, next := blocksOf(7, root)
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		_, err := Import(&localConfig{vchainId}, encodeSnapshot(t, root, block, next, state), target, func(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+			return errors.New("invalid proof")
+		}, harness.Logger)
+		require.Error(t, err, "expected import to fail when the proof of the next block does not verify")
+		requireEmpty(t, target)
+	})
+}
+
+func TestImport_RejectsNextBlockNotFollowingBlock(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		root := rootOf(state)
+		block, _ := blocksOf(7, root)
+		_, next := blocksOf(7, root)
+		next.TransactionsBlock.Header.MutatePrevBlockHashPtr(hash.CalcSha256([]byte("other block")))
+
+		target := memory.NewStatePersistence(metric.NewRegistry())
+		_, err := Import(&localConfig{vchainId}, encodeSnapshot(t, root, block, next, state), target, acceptProof, harness.Logger)
+		require.Error(t, err, "expected import to fail when the next block does not point to the block of the snapshot")
+		requireEmpty(t, target)
+	})
+}
+
+type sourceNode struct {
+	stateStorage statestorage.StateStorage
+	blockStorage *services.MockBlockStorage
+}
+
+func newSourceNode(t *testing.T, harness *with.LoggingHarness, height primitives.BlockHeight, root primitives.Sha256) *sourceNode {
+	persistence := memory.NewStatePersistence(metric.NewRegistry())
+	require.NoError(t, persistence.Write(height, 700, 70, 60, []byte{0x07}, root, state))
+
+	cfg := config.ForStateStorageTest(1, 0, 0, statestorage.DivergencePolicyHalt, false)
+	return &sourceNode{
+		stateStorage: statestorage.NewStateStorage(cfg, persistence, nil, harness.Logger, metric.NewRegistry()),
+		blockStorage: &services.MockBlockStorage{},
+	}
+}
+
+func (n *sourceNode) blockStorageHasBlocks(blocks ...*protocol.BlockPairContainer) {
+	last := blocks[len(blocks)-1].TransactionsBlock.Header.BlockHeight()
+	n.blockStorage.When("GetLastCommittedBlockHeight", mock.Any, mock.Any).Return(&services.GetLastCommittedBlockHeightOutput{LastCommittedBlockHeight: last}, nil)
+	for _, block := range blocks {
+		height := block.TransactionsBlock.Header.BlockHeight()
+		n.blockStorage.When("GetTransactionsBlockHeader", mock.Any, &services.GetTransactionsBlockHeaderInput{BlockHeight: height}).Return(&services.GetTransactionsBlockHeaderOutput{
+			TransactionsBlockHeader: block.TransactionsBlock.Header,
+			TransactionsBlockProof:  block.TransactionsBlock.BlockProof,
+		}, nil)
+		n.blockStorage.When("GetResultsBlockHeader", mock.Any, &services.GetResultsBlockHeaderInput{BlockHeight: height}).Return(&services.GetResultsBlockHeaderOutput{
+			ResultsBlockHeader: block.ResultsBlock.Header,
+			ResultsBlockProof:  block.ResultsBlock.BlockProof,
+		}, nil)
+	}
+}
+
+// blocksOf builds the headers of the block of the snapshot height, matching the metadata newSourceNode persists, and
+// of the block following it, which carries nextPreExecutionRoot
+func blocksOf(height primitives.BlockHeight, nextPreExecutionRoot primitives.Sha256) (block *protocol.BlockPairContainer, next *protocol.BlockPairContainer) {
+	block = headersOnlyBlock(&protocol.TransactionsBlockHeaderBuilder{
+		VirtualChainId: vchainId,
+		BlockHeight:    height,
+	}, &protocol.ResultsBlockHeaderBuilder{
+		VirtualChainId:       vchainId,
+		BlockHeight:          height,
+		Timestamp:            700,
+		ReferenceTime:        70,
+		BlockProposerAddress: []byte{0x07},
+	})
+	next = headersOnlyBlock(&protocol.TransactionsBlockHeaderBuilder{
+		VirtualChainId:   vchainId,
+		BlockHeight:      height + 1,
+		PrevBlockHashPtr: digest.CalcTransactionsBlockHash(block.TransactionsBlock),
+	}, &protocol.ResultsBlockHeaderBuilder{
+		VirtualChainId:                  vchainId,
+		BlockHeight:                     height + 1,
+		PrevBlockHashPtr:                digest.CalcResultsBlockHash(block.ResultsBlock),
+		PreExecutionStateMerkleRootHash: nextPreExecutionRoot,
+	})
+	return block, next
+}
+
+func headersOnlyBlock(tx *protocol.TransactionsBlockHeaderBuilder, rx *protocol.ResultsBlockHeaderBuilder) *protocol.BlockPairContainer {
+	txHeader := tx.Build()
+	rx.TransactionsBlockHashPtr = hash.CalcSha256(txHeader.Raw())
+	rx.ReceiptsMerkleRootHash, _ = digest.CalcReceiptsMerkleRoot(nil)
+	return &protocol.BlockPairContainer{
+		TransactionsBlock: &protocol.TransactionsBlockContainer{Header: txHeader, BlockProof: (&protocol.TransactionsBlockProofBuilder{}).Build()},
+		ResultsBlock:      &protocol.ResultsBlockContainer{Header: rx.Build(), BlockProof: (&protocol.ResultsBlockProofBuilder{}).Build()},
+	}
+}
+
+func acceptProof(block *protocol.BlockPairContainer, prevBlock *protocol.BlockPairContainer) error {
+	return nil
+}
+
+func encodeSnapshot(t *testing.T, root primitives.Sha256, block *protocol.BlockPairContainer, next *protocol.BlockPairContainer, records adapter.ChainState) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	require.NoError(t, w.WriteHeader(&Header{
+		StateSnapshotMetadata: statestorage.StateSnapshotMetadata{
+			BlockHeight:          block.ResultsBlock.Header.BlockHeight(),
+			BlockTimestamp:       block.ResultsBlock.Header.Timestamp(),
+			ReferenceTime:        block.ResultsBlock.Header.ReferenceTime(),
+			BlockProposerAddress: block.ResultsBlock.Header.BlockProposerAddress(),
+			StateMerkleRootHash:  root,
+		},
+		Block:     block,
+		NextBlock: next,
+	}))
+	for contract, contractRecords := range records {
+		for key, value := range contractRecords {
+			require.NoError(t, w.WriteRecord(contract, key, value))
+		}
+	}
+	require.NoError(t, w.Close())
+	return buf
+}
+
+func rootOf(state adapter.ChainState) primitives.Sha256 {
+	diffs := make(merkle.TrieDiffs, 0)
+	for contract, records := range state {
+		for key, value := range records {
+			diffs = append(diffs, &merkle.TrieDiff{Key: hash.CalcSha256([]byte(contract), []byte(key)), Value: hash.CalcSha256(value)})
+		}
+	}
+	forest, emptyRoot := merkle.NewForest()
+	root, err := forest.Update(emptyRoot, diffs)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
+
+func requireEmpty(t *testing.T, persistence adapter.StatePersistence) {
+	height, _, _, _, _, _, err := persistence.ReadMetadata()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, height, "failed import should not write state")
+}
+
+type localConfig struct {
+	vchainId primitives.VirtualChainId
+}
+
+func (c *localConfig) VirtualChainId() primitives.VirtualChainId {
+	return c.vchainId
+}
@@ -0,0 +1,184 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package statestorage
+
+import (
+	"bytes"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+	"reflect"
+)
+
+type GetStateProofInput struct {
+	BlockHeight  primitives.BlockHeight // zero for the last committed block
+	ContractName primitives.ContractName
+	Key          []byte
+}
+
+type GetStateProofOutput struct {
+	BlockHeight         primitives.BlockHeight
+	StateMerkleRootHash primitives.Sha256
+	Value               []byte // zero value if the key is not in the state
+	Proof               *StateProof
+}
+
+// StateProofNode is one step on the path from the state root down to a key: the hash of the branch not taken and
+// the length (in bits) of the path prefix compressed into the node
+type StateProofNode struct {
+	SiblingHash primitives.Sha256
+	PrefixSize  uint32
+}
+
+// StateProof proves the value of a single state key (or its absence) against a state merkle root.
+// The last node holds the hash of the node where the key path ends. For exclusion proofs Path holds the bit path
+// of that node and ExtraHashLeft/ExtraHashRight hold what it hashes over (its value, or both child hashes)
+type StateProof struct {
+	Nodes          []*StateProofNode
+	Path           []byte
+	ExtraHashLeft  []byte
+	ExtraHashRight []byte
+}
+
+// crypto-lib-go keeps the fields of merkle.TrieProof unexported, so the proof is copied out field by field. every field
+// is checked for the exact kind it is read as, so a change of layout fails here rather than misreading the proof, and
+// GetStateProof verifies every proof before serving it, so a change of hashing fails there
+// TODO(v1) - export the proof fields and a verifier of serialized proofs in crypto-lib-go, and drop both the reflection
+// and the hashing of Verify
+func newStateProof(proof *merkle.TrieProof) (*StateProof, error) {
+	if proof == nil {
+		return nil, errors.Errorf("merkle proof is nil")
+	}
+
+	v := reflect.ValueOf(proof).Elem()
+	nodes, path, left, right := v.FieldByName("nodes"), v.FieldByName("path"), v.FieldByName("extraHashLeft"), v.FieldByName("extraHashRight")
+	if !isSliceOf(nodes, reflect.Ptr) || !isSliceOf(path, reflect.Uint8) || !isSliceOf(left, reflect.Uint8) || !isSliceOf(right, reflect.Uint8) {
+		return nil, errors.Errorf("unsupported merkle proof layout %s", v.Type())
+	}
+
+	result := &StateProof{
+		Nodes:          make([]*StateProofNode, 0, nodes.Len()),
+		Path:           copyBytes(path.Bytes()),
+		ExtraHashLeft:  copyBytes(left.Bytes()),
+		ExtraHashRight: copyBytes(right.Bytes()),
+	}
+	for i := 0; i < nodes.Len(); i++ {
+		n := nodes.Index(i).Elem()
+		siblingHash, prefixSize := n.FieldByName("otherChildHash"), n.FieldByName("prefixSize")
+		if !isSliceOf(siblingHash, reflect.Uint8) || !prefixSize.IsValid() || prefixSize.Kind() != reflect.Int {
+			return nil, errors.Errorf("unsupported merkle proof node layout %s", n.Type())
+		}
+		result.Nodes = append(result.Nodes, &StateProofNode{
+			SiblingHash: copyBytes(siblingHash.Bytes()),
+			PrefixSize:  uint32(prefixSize.Int()),
+		})
+	}
+	return result, nil
+}
+
+// Verify checks that the proof ties value to the state key of contract under root. A zero (empty) value verifies
+// that the key is absent from the state
+func (p *StateProof) Verify(root primitives.Sha256, contract primitives.ContractName, key []byte, value []byte) error {
+	if p == nil {
+		return errors.Errorf("state proof is nil")
+	}
+
+	path := toBitPath(hash.CalcSha256([]byte(contract), key))
+	valueHash := hash.CalcSha256(value)
+
+	if len(p.Nodes) == 0 { // only the empty trie has no nodes on any path
+		_, emptyRoot := merkle.NewForest()
+		if !root.Equal(emptyRoot) {
+			return errors.Errorf("empty state proof for a non empty state root %s", root)
+		}
+		if !isZeroValue(value) {
+			return errors.Errorf("empty state can not hold a value for key %x of contract %s", key, contract)
+		}
+		return nil
+	}
+
+	last := p.Nodes[len(p.Nodes)-1]
+	lastNodePathIndex := 0
+	for _, n := range p.Nodes[:len(p.Nodes)-1] {
+		lastNodePathIndex += int(n.PrefixSize) + 1
+	}
+	if lastNodePathIndex+int(last.PrefixSize) > len(path) {
+		return errors.Errorf("state proof is longer than the key path")
+	}
+
+	currentHash := last.SiblingHash
+	keyStart := lastNodePathIndex
+	for i := len(p.Nodes) - 2; i >= 0; i-- {
+		keyEnd := keyStart - 1
+		keyStart = keyEnd - int(p.Nodes[i].PrefixSize)
+		if path[keyEnd] == 0 {
+			currentHash = hash.CalcSha256(currentHash, p.Nodes[i].SiblingHash, path[keyStart:keyEnd])
+		} else {
+			currentHash = hash.CalcSha256(p.Nodes[i].SiblingHash, currentHash, path[keyStart:keyEnd])
+		}
+	}
+	if !currentHash.Equal(root) {
+		return errors.Errorf("state proof does not lead to state root %s", root)
+	}
+
+	if !isZeroValue(value) {
+		if !hash.CalcSha256(valueHash, path[lastNodePathIndex:]).Equal(last.SiblingHash) {
+			return errors.Errorf("state proof does not include value for key %x of contract %s", key, contract)
+		}
+		return nil
+	}
+
+	return p.verifyExclusion(path, lastNodePathIndex)
+}
+
+func (p *StateProof) verifyExclusion(path []byte, lastNodePathIndex int) error {
+	last := p.Nodes[len(p.Nodes)-1]
+	if len(p.Path) != len(path) {
+		return errors.Errorf("state proof path length %d does not match key path length %d", len(p.Path), len(path))
+	}
+
+	lastNodePrefix := p.Path[lastNodePathIndex : lastNodePathIndex+int(last.PrefixSize)]
+	var lastNodeHash primitives.Sha256
+	if len(p.ExtraHashRight) > 0 {
+		lastNodeHash = hash.CalcSha256(p.ExtraHashLeft, p.ExtraHashRight, lastNodePrefix)
+	} else {
+		lastNodeHash = hash.CalcSha256(p.ExtraHashLeft, lastNodePrefix)
+	}
+	if !lastNodeHash.Equal(last.SiblingHash) {
+		return errors.Errorf("state proof last node does not match its contents")
+	}
+
+	if !bytes.Equal(p.Path[:lastNodePathIndex], path[:lastNodePathIndex]) {
+		return errors.Errorf("state proof path does not match key path")
+	}
+
+	// the key is absent only if its path leaves the trie inside the prefix of the last node
+	if bytes.Equal(lastNodePrefix, path[lastNodePathIndex:lastNodePathIndex+len(lastNodePrefix)]) {
+		return errors.Errorf("state proof does not exclude key")
+	}
+	return nil
+}
+
+func isSliceOf(v reflect.Value, elemKind reflect.Kind) bool {
+	return v.IsValid() && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == elemKind
+}
+
+func toBitPath(key []byte) []byte {
+	bits := make([]byte, len(key)*8)
+	for i := range bits {
+		bits[i] = 1 & (key[i/8] >> uint(7-(i%8)))
+	}
+	return bits
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
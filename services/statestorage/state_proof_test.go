@@ -0,0 +1,58 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package statestorage
+
+import (
+	"fmt"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/merkle"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// StateProof copies the proof out of crypto-lib-go and hashes it on its own, so it must agree with the library
+// verifier on every proof - a crypto-lib-go upgrade that changes either the proof layout or the hashing fails here
+func TestStateProof_AgreesWithMerkleForestVerify(t *testing.T) {
+	const contract = primitives.ContractName("c")
+	forest, root := merkle.NewForest()
+
+	var diffs merkle.TrieDiffs
+	for i := 0; i < 200; i++ {
+		diffs = append(diffs, &merkle.TrieDiff{
+			Key:   hash.CalcSha256([]byte(contract), []byte(fmt.Sprintf("key%d", i))),
+			Value: hash.CalcSha256([]byte(fmt.Sprintf("value%d", i))),
+		})
+	}
+	root, err := forest.Update(root, diffs)
+	require.NoError(t, err)
+
+	for i := 0; i < 400; i++ { // half of the keys are in the state, half are not
+		key := []byte(fmt.Sprintf("key%d", i))
+		var value []byte
+		if i < 200 {
+			value = []byte(fmt.Sprintf("value%d", i))
+		}
+		path := hash.CalcSha256([]byte(contract), key)
+
+		trieProof, err := forest.GetProof(root, path)
+		require.NoError(t, err)
+		libraryVerified, err := forest.Verify(root, trieProof, path, hash.CalcSha256(value))
+		require.NoError(t, err)
+		require.True(t, libraryVerified, "crypto-lib-go did not verify its own proof of %s", key)
+
+		proof, err := newStateProof(trieProof)
+		require.NoError(t, err)
+		require.NoError(t, proof.Verify(root, contract, key, value), "state proof of %s", key)
+		require.Error(t, proof.Verify(root, contract, key, []byte("other value")), "state proof of %s verified a wrong value", key)
+	}
+}
+
+func TestNewStateProof_FailsOnNilProof(t *testing.T) {
+	_, err := newStateProof(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package statestorage
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/pkg/errors"
+)
+
+type StateSnapshotMetadata struct {
+	BlockHeight          primitives.BlockHeight
+	BlockTimestamp       primitives.TimestampNano
+	ReferenceTime        primitives.TimestampSeconds
+	PrevReferenceTime    primitives.TimestampSeconds
+	BlockProposerAddress primitives.NodeAddress
+	StateMerkleRootHash  primitives.Sha256
+}
+
+// StateSnapshotWriter receives the metadata of an exported state followed by every non-zero record of it
+type StateSnapshotWriter interface {
+	WriteMetadata(ctx context.Context, metadata *StateSnapshotMetadata) error
+	WriteRecord(contract primitives.ContractName, key string, value []byte) error
+}
+
+// ExportStateSnapshot writes out the full persisted state. Commits are blocked until the export is done so the
+// snapshot stays consistent with its metadata
+func (s *service) ExportStateSnapshot(ctx context.Context, w StateSnapshotWriter) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	metadata := s.revisions.getPersistedMetadata()
+	if err := w.WriteMetadata(ctx, metadata); err != nil {
+		return errors.Wrapf(err, "failed to export state snapshot metadata for block height %d", metadata.BlockHeight)
+	}
+
+	var writeErr error
+	err := s.revisions.scanPersistedRecords(func(contract primitives.ContractName, key string, value []byte) bool {
+		if ctx.Err() != nil {
+			writeErr = ctx.Err()
+		} else {
+			writeErr = w.WriteRecord(contract, key, value)
+		}
+		return writeErr == nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to scan state for snapshot of block height %d", metadata.BlockHeight)
+	}
+	return errors.Wrapf(writeErr, "failed to export state snapshot for block height %d", metadata.BlockHeight)
+}
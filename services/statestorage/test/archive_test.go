@@ -0,0 +1,85 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestArchiveReadsKeysOlderThanRetainedRevisions(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newArchiveStateStorageDriver(2)
+		for i := 1; i <= 10; i++ {
+			d.CommitValuePairs(ctx, "foo", "bar", fmt.Sprintf("baz%d", i))
+		}
+
+		for i := 1; i <= 10; i++ {
+			value, err := d.ReadSingleKeyFromRevision(ctx, i, "foo", "bar")
+			require.NoError(t, err, "archive should serve block height %d", i)
+			require.EqualValues(t, fmt.Sprintf("baz%d", i), value)
+		}
+
+		value, err := d.ReadSingleKeyFromRevision(ctx, 0, "foo", "bar")
+		require.NoError(t, err)
+		require.Empty(t, value, "key should not exist at genesis")
+	})
+}
+
+func TestArchiveReturnsStateHashOfOldRevisions(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newArchiveStateStorageDriver(2)
+		roots := make(map[int][]byte)
+		for i := 1; i <= 6; i++ {
+			d.CommitValuePairs(ctx, "foo", fmt.Sprintf("key%d", i), "value")
+			root, err := d.GetStateHash(ctx, i)
+			require.NoError(t, err)
+			roots[i] = root
+		}
+
+		for i := 1; i <= 6; i++ {
+			root, err := d.GetStateHash(ctx, i)
+			require.NoError(t, err)
+			require.EqualValues(t, roots[i], root, "archived state hash of block height %d does not match the one reported when it was committed", i)
+		}
+	})
+}
+
+func TestArchiveReturnsBlockInfoOfOldRevisions(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newArchiveStateStorageDriver(1)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		_, ts, err := d.GetBlockHeightAndTimestamp(ctx)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			d.CommitValuePairs(ctx, "foo", "bar", "qux")
+		}
+
+		info, err := d.GetBlockInfo(ctx, 1)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, info.BlockHeight)
+		require.EqualValues(t, ts, info.BlockTimestamp)
+	})
+}
+
+func TestGetBlockInfoFailsForEvictedHeightWithoutArchive(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(1)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		d.CommitValuePairs(ctx, "foo", "bar", "qux")
+
+		_, err := d.GetBlockInfo(ctx, 1)
+		require.Error(t, err, "expected error for a height that is no longer kept")
+
+		info, err := d.GetBlockInfo(ctx, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, info.BlockHeight)
+	})
+}
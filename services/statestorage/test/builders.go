@@ -33,6 +33,25 @@ func (b *commitStateDiffInputBuilder) WithBlockTimestamp(t int) *commitStateDiff
 	return b
 }
 
+func (b *commitStateDiffInputBuilder) WithPreExecutionStateRootHash(root primitives.Sha256) *commitStateDiffInputBuilder {
+	b.headerBuilder.PreExecutionStateMerkleRootHash = root
+	return b
+}
+
+func (b *commitStateDiffInputBuilder) WithHeader(header *protocol.ResultsBlockHeader) *commitStateDiffInputBuilder {
+	b.headerBuilder.BlockHeight = header.BlockHeight()
+	b.headerBuilder.Timestamp = header.Timestamp()
+	b.headerBuilder.ReferenceTime = header.ReferenceTime()
+	b.headerBuilder.BlockProposerAddress = header.BlockProposerAddress()
+	b.headerBuilder.PreExecutionStateMerkleRootHash = header.PreExecutionStateMerkleRootHash()
+	return b
+}
+
+func (b *commitStateDiffInputBuilder) WithDiffs(diffs []*protocol.ContractStateDiff) *commitStateDiffInputBuilder {
+	b.diffs = append(b.diffs, diffs...)
+	return b
+}
+
 func (b *commitStateDiffInputBuilder) WithDiff(diff *protocol.ContractStateDiff) *commitStateDiffInputBuilder {
 	b.diffs = append(b.diffs, diff)
 	return b
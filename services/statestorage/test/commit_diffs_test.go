@@ -8,6 +8,9 @@ package test
 
 import (
 	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/stretchr/testify/require"
@@ -40,10 +43,10 @@ func TestNonConsecutiveBlockHeights(t *testing.T) {
 		d := NewStateStorageDriver(1)
 
 		registerContractDiff := builders.ContractStateDiff().WithContractName("contract1").WithStringRecord("key1", "whatever").Build()
-		d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(registerContractDiff).Build())
+		d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(registerContractDiff).Build())
 
 		diff := builders.ContractStateDiff().WithContractName("contract1").WithStringRecord("key1", "whatever").Build()
-		result, err := d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(3).WithDiff(diff).Build())
+		result, err := d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(3).WithDiff(diff).Build())
 
 		require.NoError(t, err)
 		require.EqualValues(t, 2, result.NextDesiredBlockHeight, "unexpected NextDesiredBlockHeight")
@@ -74,3 +77,29 @@ func TestCommitPastBlockHeights(t *testing.T) {
 		require.EqualValues(t, []byte{}, output2, "unexpected value read")
 	})
 }
+
+func TestCommitAfterRestartFromPersistedState(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		persistence := memory.NewStatePersistence(metric.NewRegistry())
+		d := newStateStorageDriverWithPersistence(1, 0, 0, statestorage.DivergencePolicyHalt, persistence)
+
+		d.CommitValuePairsAtHeight(ctx, 1, "c1", "key1", "v1", "key2", "v2")
+		d.CommitValuePairsAtHeight(ctx, 2, "c2", "key1", "v3")
+		d.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v4")
+		expectedHash, err := d.GetStateHash(ctx, 3)
+		require.NoError(t, err)
+
+		restarted := newStateStorageDriverWithPersistence(1, 0, 0, statestorage.DivergencePolicyHalt, persistence)
+		h, _, err := restarted.GetBlockHeightAndTimestamp(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, h, "expected restarted state storage to resume from the persisted height")
+
+		result, err := restarted.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v4")
+		require.NoError(t, err)
+		require.EqualValues(t, 4, result.NextDesiredBlockHeight)
+
+		actualHash, err := restarted.GetStateHash(ctx, 3)
+		require.NoError(t, err)
+		require.Equal(t, expectedHash, actualHash, "state hash after restart should match the one computed before restart")
+	})
+}
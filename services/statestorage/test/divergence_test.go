@@ -0,0 +1,120 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var divergedRoot = primitives.Sha256{0x01, 0x02, 0x03}
+
+func commitWithPreExecutionRoot(ctx context.Context, d *Driver, h int, root primitives.Sha256) (int, error) {
+	diff := builders.ContractStateDiff().WithContractName("c1").WithStringRecord("key1", "v1").Build()
+	out, err := d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(h).WithPreExecutionStateRootHash(root).WithDiff(diff).Build())
+	if err != nil {
+		return 0, err
+	}
+	return int(out.NextDesiredBlockHeight), nil
+}
+
+func TestCommitStateDiff_HaltsOnDivergedPreExecutionStateRoot(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newStateStorageDriverWithPersistence(5, 0, 0, statestorage.DivergencePolicyHalt, memory.NewStatePersistence(metric.NewRegistry()))
+		d.CommitValuePairsAtHeight(ctx, 1, "c1", "key1", "v1")
+
+		_, err := commitWithPreExecutionRoot(ctx, d, 2, divergedRoot)
+		require.Error(t, err)
+		divergence, ok := err.(*statestorage.ErrStateDivergence)
+		require.True(t, ok, "expected a state divergence error but got %v", err)
+		require.EqualValues(t, 2, divergence.BlockHeight)
+		require.Equal(t, divergedRoot, divergence.ExpectedStateRoot)
+
+		h, _, _ := d.GetBlockHeightAndTimestamp(ctx)
+		require.EqualValues(t, 1, h, "diverged block should not be committed")
+	})
+}
+
+func TestCommitStateDiff_LogPolicyCommitsDivergedBlock(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newStateStorageDriverWithPersistence(5, 0, 0, statestorage.DivergencePolicyLog, memory.NewStatePersistence(metric.NewRegistry()))
+		d.CommitValuePairsAtHeight(ctx, 1, "c1", "key1", "v1")
+
+		next, err := commitWithPreExecutionRoot(ctx, d, 2, divergedRoot)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, next)
+	})
+}
+
+func TestCommitStateDiff_ReplayPolicyReplaysFromPersistedSnapshot(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newStateStorageDriverWithPersistence(2, 0, 0, statestorage.DivergencePolicyReplay, memory.NewStatePersistence(metric.NewRegistry()))
+		d.CommitValuePairsAtHeight(ctx, 1, "c1", "key1", "v1")
+		d.CommitValuePairsAtHeight(ctx, 2, "c1", "key1", "v2")
+		d.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v3") // height 1 is now persisted
+
+		next, err := commitWithPreExecutionRoot(ctx, d, 4, divergedRoot)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, next, "expected replay to start right after the persisted snapshot")
+
+		h, _, _ := d.GetBlockHeightAndTimestamp(ctx)
+		require.EqualValues(t, 1, h)
+
+		d.CommitValuePairsAtHeight(ctx, 2, "c1", "key1", "v2")
+		d.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v3")
+		output, err := d.CommitValuePairsAtHeight(ctx, 4, "c1", "key1", "v4")
+		require.NoError(t, err)
+		require.EqualValues(t, 5, output.NextDesiredBlockHeight)
+
+		value, err := d.ReadSingleKey(ctx, "c1", "key1")
+		require.NoError(t, err)
+		require.EqualValues(t, "v4", value)
+	})
+}
+
+func TestCommitStateDiff_ReplayPolicyHaltsWhenReplayDivergesAgain(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newStateStorageDriverWithPersistence(2, 0, 0, statestorage.DivergencePolicyReplay, memory.NewStatePersistence(metric.NewRegistry()))
+		d.CommitValuePairsAtHeight(ctx, 1, "c1", "key1", "v1")
+		d.CommitValuePairsAtHeight(ctx, 2, "c1", "key1", "v2")
+		d.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v3") // height 1 is now persisted
+
+		next, err := commitWithPreExecutionRoot(ctx, d, 4, divergedRoot)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, next, "expected replay to start right after the persisted snapshot")
+
+		d.CommitValuePairsAtHeight(ctx, 2, "c1", "key1", "v2")
+		d.CommitValuePairsAtHeight(ctx, 3, "c1", "key1", "v3")
+		_, err = commitWithPreExecutionRoot(ctx, d, 4, divergedRoot)
+		require.IsType(t, &statestorage.ErrStateDivergence{}, err, "expected to halt when the replayed block diverges again")
+
+		h, _, _ := d.GetBlockHeightAndTimestamp(ctx)
+		require.EqualValues(t, 3, h, "diverged block should not be committed")
+	})
+}
+
+func TestNewStateStorage_PanicsOnUnknownDivergencePolicy(t *testing.T) {
+	require.Panics(t, func() {
+		newStateStorageDriverWithPersistence(1, 0, 0, "ignore", memory.NewStatePersistence(metric.NewRegistry()))
+	})
+}
+
+func TestCommitStateDiff_ReplayPolicyHaltsWhenPersistedSnapshotDiverged(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := newStateStorageDriverWithPersistence(1, 0, 0, statestorage.DivergencePolicyReplay, memory.NewStatePersistence(metric.NewRegistry()))
+
+		_, err := commitWithPreExecutionRoot(ctx, d, 1, divergedRoot)
+		require.IsType(t, &statestorage.ErrStateDivergence{}, err, "expected to halt when there are no transient revisions to discard")
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter"
 	"github.com/orbs-network/orbs-network-go/services/statestorage/adapter/memory"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -21,7 +22,7 @@ import (
 )
 
 type Driver struct {
-	service services.StateStorage
+	service statestorage.StateStorage
 }
 
 type keyValue struct {
@@ -38,10 +39,22 @@ func newStateStorageDriverWithGrace(numOfStateRevisionsToRetain uint32, graceBlo
 		numOfStateRevisionsToRetain = 1
 	}
 
-	cfg := config.ForStateStorageTest(numOfStateRevisionsToRetain, graceBlockDiff, graceTimeoutMillis)
+	return newStateStorageDriverWithPersistence(numOfStateRevisionsToRetain, graceBlockDiff, graceTimeoutMillis, statestorage.DivergencePolicyHalt, memory.NewStatePersistence(metric.NewRegistry()))
+}
+
+func newArchiveStateStorageDriver(numOfStateRevisionsToRetain uint32) *Driver {
+	cfg := config.ForStateStorageTest(numOfStateRevisionsToRetain, 0, 0, statestorage.DivergencePolicyHalt, true)
+	return newStateStorageDriverWithConfig(cfg, memory.NewArchiveStatePersistence(metric.NewRegistry()))
+}
+
+func newStateStorageDriverWithPersistence(numOfStateRevisionsToRetain uint32, graceBlockDiff uint32, graceTimeoutMillis uint64, divergencePolicy string, p adapter.StatePersistence) *Driver {
+	cfg := config.ForStateStorageTest(numOfStateRevisionsToRetain, graceBlockDiff, graceTimeoutMillis, divergencePolicy, false)
+	return newStateStorageDriverWithConfig(cfg, p)
+}
+
+func newStateStorageDriverWithConfig(cfg config.StateStorageConfig, p adapter.StatePersistence) *Driver {
 	registry := metric.NewRegistry()
 
-	p := memory.NewStatePersistence(registry)
 	logger := log.GetLogger().WithOutput() // a mute logger
 
 	return &Driver{service: statestorage.NewStateStorage(cfg, p, nil, logger, registry)}
@@ -96,7 +109,30 @@ func (d *Driver) GetBlockHeightAndTimestamp(ctx context.Context) (int, int, erro
 	return int(output.BlockHeight), int(output.BlockTimestamp), err
 }
 
+func (d *Driver) GetStateHash(ctx context.Context, h int) (primitives.Sha256, error) {
+	output, err := d.service.GetStateHash(ctx, &services.GetStateHashInput{BlockHeight: primitives.BlockHeight(h)})
+	if err != nil {
+		return nil, err
+	}
+	return output.StateMerkleRootHash, nil
+}
+
+func (d *Driver) GetStateProof(ctx context.Context, h int, contract string, key string) (*statestorage.GetStateProofOutput, error) {
+	return d.service.GetStateProof(ctx, &statestorage.GetStateProofInput{BlockHeight: primitives.BlockHeight(h), ContractName: primitives.ContractName(contract), Key: []byte(key)})
+}
+
+func (d *Driver) GetBlockInfo(ctx context.Context, h int) (*statestorage.GetBlockInfoOutput, error) {
+	return d.service.GetBlockInfo(ctx, &statestorage.GetBlockInfoInput{BlockHeight: primitives.BlockHeight(h)})
+}
+
+// commits the state diff on top of the current state, unless the input already carries an explicit pre-execution state root
 func (d *Driver) CommitStateDiff(ctx context.Context, state *services.CommitStateDiffInput) (*services.CommitStateDiffOutput, error) {
+	if len(state.ResultsBlockHeader.PreExecutionStateMerkleRootHash()) == 0 {
+		h := int(state.ResultsBlockHeader.BlockHeight())
+		if root, err := d.GetStateHash(ctx, h-1); err == nil {
+			state = CommitStateDiff().WithHeader(state.ResultsBlockHeader).WithPreExecutionStateRootHash(root).WithDiffs(state.ContractStateDiffs).Build()
+		}
+	}
 	return d.service.CommitStateDiff(ctx, state)
 }
 
@@ -29,7 +29,7 @@ func TestReflectsSuccessfulCommit(t *testing.T) {
 	with.Context(func(ctx context.Context) {
 		d := NewStateStorageDriver(1)
 		heightBefore, _, _ := d.GetBlockHeightAndTimestamp(ctx)
-		d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithBlockTimestamp(6579).WithDiff(builders.ContractStateDiff().Build()).Build())
+		d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithBlockTimestamp(6579).WithDiff(builders.ContractStateDiff().Build()).Build())
 		heightAfter, timestampAfter, err := d.GetBlockHeightAndTimestamp(ctx)
 
 		require.NoError(t, err, "unexpected error")
@@ -42,10 +42,10 @@ func TestIgnoreFailedCommit(t *testing.T) {
 	with.Context(func(ctx context.Context) {
 		d := NewStateStorageDriver(1)
 		stateDiff := builders.ContractStateDiff().Build()
-		d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(stateDiff).Build())
-		d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(2).WithDiff(stateDiff).Build())
+		d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(stateDiff).Build())
+		d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(2).WithDiff(stateDiff).Build())
 		heightBefore, _, _ := d.GetBlockHeightAndTimestamp(ctx)
-		d.service.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(stateDiff).Build())
+		d.CommitStateDiff(ctx, CommitStateDiff().WithBlockHeight(1).WithDiff(stateDiff).Build())
 		heightAfter, _, err := d.GetBlockHeightAndTimestamp(ctx)
 
 		require.NoError(t, err, "unexpected error")
@@ -0,0 +1,97 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetStateProofOfExistingKeyVerifiesAgainstStateRoot(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		for i := 0; i < 20; i++ {
+			d.CommitValuePairs(ctx, "foo", fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+		}
+
+		output, err := d.GetStateProof(ctx, 20, "foo", "key7")
+		require.NoError(t, err)
+
+		root, err := d.GetStateHash(ctx, 20)
+		require.NoError(t, err)
+		require.EqualValues(t, root, output.StateMerkleRootHash, "proof should be against the state root of the requested height")
+		require.EqualValues(t, "value7", output.Value)
+		require.NoError(t, output.Proof.Verify(root, "foo", []byte("key7"), output.Value))
+
+		require.Error(t, output.Proof.Verify(root, "foo", []byte("key7"), []byte("value8")), "proof verified a value that is not in the state")
+		require.Error(t, output.Proof.Verify(root, "foo", []byte("key8"), []byte("value7")), "proof verified a different key")
+		require.Error(t, output.Proof.Verify(root, "foo", []byte("key7"), []byte{}), "proof of inclusion verified as exclusion")
+	})
+}
+
+func TestGetStateProofOfMissingKeyProvesExclusion(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		for i := 0; i < 20; i++ {
+			d.CommitValuePairs(ctx, "foo", fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+		}
+
+		output, err := d.GetStateProof(ctx, 0, "foo", "missing")
+		require.NoError(t, err)
+		require.EqualValues(t, 20, output.BlockHeight, "zero block height should default to the last committed block")
+		require.Empty(t, output.Value, "missing key should return the zero value")
+		require.NoError(t, output.Proof.Verify(output.StateMerkleRootHash, "foo", []byte("missing"), output.Value))
+		require.Error(t, output.Proof.Verify(output.StateMerkleRootHash, "foo", []byte("missing"), []byte("bar")), "exclusion proof verified a value")
+	})
+}
+
+func TestGetStateProofOfOlderRevision(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(5)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		d.CommitValuePairs(ctx, "foo", "bar", "qux")
+
+		older, err := d.GetStateProof(ctx, 1, "foo", "bar")
+		require.NoError(t, err)
+		require.EqualValues(t, "baz", older.Value)
+
+		newer, err := d.GetStateProof(ctx, 2, "foo", "bar")
+		require.NoError(t, err)
+		require.EqualValues(t, "qux", newer.Value)
+
+		require.NoError(t, older.Proof.Verify(older.StateMerkleRootHash, "foo", []byte("bar"), []byte("baz")))
+		require.Error(t, older.Proof.Verify(newer.StateMerkleRootHash, "foo", []byte("bar"), []byte("baz")), "proof verified against the state root of a different height")
+	})
+}
+
+func TestGetStateProofOfEmptyState(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(1)
+
+		output, err := d.GetStateProof(ctx, 0, "foo", "bar")
+		require.NoError(t, err)
+		require.NoError(t, output.Proof.Verify(output.StateMerkleRootHash, "foo", []byte("bar"), []byte{}))
+		require.Error(t, output.Proof.Verify(output.StateMerkleRootHash, "foo", []byte("bar"), []byte("baz")))
+	})
+}
+
+func TestGetStateProofFailsForEvictedHeight(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		d := NewStateStorageDriver(1)
+		d.CommitValuePairs(ctx, "foo", "bar", "baz")
+		d.CommitValuePairs(ctx, "foo", "bar", "qux")
+
+		_, err := d.GetStateProof(ctx, 0, "", "bar")
+		require.Error(t, err, "expected error for missing contract name")
+
+		_, err = d.GetStateProof(ctx, 1, "foo", "bar")
+		require.Error(t, err, "expected error for a height that is no longer kept")
+	})
+}
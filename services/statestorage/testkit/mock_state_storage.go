@@ -0,0 +1,40 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package testkit
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+)
+
+type MockStateStorage struct {
+	services.MockStateStorage
+}
+
+func (s *MockStateStorage) GetStateProof(ctx context.Context, input *statestorage.GetStateProofInput) (*statestorage.GetStateProofOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*statestorage.GetStateProofOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockStateStorage) GetBlockInfo(ctx context.Context, input *statestorage.GetBlockInfoInput) (*statestorage.GetBlockInfoOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*statestorage.GetBlockInfoOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
+
+func (s *MockStateStorage) ExportStateSnapshot(ctx context.Context, w statestorage.StateSnapshotWriter) error {
+	ret := s.Called(ctx, w)
+	return ret.Error(0)
+}
@@ -47,6 +47,11 @@ func (s *service) AddNewTransaction(ctx context.Context, input *services.AddNewT
 		return s.addTransactionOutputFor(nil, status), err
 	}
 
+	if err := s.takeRateLimitTokens(input.SignedTransaction, nil); err != nil {
+		logger.Info("transaction is over the rate limit", log.Error(err))
+		return s.addTransactionOutputFor(nil, err.TransactionStatus), err
+	}
+
 	// TK: this was originally in the body of this function but extracted to a function to make s.addCommitLock more fine grained
 	output, err := s.addToPendingPoolAfterCheckingCommitted(input.SignedTransaction, txHash, logger)
 	if output != nil {
@@ -79,6 +84,20 @@ func (s *service) addToPendingPoolAfterCheckingCommitted(tx *protocol.SignedTran
 	return nil, nil
 }
 
+// takeRateLimitTokens takes a token of the signer of a transaction and, for transactions forwarded by another node, a
+// token of the gateway that forwarded it. transactions over the limit are rejected for congestion
+func (s *service) takeRateLimitTokens(tx *protocol.SignedTransaction, gatewayNodeAddress primitives.NodeAddress) *ErrTransactionRateLimited {
+	if gatewayNodeAddress != nil && !s.gatewayRateLimiter.allow(gatewayNodeAddress.KeyForMap()) {
+		s.metrics.rateLimitedByGateway.Inc()
+		return newErrTransactionRateLimited("gateway")
+	}
+	if !s.signerRateLimiter.allow(string(tx.Transaction().Signer().Raw())) {
+		s.metrics.rateLimitedBySigner.Inc()
+		return newErrTransactionRateLimited("signer")
+	}
+	return nil
+}
+
 func (s *service) validateSingleTransactionForPreOrder(ctx context.Context, transaction *protocol.SignedTransaction) error {
 	lastCommittedBlockHeight, _, estimatedCurrentBlockReferenceTime := s.lastCommittedBlockInfo()
 	// the real pre order checks will run during consensus on some future new block, try to estimate its height and timestamp as closely as possible
@@ -12,6 +12,10 @@ import (
 	"github.com/orbs-network/scribe/log"
 )
 
+// withdrawnTransactionStatus reports transactions cancelled or replaced by their signer while pending. the protocol spec
+// has no status for withdrawn transactions, so they are reported as having no record
+const withdrawnTransactionStatus = protocol.TRANSACTION_STATUS_NO_RECORD_FOUND
+
 type ErrTransactionRejected struct {
 	TransactionStatus protocol.TransactionStatus
 	Expected          *log.Field
@@ -28,3 +32,22 @@ func (e *ErrTransactionRejected) Error() string {
 		return fmt.Sprintf("transaction rejected: %s", e.TransactionStatus)
 	}
 }
+
+// ErrTransactionRateLimited rejects a transaction over the rate limit of its signer or of the gateway that forwarded it.
+// the protocol spec has a single congestion status, so such transactions are reported as congested like those arriving
+// at a full pool, and the error, returned to the client in the error details of the response, tells the two apart
+type ErrTransactionRateLimited struct {
+	ErrTransactionRejected
+	Limit string // the rate limit exceeded, signer or gateway
+}
+
+func newErrTransactionRateLimited(limit string) *ErrTransactionRateLimited {
+	return &ErrTransactionRateLimited{ErrTransactionRejected: ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_REJECTED_CONGESTION}, Limit: limit}
+}
+
+func (e *ErrTransactionRateLimited) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("transaction rejected: %s (%s rate limit exceeded)", e.TransactionStatus, e.Limit)
+}
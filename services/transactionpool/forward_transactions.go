@@ -54,6 +54,10 @@ func (s *service) HandleForwardedTransactions(ctx context.Context, input *gossip
 
 	for _, tx := range input.Message.SignedTransactions {
 		txHash := digest.CalcTxHash(tx.Transaction())
+		if err := s.takeRateLimitTokens(tx, sender.SenderNodeAddress()); err != nil {
+			logger.Info("dropping forwarded transaction over the rate limit", log.Error(err), log.Stringable("gateway", sender.SenderNodeAddress()), logfields.Transaction(txHash))
+			continue
+		}
 		logger.Info("adding forwarded transaction to the pool", log.String("flow", "checkpoint"), logfields.Transaction(txHash))
 		if _, err := s.pendingPool.add(tx, sender.SenderNodeAddress()); err != nil {
 			logger.Error("error adding forwarded transaction to pending pool", log.Error(err), log.Stringable("transaction", tx), logfields.Transaction(txHash))
@@ -115,6 +115,8 @@ func (s *service) GetTransactionsForOrdering(ctx context.Context, input *service
 			sizeLimit:            input.MaxTransactionsSetSizeKb * 1024,
 		}
 		batch.fetchUsing(s.pendingPool)
+		// marked before they are validated, so a signer cannot withdraw a transaction once it may be proposed
+		batch.incomingTransactions = s.pendingPool.markProposed(batch.incomingTransactions, input.CurrentBlockHeight)
 		batch.filterInvalidTransactions(ctx, s.validationContext, s.committedPool, proposedBlockTimestamp)
 		return batch, batch.runPreOrderValidations(ctx, pov, input.CurrentBlockHeight, proposedBlockTimestamp, proposedReferenceTime)
 	}
@@ -8,6 +8,7 @@ package transactionpool
 
 import (
 	"context"
+	"fmt"
 	"github.com/orbs-network/crypto-lib-go/crypto/signer"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
@@ -16,7 +17,6 @@ import (
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
-	"github.com/orbs-network/orbs-spec/types/go/services/gossiptopics"
 	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
 	"github.com/orbs-network/scribe/log"
 )
@@ -25,7 +25,7 @@ const MetricLastCommittedTime = "TransactionPool.LastCommitted.TimeNano" // neve
 
 func NewTransactionPool(ctx context.Context,
 	maybeClock adapter.Clock,
-	gossip gossiptopics.TransactionRelay,
+	gossip TransactionRelay,
 	virtualMachine services.VirtualMachine,
 	signer signer.Signer,
 	blockHeightReporter BlockHeightReporter,
@@ -43,6 +43,12 @@ func NewTransactionPool(ctx context.Context,
 
 	logger := parent.WithTags(LogTag)
 
+	orderingPolicy, err := NewTransactionOrderingPolicy(config.TransactionPoolOrderingPolicy(), config.TransactionPoolOrderingContractQuotaPercent())
+	if err != nil {
+		panic(fmt.Sprintf("invalid transaction ordering policy: %s", err))
+	}
+	pendingPool.orderingPolicy = orderingPolicy
+
 	txForwarder := NewTransactionForwarder(ctx, logger, signer, config, gossip)
 
 	s := &service{
@@ -62,14 +68,19 @@ func NewTransactionPool(ctx context.Context,
 	}
 
 	s.validationContext = s.createValidationContext()
+	s.signerRateLimiter = newRateLimiter(config.TransactionPoolSignerRateLimitPerSecond(), config.TransactionPoolSignerRateLimitBurst(), s.clock.CurrentTime)
+	s.gatewayRateLimiter = newRateLimiter(config.TransactionPoolGatewayRateLimitPerSecond(), config.TransactionPoolGatewayRateLimitBurst(), s.clock.CurrentTime)
 	s.lastCommitted.timestamp = primitives.TimestampNano(0) // this is so that we reject transactions on startup, before any block has been committed
 	s.lastCommitted.referenceTime = primitives.TimestampSeconds(0)
 	s.metrics.blockHeight = metricFactory.NewGauge("TransactionPool.BlockHeight")
 	s.metrics.lastCommittedTimestamp = metricFactory.NewGauge(MetricLastCommittedTime)
 	s.metrics.commitRate = metricFactory.NewRate("TransactionPool.CommitRate")
 	s.metrics.commitCount = metricFactory.NewGauge("TransactionPool.TotalCommits.Count")
+	s.metrics.rateLimitedBySigner = metricFactory.NewCounter("TransactionPool.RateLimited.Signer.Count")
+	s.metrics.rateLimitedByGateway = metricFactory.NewCounter("TransactionPool.RateLimited.Gateway.Count")
 
 	gossip.RegisterTransactionRelayHandler(s)
+	gossip.RegisterWithdrawnTransactionHandler(s)
 	pendingPool.onTransactionRemoved = s.onTransactionError
 
 	s.Supervise(startCleaningProcess(ctx, "committed pool", config.TransactionPoolCommittedPoolClearExpiredInterval, config.TransactionExpirationWindow, s.committedPool, s.lastCommittedBlockInfo, logger))
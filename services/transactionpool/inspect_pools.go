@@ -0,0 +1,124 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"sort"
+	"time"
+)
+
+// PoolInspector lists the transactions held by the pools, to debug transactions which are not committed
+type PoolInspector interface {
+	InspectPendingPool(filter *PendingPoolFilter) []*PendingTransactionInfo
+	InspectCommittedPool(limit int) []*CommittedTransactionInfo
+}
+
+type PendingPoolFilter struct {
+	SignerPublicKey primitives.Ed25519PublicKey // nil for any signer
+	ContractName    primitives.ContractName     // empty for any contract
+	MinTimeInPool   time.Duration
+	Limit           int // zero for no limit
+}
+
+type PendingTransactionInfo struct {
+	TxHash             primitives.Sha256
+	SignerPublicKey    primitives.Ed25519PublicKey
+	ContractName       primitives.ContractName
+	MethodName         primitives.MethodName
+	Timestamp          primitives.TimestampNano
+	GatewayNodeAddress primitives.NodeAddress
+	SizeInBytes        uint32
+	TimeInPool         time.Duration
+}
+
+type CommittedTransactionInfo struct {
+	TxHash          primitives.Sha256
+	ExecutionResult protocol.ExecutionResult
+	BlockHeight     primitives.BlockHeight
+	BlockTimestamp  primitives.TimestampNano
+	SizeInBytes     uint32
+}
+
+// InspectPendingPool returns the pending transactions matching the filter, from the longest pending one
+func (s *service) InspectPendingPool(filter *PendingPoolFilter) []*PendingTransactionInfo {
+	return s.pendingPool.inspect(filter, time.Now())
+}
+
+// InspectCommittedPool returns the transactions committed most recently
+func (s *service) InspectCommittedPool(limit int) []*CommittedTransactionInfo {
+	return s.committedPool.inspect(limit)
+}
+
+func (p *pendingTxPool) inspect(filter *PendingPoolFilter, now time.Time) []*PendingTransactionInfo {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var infos []*PendingTransactionInfo
+	for e := p.transactionList.Back(); e != nil && (filter.Limit == 0 || len(infos) < filter.Limit); e = e.Prev() {
+		tx := e.Value.(*protocol.SignedTransaction)
+		txHash := digest.CalcTxHash(tx.Transaction())
+		ptx, found := p.transactionsByHash[txHash.KeyForMap()]
+		if !found {
+			continue
+		}
+
+		var signerPublicKey primitives.Ed25519PublicKey
+		if signer := tx.Transaction().Signer(); signer.IsSchemeEddsa() {
+			signerPublicKey = signer.Eddsa().SignerPublicKey()
+		}
+		timeInPool := now.Sub(ptx.timeAdded)
+
+		if filter.SignerPublicKey != nil && !filter.SignerPublicKey.Equal(signerPublicKey) {
+			continue
+		}
+		if filter.ContractName != "" && filter.ContractName != tx.Transaction().ContractName() {
+			continue
+		}
+		if timeInPool < filter.MinTimeInPool {
+			continue
+		}
+
+		infos = append(infos, &PendingTransactionInfo{
+			TxHash:             txHash,
+			SignerPublicKey:    signerPublicKey,
+			ContractName:       tx.Transaction().ContractName(),
+			MethodName:         tx.Transaction().MethodName(),
+			Timestamp:          tx.Transaction().Timestamp(),
+			GatewayNodeAddress: ptx.gatewayNodeAddress,
+			SizeInBytes:        sizeOfSignedTransaction(tx),
+			TimeInPool:         timeInPool,
+		})
+	}
+	return infos
+}
+
+func (p *committedTxPool) inspect(limit int) []*CommittedTransactionInfo {
+	p.RLock()
+	defer p.RUnlock()
+
+	infos := make([]*CommittedTransactionInfo, 0, len(p.transactions))
+	for _, tx := range p.transactions {
+		infos = append(infos, &CommittedTransactionInfo{
+			TxHash:          tx.receipt.Txhash(),
+			ExecutionResult: tx.receipt.ExecutionResult(),
+			BlockHeight:     tx.blockHeight,
+			BlockTimestamp:  tx.blockTimestamp,
+			SizeInBytes:     sizeOfCommittedTransaction(tx),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].BlockHeight > infos[j].BlockHeight
+	})
+	if limit > 0 && len(infos) > limit {
+		infos = infos[:limit]
+	}
+	return infos
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/test/rand"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestInspectPendingPool_FiltersBySignerContractAndAge(t *testing.T) {
+	p := makePendingPool()
+	a := signedBy(1, 2)
+	b := builders.TransferTransaction().WithEd25519Signer(keys.Ed25519KeyPairForTests(2)).WithContract("Other").Build()
+	add(p, a...)
+	add(p, b)
+
+	bySigner := p.inspect(&PendingPoolFilter{SignerPublicKey: keys.Ed25519KeyPairForTests(1).PublicKey()}, time.Now())
+	require.Len(t, bySigner, 2, "should list the transactions of the signer only")
+	require.EqualValues(t, digest.CalcTxHash(a[0].Transaction()), bySigner[0].TxHash, "should list the longest pending transaction first")
+	require.EqualValues(t, nodeAddress, bySigner[0].GatewayNodeAddress)
+	require.EqualValues(t, len(a[0].Raw()), bySigner[0].SizeInBytes)
+
+	byContract := p.inspect(&PendingPoolFilter{ContractName: "Other"}, time.Now())
+	require.Len(t, byContract, 1, "should list the transactions of the contract only")
+	require.EqualValues(t, keys.Ed25519KeyPairForTests(2).PublicKey(), byContract[0].SignerPublicKey)
+
+	require.Empty(t, p.inspect(&PendingPoolFilter{MinTimeInPool: time.Hour}, time.Now()), "no transaction is pending for an hour")
+	require.Len(t, p.inspect(&PendingPoolFilter{MinTimeInPool: time.Hour}, time.Now().Add(2*time.Hour)), 3, "all transactions are pending for an hour two hours from now")
+	require.Len(t, p.inspect(&PendingPoolFilter{Limit: 1}, time.Now()), 1, "should not list more than the limit")
+}
+
+func TestInspectCommittedPool_ListsMostRecentlyCommittedFirst(t *testing.T) {
+	ctrlRand := rand.NewControlledRand(t)
+	p := NewCommittedPool(func() time.Duration { return time.Minute }, metric.NewRegistry())
+	for height := 1; height <= 3; height++ {
+		p.add(builders.TransactionReceipt().WithRandomHash(ctrlRand).Build(), primitives.BlockHeight(height), primitives.TimestampNano(height))
+	}
+
+	infos := p.inspect(2)
+
+	require.Len(t, infos, 2, "should not list more than the limit")
+	require.EqualValues(t, 3, infos[0].BlockHeight)
+	require.EqualValues(t, 2, infos[1].BlockHeight)
+
+	p.clearTransactionsOlderThan(context.Background(), primitives.TimestampNano(time.Hour))
+	require.Empty(t, p.inspect(0))
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/pkg/errors"
+)
+
+const (
+	OrderingPolicyFifo             = "fifo"               // oldest transactions first
+	OrderingPolicySignerRoundRobin = "signer-round-robin" // one transaction of each signer in turn, signers ordered by their oldest transaction
+	OrderingPolicyContractQuota    = "contract-quota"     // oldest transactions first, up to a share of the block per contract
+)
+
+// PendingTransactions walks the pending pool from the oldest transaction until visit returns false
+type PendingTransactions func(visit func(tx *protocol.SignedTransaction) (wantsMore bool))
+
+// TransactionOrderingPolicy picks the transactions proposed for the next block out of the pending pool.
+// policies only depend on the pending transactions and their arrival order, never on the clock or on randomness, and
+// only change which pending transactions are proposed and in what order. so validators accept the proposals of a leader
+// through ValidateTransactionsForOrdering whatever policy each of them runs
+type TransactionOrderingPolicy interface {
+	SelectTransactions(pending PendingTransactions, maxNumOfTransactions uint32, sizeLimitInBytes uint32) Transactions
+}
+
+// NewTransactionOrderingPolicy returns the policy of the given name, contractQuotaPercent is the share of the block
+// each contract may fill under OrderingPolicyContractQuota
+func NewTransactionOrderingPolicy(name string, contractQuotaPercent uint32) (TransactionOrderingPolicy, error) {
+	switch name {
+	case OrderingPolicyFifo, "":
+		return &fifoOrderingPolicy{}, nil
+	case OrderingPolicySignerRoundRobin:
+		return &signerRoundRobinOrderingPolicy{}, nil
+	case OrderingPolicyContractQuota:
+		if contractQuotaPercent == 0 || contractQuotaPercent > 100 {
+			return nil, errors.Errorf("contract quota of %d%% is not within 1-100%%", contractQuotaPercent)
+		}
+		return &contractQuotaOrderingPolicy{quotaPercent: contractQuotaPercent}, nil
+	default:
+		return nil, errors.Errorf("unknown transaction ordering policy %s", name)
+	}
+}
+
+// blockFiller accumulates transactions within the limits of a block, a zero size limit is no limit
+type blockFiller struct {
+	maxNumOfTransactions uint32
+	sizeLimitInBytes     uint32
+	sizeInBytes          uint32
+	txs                  Transactions
+}
+
+func (b *blockFiller) isFull() bool {
+	return uint32(len(b.txs)) >= b.maxNumOfTransactions
+}
+
+// add returns false if the transaction does not fit in the block
+func (b *blockFiller) add(tx *protocol.SignedTransaction) bool {
+	txSize := sizeOfSignedTransaction(tx)
+	if b.isFull() || b.sizeLimitInBytes > 0 && b.sizeInBytes+txSize > b.sizeLimitInBytes {
+		return false
+	}
+	b.sizeInBytes += txSize
+	b.txs = append(b.txs, tx)
+	return true
+}
+
+type fifoOrderingPolicy struct{}
+
+func (p *fifoOrderingPolicy) SelectTransactions(pending PendingTransactions, maxNumOfTransactions uint32, sizeLimitInBytes uint32) Transactions {
+	block := &blockFiller{maxNumOfTransactions: maxNumOfTransactions, sizeLimitInBytes: sizeLimitInBytes}
+	pending(func(tx *protocol.SignedTransaction) bool {
+		return block.add(tx)
+	})
+	return block.txs
+}
+
+type signerRoundRobinOrderingPolicy struct{}
+
+func (p *signerRoundRobinOrderingPolicy) SelectTransactions(pending PendingTransactions, maxNumOfTransactions uint32, sizeLimitInBytes uint32) Transactions {
+	// no signer gets more than a block of transactions, so their queues are capped at the size of a block
+	var queues [][]*protocol.SignedTransaction
+	queueBySigner := make(map[string]int)
+	pending(func(tx *protocol.SignedTransaction) bool {
+		signer := string(tx.Transaction().Signer().Raw())
+		i, found := queueBySigner[signer]
+		if !found {
+			i = len(queues)
+			queueBySigner[signer] = i
+			queues = append(queues, nil)
+		}
+		if uint32(len(queues[i])) < maxNumOfTransactions {
+			queues[i] = append(queues[i], tx)
+		}
+		return true
+	})
+
+	block := &blockFiller{maxNumOfTransactions: maxNumOfTransactions, sizeLimitInBytes: sizeLimitInBytes}
+	for round := 0; ; round++ {
+		pickedAny := false
+		for _, queue := range queues {
+			if round >= len(queue) {
+				continue
+			}
+			if !block.add(queue[round]) {
+				return block.txs
+			}
+			pickedAny = true
+		}
+		if !pickedAny {
+			return block.txs
+		}
+	}
+}
+
+type contractQuotaOrderingPolicy struct {
+	quotaPercent uint32
+}
+
+func (p *contractQuotaOrderingPolicy) SelectTransactions(pending PendingTransactions, maxNumOfTransactions uint32, sizeLimitInBytes uint32) Transactions {
+	quota := uint64(maxNumOfTransactions) * uint64(p.quotaPercent) / 100
+	if quota == 0 {
+		quota = 1
+	}
+
+	countByContract := make(map[primitives.ContractName]uint64)
+	block := &blockFiller{maxNumOfTransactions: maxNumOfTransactions, sizeLimitInBytes: sizeLimitInBytes}
+	pending(func(tx *protocol.SignedTransaction) bool {
+		contract := tx.Transaction().ContractName()
+		if countByContract[contract] >= quota {
+			return !block.isFull()
+		}
+		countByContract[contract]++
+		return block.add(tx)
+	})
+	return block.txs
+}
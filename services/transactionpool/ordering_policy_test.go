@@ -0,0 +1,98 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSignerRoundRobinOrderingPolicy_TakesOneTransactionOfEachSignerInTurn(t *testing.T) {
+	p := makePendingPoolWithOrderingPolicy(t, OrderingPolicySignerRoundRobin, 0)
+
+	flooder := signedBy(0, 5)
+	a := signedBy(1, 2)
+	b := signedBy(2, 1)
+	add(p, flooder...)
+	add(p, a...)
+	add(p, b...)
+
+	txSet := p.getBatch(6, 0)
+
+	require.Equal(t, Transactions{flooder[0], a[0], b[0], flooder[1], a[1], flooder[2]}, txSet, "got transactions in wrong order")
+}
+
+func TestSignerRoundRobinOrderingPolicy_DoesNotExceedSizeLimitInBytes(t *testing.T) {
+	p := makePendingPoolWithOrderingPolicy(t, OrderingPolicySignerRoundRobin, 0)
+
+	a := signedBy(1, 2)
+	b := signedBy(2, 2)
+	add(p, a...)
+	add(p, b...)
+
+	slightlyMoreThanThreeTransactionsInBytes := uint32(len(a[0].Raw()) + len(b[0].Raw()) + len(a[1].Raw()) + 1)
+	txSet := p.getBatch(4, slightlyMoreThanThreeTransactionsInBytes)
+
+	require.Equal(t, Transactions{a[0], b[0], a[1]}, txSet)
+}
+
+func TestContractQuotaOrderingPolicy_LimitsShareOfEachContract(t *testing.T) {
+	p := makePendingPoolWithOrderingPolicy(t, OrderingPolicyContractQuota, 50)
+
+	var popular Transactions
+	for i := 0; i < 4; i++ {
+		popular = append(popular, builders.TransferTransaction().WithContract("Popular").Build())
+	}
+	other := builders.TransferTransaction().WithContract("Other").Build()
+	add(p, popular...)
+	add(p, other)
+
+	txSet := p.getBatch(4, 0)
+
+	require.Equal(t, Transactions{popular[0], popular[1], other}, txSet, "expected a contract to fill no more than half of the block")
+}
+
+func TestOrderingPolicy_IsDeterministic(t *testing.T) {
+	var txs Transactions
+	for i := 0; i < 30; i++ {
+		txs = append(txs, builders.TransferTransaction().WithEd25519Signer(keys.Ed25519KeyPairForTests(i%4)).WithContract([]string{"A", "B", "C"}[i%3]).Build())
+	}
+
+	for _, name := range []string{OrderingPolicyFifo, OrderingPolicySignerRoundRobin, OrderingPolicyContractQuota} {
+		p1 := makePendingPoolWithOrderingPolicy(t, name, 20)
+		p2 := makePendingPoolWithOrderingPolicy(t, name, 20)
+		add(p1, txs...)
+		add(p2, txs...)
+
+		require.Equal(t, p1.getBatch(10, 0), p2.getBatch(10, 0), "expected policy %s to select the same transactions for the same pool", name)
+	}
+}
+
+func TestNewTransactionOrderingPolicy_RejectsUnknownPolicyAndInvalidQuota(t *testing.T) {
+	_, err := NewTransactionOrderingPolicy("random", 0)
+	require.Error(t, err)
+
+	_, err = NewTransactionOrderingPolicy(OrderingPolicyContractQuota, 0)
+	require.Error(t, err)
+}
+
+func makePendingPoolWithOrderingPolicy(t *testing.T, name string, contractQuotaPercent uint32) *pendingTxPool {
+	p := makePendingPool()
+	policy, err := NewTransactionOrderingPolicy(name, contractQuotaPercent)
+	require.NoError(t, err)
+	p.orderingPolicy = policy
+	return p
+}
+
+func signedBy(signer int, numOfTransactions int) (txs Transactions) {
+	for i := 0; i < numOfTransactions; i++ {
+		txs = append(txs, builders.TransferTransaction().WithEd25519Signer(keys.Ed25519KeyPairForTests(signer)).Build())
+	}
+	return
+}
@@ -10,9 +10,12 @@ import (
 	"container/list"
 	"context"
 	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/govnr"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
 	"sync"
 	"time"
 )
@@ -23,9 +26,11 @@ func NewPendingPool(pendingPoolSizeInBytes func() uint32, metricFactory metric.F
 	return &pendingTxPool{
 		pendingPoolSizeInBytes: pendingPoolSizeInBytes,
 		transactionsByHash:     make(map[string]*pendingTransaction),
+		withdrawnByHash:        make(map[string]primitives.TimestampNano),
 		transactionList:        list.New(),
 		lock:                   &sync.RWMutex{},
 		onNewTransaction:       onNewTransaction,
+		orderingPolicy:         &fifoOrderingPolicy{},
 
 		metrics: newPendingPoolMetrics(metricFactory),
 	}
@@ -36,6 +41,7 @@ type pendingTransaction struct {
 	transaction        *protocol.SignedTransaction
 	listElement        *list.Element
 	timeAdded          time.Time
+	proposedForHeight  primitives.BlockHeight // of the last block proposed with the transaction
 }
 
 type pendingPoolMetrics struct {
@@ -59,12 +65,15 @@ func newPendingPoolMetrics(factory metric.Factory) *pendingPoolMetrics {
 type pendingTxPool struct {
 	currentSizeInBytes uint32
 	transactionsByHash map[string]*pendingTransaction
+	withdrawnByHash    map[string]primitives.TimestampNano // by withdrawnKey, until when to keep each withdrawn transaction out
 	transactionList    *list.List
 	onNewTransaction   func()
 	lock               *sync.RWMutex
 
 	pendingPoolSizeInBytes func() uint32
 	onTransactionRemoved   transactionRemovedListener
+	orderingPolicy         TransactionOrderingPolicy
+	journal                *pendingPoolJournal
 
 	metrics *pendingPoolMetrics
 }
@@ -85,6 +94,12 @@ func (p *pendingTxPool) add(transaction *protocol.SignedTransaction, gatewayNode
 		return nil, &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_DUPLICATE_TRANSACTION_ALREADY_PENDING}
 	}
 
+	if signer := transaction.Transaction().Signer(); signer.IsSchemeEddsa() {
+		if _, withdrawn := p.withdrawnByHash[withdrawnKey(key, signer.Eddsa().SignerPublicKey())]; withdrawn {
+			return nil, &ErrTransactionRejected{TransactionStatus: withdrawnTransactionStatus}
+		}
+	}
+
 	p.currentSizeInBytes += size
 	p.transactionsByHash[key.KeyForMap()] = &pendingTransaction{
 		transaction:        transaction,
@@ -93,6 +108,10 @@ func (p *pendingTxPool) add(transaction *protocol.SignedTransaction, gatewayNode
 		timeAdded:          time.Now(),
 	}
 
+	if p.journal != nil {
+		p.journal.added(transaction, gatewayNodeAddress)
+	}
+
 	p.metrics.transactionCountGauge.Inc()
 	p.metrics.poolSizeInBytesGauge.AddUint32(size)
 	p.metrics.transactionRatePerSecond.Measure(1)
@@ -114,12 +133,107 @@ func (p *pendingTxPool) remove(ctx context.Context, txHash primitives.Sha256, re
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	return p.removeUnderMutex(ctx, txHash, removalReason)
+}
+
+// withdraw removes a pending transaction on behalf of its signer, and keeps it out of the pool until it expires so a
+// copy forwarded later by another node is not added back. a transaction which is not pending yet is kept out until
+// latestTimestamp, the latest timestamp it may carry, when it arrives signed by signerPublicKey. a transaction proposed
+// for a block above lastCommittedHeight may still be committed, so it is not withdrawn
+func (p *pendingTxPool) withdraw(ctx context.Context, txHash primitives.Sha256, signerPublicKey primitives.Ed25519PublicKey, lastCommittedHeight primitives.BlockHeight, latestTimestamp primitives.TimestampNano) *ErrTransactionRejected {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pendingTx, ok := p.transactionsByHash[txHash.KeyForMap()]
+	if !ok {
+		p.keepOutUnderMutex(withdrawnKey(txHash, signerPublicKey), latestTimestamp)
+		return nil
+	}
+
+	signer := pendingTx.transaction.Transaction().Signer()
+	if !signer.IsSchemeEddsa() || !signer.Eddsa().SignerPublicKey().Equal(signerPublicKey) {
+		return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH}
+	}
+
+	if pendingTx.proposedForHeight > lastCommittedHeight {
+		return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_PENDING, Expected: log.Uint64("proposed-for-height", uint64(pendingTx.proposedForHeight)), Actual: log.Uint64("last-committed-height", uint64(lastCommittedHeight))}
+	}
+
+	p.keepOutUnderMutex(withdrawnKey(txHash, signerPublicKey), pendingTx.transaction.Transaction().Timestamp())
+	p.removeUnderMutex(ctx, txHash, withdrawnTransactionStatus)
+	return nil
+}
+
+// keepOutUnderMutex keeps the withdrawn transaction out of the pool until keepUntil, and journals it so that it stays
+// out across a restart
+func (p *pendingTxPool) keepOutUnderMutex(key string, keepUntil primitives.TimestampNano) {
+	p.withdrawnByHash[key] = keepUntil
+	if p.journal != nil {
+		p.journal.withdrawn(key, keepUntil)
+	}
+}
+
+// restoreWithdrawn keeps out of the pool the journaled withdrawn transactions which are not expired
+func (p *pendingTxPool) restoreWithdrawn(withdrawn map[string]primitives.TimestampNano, expiredBefore primitives.TimestampNano) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for key, keepUntil := range withdrawn {
+		if keepUntil >= expiredBefore {
+			p.withdrawnByHash[key] = keepUntil
+		}
+	}
+}
+
+// isWithdrawn tells whether the signer of the transaction withdrew it
+func (p *pendingTxPool) isWithdrawn(txHash primitives.Sha256, transaction *protocol.SignedTransaction) bool {
+	signer := transaction.Transaction().Signer()
+	if !signer.IsSchemeEddsa() {
+		return false
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	_, withdrawn := p.withdrawnByHash[withdrawnKey(txHash, signer.Eddsa().SignerPublicKey())]
+	return withdrawn
+}
+
+// withdrawnKey keys withdrawn transactions by their signer too, so that withdrawing a transaction which is not pending
+// yet keeps it out only when it arrives signed by the key which withdrew it
+func withdrawnKey(txHash primitives.Sha256, signerPublicKey primitives.Ed25519PublicKey) string {
+	return txHash.KeyForMap() + string(signerPublicKey)
+}
+
+// markProposed marks the given transactions as proposed for the block at height, and returns those still pending. a
+// transaction withdrawn since it was fetched for the proposal is left out of it
+func (p *pendingTxPool) markProposed(txs Transactions, height primitives.BlockHeight) Transactions {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pending := make(Transactions, 0, len(txs))
+	for _, tx := range txs {
+		if ptx, found := p.transactionsByHash[digest.CalcTxHash(tx.Transaction()).KeyForMap()]; found {
+			if height > ptx.proposedForHeight {
+				ptx.proposedForHeight = height
+			}
+			pending = append(pending, tx)
+		}
+	}
+	return pending
+}
+
+func (p *pendingTxPool) removeUnderMutex(ctx context.Context, txHash primitives.Sha256, removalReason protocol.TransactionStatus) *primitives.NodeAddress {
 	pendingTx, ok := p.transactionsByHash[txHash.KeyForMap()]
 	if ok {
 		delete(p.transactionsByHash, txHash.KeyForMap())
 		p.currentSizeInBytes -= sizeOfSignedTransaction(pendingTx.transaction)
 		p.transactionList.Remove(pendingTx.listElement)
 
+		if p.journal != nil {
+			p.journal.removed(txHash)
+			p.journal.requestCompactionIfNeeded(len(p.transactionsByHash) + len(p.withdrawnByHash))
+		}
+
 		if p.onTransactionRemoved != nil {
 			p.onTransactionRemoved(ctx, txHash, removalReason)
 		}
@@ -138,34 +252,66 @@ func (p *pendingTxPool) getBatch(maxNumOfTransactions uint32, sizeLimitInBytes u
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	var sizeInBytes uint32
-
-	e := p.transactionList.Back()
-	for {
-		if e == nil {
-			break
+	txs = p.orderingPolicy.SelectTransactions(func(visit func(tx *protocol.SignedTransaction) bool) {
+		for e := p.transactionList.Back(); e != nil; e = e.Prev() {
+			if !visit(e.Value.(*protocol.SignedTransaction)) {
+				return
+			}
 		}
+	}, maxNumOfTransactions, sizeLimitInBytes)
 
-		if uint32(len(txs)) >= maxNumOfTransactions {
-			break
-		}
+	for _, tx := range txs {
+		p.transactionPickedFromQueueUnderMutex(tx)
+	}
 
-		tx := e.Value.(*protocol.SignedTransaction)
-		//
-		txSize := sizeOfSignedTransaction(tx)
-		if sizeLimitInBytes > 0 && sizeInBytes+txSize > sizeLimitInBytes {
-			break
+	return
+}
+
+// startJournal journals the pending pool to a journal at path, which starts with the transactions already pending and
+// withdrawn, and starts the goroutine compacting it
+func (p *pendingTxPool) startJournal(ctx context.Context, path string, logger log.Logger) (*govnr.ForeverHandle, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	journal, err := createPendingPoolJournal(path, p.journaledPoolUnderMutex(), logger)
+	if err != nil {
+		return nil, err
+	}
+	p.journal = journal
+	return govnr.Forever(ctx, "pending pool journal compaction", logfields.GovnrErrorer(logger), func() {
+		select {
+		case <-ctx.Done():
+		case <-journal.compactionRequests:
+			p.compactJournal()
 		}
+	}), nil
+}
 
-		sizeInBytes += txSize
-		txs = append(txs, tx)
+// compactJournal takes the pending and withdrawn transactions under the read lock and rewrites the journal after
+// releasing it
+func (p *pendingTxPool) compactJournal() {
+	p.lock.RLock()
+	pool := p.journaledPoolUnderMutex()
+	p.journal.startCompaction()
+	p.lock.RUnlock()
 
-		e = e.Prev()
+	p.journal.compact(pool)
+}
 
-		p.transactionPickedFromQueueUnderMutex(tx)
+// journaledPoolUnderMutex returns the pending transactions from the oldest one and a copy of the withdrawn ones
+func (p *pendingTxPool) journaledPoolUnderMutex() *journaledPool {
+	withdrawn := make(map[string]primitives.TimestampNano, len(p.withdrawnByHash))
+	for key, keepUntil := range p.withdrawnByHash {
+		withdrawn[key] = keepUntil
 	}
-
-	return
+	pending := make([]*journaledTransaction, 0, len(p.transactionsByHash))
+	for e := p.transactionList.Back(); e != nil; e = e.Prev() {
+		tx := e.Value.(*protocol.SignedTransaction)
+		if ptx, found := p.transactionsByHash[digest.CalcTxHash(tx.Transaction()).KeyForMap()]; found {
+			pending = append(pending, &journaledTransaction{transaction: tx, gatewayNodeAddress: ptx.gatewayNodeAddress})
+		}
+	}
+	return &journaledPool{pending: pending, withdrawn: withdrawn}
 }
 
 func (p *pendingTxPool) get(txHash primitives.Sha256) *protocol.SignedTransaction {
@@ -187,6 +333,14 @@ func (p *pendingTxPool) clearTransactionsOlderThan(ctx context.Context, timestam
 			p.remove(ctx, digest.CalcTxHash(tx.Transaction()), protocol.TRANSACTION_STATUS_REJECTED_TIMESTAMP_WINDOW_EXCEEDED)
 		}
 	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for key, keepUntil := range p.withdrawnByHash {
+		if keepUntil < timestamp {
+			delete(p.withdrawnByHash, key)
+		}
+	}
 }
 
 func (p *pendingTxPool) lastTransaction() *list.Element {
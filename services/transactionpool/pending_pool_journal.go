@@ -0,0 +1,355 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// the journal is a sequence of records, each of them:
+//
+//	kind uint8 | payload size uint32 | payload | CRC32 (Castagnoli) of the preceding bytes of the record
+//
+// the payload of an added transaction is the size of its gateway node address as uint32, the address and the membuffer
+// of the transaction. the payload of a removed transaction is its hash. the payload of a withdrawn transaction is the
+// timestamp until which it is kept out of the pool as uint64 followed by its withdrawn key. records are written straight
+// to the file, so they survive a restart of the node process. a crash of the machine may lose the last records, and a
+// torn last record is dropped when the journal is read
+const (
+	journalRecordAdded     = uint8(1)
+	journalRecordRemoved   = uint8(2)
+	journalRecordWithdrawn = uint8(3)
+)
+
+const maxJournalPayloadSize = 16 * 1024 * 1024
+
+// the journal is rewritten with the pending transactions once it holds this many records more than twice their number
+const journalCompactionSlack = 10000
+
+var journalCrcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type journaledTransaction struct {
+	transaction        *protocol.SignedTransaction
+	gatewayNodeAddress primitives.NodeAddress
+}
+
+// journaledPool is the part of the pending pool kept in the journal, the pending transactions from the oldest one and
+// the withdrawn transactions kept out of the pool
+type journaledPool struct {
+	pending   []*journaledTransaction
+	withdrawn map[string]primitives.TimestampNano // by withdrawnKey, until when to keep each withdrawn transaction out
+}
+
+type pendingPoolJournal struct {
+	path               string
+	logger             log.Logger
+	compactionRequests chan struct{}
+
+	lock                   sync.Mutex
+	file                   *os.File
+	numRecords             int
+	compacting             bool
+	recordsWhileCompacting [][]byte
+}
+
+// readPendingPoolJournal returns the transactions added to the journal at path and not removed since, in the order
+// they were added, and the transactions withdrawn. a missing journal holds no transactions
+func readPendingPoolJournal(path string, logger log.Logger) (*journaledPool, error) {
+	withdrawn := make(map[string]primitives.TimestampNano)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &journaledPool{withdrawn: withdrawn}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open pending pool journal %s", path)
+	}
+	defer file.Close()
+
+	var added []*journaledTransaction
+	indexByHash := make(map[string]int)
+	r := bufio.NewReaderSize(file, 1024*1024)
+	for {
+		kind, payload, err := readJournalRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Info("dropping the end of the pending pool journal which was not fully written", log.Error(err))
+			break
+		}
+
+		switch kind {
+		case journalRecordAdded:
+			entry, err := decodeAddedRecord(payload)
+			if err != nil {
+				return nil, err
+			}
+			indexByHash[digest.CalcTxHash(entry.transaction.Transaction()).KeyForMap()] = len(added)
+			added = append(added, entry)
+		case journalRecordRemoved:
+			if i, found := indexByHash[primitives.Sha256(payload).KeyForMap()]; found {
+				added[i] = nil
+				delete(indexByHash, primitives.Sha256(payload).KeyForMap())
+			}
+		case journalRecordWithdrawn:
+			key, keepUntil, err := decodeWithdrawnRecord(payload)
+			if err != nil {
+				return nil, err
+			}
+			withdrawn[key] = keepUntil
+		default:
+			return nil, errors.Errorf("unknown record kind %d in pending pool journal %s", kind, path)
+		}
+	}
+
+	var pending []*journaledTransaction
+	for _, entry := range added {
+		if entry != nil {
+			pending = append(pending, entry)
+		}
+	}
+	return &journaledPool{pending: pending, withdrawn: withdrawn}, nil
+}
+
+// createPendingPoolJournal starts the journal at path over with the given pool, through a temporary file so a crash
+// leaves either the previous journal or the new one
+func createPendingPoolJournal(path string, pool *journaledPool, logger log.Logger) (*pendingPoolJournal, error) {
+	file, err := createJournalFile(path+".tmp", pool)
+	if err != nil {
+		return nil, err
+	}
+	if err := replaceJournalFile(file, path); err != nil {
+		return nil, err
+	}
+	return &pendingPoolJournal{
+		path:               path,
+		logger:             logger,
+		compactionRequests: make(chan struct{}, 1),
+		file:               file,
+		numRecords:         pool.numRecords(),
+	}, nil
+}
+
+// createJournalFile writes the given pool to a new file at path, which is left open for appending
+func createJournalFile(path string, pool *journaledPool) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create pending pool journal %s", path)
+	}
+	w := bufio.NewWriterSize(file, 1024*1024)
+	for _, entry := range pool.pending {
+		if _, err := w.Write(encodeAddedRecord(entry.transaction, entry.gatewayNodeAddress)); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to write pending pool journal %s", path)
+		}
+	}
+	for key, keepUntil := range pool.withdrawn {
+		if _, err := w.Write(encodeWithdrawnRecord(key, keepUntil)); err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "failed to write pending pool journal %s", path)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "failed to write pending pool journal %s", path)
+	}
+	return file, nil
+}
+
+// replaceJournalFile syncs a file written by createJournalFile and renames it over the journal at path. the file is
+// closed if it fails
+func replaceJournalFile(file *os.File, path string) error {
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to sync pending pool journal %s", file.Name())
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to replace pending pool journal %s", path)
+	}
+	return nil
+}
+
+func (j *pendingPoolJournal) added(tx *protocol.SignedTransaction, gatewayNodeAddress primitives.NodeAddress) {
+	j.write(encodeAddedRecord(tx, gatewayNodeAddress))
+}
+
+func (j *pendingPoolJournal) removed(txHash primitives.Sha256) {
+	j.write(encodeJournalRecord(journalRecordRemoved, txHash))
+}
+
+func (j *pendingPoolJournal) withdrawn(key string, keepUntil primitives.TimestampNano) {
+	j.write(encodeWithdrawnRecord(key, keepUntil))
+}
+
+// write appends a record, a journal that fails to write only makes the node lose pending transactions on restart so
+// the failure is logged and the pool carries on. records written while the journal is compacted are also kept for the
+// compacted journal
+func (j *pendingPoolJournal) write(record []byte) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if _, err := j.file.Write(record); err != nil {
+		j.logger.Error("failed to write to pending pool journal", log.Error(err), log.String("filename", j.path))
+	}
+	j.numRecords++
+	if j.compacting {
+		j.recordsWhileCompacting = append(j.recordsWhileCompacting, record)
+	}
+}
+
+// requestCompactionIfNeeded asks the compaction goroutine to rewrite the journal once it holds too many records for the
+// number of pending and withdrawn transactions
+func (j *pendingPoolJournal) requestCompactionIfNeeded(numKept int) {
+	j.lock.Lock()
+	needed := !j.compacting && j.numRecords > 2*numKept+journalCompactionSlack
+	j.lock.Unlock()
+
+	if needed {
+		select {
+		case j.compactionRequests <- struct{}{}:
+		default: // a compaction is already requested
+		}
+	}
+}
+
+// startCompaction must be called while the pool passed to compact is taken, so that every record written after it is
+// copied to the compacted journal
+func (j *pendingPoolJournal) startCompaction() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.compacting = true
+	j.recordsWhileCompacting = nil
+}
+
+// compact rewrites the journal with the given pool followed by the records written since startCompaction. the pool is
+// written without holding the journal lock, so adding to and removing from the pool waits only for the records written
+// meanwhile to be copied
+func (j *pendingPoolJournal) compact(pool *journaledPool) {
+	file, err := createJournalFile(j.path+".tmp", pool)
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	numRecords := pool.numRecords() + len(j.recordsWhileCompacting)
+	if err == nil {
+		err = appendJournalRecords(file, j.recordsWhileCompacting)
+	}
+	if err == nil {
+		err = replaceJournalFile(file, j.path)
+	}
+	j.compacting = false
+	j.recordsWhileCompacting = nil
+	if err != nil {
+		j.logger.Error("failed to compact pending pool journal", log.Error(err))
+		j.numRecords = numRecords // postpone the next attempt
+		return
+	}
+
+	j.closeUnderMutex()
+	j.file = file
+	j.numRecords = numRecords
+}
+
+func appendJournalRecords(file *os.File, records [][]byte) error {
+	for _, record := range records {
+		if _, err := file.Write(record); err != nil {
+			file.Close()
+			return errors.Wrapf(err, "failed to write pending pool journal %s", file.Name())
+		}
+	}
+	return nil
+}
+
+func (j *pendingPoolJournal) closeUnderMutex() {
+	if err := j.file.Close(); err != nil {
+		j.logger.Error("failed to close pending pool journal", log.Error(err), log.String("filename", j.path))
+	}
+}
+
+func encodeAddedRecord(tx *protocol.SignedTransaction, gatewayNodeAddress primitives.NodeAddress) []byte {
+	payload := make([]byte, 4, 4+len(gatewayNodeAddress)+len(tx.Raw()))
+	binary.LittleEndian.PutUint32(payload, uint32(len(gatewayNodeAddress)))
+	payload = append(payload, gatewayNodeAddress...)
+	payload = append(payload, tx.Raw()...)
+	return encodeJournalRecord(journalRecordAdded, payload)
+}
+
+func decodeAddedRecord(payload []byte) (*journaledTransaction, error) {
+	if len(payload) < 4 || uint64(binary.LittleEndian.Uint32(payload)) > uint64(len(payload)-4) {
+		return nil, errors.New("invalid added transaction record in pending pool journal")
+	}
+	addressSize := binary.LittleEndian.Uint32(payload)
+	return &journaledTransaction{
+		gatewayNodeAddress: primitives.NodeAddress(payload[4 : 4+addressSize]),
+		transaction:        protocol.SignedTransactionReader(payload[4+addressSize:]),
+	}, nil
+}
+
+func encodeWithdrawnRecord(key string, keepUntil primitives.TimestampNano) []byte {
+	payload := make([]byte, 8, 8+len(key))
+	binary.LittleEndian.PutUint64(payload, uint64(keepUntil))
+	payload = append(payload, key...)
+	return encodeJournalRecord(journalRecordWithdrawn, payload)
+}
+
+func decodeWithdrawnRecord(payload []byte) (string, primitives.TimestampNano, error) {
+	if len(payload) < 8 {
+		return "", 0, errors.New("invalid withdrawn transaction record in pending pool journal")
+	}
+	return string(payload[8:]), primitives.TimestampNano(binary.LittleEndian.Uint64(payload)), nil
+}
+
+func (p *journaledPool) numRecords() int {
+	return len(p.pending) + len(p.withdrawn)
+}
+
+func encodeJournalRecord(kind uint8, payload []byte) []byte {
+	record := make([]byte, 5, 5+len(payload)+4)
+	record[0] = kind
+	binary.LittleEndian.PutUint32(record[1:], uint32(len(payload)))
+	record = append(record, payload...)
+	sum := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sum, crc32.Checksum(record, journalCrcTable))
+	return append(record, sum...)
+}
+
+func readJournalRecord(r io.Reader) (uint8, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, errors.New("journal ends within a record header")
+		}
+		return 0, nil, err
+	}
+	size := binary.LittleEndian.Uint32(header[1:])
+	if size > maxJournalPayloadSize {
+		return 0, nil, errors.Errorf("journal record of %d bytes exceeds the size limit", size)
+	}
+
+	rest := make([]byte, size+4)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return 0, nil, errors.New("journal ends within a record")
+	}
+	payload := rest[:size]
+	sum := crc32.Update(crc32.Checksum(header, journalCrcTable), journalCrcTable, payload)
+	if sum != binary.LittleEndian.Uint32(rest[size:]) {
+		return 0, nil, errors.New("journal record checksum mismatch")
+	}
+	return header[0], payload, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPendingPoolJournal_KeepsPendingTransactionsInArrivalOrder(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			path := tempJournalPath(t)
+			defer os.RemoveAll(filepath.Dir(path))
+			gateway := keys.EcdsaSecp256K1KeyPairForTests(3).NodeAddress()
+
+			p := makePendingPool()
+			tx1 := builders.TransferTransaction().Build()
+			add(p, tx1)
+			compaction, err := p.startJournal(ctx, path, harness.Logger)
+			require.NoError(t, err)
+			compaction.MarkSupervised()
+
+			tx2 := builders.TransferTransaction().Build()
+			tx3 := builders.TransferTransaction().Build()
+			p.add(tx2, gateway)
+			add(p, tx3)
+			p.remove(ctx, digest.CalcTxHash(tx1.Transaction()), 0)
+
+			journaled, err := readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Len(t, journaled.pending, 2)
+			require.Equal(t, tx2.Raw(), journaled.pending[0].transaction.Raw())
+			require.Equal(t, gateway, journaled.pending[0].gatewayNodeAddress)
+			require.Equal(t, tx3.Raw(), journaled.pending[1].transaction.Raw())
+		})
+	})
+}
+
+func TestPendingPoolJournal_DropsTornLastRecord(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			path := tempJournalPath(t)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			p := makePendingPool()
+			compaction, err := p.startJournal(ctx, path, harness.Logger)
+			require.NoError(t, err)
+			compaction.MarkSupervised()
+			tx1 := builders.TransferTransaction().Build()
+			add(p, tx1, builders.TransferTransaction().Build())
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			require.NoError(t, os.Truncate(path, info.Size()-3))
+
+			journaled, err := readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Len(t, journaled.pending, 1, "expected the torn record to be dropped")
+			require.Equal(t, tx1.Raw(), journaled.pending[0].transaction.Raw())
+		})
+	})
+}
+
+func TestPendingPoolJournal_CompactionKeepsOnlyPendingTransactions(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			path := tempJournalPath(t)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			p := makePendingPool()
+			compaction, err := p.startJournal(ctx, path, harness.Logger)
+			require.NoError(t, err)
+			compaction.MarkSupervised()
+			tx1 := builders.TransferTransaction().Build()
+			tx2 := builders.TransferTransaction().Build()
+			add(p, tx1, tx2)
+			p.remove(ctx, digest.CalcTxHash(tx1.Transaction()), 0)
+			sizeBefore := fileSize(t, path)
+
+			p.compactJournal()
+			require.True(t, fileSize(t, path) < sizeBefore, "expected compaction to shrink the journal")
+
+			tx3 := builders.TransferTransaction().Build()
+			add(p, tx3)
+			journaled, err := readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Len(t, journaled.pending, 2)
+			require.Equal(t, tx2.Raw(), journaled.pending[0].transaction.Raw())
+			require.Equal(t, tx3.Raw(), journaled.pending[1].transaction.Raw(), "expected the compacted journal to be appended to")
+		})
+	})
+}
+
+func TestPendingPoolJournal_CompactionKeepsRecordsWrittenWhileItRuns(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			path := tempJournalPath(t)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			p := makePendingPool()
+			compaction, err := p.startJournal(ctx, path, harness.Logger)
+			require.NoError(t, err)
+			compaction.MarkSupervised()
+			tx1 := builders.TransferTransaction().Build()
+			tx2 := builders.TransferTransaction().Build()
+			add(p, tx1, tx2)
+
+			p.lock.RLock()
+			pool := p.journaledPoolUnderMutex()
+			p.journal.startCompaction()
+			p.lock.RUnlock()
+
+			tx3 := builders.TransferTransaction().Build()
+			add(p, tx3)
+			p.remove(ctx, digest.CalcTxHash(tx1.Transaction()), 0)
+			p.journal.compact(pool)
+
+			journaled, err := readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Len(t, journaled.pending, 2)
+			require.Equal(t, tx2.Raw(), journaled.pending[0].transaction.Raw())
+			require.Equal(t, tx3.Raw(), journaled.pending[1].transaction.Raw(), "expected a transaction added during compaction to be kept")
+		})
+	})
+}
+
+func TestPendingPoolJournal_MissingJournalHoldsNoTransactions(t *testing.T) {
+	with.Logging(t, func(harness *with.LoggingHarness) {
+		journaled, err := readPendingPoolJournal(filepath.Join(os.TempDir(), "no-such-dir", "pending.journal"), harness.Logger)
+		require.NoError(t, err)
+		require.Empty(t, journaled.pending)
+	})
+}
+
+func TestPendingPoolJournal_KeepsWithdrawnTransactionsAcrossCompaction(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(harness *with.LoggingHarness) {
+			path := tempJournalPath(t)
+			defer os.RemoveAll(filepath.Dir(path))
+
+			p := makePendingPool()
+			compaction, err := p.startJournal(ctx, path, harness.Logger)
+			require.NoError(t, err)
+			compaction.MarkSupervised()
+			tx1 := builders.TransferTransaction().Build()
+			tx2 := builders.TransferTransaction().Build()
+			add(p, tx1)
+			signer := keys.Ed25519KeyPairForTests(1).PublicKey()
+			require.Nil(t, p.withdraw(ctx, digest.CalcTxHash(tx1.Transaction()), signer, 0, 0))
+			require.Nil(t, p.withdraw(ctx, digest.CalcTxHash(tx2.Transaction()), signer, 0, 42))
+
+			journaled, err := readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Empty(t, journaled.pending)
+			require.Equal(t, p.withdrawnByHash, journaled.withdrawn, "expected withdrawn transactions to be journaled")
+
+			p.compactJournal()
+			journaled, err = readPendingPoolJournal(path, harness.Logger)
+			require.NoError(t, err)
+			require.Equal(t, p.withdrawnByHash, journaled.withdrawn, "expected compaction to keep withdrawn transactions")
+
+			restored := makePendingPool()
+			restored.restoreWithdrawn(journaled.withdrawn, 0)
+			_, rejected := restored.add(tx1, nil)
+			require.NotNil(t, rejected, "withdrawn transaction was added after a restart")
+		})
+	})
+}
+
+func tempJournalPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pending_pool_journal")
+	require.NoError(t, err)
+	return filepath.Join(dir, "pending.journal")
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter keeps a token bucket per key, e.g. per signer public key. a bucket holds up to burst tokens and refills
+// at ratePerSecond tokens per second, a zero rate is no limit
+type rateLimiter struct {
+	sync.Mutex
+	ratePerSecond float64
+	burst         float64
+	now           func() time.Time
+
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newRateLimiter(ratePerSecond uint32, burst uint32, now func() time.Time) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		ratePerSecond: float64(ratePerSecond),
+		burst:         float64(burst),
+		now:           now,
+		buckets:       make(map[string]*tokenBucket),
+		lastSweep:     now(),
+	}
+}
+
+// allow takes a token from the bucket of key, returns false if the bucket is empty
+func (r *rateLimiter) allow(key string) bool {
+	if r.ratePerSecond == 0 {
+		return true
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	r.sweepFullBucketsUnderMutex(now)
+
+	bucket, found := r.buckets[key]
+	if !found {
+		bucket = &tokenBucket{tokens: r.burst, lastRefill: now}
+		r.buckets[key] = bucket
+	}
+	bucket.refill(now, r.ratePerSecond, r.burst)
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweepFullBucketsUnderMutex forgets the keys whose bucket has refilled since their last transaction, as a new bucket
+// is full too. the sweep runs once per time it takes to refill a bucket, so the buckets kept are of recent keys only
+func (r *rateLimiter) sweepFullBucketsUnderMutex(now time.Time) {
+	refillTime := time.Duration(r.burst / r.ratePerSecond * float64(time.Second))
+	if now.Sub(r.lastSweep) < refillTime {
+		return
+	}
+	for key, bucket := range r.buckets {
+		if now.Sub(bucket.lastRefill) >= refillTime {
+			delete(r.buckets, key)
+		}
+	}
+	r.lastSweep = now
+}
+
+func (b *tokenBucket) refill(now time.Time, ratePerSecond float64, burst float64) {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * ratePerSecond
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.lastRefill = now
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_AllowsBurstThenRefillsAtRate(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(2, 3, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.allow("signer"), "expected a burst of 3 transactions to be allowed")
+	}
+	require.False(t, limiter.allow("signer"), "expected a transaction over the burst to be rejected")
+	require.True(t, limiter.allow("another signer"), "expected every key to have its own bucket")
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, limiter.allow("signer"), "expected one token to refill after half a second")
+	require.False(t, limiter.allow("signer"))
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.allow("signer"))
+	}
+	require.False(t, limiter.allow("signer"), "expected a bucket not to refill over its burst")
+}
+
+func TestRateLimiter_ZeroRateIsNoLimit(t *testing.T) {
+	limiter := newRateLimiter(0, 1, time.Now)
+
+	for i := 0; i < 100; i++ {
+		require.True(t, limiter.allow("signer"))
+	}
+}
+
+func TestRateLimiter_ForgetsKeysWhoseBucketRefilled(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(10, 10, func() time.Time { return now })
+
+	limiter.allow("idle signer")
+	now = now.Add(500 * time.Millisecond)
+	limiter.allow("active signer")
+	now = now.Add(700 * time.Millisecond)
+	limiter.allow("active signer")
+
+	require.Len(t, limiter.buckets, 1, "expected the bucket of the idle signer to be swept")
+	require.Contains(t, limiter.buckets, "active signer")
+}
+
+func TestErrTransactionRateLimited_IsToldApartFromFullPool(t *testing.T) {
+	fullPool := &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_REJECTED_CONGESTION}
+	rateLimited := newErrTransactionRateLimited("signer")
+
+	require.Equal(t, fullPool.TransactionStatus, rateLimited.TransactionStatus, "expected rate limited transactions to be reported as congested")
+	require.NotEqual(t, fullPool.Error(), rateLimited.Error())
+	require.Contains(t, rateLimited.Error(), "signer rate limit exceeded")
+}
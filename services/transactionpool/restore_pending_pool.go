@@ -0,0 +1,87 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+)
+
+// CommittedTransactionFinder finds the block a transaction was committed in, the block is nil if it was not found
+type CommittedTransactionFinder interface {
+	GetBlockByTx(txHash primitives.Sha256) (block *protocol.BlockPairContainer, txIndexInBlock int, err error)
+}
+
+// RestorePendingPool runs on boot when a pending pool journal is configured. it adds the journaled transactions back to
+// the pending pool, except those already committed, expired or withdrawn, keeps the withdrawn transactions out of it,
+// forwards again those this node was the gateway for, and journals the pending pool from then on. the gateways of the other transactions forward them again when they restore
+// their own pending pools
+func (s *service) RestorePendingPool(ctx context.Context, committed CommittedTransactionFinder) error {
+	path := s.config.TransactionPoolPendingPoolJournalPath()
+	if path == "" {
+		return nil
+	}
+	logger := s.logger.WithTags(trace.LogFieldFrom(ctx), log.String("filename", path))
+
+	journaled, err := readPendingPoolJournal(path, logger)
+	if err != nil {
+		return err
+	}
+
+	expiredBefore := primitives.TimestampNano(s.clock.CurrentTime().Add(-s.config.TransactionExpirationWindow()).UnixNano())
+	s.pendingPool.restoreWithdrawn(journaled.withdrawn, expiredBefore)
+
+	var restored, toForward []*protocol.SignedTransaction
+	var numCommitted, numExpired int
+	for _, entry := range journaled.pending {
+		tx := entry.transaction
+		txHash := digest.CalcTxHash(tx.Transaction())
+		if tx.Transaction().Timestamp() < expiredBefore {
+			numExpired++
+			continue
+		}
+		if s.committedPool.has(txHash) {
+			numCommitted++
+			continue
+		}
+		block, _, err := committed.GetBlockByTx(txHash)
+		if err != nil {
+			return errors.Wrapf(err, "failed to look up journaled transaction %s in block storage", txHash)
+		}
+		if block != nil {
+			numCommitted++
+			continue
+		}
+
+		if _, err := s.pendingPool.add(tx, entry.gatewayNodeAddress); err != nil {
+			logger.Info("dropping journaled transaction", log.Error(err), logfields.Transaction(txHash))
+			continue
+		}
+		restored = append(restored, tx)
+		if entry.gatewayNodeAddress.Equal(s.config.NodeAddress()) {
+			toForward = append(toForward, tx)
+		}
+	}
+
+	compaction, err := s.pendingPool.startJournal(ctx, path, logger)
+	if err != nil {
+		return err
+	}
+	s.Supervise(compaction)
+
+	for _, tx := range toForward {
+		s.transactionForwarder.submit(tx)
+	}
+	logger.Info("restored pending pool from journal", log.Int("restored", len(restored)), log.Int("forwarded", len(toForward)), log.Int("committed", numCommitted), log.Int("expired", numExpired))
+	return nil
+}
@@ -7,9 +7,11 @@
 package transactionpool
 
 import (
+	"context"
 	"github.com/orbs-network/govnr"
 	"github.com/orbs-network/orbs-network-go/config"
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip"
 	"github.com/orbs-network/orbs-network-go/services/transactionpool/adapter"
 	"github.com/orbs-network/orbs-network-go/synchronization"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -22,6 +24,19 @@ import (
 
 var LogTag = log.Service("transaction-pool")
 
+// TransactionPool is the transaction pool service of the protocol spec together with the updates of pending
+// transactions by their signers
+type TransactionPool interface {
+	services.TransactionPool
+	UpdatePendingTransaction(ctx context.Context, input *UpdatePendingTransactionInput) (*UpdatePendingTransactionOutput, error)
+}
+
+// TransactionRelay is the transaction relay topic of gossip together with the withdrawal of pending transactions
+type TransactionRelay interface {
+	gossiptopics.TransactionRelay
+	gossip.WithdrawnTransactionRelay
+}
+
 type BlockHeightReporter interface {
 	IncrementTo(height primitives.BlockHeight)
 }
@@ -30,7 +45,7 @@ type service struct {
 	govnr.TreeSupervisor
 
 	clock               adapter.Clock
-	gossip              gossiptopics.TransactionRelay
+	gossip              TransactionRelay
 	virtualMachine      services.VirtualMachine
 	blockHeightReporter BlockHeightReporter // used to allow test to wait for a block height to reach the transaction pool
 	logger              log.Logger
@@ -55,12 +70,16 @@ type service struct {
 	transactionWaiter                   *transactionWaiter
 	validationContext                   *validationContext
 	addNewTransactionConcurrencyLimiter *requestConcurrencyLimiter
+	signerRateLimiter                   *rateLimiter
+	gatewayRateLimiter                  *rateLimiter
 
 	metrics struct {
 		blockHeight            *metric.Gauge
 		lastCommittedTimestamp *metric.Gauge
 		commitRate             *metric.Rate
 		commitCount            *metric.Gauge
+		rateLimitedBySigner    *metric.Counter
+		rateLimitedByGateway   *metric.Counter
 	}
 
 	addCommitLock sync.RWMutex
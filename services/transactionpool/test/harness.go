@@ -17,6 +17,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
 	"github.com/orbs-network/orbs-network-go/services/transactionpool"
 	"github.com/orbs-network/orbs-network-go/services/transactionpool/adapter"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool/testkit"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
 	"github.com/orbs-network/orbs-network-go/test/with"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
@@ -32,7 +33,7 @@ import (
 type harness struct {
 	*with.ConcurrencyHarness
 	txpool                  services.TransactionPool
-	gossip                  *gossiptopics.MockTransactionRelay
+	gossip                  *testkit.MockTransactionRelay
 	vm                      *services.MockVirtualMachine
 	signer                  signer.Signer
 	trh                     *handlers.MockTransactionResultsHandler
@@ -258,8 +259,9 @@ func newHarnessWithInfiniteTimeBetweenEmptyBlocks(parent *with.ConcurrencyHarnes
 }
 
 func newHarnessWithConfig(parent *with.ConcurrencyHarness, sizeLimit uint32, timeBetweenEmptyBlocks time.Duration) *harness {
-	gossip := &gossiptopics.MockTransactionRelay{}
+	gossip := &testkit.MockTransactionRelay{}
 	gossip.When("RegisterTransactionRelayHandler", mock.Any).Return()
+	gossip.When("RegisterWithdrawnTransactionHandler", mock.Any).Return()
 
 	virtualMachine := &services.MockVirtualMachine{}
 
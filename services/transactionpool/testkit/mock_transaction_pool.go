@@ -0,0 +1,26 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package testkit
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/transactionpool"
+	"github.com/orbs-network/orbs-spec/types/go/services"
+)
+
+type MockTransactionPool struct {
+	services.MockTransactionPool
+}
+
+func (s *MockTransactionPool) UpdatePendingTransaction(ctx context.Context, input *transactionpool.UpdatePendingTransactionInput) (*transactionpool.UpdatePendingTransactionOutput, error) {
+	ret := s.Called(ctx, input)
+	if out := ret.Get(0); out != nil {
+		return out.(*transactionpool.UpdatePendingTransactionOutput), ret.Error(1)
+	} else {
+		return nil, ret.Error(1)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package testkit
+
+import (
+	"context"
+	"github.com/orbs-network/orbs-network-go/services/gossip"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-spec/types/go/services/gossiptopics"
+)
+
+type MockTransactionRelay struct {
+	gossiptopics.MockTransactionRelay
+}
+
+func (s *MockTransactionRelay) BroadcastWithdrawnTransaction(ctx context.Context, message *codec.WithdrawnTransactionMessage) error {
+	return s.Called(ctx, message).Error(0)
+}
+
+func (s *MockTransactionRelay) RegisterWithdrawnTransactionHandler(handler gossip.WithdrawnTransactionHandler) {
+	s.Called(handler)
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/hash"
+	"github.com/orbs-network/crypto-lib-go/crypto/keys"
+	"github.com/orbs-network/crypto-lib-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
+	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/orbs-network/scribe/log"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// PendingTransactionUpdate withdraws a pending transaction on behalf of its signer, and replaces it with Replacement
+// unless it is nil
+type PendingTransactionUpdate struct {
+	TxHash          primitives.Sha256
+	SignerPublicKey primitives.Ed25519PublicKey
+	Signature       primitives.Ed25519Sig
+	Replacement     *protocol.SignedTransaction
+}
+
+type UpdatePendingTransactionInput struct {
+	Update *PendingTransactionUpdate
+}
+
+type UpdatePendingTransactionOutput struct {
+	TransactionStatus protocol.TransactionStatus // of the replacement, or TRANSACTION_STATUS_RESERVED for a cancellation
+	BlockHeight       primitives.BlockHeight
+	BlockTimestamp    primitives.TimestampNano
+}
+
+var pendingTransactionUpdateDomain = []byte("orbs-pending-transaction-update")
+
+// PendingTransactionUpdateDigest is the hash the signer of the pending transaction txHash signs to cancel it, or to
+// replace it with the transaction replacementTxHash
+func PendingTransactionUpdateDigest(virtualChainId primitives.VirtualChainId, txHash primitives.Sha256, replacementTxHash primitives.Sha256) primitives.Sha256 {
+	vcid := make([]byte, 4)
+	binary.BigEndian.PutUint32(vcid, uint32(virtualChainId))
+	return hash.CalcSha256(pendingTransactionUpdateDomain, vcid, txHash, replacementTxHash)
+}
+
+// UpdatePendingTransaction cancels or replaces a pending transaction on behalf of its signer. the signed withdrawal is
+// broadcast for the other nodes to withdraw the transaction from their pools too, and a replacement is forwarded to
+// them as any new transaction is. a node which proposed the transaction for a block before the withdrawal reached it
+// may still commit it
+func (s *service) UpdatePendingTransaction(ctx context.Context, input *UpdatePendingTransactionInput) (*UpdatePendingTransactionOutput, error) {
+	update := input.Update
+	logger := s.logger.WithTags(logfields.Transaction(update.TxHash), trace.LogFieldFrom(ctx))
+
+	if err := s.validatePendingTransactionUpdate(update); err != nil {
+		logger.Info("pending transaction update is invalid", log.Error(err))
+		return s.updatePendingTransactionOutputFor(err.TransactionStatus), err
+	}
+
+	if update.Replacement != nil {
+		lastCommittedBlockHeight, lastCommittedBlockTimestamp, _ := s.lastCommittedBlockInfo()
+		if err := s.validationContext.ValidateAddedTransaction(update.Replacement, time.Now(), lastCommittedBlockTimestamp); err != nil {
+			logger.Info("replacement transaction is invalid", log.Error(err), logfields.BlockHeight(lastCommittedBlockHeight), logfields.TimestampNano("last-committed", lastCommittedBlockTimestamp))
+			return s.updatePendingTransactionOutputFor(err.TransactionStatus), err
+		}
+
+		if err := s.validateSingleTransactionForPreOrder(ctx, update.Replacement); err != nil {
+			status := protocol.TRANSACTION_STATUS_REJECTED_SMART_CONTRACT_PRE_ORDER
+			if errRejected, ok := err.(*ErrTransactionRejected); ok {
+				status = errRejected.TransactionStatus
+			}
+			logger.Info("error validating replacement transaction for preorder", log.Error(err))
+			return s.updatePendingTransactionOutputFor(status), err
+		}
+
+		if err := s.takeRateLimitTokens(update.Replacement, nil); err != nil {
+			logger.Info("replacement transaction is over the rate limit", log.Error(err))
+			return s.updatePendingTransactionOutputFor(err.TransactionStatus), err
+		}
+	}
+
+	if err := s.applyPendingTransactionUpdate(ctx, update); err != nil {
+		logger.Info("failed to update pending transaction", log.Error(err))
+		return s.updatePendingTransactionOutputFor(err.TransactionStatus), err
+	}
+
+	logger.Info("pending transaction withdrawn by its signer", log.String("flow", "checkpoint"), log.String("update", pendingTransactionUpdateKind(update)))
+
+	if err := s.gossip.BroadcastWithdrawnTransaction(ctx, &codec.WithdrawnTransactionMessage{
+		TxHash:            update.TxHash,
+		ReplacementTxHash: replacementTxHashOf(update),
+		SignerPublicKey:   update.SignerPublicKey,
+		Signature:         update.Signature,
+	}); err != nil {
+		logger.Error("failed to broadcast withdrawn transaction", log.Error(err))
+	}
+
+	if update.Replacement == nil {
+		return s.updatePendingTransactionOutputFor(protocol.TRANSACTION_STATUS_RESERVED), nil
+	}
+	s.transactionForwarder.submit(update.Replacement)
+	return s.updatePendingTransactionOutputFor(protocol.TRANSACTION_STATUS_PENDING), nil
+}
+
+// HandleWithdrawnTransaction withdraws a transaction whose signer withdrew it through another node. the withdrawal is
+// verified as it was on that node, and the pending transaction must be signed by the key which signed the withdrawal
+func (s *service) HandleWithdrawnTransaction(ctx context.Context, message *codec.WithdrawnTransactionMessage) error {
+	if err := verifyPendingTransactionUpdateSignature(s.config.VirtualChainId(), message.TxHash, message.ReplacementTxHash, message.SignerPublicKey, message.Signature); err != nil {
+		return errors.Wrapf(err, "invalid withdrawal of transaction %s", message.TxHash)
+	}
+
+	s.addCommitLock.RLock()
+	defer s.addCommitLock.RUnlock()
+
+	if err := s.withdrawUnderCommitLock(ctx, message.TxHash, message.SignerPublicKey); err != nil {
+		return errors.Wrapf(err, "failed to withdraw transaction %s", message.TxHash)
+	}
+	s.logger.Info("pending transaction withdrawn by its signer through another node", trace.LogFieldFrom(ctx), logfields.Transaction(message.TxHash), log.String("flow", "checkpoint"))
+	return nil
+}
+
+// validatePendingTransactionUpdate checks the update is signed by the key it names, which must also sign its replacement
+func (s *service) validatePendingTransactionUpdate(update *PendingTransactionUpdate) *ErrTransactionRejected {
+	replacementTxHash := replacementTxHashOf(update)
+	if update.Replacement != nil {
+		if replacementTxHash.Equal(update.TxHash) {
+			return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_DUPLICATE_TRANSACTION_ALREADY_PENDING}
+		}
+
+		signer := update.Replacement.Transaction().Signer()
+		if !signer.IsSchemeEddsa() || !signer.Eddsa().SignerPublicKey().Equal(update.SignerPublicKey) {
+			return &ErrTransactionRejected{protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH, log.Stringable("signer", update.SignerPublicKey), log.Stringable("replacement-signer", signer)}
+		}
+	}
+
+	return verifyPendingTransactionUpdateSignature(s.config.VirtualChainId(), update.TxHash, replacementTxHash, update.SignerPublicKey, update.Signature)
+}
+
+func verifyPendingTransactionUpdateSignature(virtualChainId primitives.VirtualChainId, txHash primitives.Sha256, replacementTxHash primitives.Sha256, signerPublicKey primitives.Ed25519PublicKey, sig primitives.Ed25519Sig) *ErrTransactionRejected {
+	if len(signerPublicKey) != keys.ED25519_PUBLIC_KEY_SIZE_BYTES {
+		return &ErrTransactionRejected{protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH, log.Int("public-key-length", keys.ED25519_PUBLIC_KEY_SIZE_BYTES), log.Int("public-key-length", len(signerPublicKey))}
+	}
+
+	signedData := PendingTransactionUpdateDigest(virtualChainId, txHash, replacementTxHash)
+	if !signature.VerifyEd25519(signerPublicKey, signedData, sig) {
+		return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH}
+	}
+
+	return nil
+}
+
+// applyPendingTransactionUpdate withdraws the updated transaction and adds its replacement to the pending pool, with
+// this node as its gateway. a transaction which is not pending yet is kept out of the pool when it arrives, and a
+// transaction in a block proposed since the last committed block can no longer be withdrawn
+func (s *service) applyPendingTransactionUpdate(ctx context.Context, update *PendingTransactionUpdate) *ErrTransactionRejected {
+	s.addCommitLock.RLock()
+	defer s.addCommitLock.RUnlock()
+
+	if update.Replacement != nil && s.committedPool.get(digest.CalcTxHash(update.Replacement.Transaction())) != nil {
+		return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_DUPLICATE_TRANSACTION_ALREADY_COMMITTED}
+	}
+
+	if err := s.withdrawUnderCommitLock(ctx, update.TxHash, update.SignerPublicKey); err != nil {
+		return err
+	}
+
+	if update.Replacement != nil {
+		if _, err := s.pendingPool.add(update.Replacement, s.config.NodeAddress()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withdrawUnderCommitLock withdraws the transaction txHash from the pending pool on behalf of the signer holding
+// signerPublicKey, and must be called holding the read lock of addCommitLock
+func (s *service) withdrawUnderCommitLock(ctx context.Context, txHash primitives.Sha256, signerPublicKey primitives.Ed25519PublicKey) *ErrTransactionRejected {
+	if s.committedPool.get(txHash) != nil {
+		return &ErrTransactionRejected{TransactionStatus: protocol.TRANSACTION_STATUS_DUPLICATE_TRANSACTION_ALREADY_COMMITTED}
+	}
+
+	lastCommittedBlockHeight, _, _ := s.lastCommittedBlockInfo()
+	// a transaction arriving from now on carries a timestamp no later than the future timestamp grace
+	latestTimestamp := primitives.TimestampNano(s.clock.CurrentTime().Add(s.config.TransactionPoolFutureTimestampGraceTimeout()).UnixNano())
+	return s.pendingPool.withdraw(ctx, txHash, signerPublicKey, lastCommittedBlockHeight, latestTimestamp)
+}
+
+func (s *service) updatePendingTransactionOutputFor(status protocol.TransactionStatus) *UpdatePendingTransactionOutput {
+	bh, ts, _ := s.lastCommittedBlockInfo()
+	return &UpdatePendingTransactionOutput{
+		TransactionStatus: status,
+		BlockHeight:       bh,
+		BlockTimestamp:    ts,
+	}
+}
+
+func replacementTxHashOf(update *PendingTransactionUpdate) primitives.Sha256 {
+	if update.Replacement == nil {
+		return nil
+	}
+	return digest.CalcTxHash(update.Replacement.Transaction())
+}
+
+func pendingTransactionUpdateKind(update *PendingTransactionUpdate) string {
+	if update.Replacement == nil {
+		return "cancel"
+	}
+	return "replace"
+}
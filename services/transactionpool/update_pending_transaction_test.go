@@ -0,0 +1,209 @@
+// Copyright 2019 the orbs-network-go authors
+// This file is part of the orbs-network-go library in the Orbs project.
+//
+// This source code is licensed under the MIT license found in the LICENSE file in the root directory of this source tree.
+// The above notice should be included in all copies or substantial portions of the software.
+
+package transactionpool
+
+import (
+	"context"
+	"github.com/orbs-network/crypto-lib-go/crypto/digest"
+	"github.com/orbs-network/crypto-lib-go/crypto/signature"
+	"github.com/orbs-network/orbs-network-go/config"
+	"github.com/orbs-network/orbs-network-go/instrumentation/metric"
+	"github.com/orbs-network/orbs-network-go/services/gossip/codec"
+	"github.com/orbs-network/orbs-network-go/test/builders"
+	"github.com/orbs-network/orbs-network-go/test/crypto/keys"
+	"github.com/orbs-network/orbs-network-go/test/with"
+	"github.com/orbs-network/orbs-spec/types/go/primitives"
+	"github.com/orbs-network/orbs-spec/types/go/protocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPendingTransactionPool_WithdrawRemovesTransactionAndKeepsItOut(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		p := makePendingPool()
+		var removedWith protocol.TransactionStatus
+		p.onTransactionRemoved = func(ctx context.Context, txHash primitives.Sha256, reason protocol.TransactionStatus) {
+			removedWith = reason
+		}
+		tx := signedBy(1, 1)[0]
+		txHash, _ := p.add(tx, nodeAddress)
+
+		require.Nil(t, p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(1).PublicKey(), 0, 0), "the signer should withdraw its transaction")
+		require.False(t, p.has(tx), "withdrawn transaction is still pending")
+		require.Equal(t, protocol.TRANSACTION_STATUS_NO_RECORD_FOUND, removedWith, "removal listener was not told of the withdrawal")
+
+		_, err := p.add(tx, nodeAddress)
+		require.NotNil(t, err, "withdrawn transaction was added again")
+		require.Equal(t, protocol.TRANSACTION_STATUS_NO_RECORD_FOUND, err.TransactionStatus)
+	})
+}
+
+func TestPendingTransactionPool_WithdrawRequiresTheSignerOfAPendingTransaction(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		p := makePendingPool()
+		tx := signedBy(1, 1)[0]
+		txHash, _ := p.add(tx, nodeAddress)
+
+		err := p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(2).PublicKey(), 0, 0)
+		require.NotNil(t, err, "another signer withdrew the transaction")
+		require.Equal(t, protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH, err.TransactionStatus)
+		require.True(t, p.has(tx), "transaction withdrawn by another signer")
+	})
+}
+
+func TestPendingTransactionPool_WithdrawKeepsOutATransactionWhichArrivesLater(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		p := makePendingPool()
+		tx := signedBy(1, 1)[0]
+		otherTx := signedBy(1, 1)[0]
+		txHash := digest.CalcTxHash(tx.Transaction())
+		otherTxHash := digest.CalcTxHash(otherTx.Transaction())
+		latestTimestamp := tx.Transaction().Timestamp() + 1
+
+		require.Nil(t, p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(1).PublicKey(), 0, latestTimestamp), "the signer should withdraw a transaction which is not pending yet")
+		require.Nil(t, p.withdraw(ctx, otherTxHash, keys.Ed25519KeyPairForTests(2).PublicKey(), 0, latestTimestamp), "withdrawing a transaction which is not pending yet needs no signer check")
+
+		_, err := p.add(tx, nodeAddress)
+		require.NotNil(t, err, "transaction withdrawn before it arrived was added")
+		require.Equal(t, protocol.TRANSACTION_STATUS_NO_RECORD_FOUND, err.TransactionStatus)
+
+		_, err = p.add(otherTx, nodeAddress)
+		require.Nil(t, err, "transaction withdrawn by another signer before it arrived was kept out")
+	})
+}
+
+func TestPendingTransactionPool_WithdrawFailsForATransactionInAProposedBlock(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		p := makePendingPool()
+		tx := signedBy(1, 1)[0]
+		txHash, _ := p.add(tx, nodeAddress)
+		require.Len(t, p.markProposed(Transactions{tx}, 5), 1)
+
+		err := p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(1).PublicKey(), 4, 0)
+		require.NotNil(t, err, "transaction in a proposed block was withdrawn")
+		require.Equal(t, protocol.TRANSACTION_STATUS_PENDING, err.TransactionStatus)
+		require.True(t, p.has(tx), "transaction in a proposed block is no longer pending")
+
+		require.Nil(t, p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(1).PublicKey(), 5, 0), "transaction left out of the committed block should be withdrawn")
+		require.Empty(t, p.markProposed(Transactions{tx}, 6), "withdrawn transaction was proposed")
+	})
+}
+
+func TestPendingTransactionPool_ForgetsWithdrawnTransactionsOnceExpired(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		p := makePendingPool()
+		tx := signedBy(1, 1)[0]
+		txHash, _ := p.add(tx, nodeAddress)
+		require.Nil(t, p.withdraw(ctx, txHash, keys.Ed25519KeyPairForTests(1).PublicKey(), 0, 0))
+
+		p.clearTransactionsOlderThan(ctx, tx.Transaction().Timestamp()+1)
+
+		require.Empty(t, p.withdrawnByHash, "expired withdrawn transaction was not forgotten")
+	})
+}
+
+func TestApplyPendingTransactionUpdate_ReplacesTransactionSignedBySameSigner(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		s := newServiceForPendingTransactionUpdates(parent)
+		original := signedBy(1, 1)[0]
+		replacement := signedBy(1, 1)[0]
+		s.pendingPool.add(original, nodeAddress)
+
+		update := signedUpdate(t, 1, original, replacement)
+		require.Nil(t, s.validatePendingTransactionUpdate(update))
+		require.Nil(t, s.applyPendingTransactionUpdate(context.Background(), update))
+
+		require.False(t, s.pendingPool.has(original), "replaced transaction is still pending")
+		require.True(t, s.pendingPool.has(replacement), "replacement was not added to the pool")
+	})
+}
+
+func TestValidatePendingTransactionUpdate_RejectsUpdateNotSignedByTheSigner(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		s := newServiceForPendingTransactionUpdates(parent)
+		original := signedBy(1, 1)[0]
+
+		forged := signedUpdate(t, 2, original, nil)
+		forged.SignerPublicKey = keys.Ed25519KeyPairForTests(1).PublicKey()
+
+		err := s.validatePendingTransactionUpdate(forged)
+		require.NotNil(t, err, "update not signed by the key it names was valid")
+		require.Equal(t, protocol.TRANSACTION_STATUS_REJECTED_SIGNATURE_MISMATCH, err.TransactionStatus)
+	})
+}
+
+func TestHandleWithdrawnTransaction_WithdrawsTransactionSignedByTheSigner(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		s := newServiceForPendingTransactionUpdates(parent)
+		original := signedBy(1, 1)[0]
+		replacement := signedBy(1, 1)[0]
+		s.pendingPool.add(original, nodeAddress)
+		update := signedUpdate(t, 1, original, replacement)
+
+		require.NoError(t, s.HandleWithdrawnTransaction(context.Background(), withdrawnTransactionMessageOf(update)))
+
+		require.False(t, s.pendingPool.has(original), "withdrawn transaction is still pending")
+		require.True(t, s.pendingPool.isWithdrawn(update.TxHash, original), "withdrawn transaction is not kept out of blocks")
+		require.False(t, s.pendingPool.has(replacement), "replacement should be forwarded by its gateway rather than added with the withdrawal")
+	})
+}
+
+func TestHandleWithdrawnTransaction_RejectsWithdrawalNotSignedByTheSigner(t *testing.T) {
+	with.Logging(t, func(parent *with.LoggingHarness) {
+		s := newServiceForPendingTransactionUpdates(parent)
+		original := signedBy(1, 1)[0]
+		s.pendingPool.add(original, nodeAddress)
+
+		forged := withdrawnTransactionMessageOf(signedUpdate(t, 2, original, nil))
+		forged.SignerPublicKey = keys.Ed25519KeyPairForTests(1).PublicKey()
+		require.Error(t, s.HandleWithdrawnTransaction(context.Background(), forged), "withdrawal not signed by the key it names was accepted")
+
+		byAnotherSigner := withdrawnTransactionMessageOf(signedUpdate(t, 2, original, nil))
+		require.Error(t, s.HandleWithdrawnTransaction(context.Background(), byAnotherSigner), "withdrawal by another signer was accepted")
+
+		require.True(t, s.pendingPool.has(original), "transaction was withdrawn by a withdrawal it was not signed for")
+	})
+}
+
+func newServiceForPendingTransactionUpdates(parent *with.LoggingHarness) *service {
+	return &service{
+		clock:         createClockIfNeeded(nil),
+		config:        config.ForTransactionPoolTests(100000, keys.EcdsaSecp256K1KeyPairForTests(0), time.Second),
+		logger:        parent.Logger,
+		pendingPool:   makePendingPool(),
+		committedPool: NewCommittedPool(func() time.Duration { return time.Minute }, metric.NewRegistry()),
+	}
+}
+
+func signedUpdate(t *testing.T, signer int, tx *protocol.SignedTransaction, replacement *protocol.SignedTransaction) *PendingTransactionUpdate {
+	keyPair := keys.Ed25519KeyPairForTests(signer)
+	txHash := digest.CalcTxHash(tx.Transaction())
+	var replacementTxHash primitives.Sha256
+	if replacement != nil {
+		replacementTxHash = digest.CalcTxHash(replacement.Transaction())
+	}
+
+	sig, err := signature.SignEd25519(keyPair.PrivateKey(), PendingTransactionUpdateDigest(builders.DEFAULT_TEST_VIRTUAL_CHAIN_ID, txHash, replacementTxHash))
+	require.NoError(t, err)
+
+	return &PendingTransactionUpdate{
+		TxHash:          txHash,
+		SignerPublicKey: keyPair.PublicKey(),
+		Signature:       sig,
+		Replacement:     replacement,
+	}
+}
+
+func withdrawnTransactionMessageOf(update *PendingTransactionUpdate) *codec.WithdrawnTransactionMessage {
+	return &codec.WithdrawnTransactionMessage{
+		TxHash:            update.TxHash,
+		ReplacementTxHash: replacementTxHashOf(update),
+		SignerPublicKey:   update.SignerPublicKey,
+		Signature:         update.Signature,
+	}
+}
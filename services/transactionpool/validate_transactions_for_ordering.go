@@ -26,6 +26,7 @@ func (s *service) ValidateTransactionsForOrdering(ctx context.Context, input *se
 	}
 
 	proposedBlockTimestamp := input.CurrentBlockTimestamp
+	s.pendingPool.markProposed(input.SignedTransactions, input.CurrentBlockHeight)
 
 	for _, tx := range input.SignedTransactions {
 		txHash := digest.CalcTxHash(tx.Transaction())
@@ -33,6 +34,10 @@ func (s *service) ValidateTransactionsForOrdering(ctx context.Context, input *se
 			return nil, errors.Errorf("transaction with hash %s already committed", txHash)
 		}
 
+		if s.pendingPool.isWithdrawn(txHash, tx) {
+			return nil, errors.Errorf("transaction with hash %s was withdrawn by its signer", txHash)
+		}
+
 		if err := s.validationContext.ValidateTransactionForOrdering(tx, proposedBlockTimestamp); err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("transaction with hash %s is invalid", txHash))
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/orbs-network/orbs-network-go/instrumentation/logfields"
 	"github.com/orbs-network/orbs-network-go/instrumentation/trace"
 	"github.com/orbs-network/orbs-network-go/services/processor/sdk"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
 	"github.com/orbs-network/orbs-spec/types/go/services/handlers"
@@ -26,7 +27,7 @@ type ManagementConfig interface {
 }
 
 type service struct {
-	stateStorage         services.StateStorage
+	stateStorage         statestorage.StateStorage
 	processors           map[protocol.ProcessorType]services.Processor
 	crosschainConnectors map[protocol.CrosschainConnectorType]services.CrosschainConnector
 	management           services.Management
@@ -36,7 +37,7 @@ type service struct {
 	contexts *executionContextProvider
 }
 
-func NewVirtualMachine(stateStorage services.StateStorage, processors map[protocol.ProcessorType]services.Processor, crosschainConnectors map[protocol.CrosschainConnectorType]services.CrosschainConnector, management services.Management, cfg ManagementConfig, logger log.Logger) services.VirtualMachine {
+func NewVirtualMachine(stateStorage statestorage.StateStorage, processors map[protocol.ProcessorType]services.Processor, crosschainConnectors map[protocol.CrosschainConnectorType]services.CrosschainConnector, management services.Management, cfg ManagementConfig, logger log.Logger) services.VirtualMachine {
 	s := &service{
 		processors:           processors,
 		crosschainConnectors: crosschainConnectors,
@@ -68,14 +69,28 @@ func (s *service) ProcessQuery(ctx context.Context, input *services.ProcessQuery
 		}, err
 	}
 
-	if input.BlockHeight != 0 {
-		err := errors.New("Run local method with specific block height is not yet supported")
-		return &services.ProcessQueryOutput{
-			CallResult:              protocol.EXECUTION_RESULT_ERROR_INPUT,
-			OutputArgumentArray:     protocol.ArgumentsArrayEmpty().Raw(),
-			ReferenceBlockHeight:    committedBlockHeight,
-			ReferenceBlockTimestamp: committedBlockTimestamp,
-		}, err
+	if input.BlockHeight != 0 && input.BlockHeight != committedBlockHeight {
+		if input.BlockHeight > committedBlockHeight {
+			err := errors.Errorf("Run local method with block height %d which is not yet committed, last committed block height is %d", input.BlockHeight, committedBlockHeight)
+			return &services.ProcessQueryOutput{
+				CallResult:              protocol.EXECUTION_RESULT_ERROR_INPUT,
+				OutputArgumentArray:     protocol.ArgumentsArrayEmpty().Raw(),
+				ReferenceBlockHeight:    committedBlockHeight,
+				ReferenceBlockTimestamp: committedBlockTimestamp,
+			}, err
+		}
+
+		// older heights are only kept by state storage in archive mode
+		info, err := s.stateStorage.GetBlockInfo(ctx, &statestorage.GetBlockInfoInput{BlockHeight: input.BlockHeight})
+		if err != nil {
+			return &services.ProcessQueryOutput{
+				CallResult:              protocol.EXECUTION_RESULT_ERROR_INPUT,
+				OutputArgumentArray:     protocol.ArgumentsArrayEmpty().Raw(),
+				ReferenceBlockHeight:    committedBlockHeight,
+				ReferenceBlockTimestamp: committedBlockTimestamp,
+			}, errors.Wrapf(err, "Run local method with block height %d failed", input.BlockHeight)
+		}
+		committedBlockHeight, committedBlockTimestamp, committeeReferenceTime, committedPrevReferenceTime, committedBlockProposerAddress = info.BlockHeight, info.BlockTimestamp, info.CurrentReferenceTime, info.PrevReferenceTime, info.BlockProposerAddress
 	}
 
 	logger.Info("running local method", log.Stringable("contract", input.SignedQuery.Query().ContractName()), log.Stringable("method", input.SignedQuery.Query().MethodName()), logfields.BlockHeight(committedBlockHeight))
@@ -12,10 +12,12 @@ import (
 	"fmt"
 	"github.com/orbs-network/crypto-lib-go/crypto/hash"
 	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/statestorage"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	"github.com/orbs-network/orbs-spec/types/go/primitives"
 	"github.com/orbs-network/orbs-spec/types/go/protocol"
 	"github.com/orbs-network/orbs-spec/types/go/services"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -240,6 +242,22 @@ func (h *harness) expectStateStorageLastCommittedBlockInfoRequested(returnHeight
 	h.stateStorage.When("GetLastCommittedBlockInfo", mock.Any, mock.Any).Return(outputToReturn, nil).Times(1)
 }
 
+func (h *harness) expectStateStorageBlockInfoRequested(blockHeight primitives.BlockHeight) {
+	outputToReturn := &statestorage.GetBlockInfoOutput{
+		BlockHeight:          blockHeight,
+		BlockTimestamp:       1000,
+		CurrentReferenceTime: 5000,
+		PrevReferenceTime:    4000,
+		BlockProposerAddress: hash.Make32BytesWithFirstByte(2),
+	}
+
+	h.stateStorage.When("GetBlockInfo", mock.Any, &statestorage.GetBlockInfoInput{BlockHeight: blockHeight}).Return(outputToReturn, nil).Times(1)
+}
+
+func (h *harness) expectStateStorageBlockInfoNotKept(blockHeight primitives.BlockHeight) {
+	h.stateStorage.When("GetBlockInfo", mock.Any, &statestorage.GetBlockInfoInput{BlockHeight: blockHeight}).Return(nil, errors.New("block height too old")).Times(1)
+}
+
 func (h *harness) verifyStateStorageBlockHeightRequested(t *testing.T) {
 	ok, err := h.stateStorage.Verify()
 	require.True(t, ok, "did not read from state storage: %v", err)
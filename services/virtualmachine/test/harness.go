@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/orbs-network/crypto-lib-go/crypto/hash"
 	"github.com/orbs-network/go-mock"
+	"github.com/orbs-network/orbs-network-go/services/statestorage/testkit"
 	"github.com/orbs-network/orbs-network-go/services/virtualmachine"
 	"github.com/orbs-network/orbs-network-go/test/builders"
 	testKeys "github.com/orbs-network/orbs-network-go/test/crypto/keys"
@@ -24,7 +25,7 @@ import (
 
 type harness struct {
 	blockStorage         *services.MockBlockStorage
-	stateStorage         *services.MockStateStorage
+	stateStorage         *testkit.MockStateStorage
 	processors           map[protocol.ProcessorType]*services.MockProcessor
 	crosschainConnectors map[protocol.CrosschainConnectorType]*services.MockCrosschainConnector
 	management           *services.MockManagement
@@ -35,7 +36,7 @@ type harness struct {
 
 func newHarness(logger log.Logger) *harness {
 	blockStorage := &services.MockBlockStorage{}
-	stateStorage := &services.MockStateStorage{}
+	stateStorage := &testkit.MockStateStorage{}
 
 	processors := make(map[protocol.ProcessorType]*services.MockProcessor)
 	processors[protocol.PROCESSOR_TYPE_NATIVE] = &services.MockProcessor{}
@@ -121,8 +122,12 @@ func (h *harness) handleSdkCallWithSystemPermissions(ctx context.Context, execut
 }
 
 func (h *harness) processQuery(ctx context.Context, contractName primitives.ContractName, methodName primitives.MethodName) (protocol.ExecutionResult, []byte, primitives.BlockHeight, []byte, error) {
+	return h.processQueryAtBlockHeight(ctx, 0 /* recent */, contractName, methodName)
+}
+
+func (h *harness) processQueryAtBlockHeight(ctx context.Context, blockHeight primitives.BlockHeight, contractName primitives.ContractName, methodName primitives.MethodName) (protocol.ExecutionResult, []byte, primitives.BlockHeight, []byte, error) {
 	output, err := h.service.ProcessQuery(ctx, &services.ProcessQueryInput{
-		BlockHeight: 0, // recent
+		BlockHeight: blockHeight,
 		SignedQuery: (&protocol.SignedQueryBuilder{
 			Query: &protocol.QueryBuilder{
 				Signer:             nil,
@@ -97,7 +97,7 @@ func TestProcessQuery_UnexpectedError(t *testing.T) {
 	})
 }
 
-func TestProcessQuery_WithSpecificBlockHeight(t *testing.T) {
+func TestProcessQuery_WithFutureBlockHeight(t *testing.T) {
 	with.Context(func(ctx context.Context) {
 		with.Logging(t, func(parent *with.LoggingHarness) {
 
@@ -116,10 +116,54 @@ func TestProcessQuery_WithSpecificBlockHeight(t *testing.T) {
 				}).Build(),
 			})
 
-			require.Error(t, err, "Run local method with specific block height is not yet supported")
+			require.Error(t, err, "process query should fail for a block height that is not yet committed")
 			require.EqualValues(t, protocol.EXECUTION_RESULT_ERROR_INPUT, output.CallResult)
 			require.EqualValues(t, 12, output.ReferenceBlockHeight)
 			h.verifyStateStorageBlockHeightRequested(t)
 		})
 	})
 }
+
+func TestProcessQuery_WithPastBlockHeight(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+
+			h := newHarness(parent.Logger)
+			h.expectSystemContractCalled(deployments_systemcontract.CONTRACT_NAME, deployments_systemcontract.METHOD_GET_INFO, nil, uint32(protocol.PROCESSOR_TYPE_NATIVE)) // assume all contracts are deployed
+
+			h.expectStateStorageLastCommittedBlockInfoBlockHeightRequested(12)
+			h.expectStateStorageBlockInfoRequested(7)
+			h.expectNativeContractMethodCalled("Contract1", "method1", func(executionContextId primitives.ExecutionContextId, inputArgs *protocol.ArgumentArray) (protocol.ExecutionResult, *protocol.ArgumentArray, error) {
+				return protocol.EXECUTION_RESULT_SUCCESS, builders.ArgumentsArray(), nil
+			})
+
+			result, _, refHeight, _, err := h.processQueryAtBlockHeight(ctx, 7, "Contract1", "method1")
+			require.NoError(t, err, "process query should not fail")
+			require.Equal(t, protocol.EXECUTION_RESULT_SUCCESS, result, "process query should return successful result")
+			require.EqualValues(t, 7, refHeight, "process query should run against the requested block height")
+
+			h.verifySystemContractCalled(t)
+			h.verifyStateStorageBlockHeightRequested(t)
+			h.verifyNativeContractMethodCalled(t)
+		})
+	})
+}
+
+func TestProcessQuery_WithPastBlockHeightNoLongerKept(t *testing.T) {
+	with.Context(func(ctx context.Context) {
+		with.Logging(t, func(parent *with.LoggingHarness) {
+
+			h := newHarness(parent.Logger)
+
+			h.expectStateStorageLastCommittedBlockInfoBlockHeightRequested(12)
+			h.expectStateStorageBlockInfoNotKept(7)
+
+			result, _, refHeight, _, err := h.processQueryAtBlockHeight(ctx, 7, "Contract1", "method1")
+			require.Error(t, err, "process query should fail for a block height state storage no longer keeps")
+			require.Equal(t, protocol.EXECUTION_RESULT_ERROR_INPUT, result)
+			require.EqualValues(t, 12, refHeight)
+
+			h.verifyStateStorageBlockHeightRequested(t)
+		})
+	})
+}
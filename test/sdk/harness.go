@@ -44,7 +44,7 @@ type harness struct {
 func newVmHarness(logger log.Logger) *harness {
 	registry := metric.NewRegistry()
 
-	ssCfg := config.ForStateStorageTest(10, 5, 5000)
+	ssCfg := config.ForStateStorageTest(10, 5, 5000, "halt", false)
 	ssPersistence := stateAdapter.NewStatePersistence(registry)
 	stateStorage := statestorage.NewStateStorage(ssCfg, ssPersistence, nil, logger, registry)
 